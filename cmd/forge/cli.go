@@ -0,0 +1,408 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/am"
+	"github.com/mikejsmith1985/forge-terminal/internal/commands"
+	"github.com/mikejsmith1985/forge-terminal/internal/storage"
+	"github.com/mikejsmith1985/forge-terminal/internal/terminal"
+)
+
+const cliUsage = `Usage: forge <command> [flags] [args]
+
+Commands for a running Forge, sent over its control socket:
+  ls [-json]                         list open tabs
+  open [-cwd DIR] [-shell SHELL]     open a headless tab and print its ID
+  send [-no-enter] <tab> <text>...   type text into a tab ("-" reads stdin)
+  tail [-f] <tab>                    print a tab's recent output; -f follows it
+  am search [flags] <query>...       search captured LLM conversations
+  cards ls                           list command cards
+  cards run [-tab TAB] <id>          run a command card in a tab
+
+<tab> is a tab ID or a unique prefix of one, as shown by forge ls.
+The socket is ~/.forge/run/forge.sock; set FORGE_SOCKET to use another.
+`
+
+// cliCommands are the subcommands handled by runCLI.
+var cliCommands = map[string]func(*controlClient, []string) int{
+	"ls":    cliList,
+	"open":  cliOpen,
+	"send":  cliSend,
+	"tail":  cliTail,
+	"am":    cliAM,
+	"cards": cliCards,
+}
+
+// runCLI implements the control subcommands, returning the process exit code.
+func runCLI(name string, args []string) int {
+	return cliCommands[name](newControlClient(storage.GetControlSocketPath()), args)
+}
+
+// controlClient talks HTTP to the running instance over its Unix socket.
+type controlClient struct {
+	socket string
+	http   *http.Client
+}
+
+func newControlClient(socket string) *controlClient {
+	dialer := &net.Dialer{Timeout: 2 * time.Second}
+	return &controlClient{
+		socket: socket,
+		http: &http.Client{Transport: &http.Transport{
+			Dial: func(_, _ string) (net.Conn, error) { return dialer.Dial("unix", socket) },
+		}},
+	}
+}
+
+// request sends in as JSON (when not nil) and returns the response, turning
+// error statuses into errors carrying the server's message.
+func (c *controlClient) request(method, path string, in interface{}) (*http.Response, error) {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, "http://forge"+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return nil, fmt.Errorf("Forge is not running (no control socket at %s)", c.socket)
+		}
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		var reply struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &reply) == nil && reply.Error != "" {
+			return nil, errors.New(reply.Error)
+		}
+		return nil, errors.New(strings.TrimSpace(string(data)))
+	}
+	return resp, nil
+}
+
+// call is request for JSON replies, decoded into out.
+func (c *controlClient) call(method, path string, in, out interface{}) error {
+	resp, err := c.request(method, path, in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var reply struct {
+		Success *bool  `json:"success"`
+		Error   string `json:"error"`
+	}
+	if json.Unmarshal(data, &reply) == nil && reply.Success != nil && !*reply.Success {
+		return errors.New(reply.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// cliFail prints err and returns exit code 1.
+func cliFail(err error) int {
+	fmt.Fprintln(os.Stderr, "forge:", err)
+	return 1
+}
+
+// cliFlags returns a flag set for a subcommand whose usage names its arguments.
+func cliFlags(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: forge %s %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// cliList implements `forge ls`.
+func cliList(c *controlClient, args []string) int {
+	fs := cliFlags("ls", "[-json]")
+	asJSON := fs.Bool("json", false, "print tabs as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var reply struct {
+		Tabs []terminal.TabInfo `json:"tabs"`
+	}
+	if err := c.call(http.MethodGet, "/tabs", nil, &reply); err != nil {
+		return cliFail(err)
+	}
+	if *asJSON {
+		return printJSON(reply.Tabs)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TAB\tKIND\tSHELL\tSTARTED\tDIRECTORY\tRUNNING")
+	for _, tab := range reply.Tabs {
+		kind := "browser"
+		if tab.Headless {
+			kind = "headless"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", shortID(tab.ID), kind, tab.Shell,
+			tab.Started.Local().Format("Jan 2 15:04"), tab.WorkingDir, tab.Foreground)
+	}
+	tw.Flush()
+	return 0
+}
+
+// cliOpen implements `forge open`.
+func cliOpen(c *controlClient, args []string) int {
+	fs := cliFlags("open", "[-cwd DIR] [-shell SHELL]")
+	cwd := fs.String("cwd", ".", "directory to start the shell in")
+	shell := fs.String("shell", "", "shell to run, e.g. zsh (default $SHELL; cmd, powershell or wsl on Windows)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	dir, err := filepath.Abs(*cwd)
+	if err != nil {
+		return cliFail(err)
+	}
+
+	var reply struct {
+		TabID string `json:"tabId"`
+	}
+	req := map[string]string{"cwd": dir, "shell": *shell}
+	if err := c.call(http.MethodPost, "/tabs", req, &reply); err != nil {
+		return cliFail(err)
+	}
+	fmt.Println(reply.TabID)
+	return 0
+}
+
+// cliSend implements `forge send`.
+func cliSend(c *controlClient, args []string) int {
+	fs := cliFlags("send", "[-no-enter] <tab> <text>...")
+	noEnter := fs.Bool("no-enter", false, "type the text without pressing Enter")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return 2
+	}
+
+	text := strings.Join(fs.Args()[1:], " ")
+	if text == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return cliFail(err)
+		}
+		text = strings.TrimRight(string(data), "\r\n")
+	}
+	req := map[string]interface{}{"text": text, "enter": !*noEnter}
+	if err := c.call(http.MethodPost, "/tabs/"+url.PathEscape(fs.Arg(0))+"/input", req, nil); err != nil {
+		return cliFail(err)
+	}
+	return 0
+}
+
+// cliTail implements `forge tail`.
+func cliTail(c *controlClient, args []string) int {
+	fs := cliFlags("tail", "[-f] <tab>")
+	follow := fs.Bool("f", false, "keep printing output until the tab closes or you press Ctrl+C")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	path := "/tabs/" + url.PathEscape(fs.Arg(0)) + "/output"
+	if *follow {
+		path += "?follow=1"
+	}
+	resp, err := c.request(http.MethodGet, path, nil)
+	if err != nil {
+		return cliFail(err)
+	}
+	defer resp.Body.Close()
+
+	// Ctrl+C ends the stream rather than the process, so the deferred close runs
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		<-interrupt
+		resp.Body.Close()
+	}()
+
+	io.Copy(os.Stdout, resp.Body)
+	return 0
+}
+
+// cliAM implements `forge am search`.
+func cliAM(c *controlClient, args []string) int {
+	if len(args) == 0 || args[0] != "search" {
+		fmt.Fprintln(os.Stderr, "Usage: forge am search [flags] <query>...")
+		return 2
+	}
+	fs := cliFlags("am search", "[flags] <query>...")
+	provider := fs.String("provider", "", "only conversations with this LLM provider")
+	project := fs.String("project", "", "only conversations from this project")
+	since := fs.String("since", "", "only turns on or after this date (YYYY-MM-DD)")
+	until := fs.String("until", "", "only turns on or before this date (YYYY-MM-DD)")
+	limit := fs.Int("limit", 20, "maximum number of results (up to 100)")
+	asJSON := fs.Bool("json", false, "print the results as JSON")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	query := url.Values{"q": {strings.Join(fs.Args(), " ")}, "limit": {strconv.Itoa(*limit)}}
+	for key, value := range map[string]string{"provider": *provider, "project": *project, "since": *since, "until": *until} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	var reply struct {
+		Total   int            `json:"total"`
+		Results []am.SearchHit `json:"results"`
+	}
+	if err := c.call(http.MethodGet, "/api/am/search?"+query.Encode(), nil, &reply); err != nil {
+		return cliFail(err)
+	}
+	if *asJSON {
+		return printJSON(reply.Results)
+	}
+
+	for _, hit := range reply.Results {
+		fmt.Printf("%s  %s  %s  %s#%d (%s)\n", hit.Timestamp.Local().Format("2006-01-02 15:04"),
+			hit.Provider, hit.Project, hit.ConversationID, hit.TurnIndex, hit.Role)
+		fmt.Printf("    %s\n", plainSnippet(hit.Snippet))
+	}
+	fmt.Printf("%d of %d results\n", len(reply.Results), reply.Total)
+	return 0
+}
+
+// cliCards implements `forge cards ls` and `forge cards run`.
+func cliCards(c *controlClient, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: forge cards ls | forge cards run [-tab TAB] <id>")
+		return 2
+	}
+
+	switch args[0] {
+	case "ls":
+		var cards []commands.Command
+		if err := c.call(http.MethodGet, "/api/commands", nil, &cards); err != nil {
+			return cliFail(err)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tDESCRIPTION\tCOMMAND")
+		for _, card := range cards {
+			fmt.Fprintf(tw, "%d\t%s\t%s\n", card.ID, card.Description, oneLine(card.Command, 60))
+		}
+		tw.Flush()
+		return 0
+
+	case "run":
+		fs := cliFlags("cards run", "[-tab TAB] <id>")
+		tab := fs.String("tab", "", "tab to run the card in (default: the only open tab)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+		if fs.NArg() != 1 {
+			fs.Usage()
+			return 2
+		}
+		id, err := strconv.Atoi(fs.Arg(0))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "forge: invalid card ID %q\n", fs.Arg(0))
+			return 2
+		}
+
+		var reply struct {
+			TabID          string `json:"tabId"`
+			ConversationID string `json:"conversationId"`
+		}
+		if err := c.call(http.MethodPost, fmt.Sprintf("/cards/%d/run", id), map[string]string{"tab": *tab}, &reply); err != nil {
+			return cliFail(err)
+		}
+		if reply.ConversationID != "" {
+			fmt.Printf("Ran card %d in tab %s; AM conversation %s\n", id, shortID(reply.TabID), reply.ConversationID)
+		} else {
+			fmt.Printf("Ran card %d in tab %s\n", id, shortID(reply.TabID))
+		}
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "forge: unknown cards command %q\n", args[0])
+	return 2
+}
+
+func printJSON(v interface{}) int {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return cliFail(err)
+	}
+	return 0
+}
+
+// shortID abbreviates a tab ID the way forge ls shows it; any unique prefix works
+// as a <tab> argument.
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// oneLine flattens text to one line of at most max runes for a table cell.
+func oneLine(text string, max int) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) > max {
+		return string(runes[:max-1]) + "…"
+	}
+	return string(runes)
+}
+
+// plainSnippet turns a search snippet's HTML (escaped text, <mark> around
+// matches) into terminal text.
+func plainSnippet(snippet string) string {
+	snippet = strings.NewReplacer("<mark>", "", "</mark>", "").Replace(snippet)
+	return strings.Join(strings.Fields(html.UnescapeString(snippet)), " ")
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/commands"
+	"github.com/mikejsmith1985/forge-terminal/internal/terminal"
+)
+
+// Windows shell types a tab can be opened with; any other -shell is a program.
+var windowsShellTypes = map[string]bool{"cmd": true, "powershell": true, "wsl": true}
+
+// newlines matches the line breaks a paste-only card flattens, as the browser does.
+var newlines = regexp.MustCompile(`[\r\n]+`)
+
+// listenControl opens the Unix socket the forge CLI talks to, replacing one left
+// behind by an instance that didn't shut down cleanly. Only the current user can
+// connect: the socket is 0600, in a directory created 0700. The socket is the
+// credential, so routes served on it skip token auth.
+func listenControl(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("another Forge instance is listening on %s", path)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// controlMux routes the control socket. AM search and the card list are the
+// regular API handlers; the tab routes wrap the terminal handler.
+func controlMux(termHandler *terminal.Handler) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/am/search", handleAMSearch)
+	mux.HandleFunc("/api/commands", handleCommands)
+	mux.HandleFunc("/tabs", func(w http.ResponseWriter, r *http.Request) {
+		handleControlTabs(w, r, termHandler)
+	})
+	mux.HandleFunc("/tabs/", func(w http.ResponseWriter, r *http.Request) {
+		handleControlTab(w, r, termHandler)
+	})
+	mux.HandleFunc("/cards/", func(w http.ResponseWriter, r *http.Request) {
+		handleControlCardRun(w, r, termHandler)
+	})
+	return mux
+}
+
+// handleControlTabs lists tabs (GET) or opens a headless one (POST).
+func handleControlTabs(w http.ResponseWriter, r *http.Request, termHandler *terminal.Handler) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"tabs":    termHandler.Tabs(),
+		})
+
+	case http.MethodPost:
+		var req struct {
+			Cwd   string `json:"cwd"`
+			Shell string `json:"shell"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.Cwd != "" {
+			if info, err := os.Stat(req.Cwd); err != nil || !info.IsDir() {
+				writeControlError(w, http.StatusBadRequest, fmt.Errorf("not a directory: %s", req.Cwd))
+				return
+			}
+		}
+
+		config := &terminal.ShellConfig{WorkingDir: req.Cwd}
+		if windowsShellTypes[req.Shell] {
+			config.ShellType = req.Shell
+		} else {
+			config.Shell = req.Shell
+		}
+		session, err := termHandler.OpenTab(config)
+		if err != nil {
+			writeControlError(w, http.StatusInternalServerError, err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"tabId":   session.ID,
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleControlTab serves one tab: POST /tabs/{tab}/input types into it and
+// GET /tabs/{tab}/output returns its recent output, streaming new output too with
+// ?follow=1. {tab} may be a unique prefix of the tab ID.
+func handleControlTab(w http.ResponseWriter, r *http.Request, termHandler *terminal.Handler) {
+	ref, action := splitControlPath(strings.TrimPrefix(r.URL.Path, "/tabs/"))
+
+	switch {
+	case action == "input" && r.Method == http.MethodPost:
+		var req struct {
+			Text  string `json:"text"`
+			Enter bool   `json:"enter"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		session, err := termHandler.SendInput(ref, req.Text, req.Enter)
+		if err != nil {
+			writeControlError(w, tabErrorStatus(err), err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"tabId":   session.ID,
+		})
+
+	case action == "output" && r.Method == http.MethodGet:
+		session, err := termHandler.FindTab(ref)
+		if err != nil {
+			writeControlError(w, tabErrorStatus(err), err)
+			return
+		}
+		recent, output, untap := session.FollowOutput()
+		defer untap()
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(recent)
+		if r.URL.Query().Get("follow") != "1" {
+			return
+		}
+		flusher, _ := w.(http.Flusher)
+		for {
+			if flusher != nil {
+				flusher.Flush()
+			}
+			select {
+			case data := <-output:
+				if _, err := w.Write(data); err != nil {
+					return
+				}
+			case <-session.Done():
+				return
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+	case action == "input" || action == "output":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleControlCardRun runs a command card in a tab the way clicking it does:
+// POST /cards/{id}/run with an optional {"tab": ...}. Without a tab the only
+// open one is used.
+func handleControlCardRun(w http.ResponseWriter, r *http.Request, termHandler *terminal.Handler) {
+	idStr, action := splitControlPath(strings.TrimPrefix(r.URL.Path, "/cards/"))
+	id, err := strconv.Atoi(idStr)
+	if err != nil || action != "run" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Tab string `json:"tab"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Tab == "" {
+		tabs := termHandler.Tabs()
+		if len(tabs) != 1 {
+			writeControlError(w, http.StatusBadRequest, fmt.Errorf("%d tabs open; choose one with -tab", len(tabs)))
+			return
+		}
+		req.Tab = tabs[0].ID
+	}
+
+	cmds, err := commands.LoadCommands()
+	if err != nil {
+		writeControlError(w, http.StatusInternalServerError, err)
+		return
+	}
+	var card *commands.Command
+	for i := range cmds {
+		if cmds[i].ID == id {
+			card = &cmds[i]
+			break
+		}
+	}
+	if card == nil {
+		writeControlError(w, http.StatusNotFound, fmt.Errorf("no command card with ID %d", id))
+		return
+	}
+
+	text := card.Command
+	if card.PasteOnly {
+		text = strings.TrimSpace(newlines.ReplaceAllString(text, " "))
+	}
+	session, err := termHandler.FindTab(req.Tab)
+	if err != nil {
+		writeControlError(w, tabErrorStatus(err), err)
+		return
+	}
+	resp := map[string]interface{}{
+		"success": true,
+		"tabId":   session.ID,
+	}
+	// Start capture before typing, so the card's own command line is recorded and
+	// the typed line doesn't start a second conversation
+	if card.TriggerAM {
+		if convID := startCardConversation(session.ID, card.LLMProvider, card.LLMType); convID != "" {
+			resp["conversationId"] = convID
+		}
+	}
+	if _, err := termHandler.SendInput(session.ID, text, !card.PasteOnly); err != nil {
+		writeControlError(w, tabErrorStatus(err), err)
+		return
+	}
+	log.Printf("[Control] Ran card %d (%s) in tab %s", card.ID, card.Description, session.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// splitControlPath splits "{ref}/{action}".
+func splitControlPath(path string) (string, string) {
+	ref, action, _ := strings.Cut(path, "/")
+	return ref, action
+}
+
+func tabErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, terminal.ErrTabNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, terminal.ErrTabAmbiguous):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func writeControlError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/am"
+	"github.com/mikejsmith1985/forge-terminal/internal/storage"
+)
+
+// datasetOutputDir resolves where a named dataset export is written.
+// An empty name gets a timestamped directory under the training data dir.
+func datasetOutputDir(name string) (string, error) {
+	if name == "" {
+		name = "dataset-" + time.Now().Format("20060102-150405")
+	}
+	if name != filepath.Base(name) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid dataset name: %s", name)
+	}
+	return filepath.Join(storage.GetTrainingDataDir(), name), nil
+}
+
+// runExportDataset implements `forge export-dataset`, returning the process exit code.
+func runExportDataset(args []string) int {
+	fs := flag.NewFlagSet("export-dataset", flag.ContinueOnError)
+	format := fs.String("format", am.DatasetFormatChat, "output format: chat or alpaca")
+	providers := fs.String("provider", "", "comma-separated providers to include (default all)")
+	project := fs.String("project", "", "only include conversations from this project")
+	minConfidence := fs.Float64("min-confidence", 0, "drop exchanges whose ParseConfidence is below this")
+	since := fs.String("since", "", "only include conversations started on or after this date (YYYY-MM-DD)")
+	until := fs.String("until", "", "only include conversations started on or before this date (YYYY-MM-DD)")
+	split := fs.Float64("validation-split", am.DefaultValidationSplit, "fraction of examples held out for validation")
+	noRedact := fs.Bool("no-redact", false, "keep secrets instead of redacting them")
+	out := fs.String("out", "", "output directory (default ~/.forge/assistant/training-data/<name>)")
+	name := fs.String("name", "", "dataset name when -out is not set")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	opts := am.DatasetOptions{
+		Format:          *format,
+		Project:         *project,
+		MinConfidence:   *minConfidence,
+		ValidationSplit: *split,
+		NoRedact:        *noRedact,
+	}
+	if *providers != "" {
+		opts.Providers = strings.Split(*providers, ",")
+	}
+
+	var err error
+	if opts.Since, err = parseDateParam(*since, false); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -since: %v\n", err)
+		return 2
+	}
+	if opts.Until, err = parseDateParam(*until, true); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -until: %v\n", err)
+		return 2
+	}
+
+	outDir := *out
+	if outDir == "" {
+		if outDir, err = datasetOutputDir(*name); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	manifest, err := am.ExportDataset(am.DefaultAMDir(), outDir, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export failed: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Wrote %d examples (%d train, %d validation) from %d conversations to %s\n",
+		manifest.Examples, manifest.TrainExamples, manifest.ValidationExamples,
+		manifest.ConversationsExported, outDir)
+	if manifest.Duplicates > 0 || manifest.Redactions > 0 {
+		fmt.Printf("Skipped %d duplicates, redacted %d secrets\n", manifest.Duplicates, manifest.Redactions)
+	}
+	return 0
+}
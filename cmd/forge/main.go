@@ -5,27 +5,36 @@ import (
 	"context"
 	"embed"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"io/fs"
 	"log"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/mikejsmith1985/forge-terminal/internal/am"
 	"github.com/mikejsmith1985/forge-terminal/internal/assistant"
+	"github.com/mikejsmith1985/forge-terminal/internal/assistant/eval"
+	"github.com/mikejsmith1985/forge-terminal/internal/auth"
 	"github.com/mikejsmith1985/forge-terminal/internal/commands"
 	"github.com/mikejsmith1985/forge-terminal/internal/diagnostic"
 	"github.com/mikejsmith1985/forge-terminal/internal/files"
 	"github.com/mikejsmith1985/forge-terminal/internal/llm"
+	"github.com/mikejsmith1985/forge-terminal/internal/metrics"
+	"github.com/mikejsmith1985/forge-terminal/internal/prompts"
 	"github.com/mikejsmith1985/forge-terminal/internal/storage"
 	"github.com/mikejsmith1985/forge-terminal/internal/terminal"
 	"github.com/mikejsmith1985/forge-terminal/internal/updater"
@@ -40,11 +49,43 @@ var preferredPorts = []int{8333, 8080, 9000, 3000, 3333}
 // Global assistant service (initialized in main)
 var assistantService assistant.Service
 
+// Global assistant core, for features that need more than the Service interface (initialized in main)
+var assistantCore *assistant.Core
+
+// Global AM conversation index used for conversation search (initialized in main)
+var conversationIndex *assistant.ConversationIndex
+
+// Global API authentication: install secret and session token (initialized in main)
+var authManager *auth.Manager
+
+// Global model evaluation runner (initialized in main)
+var evalRunner *eval.Runner
+
 func main() {
-	// Set up file-based logging for production diagnostics
+	// Offline subcommands run without starting the server
+	if len(os.Args) > 1 && os.Args[1] == "export-dataset" {
+		os.Exit(runExportDataset(os.Args[2:]))
+	}
+	// CLI subcommands drive the running instance over its control socket
+	if len(os.Args) > 1 && cliCommands[os.Args[1]] != nil {
+		os.Exit(runCLI(os.Args[1], os.Args[2:]))
+	}
+
+	// Bind address, port and TLS from ~/.forge/server.json and flags
+	serverCfg, err := loadServerConfig(os.Args[1:])
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, "forge:", err)
+		os.Exit(2)
+	}
+
+	// Set up file-based logging for production diagnostics. The log holds terminal
+	// and conversation details, so only this user may read it
 	logFile, err := os.OpenFile(filepath.Join(os.Getenv("HOME"), ".forge", "forge.log"),
-		os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
 	if err == nil {
+		logFile.Chmod(0600) // Logs created before this were world-readable
 		// Log to both file and stdout
 		log.SetOutput(os.Stdout) // Keep stdout for console
 		log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
@@ -64,6 +105,12 @@ func main() {
 	}
 	log.Printf("[Forge] Storage structure: %s", storage.GetCurrentStructure())
 
+	// API authentication: every route and the terminal WebSocket need this launch's token
+	authManager, err = auth.Load(storage.GetAuthSecretPath())
+	if err != nil {
+		log.Fatalf("[Auth] Failed to load API secret: %v", err)
+	}
+
 	// Serve embedded frontend with no-cache headers
 	webFS, err := fs.Sub(embeddedFS, "web")
 	if err != nil {
@@ -75,6 +122,9 @@ func main() {
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		// Serve index.html with version-busted asset URLs
 		if r.URL.Path == "/" || r.URL.Path == "/index.html" {
+			if !authorizeFrontend(w, r) {
+				return
+			}
 			serveIndexWithVersion(w, r, webFS)
 			return
 		}
@@ -89,15 +139,48 @@ func main() {
 	// WebSocket terminal handler
 	// Run AM cleanup on startup and initialize AM system
 	go am.CleanupOldLogs()
+	// Fold journals left by an unclean exit before any tab loads its conversations
+	if _, err := am.CompactJournals(am.DefaultAMDir()); err != nil {
+		log.Printf("[AM] Failed to recover conversation journals: %v", err)
+	}
+	// Event journal: record AM events under ~/.forge/am/events when enabled in ~/.forge/eventjournal.json
+	if cfg := am.LoadEventJournalConfig(); cfg.Enabled {
+		if journal, err := am.OpenEventJournal(storage.GetEventJournalDir(), cfg.RetentionDays); err != nil {
+			log.Printf("[AM Events] Failed to open event journal: %v", err)
+		} else {
+			am.EventBus.EnableJournal(journal)
+			defer am.EventBus.DisableJournal()
+		}
+	}
 	amSystem := am.InitSystem(am.DefaultAMDir())
 	if err := amSystem.Start(); err != nil {
 		log.Printf("[AM] Failed to start AM system: %v", err)
 	}
 
+	// Retention janitor: compresses and prunes old conversations per ~/.forge/am/retention.json
+	retentionJanitor := am.StartRetentionJanitor(am.DefaultAMDir(), amSystem.HealthMonitor)
+
+	// Native transcripts: when a Claude or Aider conversation ends, import the CLI's own log
+	transcriptImporter := am.StartNativeTranscriptImporter(am.DefaultAMDir())
+
+	// Full-text search index; loads or rebuilds in the background, then tracks saves
+	go func() {
+		if _, err := am.OpenSearchIndex(am.DefaultAMDir()); err != nil {
+			log.Printf("[AM Search] Failed to open search index: %v", err)
+		}
+	}()
+
 	// Initialize assistant core with AM system
-	assistantCore := assistant.NewCore(amSystem)
+	assistantCore = assistant.NewCore(amSystem)
 	log.Printf("[Assistant] Core initialized")
 
+	// Keep AM conversations searchable: index new ones as they complete
+	conversationIndex = assistantCore.GetConversationIndex()
+	conversationIndex.Subscribe(am.DefaultAMDir())
+
+	// Prometheus metrics read from AM health and the RAG indexes at scrape time
+	collectMetrics(amSystem)
+
 	// Index documentation for RAG
 	go func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
@@ -127,14 +210,48 @@ func main() {
 		} else {
 			log.Printf("[RAG] Docs path not found: %s", docsPath)
 		}
+
+		// Backfill conversations captured while Forge wasn't running
+		if _, err := conversationIndex.IndexAll(ctx, am.DefaultAMDir()); err != nil {
+			log.Printf("[RAG] Warning: Failed to index AM conversations: %v", err)
+		}
 	}()
 
+	// Model evaluation runs in-process against the assistant's own prompt
+	ollamaClient := assistantCore.GetOllamaClient()
+	evalRunner = eval.NewRunner(eval.RunnerConfig{
+		Backend:    ollamaClient,
+		RunsDir:    filepath.Join(storage.GetEvalDir(), "runs"),
+		GoldensDir: filepath.Join(storage.GetEvalDir(), "goldens"),
+		JudgeModel: os.Getenv("FORGE_EVAL_JUDGE_MODEL"),
+		SystemPrompt: func(ctx context.Context, question string) string {
+			data := assistantCore.GetRAGEngine().PromptData(ctx, question, assistant.DefaultRAGConfig())
+			prompt, err := prompts.Render(prompts.RAG, data)
+			if err != nil {
+				return assistantCore.GetKnowledgeBase().GetSystemPrompt()
+			}
+			return prompt
+		},
+	})
+
 	// Wrap core in LocalService (v1 implementation)
 	assistantService = assistant.NewLocalService(assistantCore)
 	log.Printf("[Assistant] LocalService initialized")
 
 	termHandler := terminal.NewHandler(assistantService, assistantCore)
-	http.HandleFunc("/ws", termHandler.HandleWebSocket)
+	http.HandleFunc("/ws", RequireAuth(termHandler.HandleWebSocket))
+
+	// Control socket for the forge CLI (forge ls, open, send, tail, ...)
+	controlListener, err := listenControl(storage.GetControlSocketPath())
+	if err != nil {
+		log.Printf("[Control] ⚠️ CLI control socket unavailable: %v", err)
+	} else {
+		log.Printf("[Control] CLI control socket at %s", storage.GetControlSocketPath())
+		go http.Serve(controlListener, controlMux(termHandler))
+	}
+
+	// Auth API - rotate the session token (and optionally the install secret)
+	http.HandleFunc("/api/auth/rotate", WrapWithMiddleware(handleAuthRotate))
 
 	// Commands API
 	http.HandleFunc("/api/commands", WrapWithMiddleware(handleCommands))
@@ -177,10 +294,24 @@ func main() {
 	http.HandleFunc("/api/am/llm/conversations/", WrapWithMiddleware(handleAMLLMConversations))
 	http.HandleFunc("/api/am/llm/conversation/", WrapWithMiddleware(handleAMLLMConversationDetail))
 	http.HandleFunc("/api/am/health", WrapWithMiddleware(handleAMHealth))
+	http.HandleFunc("/api/am/search", WrapWithMiddleware(handleAMSearch))
+	http.HandleFunc("/api/am/export", WrapWithMiddleware(handleAMExport))
+	http.HandleFunc("/api/am/tags", WrapWithMiddleware(handleAMTags))
+	http.HandleFunc("/api/am/retention", WrapWithMiddleware(handleAMRetention))
+	http.HandleFunc("/api/am/providers", WrapWithMiddleware(handleAMProviders))
+	http.HandleFunc("/api/am/retention/preview", WrapWithMiddleware(handleAMRetentionPreview))
+	http.HandleFunc("/api/am/retention/run", WrapWithMiddleware(handleAMRetentionRun))
 	http.HandleFunc("/api/am/conversations", WrapWithMiddleware(handleAMActiveConversations))
 	http.HandleFunc("/api/am/master-control", WrapWithMiddleware(handleAMMasterControl))
+	http.HandleFunc("/api/am/autorespond", WrapWithMiddleware(handleAMAutoRespond))
+	http.HandleFunc("/api/am/usage", WrapWithMiddleware(handleAMUsage))
+	http.HandleFunc("/api/am/events", WrapWithMiddleware(handleAMEvents)) // SSE of AM events, replayable by cursor
+	http.HandleFunc("/metrics", WrapWithMiddleware(metrics.Default.Handler())) // Prometheus text format
 	http.HandleFunc("/api/am/restore/sessions", WrapWithMiddleware(handleAMRestoreSessions))
 	http.HandleFunc("/api/am/restore/context/", WrapWithMiddleware(handleAMRestoreContext))
+	http.HandleFunc("/api/am/restore/launch/", WrapWithMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		handleAMRestoreLaunch(w, r, termHandler)
+	}))
 	http.HandleFunc("/api/am/log", WrapWithMiddleware(handleAMLog))
 
 	// Vision Configuration & Insights API
@@ -211,17 +342,32 @@ func main() {
 	http.HandleFunc("/api/assistant/chat", WrapWithMiddleware(handleAssistantChat))
 	http.HandleFunc("/api/assistant/execute", WrapWithMiddleware(handleAssistantExecute))
 	http.HandleFunc("/api/assistant/model", WrapWithMiddleware(handleAssistantSetModel))
+	http.HandleFunc("/api/assistant/models", WrapWithMiddleware(handleAssistantModels))
+	http.HandleFunc("/api/assistant/models/pull", WrapWithMiddleware(handleAssistantModelPull))
+	http.HandleFunc("/api/assistant/models/", WrapWithMiddleware(handleAssistantModel))
 	http.HandleFunc("/api/assistant/run-tests", WrapWithMiddleware(handleAssistantRunTests))
-	http.HandleFunc("/api/assistant/train-model", WrapWithMiddleware(handleAssistantTrainModel))
-	http.HandleFunc("/api/assistant/training-status/", WrapWithMiddleware(handleAssistantTrainingStatus))
-
-	// Find an available port
-	addr, listener, err := findAvailablePort()
+	http.HandleFunc("/api/assistant/training-data/export", WrapWithMiddleware(handleAssistantTrainingDataExport))
+	http.HandleFunc("/api/assistant/eval/sets", WrapWithMiddleware(handleAssistantEvalSets))
+	http.HandleFunc("/api/assistant/eval/runs", WrapWithMiddleware(handleAssistantEvalRuns))
+	http.HandleFunc("/api/assistant/eval/runs/", WrapWithMiddleware(handleAssistantEvalRun))
+	http.HandleFunc("/api/assistant/eval/compare", WrapWithMiddleware(handleAssistantEvalCompare))
+	http.HandleFunc("/api/assistant/conversations/search", WrapWithMiddleware(handleAssistantConversationSearch))
+	http.HandleFunc("/api/assistant/prompts", WrapWithMiddleware(handleAssistantPrompts))
+	http.HandleFunc("/api/assistant/prompts/preview", WrapWithMiddleware(handleAssistantPromptPreview))
+
+	// Listen on the configured address (an available local port by default)
+	addr, listener, err := listen(serverCfg)
 	if err != nil {
-		log.Fatalf("Failed to find available port: %v", err)
+		log.Fatalf("Failed to listen: %v", err)
 	}
 
-	log.Printf("🔥 Forge Terminal starting at http://%s", addr)
+	log.Printf("🔥 Forge Terminal starting at %s://%s", serverCfg.scheme(), addr)
+	launchURL := fmt.Sprintf("%s://%s/?%s=%s", serverCfg.scheme(), serverCfg.browserHost(addr), auth.QueryParam, authManager.Token())
+	// The token link goes to the terminal only; forge.log outlives the launch
+	fmt.Printf("[Auth] Open %s to connect from another browser\n", launchURL)
+	if !serverCfg.isLoopback() {
+		log.Printf("[Server] Remote access enabled; cross-origin requests allowed from: %s", strings.Join(auth.AllowedOrigins(), ", "))
+	}
 
 	// Handle graceful shutdown
 	stop := make(chan os.Signal, 1)
@@ -230,12 +376,20 @@ func main() {
 	go func() {
 		<-stop
 		log.Println("\n👋 Shutting down Forge...")
+		retentionJanitor.Stop()
+		if controlListener != nil {
+			controlListener.Close() // Removes the socket file
+		}
+		transcriptImporter.Stop()
+		if index := am.GetSearchIndex(am.DefaultAMDir()); index != nil {
+			index.Close()
+		}
 		os.Exit(0)
 	}()
 
 	// Auto-open browser (skip if NO_BROWSER env var is set for testing)
 	if os.Getenv("NO_BROWSER") == "" {
-		go openBrowser("http://" + addr)
+		go openBrowser(launchURL)
 	}
 
 	log.Fatal(http.Serve(listener, nil))
@@ -479,10 +633,76 @@ func handleWSLDetect(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// findAvailablePort tries preferred ports in order and returns the first available one
-func findAvailablePort() (string, net.Listener, error) {
+// authorizeFrontend hands the session token to the embedded frontend as a cookie,
+// only in exchange for the launch URL's ?token=, which is then dropped from the
+// address bar. Loopback alone isn't enough: any local user or process could
+// otherwise claim the cookie. It writes a 401 and returns false when the request
+// can't be authorized.
+func authorizeFrontend(w http.ResponseWriter, r *http.Request) bool {
+	if r.URL.Query().Get(auth.QueryParam) != "" {
+		if !authManager.Authenticate(r) {
+			http.Error(w, "Invalid or expired Forge token", http.StatusUnauthorized)
+			return false
+		}
+		authManager.SetCookie(w, r)
+		query := r.URL.Query()
+		query.Del(auth.QueryParam)
+		target := r.URL.Path
+		if encoded := query.Encode(); encoded != "" {
+			target += "?" + encoded
+		}
+		http.Redirect(w, r, target, http.StatusSeeOther)
+		return false
+	}
+	if authManager.Authenticate(r) {
+		return true
+	}
+	http.Error(w, "Open Forge with the token link printed when it started", http.StatusUnauthorized)
+	return false
+}
+
+// handleAuthRotate replaces the session token, logging out every other client.
+// With {"secret": true} the per-install secret is regenerated too. The caller
+// gets the new token as a cookie and in the response.
+func handleAuthRotate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Secret bool `json:"secret"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	token, err := authManager.Rotate(req.Secret)
+	if err != nil {
+		log.Printf("[Auth] Rotation failed: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	log.Printf("[Auth] ✅ Session token rotated (secret rotated: %v)", req.Secret)
+	authManager.SetCookie(w, r)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"token":   token,
+	})
+}
+
+// findAvailablePort tries preferred ports on bind in order and returns the first available one
+func findAvailablePort(bind string) (string, net.Listener, error) {
 	for _, port := range preferredPorts {
-		addr := fmt.Sprintf("127.0.0.1:%d", port)
+		addr := net.JoinHostPort(bind, strconv.Itoa(port))
 		listener, err := net.Listen("tcp", addr)
 		if err == nil {
 			return addr, listener, nil
@@ -491,7 +711,7 @@ func findAvailablePort() (string, net.Listener, error) {
 	}
 
 	// Fallback: let OS assign a random available port
-	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	listener, err := net.Listen("tcp", net.JoinHostPort(bind, "0"))
 	if err != nil {
 		return "", nil, fmt.Errorf("no available ports: %w", err)
 	}
@@ -845,29 +1065,45 @@ func handleWelcome(w http.ResponseWriter, r *http.Request) {
 // AM (Artificial Memory) handlers
 
 func inferLLMProvider(explicit string, command string) llm.Provider {
-	// Use explicit provider if specified
-	switch strings.ToLower(explicit) {
-	case "copilot", "github-copilot":
-		return llm.ProviderGitHubCopilot
-	case "claude":
-		return llm.ProviderClaude
-	case "aider":
-		return llm.ProviderAider
+	registry := llm.GetRegistry()
+
+	// Use explicit provider if specified (ID or alias)
+	if def := registry.Resolve(explicit); def != nil {
+		return def.ID
 	}
 
 	// Fallback: infer from command text
-	lower := strings.ToLower(command)
-	if strings.Contains(lower, "copilot") || strings.Contains(lower, "gh copilot") {
-		return llm.ProviderGitHubCopilot
+	if def := registry.Infer(command); def != nil {
+		return def.ID
 	}
-	if strings.Contains(lower, "claude") {
-		return llm.ProviderClaude
+
+	return llm.ProviderUnknown
+}
+
+// handleAMProviders lists the registered LLM provider definitions (GET) or reloads
+// them from ~/.forge/providers (POST).
+func handleAMProviders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
-	if strings.Contains(lower, "aider") {
-		return llm.ProviderAider
+
+	w.Header().Set("Content-Type", "application/json")
+
+	loadErrors := []string{}
+	if r.Method == http.MethodPost {
+		for _, err := range llm.ReloadProviders() {
+			log.Printf("[AM API] ⚠️ Provider definition skipped: %v", err)
+			loadErrors = append(loadErrors, err.Error())
+		}
+		log.Printf("[AM API] ✅ Reloaded provider definitions")
 	}
 
-	return llm.ProviderUnknown
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   true,
+		"providers": llm.GetRegistry().List(),
+		"errors":    loadErrors,
+	})
 }
 
 // inferLLMType determines the command type from explicit field
@@ -1098,6 +1334,70 @@ func handleAMMasterControl(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleAMAutoRespond reports the auto-respond kill switch and the policy that applies
+// to a directory (GET ?dir=), or engages/releases the kill switch (POST). A POST with
+// trustDir trusts (or, with "trust": false, stops trusting) the policy that
+// directory's repository ships instead.
+func handleAMAutoRespond(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		policy, err := am.LoadAutoRespondPolicy(r.URL.Query().Get("dir"))
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success":    false,
+				"error":      err.Error(),
+				"killSwitch": am.AutoRespondKillSwitchEngaged(),
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":           true,
+			"killSwitch":        am.AutoRespondKillSwitchEngaged(),
+			"policy":            policy,
+			"projectPolicyPath": am.ProjectPolicyPath(r.URL.Query().Get("dir")),
+		})
+	case http.MethodPost:
+		var req struct {
+			KillSwitch bool   `json:"killSwitch"`
+			TrustDir   string `json:"trustDir"`
+			Trust      bool   `json:"trust"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"error":   "Invalid JSON",
+			})
+			return
+		}
+		if req.TrustDir != "" {
+			if err := am.TrustProjectPolicy(req.TrustDir, req.Trust); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"success": false,
+					"error":   err.Error(),
+				})
+				return
+			}
+			log.Printf("[AM Auto-Respond] Repository policy in %s trusted: %v", req.TrustDir, req.Trust)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": true,
+				"trusted": req.Trust,
+			})
+			return
+		}
+		am.SetAutoRespondKillSwitch(req.KillSwitch)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":    true,
+			"killSwitch": req.KillSwitch,
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
 func handleAMLLMConversations(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -1121,6 +1421,28 @@ func handleAMLLMConversations(w http.ResponseWriter, r *http.Request) {
 	log.Printf("[AM API] Retrieved LLM logger for tab %s", tabID)
 
 	conversations := llmLogger.GetConversations()
+
+	// Optional ?tag=a,b keeps conversations carrying every listed tag
+	annotations, err := am.ListAnnotations(am.DefaultAMDir())
+	if err != nil {
+		log.Printf("[AM API] ⚠️ Failed to read annotations: %v", err)
+	}
+	if tagParam := r.URL.Query().Get("tag"); tagParam != "" {
+		tags := strings.Split(tagParam, ",")
+		filtered := conversations[:0:0]
+		for _, conv := range conversations {
+			if annotations[conv.ConversationID].HasTags(tags) {
+				filtered = append(filtered, conv)
+			}
+		}
+		conversations = filtered
+	}
+	listed := make(map[string]*am.ConversationAnnotations)
+	for _, conv := range conversations {
+		if a, ok := annotations[conv.ConversationID]; ok {
+			listed[conv.ConversationID] = a
+		}
+	}
 	count := len(conversations)
 
 	log.Printf("[AM API] GetConversations() returned %d conversations for tab %s", count, tabID)
@@ -1139,21 +1461,72 @@ func handleAMLLMConversations(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success":       true,
 		"conversations": conversations,
+		"annotations":   listed,
 		"count":         count,
 	})
 }
 
 func handleAMLLMConversationDetail(w http.ResponseWriter, r *http.Request) {
+	// Extract tab ID and conversation ID from URL path
+	// Format: /api/am/llm/conversation/{tabID}/{conversationID}
+	//     or: /api/am/llm/conversation/{conversationID} (any tab)
+	//     or: /api/am/llm/conversation/{conversationID}/export?format=md|html|jsonl
+	//     or: /api/am/llm/conversation/{conversationID}/annotations
+	//     or: /api/am/llm/conversation/{conversationID}/turns/{index}/star
+	//     or: /api/am/llm/conversation/{conversationID}/native-transcript
+	//     or: /api/am/llm/conversation/{conversationID}/checkpoints[/diff|/restore]
+	pathParts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	if len(pathParts) == 7 && pathParts[6] == "export" {
+		handleAMConversationExport(w, r, pathParts[5])
+		return
+	}
+	if len(pathParts) == 7 && pathParts[6] == "annotations" {
+		handleAMConversationAnnotations(w, r, pathParts[5])
+		return
+	}
+	if len(pathParts) == 7 && pathParts[6] == "native-transcript" {
+		handleAMNativeTranscript(w, r, pathParts[5])
+		return
+	}
+	if (len(pathParts) == 7 || len(pathParts) == 8) && pathParts[6] == "checkpoints" {
+		action := ""
+		if len(pathParts) == 8 {
+			action = pathParts[7]
+		}
+		handleAMCheckpoints(w, r, pathParts[5], action)
+		return
+	}
+	if len(pathParts) == 9 && pathParts[6] == "turns" && pathParts[8] == "star" {
+		handleAMTurnStar(w, r, pathParts[5], pathParts[7])
+		return
+	}
+
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	if len(pathParts) == 6 && pathParts[5] != "" {
+		convID := pathParts[5]
+		log.Printf("[AM API] GET /api/am/llm/conversation/%s", convID)
 
-	// Extract tab ID and conversation ID from URL path
-	// Format: /api/am/llm/conversation/{tabID}/{conversationID}
-	pathParts := strings.Split(r.URL.Path, "/")
+		conversation, err := am.FindConversation(am.DefaultAMDir(), convID)
+		if err != nil {
+			log.Printf("[AM API] ⚠️ Conversation %s not found", convID)
+			http.Error(w, "Conversation not found", http.StatusNotFound)
+			return
+		}
+
+		annotations, _ := am.GetAnnotations(am.DefaultAMDir(), convID)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":      true,
+			"conversation": conversation,
+			"annotations":  annotations,
+			"usage":        am.ComputeUsage(conversation, am.LoadPricingTable()),
+		})
+		return
+	}
 	if len(pathParts) < 7 {
 		http.Error(w, "Tab ID and Conversation ID required", http.StatusBadRequest)
 		return
@@ -1178,94 +1551,732 @@ func handleAMLLMConversationDetail(w http.ResponseWriter, r *http.Request) {
 		conversation.ConversationID, conversation.Provider,
 		len(conversation.Turns), len(conversation.ScreenSnapshots))
 
+	annotations, _ := am.GetAnnotations(am.DefaultAMDir(), convID)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success":      true,
 		"conversation": conversation,
+		"annotations":  annotations,
+		"usage":        am.ComputeUsage(conversation, am.LoadPricingTable()),
 	})
 }
 
-func handleAMHealth(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
+// handleAMConversationAnnotations reads (GET) or replaces (PUT) a conversation's tags and note.
+func handleAMConversationAnnotations(w http.ResponseWriter, r *http.Request, convID string) {
 	w.Header().Set("Content-Type", "application/json")
+	amDir := am.DefaultAMDir()
 
-	system := am.GetSystem()
-	if system == nil {
+	switch r.Method {
+	case http.MethodGet:
+		annotations, err := am.GetAnnotations(amDir, convID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"status": "NOT_INITIALIZED",
+			"success":     true,
+			"annotations": annotations,
 		})
-		return
-	}
 
-	health := system.GetHealth()
-	json.NewEncoder(w).Encode(health)
+	case http.MethodPut, http.MethodPost:
+		var req struct {
+			Tags []string `json:"tags"`
+			Note string   `json:"note"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if _, err := am.FindConversation(amDir, convID); err != nil {
+			http.Error(w, "Conversation not found", http.StatusNotFound)
+			return
+		}
+
+		annotations, err := am.SetAnnotations(amDir, convID, req.Tags, req.Note)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Printf("[AM API] Annotated %s: tags=%v", convID, annotations.Tags)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":     true,
+			"annotations": annotations,
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
 }
 
-func handleAMActiveConversations(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
+// handleAMNativeTranscript imports (POST) the CLI's own transcript of a conversation
+// as a linked, high-fidelity conversation.
+func handleAMNativeTranscript(w http.ResponseWriter, r *http.Request, convID string) {
+	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	amDir := am.DefaultAMDir()
+	if _, err := am.FindConversation(amDir, convID); err != nil {
+		http.Error(w, "Conversation not found", http.StatusNotFound)
+		return
+	}
 
-	system := am.GetSystem()
-	if system == nil {
+	imported, err := am.ImportNativeTranscript(amDir, convID)
+	if err != nil {
+		status := http.StatusUnprocessableEntity
+		if errors.Is(err, am.ErrNoTranscript) {
+			status = http.StatusNotFound
+		}
+		log.Printf("[AM API] ⚠️ Native transcript import for %s failed: %v", convID, err)
+		w.WriteHeader(status)
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"active": map[string]interface{}{},
-			"count":  0,
+			"success": false,
+			"error":   err.Error(),
 		})
 		return
 	}
 
-	convs := system.GetActiveConversations()
+	log.Printf("[AM API] Imported native transcript for %s as %s (%d turns)",
+		convID, imported.ConversationID, len(imported.Turns))
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"active": convs,
-		"count":  len(convs),
+		"success":      true,
+		"conversation": imported,
 	})
 }
 
-// handleAMRestoreSessions returns all recoverable sessions.
-func handleAMRestoreSessions(w http.ResponseWriter, r *http.Request) {
+// handleAMUsage reports token usage and cost as a time series with per-provider,
+// per-project and per-day rollups.
+// Query: since, until (RFC 3339 or YYYY-MM-DD), interval (hour|day|week),
+// groupBy (provider|project|conversation), provider, project.
+func handleAMUsage(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	query := r.URL.Query()
+	q := am.UsageQuery{
+		Interval: query.Get("interval"),
+		GroupBy:  query.Get("groupBy"),
+		Provider: query.Get("provider"),
+		Project:  query.Get("project"),
+	}
+	var err error
+	if q.Since, err = parseDateParam(query.Get("since"), false); err != nil {
+		http.Error(w, "Invalid since: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if q.Until, err = parseDateParam(query.Get("until"), true); err != nil {
+		http.Error(w, "Invalid until: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := am.ValidateUsageQuery(&q); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	amDir := am.DefaultAMDir()
-	cb := am.NewContextBuilder(amDir)
-
-	sessions, err := cb.GetRecoverableSessions()
+	report, err := am.BuildUsageReport(am.DefaultAMDir(), q)
 	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success":  false,
-			"error":    err.Error(),
-			"sessions": []interface{}{},
+			"success": false,
+			"error":   err.Error(),
 		})
 		return
 	}
-
-	log.Printf("[AM Restore] Found %d recoverable sessions", len(sessions))
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success":  true,
-		"sessions": sessions,
-		"count":    len(sessions),
+		"success": true,
+		"usage":   report,
 	})
 }
 
-// handleAMRestoreContext returns restore context for a specific conversation.
-func handleAMRestoreContext(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+// collectMetrics points the scrape-time metrics at the AM health monitor and the
+// assistant's RAG indexes.
+func collectMetrics(amSystem *am.System) {
+	if hm := amSystem.HealthMonitor; hm != nil {
+		metrics.AMConversationsActive.CollectValue(func() float64 {
+			return float64(hm.GetMetrics().ConversationsActive)
+		})
+		metrics.AMConversationsEnded.Collect(func() map[string]float64 {
+			ended := make(map[string]float64)
+			for method, n := range hm.GetMetrics().EndMethods {
+				ended[method] = float64(n)
+			}
+			return ended
+		})
+		metrics.AMParseFailures.CollectValue(func() float64 {
+			return float64(hm.GetMetrics().InputParseFailures)
+		})
+	}
+	metrics.RAGIndexChunks.Collect(func() map[string]float64 {
+		ragEngine := assistantCore.GetRAGEngine()
+		if ragEngine == nil {
+			return nil
+		}
+		docs, conversations := ragEngine.IndexSizes()
+		return map[string]float64{"docs": float64(docs), "conversations": float64(conversations)}
+	})
+}
+
+// handleAMEvents streams AM events as Server-Sent Events. Each event's id is its
+// sequence number; with ?cursor= (or a Last-Event-ID header on reconnect) the
+// events after it are replayed before the live stream. ?topics= takes a
+// comma-separated list of topics to filter on.
+func handleAMEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	var topics []am.Topic
+	for _, t := range strings.Split(r.URL.Query().Get("topics"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			topics = append(topics, am.Topic(t))
+		}
+	}
+	cursorParam := r.URL.Query().Get("cursor")
+	if cursorParam == "" {
+		cursorParam = r.Header.Get("Last-Event-ID")
+	}
+	replay := cursorParam != ""
+	var cursor uint64
+	if replay {
+		var err error
+		if cursor, err = strconv.ParseUint(cursorParam, 10, 64); err != nil {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "SSE not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	// Subscribe before replaying so nothing published in between is missed
+	live := make(chan *am.LayerEvent, 256)
+	lagged := make(chan struct{})
+	var lagOnce sync.Once
+	sub := am.EventBus.Subscribe(func(event *am.LayerEvent) {
+		select {
+		case live <- event:
+		default:
+			lagOnce.Do(func() { close(lagged) })
+		}
+	}, topics...)
+	defer sub.Unsubscribe()
+
+	sent := am.EventBus.Cursor()
+	send := func(event *am.LayerEvent) {
+		data, _ := json.Marshal(event)
+		fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, data)
+		sent = event.Seq
+	}
+
+	fmt.Fprintf(w, "event: connected\ndata: {\"cursor\":%d}\n\n", sent)
+	if replay {
+		sent = cursor
+		for {
+			events, err := am.EventBus.Replay(sent, 500, topics...)
+			if err != nil {
+				log.Printf("[AM Events] Replay after %d failed: %v", sent, err)
+				data, _ := json.Marshal(map[string]string{"error": err.Error()})
+				fmt.Fprintf(w, "event: error\ndata: %s\n\n", data)
+				break
+			}
+			if len(events) == 0 {
+				break
+			}
+			for _, event := range events {
+				send(event)
+			}
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-live:
+			if event.Seq <= sent {
+				continue // Already replayed
+			}
+			send(event)
+			flusher.Flush()
+		case <-lagged:
+			// The client can't keep up; it reconnects with Last-Event-ID to catch up
+			fmt.Fprintf(w, "event: lagged\ndata: {\"cursor\":%d}\n\n", sent)
+			flusher.Flush()
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// handleAMCheckpoints lists a conversation's git working-tree checkpoints (GET),
+// diffs the working tree against one (GET diff?ref=) or restores one (POST restore
+// {"ref"}). An empty ref means the checkpoint taken when the conversation started.
+func handleAMCheckpoints(w http.ResponseWriter, r *http.Request, convID, action string) {
+	method := http.MethodGet
+	if action == "restore" {
+		method = http.MethodPost
+	}
+	if r.Method != method {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	conversation, err := am.FindConversation(am.DefaultAMDir(), convID)
+	if err != nil {
+		http.Error(w, "Conversation not found", http.StatusNotFound)
+		return
+	}
+	dir := conversation.GetWorkingDirectory()
+
+	var result map[string]interface{}
+	switch action {
+	case "":
+		var checkpoints []*am.Checkpoint
+		if checkpoints, err = am.ListCheckpoints(dir, convID); err == nil {
+			result = map[string]interface{}{"checkpoints": checkpoints}
+		}
+	case "diff":
+		var diff *am.CheckpointDiff
+		if diff, err = am.DiffCheckpoint(dir, convID, r.URL.Query().Get("ref")); err == nil {
+			result = map[string]interface{}{"diff": diff}
+		}
+	case "restore":
+		var req struct {
+			Ref string `json:"ref"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+		}
+		var backup *am.Checkpoint
+		if backup, err = am.RestoreCheckpoint(dir, convID, req.Ref); err == nil {
+			log.Printf("[AM API] Restored checkpoint %q for %s (undo: %s)", req.Ref, convID, backup.Ref)
+			result = map[string]interface{}{"undo": backup}
+		}
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		status := http.StatusUnprocessableEntity
+		if errors.Is(err, am.ErrNoCheckpoint) || errors.Is(err, am.ErrNotGitRepo) {
+			status = http.StatusNotFound
+		}
+		log.Printf("[AM API] ⚠️ Checkpoint %s for %s failed: %v", action, convID, err)
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	result["success"] = true
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleAMTurnStar stars (POST/PUT) or unstars (DELETE) one turn of a conversation.
+func handleAMTurnStar(w http.ResponseWriter, r *http.Request, convID, indexParam string) {
+	var starred bool
+	switch r.Method {
+	case http.MethodPost, http.MethodPut:
+		starred = true
+	case http.MethodDelete:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	amDir := am.DefaultAMDir()
+
+	index, err := strconv.Atoi(indexParam)
+	if err != nil || index < 0 {
+		http.Error(w, "Invalid turn index", http.StatusBadRequest)
+		return
+	}
+	conversation, err := am.FindConversation(amDir, convID)
+	if err != nil {
+		http.Error(w, "Conversation not found", http.StatusNotFound)
+		return
+	}
+	if index >= len(conversation.Turns) {
+		http.Error(w, "Turn not found", http.StatusNotFound)
+		return
+	}
+
+	annotations, err := am.StarTurn(amDir, convID, index, starred)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":     true,
+		"annotations": annotations,
+	})
+}
+
+// handleAMTags lists every tag in use with the number of conversations carrying it.
+func handleAMTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	counts, err := am.TagCounts(am.DefaultAMDir())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"tags":    counts,
+	})
+}
+
+// handleAMConversationExport downloads one conversation as Markdown, HTML or JSONL.
+// Query params: format (md, html, jsonl; default md), snapshots (include raw screens).
+func handleAMConversationExport(w http.ResponseWriter, r *http.Request, convID string) {
+	opts, ok := exportOptionsFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	conversation, err := am.FindConversation(am.DefaultAMDir(), convID)
+	if err != nil {
+		http.Error(w, "Conversation not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", am.ExportContentType(opts.Format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", am.ExportFilename(conversation, opts.Format)))
+	if err := am.ExportConversation(w, conversation, opts); err != nil {
+		log.Printf("[AM API] ❌ Export of %s failed: %v", convID, err)
+	}
+}
+
+// handleAMExport downloads a zip of conversations filtered by project, provider and date range.
+// Query params: format, snapshots, project, provider, since, until.
+func handleAMExport(w http.ResponseWriter, r *http.Request) {
+	opts, ok := exportOptionsFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := am.ExportFilter{
+		Project:  query.Get("project"),
+		Provider: query.Get("provider"),
+	}
+	var err error
+	if filter.Since, err = parseDateParam(query.Get("since"), false); err != nil {
+		http.Error(w, "Invalid since: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.Until, err = parseDateParam(query.Get("until"), true); err != nil {
+		http.Error(w, "Invalid until: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Render into memory first so a failure can still be reported as an error response
+	var buf bytes.Buffer
+	count, err := am.ExportConversations(&buf, am.DefaultAMDir(), filter, opts)
+	if err != nil {
+		http.Error(w, "Export failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	name := "forge-conversations"
+	if filter.Project != "" {
+		name += "-" + filter.Project
+	}
+	log.Printf("[AM API] Exported %d conversation(s) as %s", count, opts.Format)
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+"-"+opts.Format+".zip"))
+	w.Header().Set("X-Conversation-Count", strconv.Itoa(count))
+	w.Write(buf.Bytes())
+}
+
+// exportOptionsFromQuery reads format and snapshots, answering 400/405 itself when invalid.
+func exportOptionsFromQuery(w http.ResponseWriter, r *http.Request) (am.ExportOptions, bool) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return am.ExportOptions{}, false
+	}
+
+	query := r.URL.Query()
+	opts := am.ExportOptions{Format: strings.ToLower(query.Get("format"))}
+	if opts.Format == "" || opts.Format == "markdown" {
+		opts.Format = am.ExportMarkdown
+	}
+	if !am.ValidExportFormat(opts.Format) {
+		http.Error(w, "Invalid format: use md, html or jsonl", http.StatusBadRequest)
+		return am.ExportOptions{}, false
+	}
+	opts.IncludeSnapshots, _ = strconv.ParseBool(query.Get("snapshots"))
+	return opts, true
+}
+
+// handleAMSearch runs a full-text search over AM conversation turns.
+// Query params: q (words and "quoted phrases", all required), provider, project, tabId,
+// since, until, offset, limit.
+func handleAMSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		http.Error(w, "Query parameter q is required", http.StatusBadRequest)
+		return
+	}
+
+	filter := am.SearchFilter{
+		Provider: query.Get("provider"),
+		Project:  query.Get("project"),
+		TabID:    query.Get("tabId"),
+	}
+
+	var err error
+	if filter.Since, err = parseDateParam(query.Get("since"), false); err != nil {
+		http.Error(w, "Invalid since: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.Until, err = parseDateParam(query.Get("until"), true); err != nil {
+		http.Error(w, "Invalid until: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	limit := 20
+	if n, err := strconv.Atoi(query.Get("limit")); err == nil && n > 0 && n <= 100 {
+		limit = n
+	}
+
+	index := am.GetSearchIndex(am.DefaultAMDir())
+	if index == nil {
+		http.Error(w, "Search index not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	results, err := index.Search(q, filter, offset, limit)
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"query":   results.Query,
+		"total":   results.Total,
+		"offset":  results.Offset,
+		"limit":   results.Limit,
+		"results": results.Hits,
+	})
+}
+
+// handleAMRetention reads (GET) or replaces (PUT/POST) the AM retention policy.
+func handleAMRetention(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"policy":  am.LoadRetentionPolicy(am.DefaultAMDir()),
+		})
+
+	case http.MethodPut, http.MethodPost:
+		policy := am.DefaultRetentionPolicy()
+		if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := am.SaveRetentionPolicy(am.DefaultAMDir(), policy); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		log.Printf("[AM Retention] Policy updated: %+v", policy)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"policy":  policy,
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAMRetentionPreview reports what a retention pass would compress and delete.
+func handleAMRetentionPreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	amDir := am.DefaultAMDir()
+	report, err := am.PlanRetention(amDir, am.LoadRetentionPolicy(amDir), time.Now())
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"report":  report,
+	})
+}
+
+// handleAMRetentionRun applies the retention policy now instead of waiting for the janitor.
+func handleAMRetentionRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	amDir := am.DefaultAMDir()
+	report, err := am.ApplyRetention(amDir, am.LoadRetentionPolicy(amDir))
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if system := am.GetSystem(); system != nil && system.HealthMonitor != nil {
+		system.HealthMonitor.RecordRetention(report)
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"report":  report,
+	})
+}
+
+func handleAMHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	system := am.GetSystem()
+	if system == nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "NOT_INITIALIZED",
+		})
+		return
+	}
+
+	health := system.GetHealth()
+	json.NewEncoder(w).Encode(health)
+}
+
+func handleAMActiveConversations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	system := am.GetSystem()
+	if system == nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"active": map[string]interface{}{},
+			"count":  0,
+		})
+		return
+	}
+
+	convs := system.GetActiveConversations()
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"active": convs,
+		"count":  len(convs),
+	})
+}
+
+// handleAMRestoreSessions returns all recoverable sessions.
+func handleAMRestoreSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	amDir := am.DefaultAMDir()
+	cb := am.NewContextBuilder(amDir)
+
+	sessions, err := cb.GetRecoverableSessions()
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":  false,
+			"error":    err.Error(),
+			"sessions": []interface{}{},
+		})
+		return
+	}
+
+	log.Printf("[AM Restore] Found %d recoverable sessions", len(sessions))
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"sessions": sessions,
+		"count":    len(sessions),
+	})
+}
+
+// handleAMRestoreContext returns restore context for a specific conversation.
+func handleAMRestoreContext(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
 
 	// Extract conversation ID from path: /api/am/restore/context/{conversationId}
 	convID := strings.TrimPrefix(r.URL.Path, "/api/am/restore/context/")
@@ -1277,30 +2288,83 @@ func handleAMRestoreContext(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	amDir := am.DefaultAMDir()
-	cb := am.NewContextBuilder(amDir)
-
-	if r.Method == http.MethodPost {
-		// Mark as restored
-		err := cb.MarkAsRestored(convID)
-		if err != nil {
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"success": false,
-				"error":   err.Error(),
-			})
+	amDir := am.DefaultAMDir()
+	cb := am.NewContextBuilder(amDir)
+
+	if r.Method == http.MethodPost {
+		// Mark as restored
+		err := cb.MarkAsRestored(convID)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		log.Printf("[AM Restore] Marked conversation %s as restored", convID)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "conversation marked as restored",
+		})
+		return
+	}
+
+	// GET - return restore context
+	ctx, err := cb.GetRestoreContextByID(convID)
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	log.Printf("[AM Restore] Retrieved context for conversation %s", convID)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"context": ctx,
+	})
+}
+
+// handleAMRestoreLaunch continues a conversation in a fresh LLM session: it launches
+// the provider's CLI in the conversation's working directory in the given (or a new)
+// tab and pastes the restore prompt once the CLI is ready.
+func handleAMRestoreLaunch(w http.ResponseWriter, r *http.Request, termHandler *terminal.Handler) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract conversation ID from path: /api/am/restore/launch/{conversationId}
+	convID := strings.TrimPrefix(r.URL.Path, "/api/am/restore/launch/")
+	if convID == "" {
+		http.Error(w, "conversation ID required", http.StatusBadRequest)
+		return
+	}
+
+	var req terminal.RestoreRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
 			return
 		}
-		log.Printf("[AM Restore] Marked conversation %s as restored", convID)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-			"message": "conversation marked as restored",
-		})
+	}
+	req.ConversationID = convID
+	if _, err := am.FindConversation(am.DefaultAMDir(), convID); err != nil {
+		http.Error(w, "Conversation not found", http.StatusNotFound)
 		return
 	}
 
-	// GET - return restore context
-	ctx, err := cb.GetRestoreContextByID(convID)
+	result, err := termHandler.RestoreConversation(req)
 	if err != nil {
+		status := http.StatusUnprocessableEntity
+		if errors.Is(err, terminal.ErrRestoreBusy) {
+			status = http.StatusConflict
+		}
+		log.Printf("[AM Restore] ⚠️ Restore of %s failed: %v", convID, err)
+		w.WriteHeader(status)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
 			"error":   err.Error(),
@@ -1308,10 +2372,9 @@ func handleAMRestoreContext(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("[AM Restore] Retrieved context for conversation %s", convID)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
-		"context": ctx,
+		"restore": result,
 	})
 }
 
@@ -1347,182 +2410,499 @@ func handleAMLog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("[AM Log] Received: tabId=%s, entryType=%s, triggerAM=%v, provider=%s",
-		req.TabID, req.EntryType, req.TriggerAM, req.LLMProvider)
+	log.Printf("[AM Log] Received: tabId=%s, entryType=%s, triggerAM=%v, provider=%s",
+		req.TabID, req.EntryType, req.TriggerAM, req.LLMProvider)
+
+	// If this is a command card with triggerAM, start a conversation
+	if req.TriggerAM {
+		if convID := startCardConversation(req.TabID, req.LLMProvider, req.LLMType); convID != "" {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success":        true,
+				"conversationId": convID,
+			})
+			return
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
+
+// startCardConversation starts AM capture in a tab for a command card that
+// launches an LLM CLI. It returns the new conversation ID, or "" when there is no
+// provider or the tab already has an active conversation.
+func startCardConversation(tabID, provider, llmType string) string {
+	// Normalize provider names (aliases such as "copilot" map to registered IDs)
+	if def := llm.LookupProvider(provider); def != nil {
+		provider = string(def.ID)
+	}
+	if provider == "" {
+		return ""
+	}
+
+	amSystem := am.GetSystem()
+	if amSystem == nil {
+		return ""
+	}
+	logger := amSystem.GetLLMLogger(tabID)
+	if logger == nil {
+		return ""
+	}
+	// Only start if no active conversation
+	if logger.GetActiveConversationID() != "" {
+		log.Printf("[AM Log] Conversation already active for tab %s", tabID)
+		return ""
+	}
+	convID := logger.StartConversationFromProcess(provider, llmType, 0)
+	log.Printf("[AM Log] Started conversation %s for tab %s (provider: %s)", convID, tabID, provider)
+	return convID
+}
+
+func handleDesktopShortcut(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	err := createDesktopShortcut()
+	if err != nil {
+		log.Printf("[Desktop] Failed to create shortcut: %v", err)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	log.Printf("[Desktop] Shortcut created successfully")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Desktop shortcut created",
+	})
+}
+
+// handleAssistantStatus checks if Ollama is available.
+func handleAssistantStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	ctx := r.Context()
+
+	status, err := assistantService.GetStatus(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(status)
+}
+
+// handleAssistantChat processes chat messages.
+func handleAssistantChat(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	ctx := r.Context()
+
+	var req assistant.ChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response, err := assistantService.Chat(ctx, &req)
+	if err != nil {
+		log.Printf("[Assistant] Chat error: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleAssistantExecute executes a command.
+func handleAssistantExecute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	ctx := r.Context()
+
+	var req assistant.ExecuteCommandRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response, err := assistantService.ExecuteCommand(ctx, &req)
+	if err != nil {
+		log.Printf("[Assistant] Execute error: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleAssistantSetModel changes the current Ollama model.
+func handleAssistantSetModel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	ctx := r.Context()
+
+	var req assistant.SetModelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Model == "" {
+		http.Error(w, "Model name is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := assistantService.SetModel(ctx, req.Model); err != nil {
+		log.Printf("[Assistant] SetModel error: %v", err)
+		json.NewEncoder(w).Encode(assistant.SetModelResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	log.Printf("[Assistant] Model changed to: %s", req.Model)
+	json.NewEncoder(w).Encode(assistant.SetModelResponse{
+		Success: true,
+		Model:   req.Model,
+	})
+}
+
+// handleAssistantModels lists installed Ollama models with metadata and the roles using them.
+func handleAssistantModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	models, err := assistantCore.GetOllamaClient().GetModelsWithDetails(r.Context())
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"models":  models,
+		"roles":   assistantCore.ModelRoles(),
+	})
+}
+
+// handleAssistantModel shows (GET) or deletes (DELETE) /api/assistant/models/{name}.
+// Models used by the chat or embedding role cannot be deleted.
+func handleAssistantModel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	name, err := url.PathUnescape(strings.TrimPrefix(r.URL.Path, "/api/assistant/models/"))
+	if err != nil || name == "" {
+		http.Error(w, "Model name is required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		details, err := assistantCore.GetOllamaClient().ShowModel(r.Context(), name)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		usedBy := assistantCore.ModelUsedBy(name)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":   true,
+			"model":     details,
+			"usedBy":    usedBy,
+			"protected": len(usedBy) > 0,
+		})
+
+	case http.MethodDelete:
+		if err := assistantCore.DeleteModel(r.Context(), name); err != nil {
+			status := http.StatusBadGateway
+			if errors.Is(err, assistant.ErrModelProtected) {
+				status = http.StatusConflict
+			}
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"model":   name,
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAssistantModelPull pulls a model and streams Ollama's progress as Server-Sent Events.
+// Accepts POST {"model": "..."} or GET ?model=... so EventSource can be used directly.
+func handleAssistantModelPull(w http.ResponseWriter, r *http.Request) {
+	var model string
+	switch r.Method {
+	case http.MethodGet:
+		model = r.URL.Query().Get("model")
+	case http.MethodPost:
+		var req assistant.SetModelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		model = req.Model
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if model == "" {
+		http.Error(w, "Model name is required", http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "SSE not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
 
-	// Normalize provider names
-	provider := req.LLMProvider
-	switch strings.ToLower(provider) {
-	case "copilot", "gh-copilot":
-		provider = "github-copilot"
+	sendEvent := func(event string, payload interface{}) {
+		data, _ := json.Marshal(payload)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+		flusher.Flush()
 	}
 
-	// If this is a command card with triggerAM, start a conversation
-	if req.TriggerAM && provider != "" {
-		amSystem := am.GetSystem()
-		if amSystem != nil {
-			logger := amSystem.GetLLMLogger(req.TabID)
-			if logger != nil {
-				// Only start if no active conversation
-				if logger.GetActiveConversationID() == "" {
-					convID := logger.StartConversationFromProcess(
-						provider,
-						req.LLMType,
-						0,
-					)
-					log.Printf("[AM Log] Started conversation %s for tab %s (provider: %s)",
-						convID, req.TabID, provider)
-					json.NewEncoder(w).Encode(map[string]interface{}{
-						"success":        true,
-						"conversationId": convID,
-					})
-					return
-				} else {
-					log.Printf("[AM Log] Conversation already active for tab %s", req.TabID)
-				}
-			}
+	log.Printf("[Assistant] Pulling model %s", model)
+	err := assistantCore.GetOllamaClient().PullModel(r.Context(), model, func(p assistant.PullProgress) {
+		if p.Error == "" {
+			sendEvent("progress", p)
 		}
-	}
-
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
 	})
-}
-
-func handleDesktopShortcut(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-
-	err := createDesktopShortcut()
 	if err != nil {
-		log.Printf("[Desktop] Failed to create shortcut: %v", err)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"error":   err.Error(),
-		})
+		log.Printf("[Assistant] Pull of %s failed: %v", model, err)
+		sendEvent("error", map[string]string{"model": model, "error": err.Error()})
 		return
 	}
 
-	log.Printf("[Desktop] Shortcut created successfully")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"message": "Desktop shortcut created",
-	})
+	sendEvent("done", map[string]string{"model": model, "status": "success"})
 }
 
-// handleAssistantStatus checks if Ollama is available.
-func handleAssistantStatus(w http.ResponseWriter, r *http.Request) {
+// handleAssistantConversationSearch searches indexed AM conversations.
+// Query params: q (required), project, provider, since, until (YYYY-MM-DD or RFC3339), limit.
+func handleAssistantConversationSearch(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	ctx := r.Context()
 
-	status, err := assistantService.GetStatus(ctx)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	query := r.URL.Query()
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		http.Error(w, "Query parameter q is required", http.StatusBadRequest)
 		return
 	}
 
-	json.NewEncoder(w).Encode(status)
-}
+	filter := assistant.ConversationFilter{
+		Project:  query.Get("project"),
+		Provider: query.Get("provider"),
+	}
 
-// handleAssistantChat processes chat messages.
-func handleAssistantChat(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	var err error
+	if filter.Since, err = parseDateParam(query.Get("since"), false); err != nil {
+		http.Error(w, "Invalid since: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.Until, err = parseDateParam(query.Get("until"), true); err != nil {
+		http.Error(w, "Invalid until: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	ctx := r.Context()
+	limit := 10
+	if l := query.Get("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 {
+			limit = n
+		}
+	}
 
-	var req assistant.ChatRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if conversationIndex == nil {
+		http.Error(w, "Conversation index not initialized", http.StatusServiceUnavailable)
 		return
 	}
 
-	response, err := assistantService.Chat(ctx, &req)
+	hits, err := conversationIndex.Search(r.Context(), q, filter, limit)
 	if err != nil {
-		log.Printf("[Assistant] Chat error: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("[Assistant] Conversation search error: %v", err)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
 		return
 	}
 
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"results": hits,
+		"count":   len(hits),
+	})
 }
 
-// handleAssistantExecute executes a command.
-func handleAssistantExecute(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+// parseDateParam parses a YYYY-MM-DD or RFC3339 query value. A bare date used as an
+// upper bound covers the whole day.
+func parseDateParam(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	ctx := r.Context()
-
-	var req assistant.ExecuteCommandRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
 	}
-
-	response, err := assistantService.ExecuteCommand(ctx, &req)
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
 	if err != nil {
-		log.Printf("[Assistant] Execute error: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD or RFC3339")
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// handleAssistantPrompts lists the prompt templates in effect and where to override them.
+func handleAssistantPrompts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	json.NewEncoder(w).Encode(response)
+	w.Header().Set("Content-Type", "application/json")
+
+	store := prompts.Default()
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   true,
+		"directory": store.Dir(),
+		"templates": store.List(),
+	})
 }
 
-// handleAssistantSetModel changes the current Ollama model.
-func handleAssistantSetModel(w http.ResponseWriter, r *http.Request) {
+// handleAssistantPromptPreview renders a prompt template against real data.
+// The system template uses the knowledge base, terminal_context the tab's terminal
+// context, rag the retrieval results for message, and restore a stored conversation.
+// An optional template body previews unsaved edits.
+func handleAssistantPromptPreview(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	ctx := r.Context()
 
-	var req assistant.SetModelRequest
+	var req struct {
+		Name           string `json:"name"`
+		Template       string `json:"template,omitempty"`
+		ConversationID string `json:"conversationId,omitempty"`
+		TabID          string `json:"tabId,omitempty"`
+		Message        string `json:"message,omitempty"`
+	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	if req.Model == "" {
-		http.Error(w, "Model name is required", http.StatusBadRequest)
+	var data map[string]interface{}
+	switch req.Name {
+	case prompts.System:
+		data = assistantCore.GetKnowledgeBase().TemplateData()
+	case prompts.TerminalContext:
+		termCtx, err := assistantService.GetContext(r.Context(), req.TabID)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "error": err.Error()})
+			return
+		}
+		data = assistant.TerminalContextData(termCtx)
+	case prompts.RAG:
+		if req.Message == "" {
+			http.Error(w, "message is required for the rag template", http.StatusBadRequest)
+			return
+		}
+		data = assistantCore.GetRAGEngine().PromptData(r.Context(), req.Message, assistant.DefaultRAGConfig())
+	case prompts.Restore:
+		if req.ConversationID == "" {
+			http.Error(w, "conversationId is required for the restore template", http.StatusBadRequest)
+			return
+		}
+		conv, err := am.FindConversation(am.DefaultAMDir(), req.ConversationID)
+		if err != nil {
+			http.Error(w, "Conversation not found", http.StatusNotFound)
+			return
+		}
+		data = am.NewContextBuilder(am.DefaultAMDir()).RestorePromptData(conv)
+	default:
+		http.Error(w, "Unknown template: "+req.Name, http.StatusBadRequest)
 		return
 	}
 
-	if err := assistantService.SetModel(ctx, req.Model); err != nil {
-		log.Printf("[Assistant] SetModel error: %v", err)
-		json.NewEncoder(w).Encode(assistant.SetModelResponse{
-			Success: false,
-			Error:   err.Error(),
+	var rendered string
+	var err error
+	if req.Template != "" {
+		rendered, err = prompts.Default().RenderText(req.Name, req.Template, data)
+	} else {
+		rendered, err = prompts.Default().Render(req.Name, data)
+	}
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
 		})
 		return
 	}
 
-	log.Printf("[Assistant] Model changed to: %s", req.Model)
-	json.NewEncoder(w).Encode(assistant.SetModelResponse{
-		Success: true,
-		Model:   req.Model,
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"name":     req.Name,
+		"rendered": rendered,
 	})
 }
 
-// handleAssistantRunTests runs the model test suite asynchronously
+// handleAssistantRunTests evaluates a model against the default golden set in the background.
 func handleAssistantRunTests(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -1544,123 +2924,230 @@ func handleAssistantRunTests(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Run tests asynchronously
-	go runModelTests(req.Model)
+	run, err := evalRunner.Start([]string{req.Model}, "")
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
 		"message": "Tests started in background",
 		"model":   req.Model,
+		"runId":   run.ID,
 	})
 }
 
-// runModelTests executes the model test suite using the test-model-comparison.sh script
-func runModelTests(model string) {
-	cmd := exec.Command("bash", "scripts/test-model-comparison.sh", "--baseline-only", model)
+// handleAssistantEvalSets lists the golden question sets.
+func handleAssistantEvalSets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
 
-	output, err := cmd.CombinedOutput()
+	sets, err := evalRunner.Sets()
 	if err != nil {
-		log.Printf("[Model Tests] Error running tests for %s: %v\n%s", model, err, string(output))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
 		return
 	}
 
-	log.Printf("[Model Tests] Tests completed for %s\n%s", model, string(output))
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"sets":    sets,
+	})
 }
 
-// Training state tracking (in-memory)
-var trainingStatus = make(map[string]map[string]interface{})
+// handleAssistantEvalRuns lists runs (GET) or starts a run (POST {models, set}).
+func handleAssistantEvalRuns(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-// handleAssistantTrainModel initiates model training
-func handleAssistantTrainModel(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"runs":    evalRunner.List(),
+		})
+
+	case http.MethodPost:
+		var req struct {
+			Models []string `json:"models"`
+			Set    string   `json:"set"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		run, err := evalRunner.Start(req.Models, req.Set)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"run":     run,
+		})
+
+	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
 	}
+}
 
+// handleAssistantEvalRun returns a run (GET) or cancels it (DELETE).
+// Path: /api/assistant/eval/runs/{runID}
+func handleAssistantEvalRun(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	var req struct {
-		Model string `json:"model"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	runID := strings.TrimPrefix(r.URL.Path, "/api/assistant/eval/runs/")
+	if runID == "" {
+		http.Error(w, "Run ID required", http.StatusBadRequest)
 		return
 	}
 
-	if req.Model == "" {
-		http.Error(w, "Model name is required", http.StatusBadRequest)
-		return
-	}
+	switch r.Method {
+	case http.MethodGet:
+		run, ok := evalRunner.Get(runID)
+		if !ok {
+			http.Error(w, "Run not found", http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"run":     run,
+		})
+
+	case http.MethodDelete:
+		if err := evalRunner.Cancel(runID); err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+		})
 
-	// Initialize training status
-	trainingStatus[req.Model] = map[string]interface{}{
-		"status":             "in_progress",
-		"started_at":         time.Now(),
-		"examples_processed": 0,
-		"completed":          false,
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
+}
 
-	// Run training asynchronously
-	go trainModel(req.Model)
+// handleAssistantEvalCompare compares models side by side.
+// Query params: runs=id1,id2 or models=m1,m2 (latest completed run of each) with optional set.
+func handleAssistantEvalCompare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"message": "Training started in background",
-		"model":   req.Model,
-	})
-}
+	w.Header().Set("Content-Type", "application/json")
 
-// trainModel executes model training using the train-model.sh script
-func trainModel(model string) {
-	cmd := exec.Command("bash", "scripts/train-model.sh", model)
+	query := r.URL.Query()
+	var comparison *eval.Comparison
+	var err error
+	switch {
+	case query.Get("runs") != "":
+		comparison, err = evalRunner.Compare(strings.Split(query.Get("runs"), ","))
+	case query.Get("models") != "":
+		comparison, err = evalRunner.CompareModels(strings.Split(query.Get("models"), ","), query.Get("set"))
+	default:
+		http.Error(w, "runs or models query parameter required", http.StatusBadRequest)
+		return
+	}
 
-	output, err := cmd.CombinedOutput()
 	if err != nil {
-		log.Printf("[Model Training] Error training %s: %v\n%s", model, err, string(output))
-		trainingStatus[model]["completed"] = true
-		trainingStatus[model]["status"] = "failed"
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
 		return
 	}
 
-	log.Printf("[Model Training] Training completed for %s\n%s", model, string(output))
-
-	// Update training status
-	trainingStatus[model]["completed"] = true
-	trainingStatus[model]["status"] = "completed"
-	trainingStatus[model]["examples_processed"] = 50 // We have 50 training examples
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":    true,
+		"comparison": comparison,
+	})
 }
 
-// handleAssistantTrainingStatus returns training progress
-func handleAssistantTrainingStatus(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
+// handleAssistantTrainingDataExport exports AM conversations as a fine-tuning dataset.
+func handleAssistantTrainingDataExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 
-	// Extract model name from URL path
-	model := strings.TrimPrefix(r.URL.Path, "/api/assistant/training-status/")
-	if model == "" {
-		http.Error(w, "Model name required", http.StatusBadRequest)
+	var req struct {
+		Name            string   `json:"name"`
+		Format          string   `json:"format"`
+		Providers       []string `json:"providers"`
+		Project         string   `json:"project"`
+		MinConfidence   float64  `json:"minConfidence"`
+		Since           string   `json:"since"`
+		Until           string   `json:"until"`
+		ValidationSplit *float64 `json:"validationSplit"`
+		NoRedact        bool     `json:"noRedact"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	opts := am.DatasetOptions{
+		Format:          req.Format,
+		Providers:       req.Providers,
+		Project:         req.Project,
+		MinConfidence:   req.MinConfidence,
+		ValidationSplit: am.DefaultValidationSplit,
+		NoRedact:        req.NoRedact,
+	}
+	if req.ValidationSplit != nil {
+		opts.ValidationSplit = *req.ValidationSplit
+	}
+
+	var err error
+	if opts.Since, err = parseDateParam(req.Since, false); err != nil {
+		http.Error(w, "Invalid since: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if opts.Until, err = parseDateParam(req.Until, true); err != nil {
+		http.Error(w, "Invalid until: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	outDir, err := datasetOutputDir(req.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	status, exists := trainingStatus[model]
-	if !exists {
+	manifest, err := am.ExportDataset(am.DefaultAMDir(), outDir, opts)
+	if err != nil {
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"completed": false,
-			"status":    "not_started",
-			"model":     model,
+			"success": false,
+			"error":   err.Error(),
 		})
 		return
 	}
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"completed":          status["completed"],
-		"status":             status["status"],
-		"examples_processed": status["examples_processed"],
-		"model":              model,
+		"success":  true,
+		"path":     outDir,
+		"manifest": manifest,
 	})
 }
 
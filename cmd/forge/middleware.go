@@ -2,31 +2,16 @@ package main
 
 import (
 	"net/http"
-	"os"
-	"strings"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/auth"
 )
 
 // CORSMiddleware adds CORS headers to support GitHub Pages frontend
 func CORSMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Get allowed origins from environment or default to localhost
-		allowedOrigins := os.Getenv("ALLOWED_ORIGINS")
-		if allowedOrigins == "" {
-			// Default: allow localhost and current origin
-			allowedOrigins = "http://localhost:3000,http://localhost:8333,http://127.0.0.1:8333"
-		}
-
-		// Check if origin is allowed
+		// Reflect origins allowed by ALLOWED_ORIGINS (or the server's own origin)
 		origin := r.Header.Get("Origin")
-		isAllowed := false
-		
-		for _, allowed := range strings.Split(allowedOrigins, ",") {
-			allowed = strings.TrimSpace(allowed)
-			if allowed == "*" || origin == allowed {
-				isAllowed = true
-				break
-			}
-		}
+		isAllowed := origin != "" && auth.OriginAllowed(r)
 
 		if isAllowed {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
@@ -35,7 +20,7 @@ func CORSMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		// Handle preflight requests
 		if r.Method == http.MethodOptions {
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Forge-Token")
 			w.Header().Set("Access-Control-Max-Age", "3600")
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
 			w.WriteHeader(http.StatusOK)
@@ -44,7 +29,7 @@ func CORSMiddleware(next http.HandlerFunc) http.HandlerFunc {
 
 		// Add response headers for all requests
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Forge-Token")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 
 		next(w, r)
@@ -69,7 +54,15 @@ func SecureHeaders(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-// WrapWithMiddleware wraps a handler with CORS and security middleware
+// RequireAuth rejects requests from disallowed origins and requests without the
+// session token or install secret
+func RequireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authManager.Middleware(next)(w, r)
+	}
+}
+
+// WrapWithMiddleware wraps a handler with CORS, authentication and security middleware
 func WrapWithMiddleware(handler http.HandlerFunc) http.HandlerFunc {
-	return CORSMiddleware(SecureHeaders(handler))
+	return CORSMiddleware(RequireAuth(SecureHeaders(handler)))
 }
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/auth"
+	"github.com/mikejsmith1985/forge-terminal/internal/storage"
+)
+
+// serverConfig controls where Forge listens and whether it serves TLS. It is
+// read from ~/.forge/server.json; command-line flags override it.
+type serverConfig struct {
+	Bind     string `json:"bind,omitempty"`     // Listen address (default 127.0.0.1; 0.0.0.0 for every interface)
+	Port     int    `json:"port,omitempty"`     // 0 tries the preferred ports, then any free port
+	TLS      bool   `json:"tls,omitempty"`      // Serve HTTPS/WSS
+	CertFile string `json:"certFile,omitempty"` // User-provided certificate; empty generates a self-signed one
+	KeyFile  string `json:"keyFile,omitempty"`
+}
+
+// loadServerConfig reads ~/.forge/server.json and applies flags from args.
+func loadServerConfig(args []string) (*serverConfig, error) {
+	cfg := &serverConfig{}
+	data, err := os.ReadFile(storage.GetServerConfigPath())
+	if err == nil {
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", storage.GetServerConfigPath(), err)
+		}
+	}
+
+	fs := flag.NewFlagSet("forge", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: forge [flags]   start Forge Terminal")
+		fs.PrintDefaults()
+		fmt.Fprint(fs.Output(), "\n"+cliUsage)
+	}
+	fs.StringVar(&cfg.Bind, "bind", cfg.Bind, "address to listen on (default 127.0.0.1; 0.0.0.0 for remote access)")
+	fs.IntVar(&cfg.Port, "port", cfg.Port, "port to listen on (default: first free of the preferred ports)")
+	fs.BoolVar(&cfg.TLS, "tls", cfg.TLS, "serve HTTPS and WSS")
+	fs.StringVar(&cfg.CertFile, "cert", cfg.CertFile, "TLS certificate file (implies -tls; default self-signed in ~/.forge/tls)")
+	fs.StringVar(&cfg.KeyFile, "key", cfg.KeyFile, "TLS private key file for -cert")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if cfg.Bind == "" {
+		cfg.Bind = "127.0.0.1"
+	}
+	if cfg.Port < 0 || cfg.Port > 65535 {
+		return nil, fmt.Errorf("invalid port %d", cfg.Port)
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, fmt.Errorf("-cert and -key must be given together")
+		}
+		cfg.TLS = true
+	}
+	return cfg, nil
+}
+
+// scheme returns the URL scheme the server is reached with.
+func (c *serverConfig) scheme() string {
+	if c.TLS {
+		return "https"
+	}
+	return "http"
+}
+
+// isLoopback reports whether the server only accepts connections from this machine.
+func (c *serverConfig) isLoopback() bool {
+	if c.Bind == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(c.Bind)
+	return ip != nil && ip.IsLoopback()
+}
+
+// browserHost returns the host:port a browser on this machine should open.
+func (c *serverConfig) browserHost(addr string) string {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	if ip := net.ParseIP(c.Bind); ip != nil && ip.IsUnspecified() {
+		return net.JoinHostPort("127.0.0.1", port)
+	}
+	return addr
+}
+
+// tlsConfig loads the configured certificate, or generates a self-signed one
+// under ~/.forge/tls, and returns it with its SHA-256 fingerprint.
+func (c *serverConfig) tlsConfig() (*tls.Config, string, error) {
+	certFile, keyFile := c.CertFile, c.KeyFile
+	if certFile == "" {
+		certFile = filepath.Join(storage.GetTLSDir(), "cert.pem")
+		keyFile = filepath.Join(storage.GetTLSDir(), "key.pem")
+		if err := auth.EnsureSelfSignedCert(certFile, keyFile, auth.CertHosts(c.Bind)); err != nil {
+			return nil, "", fmt.Errorf("failed to create self-signed certificate: %w", err)
+		}
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	fingerprint, err := auth.CertFingerprint(certFile)
+	if err != nil {
+		return nil, "", err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, fingerprint, nil
+}
+
+// listen opens the configured address, wrapped in TLS when enabled. Without a
+// fixed port it tries the preferred ports first.
+func listen(cfg *serverConfig) (string, net.Listener, error) {
+	var addr string
+	var listener net.Listener
+	var err error
+	if cfg.Port != 0 {
+		addr = net.JoinHostPort(cfg.Bind, strconv.Itoa(cfg.Port))
+		if listener, err = net.Listen("tcp", addr); err != nil {
+			return "", nil, err
+		}
+	} else if addr, listener, err = findAvailablePort(cfg.Bind); err != nil {
+		return "", nil, err
+	}
+
+	if !cfg.TLS {
+		if !cfg.isLoopback() {
+			log.Printf("[Server] ⚠️ Listening on %s without TLS; tokens and terminal traffic are sent in the clear", addr)
+		}
+		return addr, listener, nil
+	}
+
+	tlsCfg, fingerprint, err := cfg.tlsConfig()
+	if err != nil {
+		listener.Close()
+		return "", nil, err
+	}
+	log.Printf("[TLS] Certificate SHA-256 fingerprint: %s", fingerprint)
+	if cfg.CertFile == "" {
+		log.Printf("[TLS] Self-signed certificate; check the fingerprint when your browser asks to trust it")
+	}
+	return addr, tls.NewListener(listener, tlsCfg), nil
+}
@@ -28,6 +28,7 @@ import { logger } from './utils/logger'
 import { getNextAvailableKeybinding, validateKeybinding, getKeybindingAvailability } from './utils/keybindingManager'
 import { performanceInstrumentation } from './utils/performanceInstrumentation'
 import { getMergedCommandCards } from './utils/defaultCommandCards'
+import { apiFetch } from './config'
 
 const MAX_TABS = 20;
 
@@ -272,7 +273,7 @@ function App() {
       }, 3000);
       
       try {
-        const res = await fetch('/api/version', { signal: controller.signal });
+        const res = await apiFetch('/api/version', { signal: controller.signal });
         clearTimeout(timeoutId);
         
         const data = await res.json();
@@ -414,7 +415,7 @@ function App() {
 
   const loadConfig = async () => {
     try {
-      const res = await fetch('/api/config');
+      const res = await apiFetch('/api/config');
       const data = await res.json();
       if (data && data.shellType) {
         // Check if config differs from the initial default
@@ -460,7 +461,7 @@ function App() {
     }
     
     try {
-      await fetch('/api/config', {
+      await apiFetch('/api/config', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify(config)
@@ -479,7 +480,7 @@ function App() {
 
   const checkWSL = async () => {
     try {
-      const res = await fetch('/api/wsl/detect');
+      const res = await apiFetch('/api/wsl/detect');
       const data = await res.json();
       setWslAvailable(data.available || false);
     } catch (err) {
@@ -490,12 +491,12 @@ function App() {
   const checkForUpdates = async () => {
     try {
       // Get current version
-      const versionRes = await fetch('/api/version');
+      const versionRes = await apiFetch('/api/version');
       const versionData = await versionRes.json();
       setCurrentVersion(versionData.version || '');
       
       // Check for updates
-      const res = await fetch('/api/update/check');
+      const res = await apiFetch('/api/update/check');
       const data = await res.json();
       
       // Store update info regardless of availability (for the modal)
@@ -536,7 +537,7 @@ function App() {
 
   const checkWelcome = async () => {
     try {
-      const res = await fetch('/api/welcome');
+      const res = await apiFetch('/api/welcome');
       const data = await res.json();
       
       // Show welcome if not already shown for this version
@@ -553,7 +554,7 @@ function App() {
     
     // Mark welcome as shown
     try {
-      await fetch('/api/welcome', { method: 'POST' });
+      await apiFetch('/api/welcome', { method: 'POST' });
     } catch (err) {
       console.error('Failed to save welcome status:', err);
     }
@@ -908,7 +909,7 @@ function App() {
       if (!confirmed) return;
       
       try {
-        const res = await fetch('/api/am/master-control', {
+        const res = await apiFetch('/api/am/master-control', {
           method: 'POST',
           headers: { 'Content-Type': 'application/json' },
           body: JSON.stringify({ enabled: false })
@@ -980,7 +981,7 @@ function App() {
       addToast('Failed to load command cards - timeout', 'error', 5000);
     }, 10000); // 10 second timeout
     
-    fetch('/api/commands')
+    apiFetch('/api/commands')
       .then(r => {
         clearTimeout(timeoutId);
         if (!r.ok) {
@@ -1012,7 +1013,7 @@ function App() {
     await new Promise(resolve => setTimeout(resolve, 800));
     
     try {
-      await fetch('/api/shutdown', { method: 'POST' });
+      await apiFetch('/api/shutdown', { method: 'POST' });
       window.close(); // Try to close the tab
     } catch (err) {
       // Server already shut down, that's expected
@@ -1030,7 +1031,7 @@ function App() {
 
   const saveCommands = async (newCommands) => {
     try {
-      await fetch('/api/commands', {
+      await apiFetch('/api/commands', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify(newCommands)
@@ -1051,7 +1052,7 @@ function App() {
       // so the backend can start/associate a conversation without relying on text detection.
       try {
         if (cmd.triggerAM && activeTab?.amEnabled) {
-          fetch('/api/am/log', {
+          apiFetch('/api/am/log', {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify({
@@ -1,3 +1,5 @@
+import { apiFetch } from '../config';
+
 // ==========================================
 //  SAVE CONVERSATION COMMAND
 // ==========================================
@@ -49,7 +51,7 @@ export const saveConversation = {
     
     // Save to AM
     try {
-      const response = await fetch('/api/am/log', {
+      const response = await apiFetch('/api/am/log', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
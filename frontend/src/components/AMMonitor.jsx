@@ -1,7 +1,7 @@
 import React, { useState, useEffect, useCallback } from 'react';
 import { Activity, Circle, Eye, EyeOff, MessageSquare } from 'lucide-react';
 import ConversationViewer from './ConversationViewer';
-import { AM_CONFIG } from '../config';
+import { AM_CONFIG, apiFetch } from '../config';
 
 /**
  * AMMonitor - Simplified AM status indicator
@@ -49,8 +49,8 @@ const AMMonitor = ({ tabId, amEnabled, devMode = false }) => {
     const checkStatus = async () => {
       try {
         const [healthRes, convRes] = await Promise.all([
-          fetch('/api/am/health'),
-          tabId && amEnabled ? fetch(`/api/am/llm/conversations/${tabId}`) : Promise.resolve(null)
+          apiFetch('/api/am/health'),
+          tabId && amEnabled ? apiFetch(`/api/am/llm/conversations/${tabId}`) : Promise.resolve(null)
         ]);
 
         if (healthRes.ok) {
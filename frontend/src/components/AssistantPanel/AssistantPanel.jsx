@@ -3,9 +3,8 @@ import ChatMessage from './ChatMessage';
 import CommandPreview from './CommandPreview';
 import ModelTestModal from './ModelTestModal';
 import ModelTestStatus from './ModelTestStatus';
-import TrainModelModal from './TrainModelModal';
-import TrainModelStatus from './TrainModelStatus';
 import './AssistantPanel.css';
+import { apiFetch } from '../../config';
 
 const AssistantPanel = ({ isOpen, onClose, currentTabId, assistantFontSize }) => {
   const [messages, setMessages] = useState([]);
@@ -26,13 +25,6 @@ const AssistantPanel = ({ isOpen, onClose, currentTabId, assistantFontSize }) =>
   const [testMessage, setTestMessage] = useState('Running baseline tests...');
   const [testCanChat, setTestCanChat] = useState(true);
 
-  // Model training state
-  const [showTrainModal, setShowTrainModal] = useState(false);
-  const [trainingInProgress, setTrainingInProgress] = useState(false);
-  const [trainingProgress, setTrainingProgress] = useState(0);
-  const [trainingMessage, setTrainingMessage] = useState('Starting training...');
-  const [trainingModel, setTrainingModel] = useState(null);
-
   // Check Ollama status on mount
   useEffect(() => {
     checkOllamaStatus();
@@ -49,7 +41,7 @@ const AssistantPanel = ({ isOpen, onClose, currentTabId, assistantFontSize }) =>
 
   const checkOllamaStatus = async () => {
     try {
-      const response = await fetch('/api/assistant/status');
+      const response = await apiFetch('/api/assistant/status');
       const data = await response.json();
       setOllamaStatus(data);
       
@@ -88,7 +80,7 @@ const AssistantPanel = ({ isOpen, onClose, currentTabId, assistantFontSize }) =>
     setIsLoading(true);
 
     try {
-      const response = await fetch('/api/assistant/chat', {
+      const response = await apiFetch('/api/assistant/chat', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
@@ -131,7 +123,7 @@ const AssistantPanel = ({ isOpen, onClose, currentTabId, assistantFontSize }) =>
 
   const handleExecuteCommand = async (command) => {
     try {
-      const response = await fetch('/api/assistant/execute', {
+      const response = await apiFetch('/api/assistant/execute', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
@@ -166,7 +158,7 @@ const AssistantPanel = ({ isOpen, onClose, currentTabId, assistantFontSize }) =>
     setPendingModel(newModel);
     
     try {
-      const response = await fetch('/api/assistant/model', {
+      const response = await apiFetch('/api/assistant/model', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ model: newModel }),
@@ -188,12 +180,6 @@ const AssistantPanel = ({ isOpen, onClose, currentTabId, assistantFontSize }) =>
         
         // Show test modal
         setShowTestModal(true);
-        
-        // Also show training modal after showing test modal
-        setTimeout(() => {
-          setShowTrainModal(true);
-          setTrainingModel(newModel);
-        }, 500);
       } else {
         setError(data.error || 'Failed to change model');
       }
@@ -227,7 +213,7 @@ const AssistantPanel = ({ isOpen, onClose, currentTabId, assistantFontSize }) =>
     setTestCanChat(false);
     
     try {
-      const response = await fetch('/api/assistant/run-tests', {
+      const response = await apiFetch('/api/assistant/run-tests', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ model: pendingModel }),
@@ -274,84 +260,6 @@ const AssistantPanel = ({ isOpen, onClose, currentTabId, assistantFontSize }) =>
     setPendingModel(null);
   };
 
-  const handleTrainModalConfirm = async () => {
-    if (!trainingModel) return;
-    
-    setShowTrainModal(false);
-    setTrainingInProgress(true);
-    setTrainingProgress(0);
-    setTrainingMessage('Starting model training...');
-    
-    try {
-      const response = await fetch('/api/assistant/train-model', {
-        method: 'POST',
-        headers: { 'Content-Type': 'application/json' },
-        body: JSON.stringify({ model: trainingModel }),
-      });
-      
-      const data = await response.json();
-      
-      if (data.success) {
-        setTrainingMessage('Training in progress: Processing knowledge base...');
-        setTrainingProgress(50);
-        
-        // Simulate progress during training
-        const progressInterval = setInterval(() => {
-          setTrainingProgress(prev => {
-            if (prev >= 90) {
-              clearInterval(progressInterval);
-              return prev;
-            }
-            return prev + Math.random() * 15;
-          });
-        }, 2000);
-        
-        // Poll for completion
-        const pollInterval = setInterval(async () => {
-          try {
-            const statusRes = await fetch(`/api/assistant/training-status/${trainingModel}`);
-            const statusData = await statusRes.json();
-            
-            if (statusData.completed) {
-              clearInterval(pollInterval);
-              clearInterval(progressInterval);
-              
-              setTrainingProgress(100);
-              setTrainingMessage(`Training complete! Model learned ${statusData.examples_processed} concepts about Forge Terminal.`);
-              
-              // Show success message
-              setMessages(prev => [...prev, {
-                role: 'system',
-                content: `✓ Model training complete! ${trainingModel} has been trained on 50 Forge Terminal knowledge examples.`,
-                timestamp: new Date().toISOString(),
-              }]);
-              
-              // Auto-dismiss after 10 seconds
-              setTimeout(() => {
-                setTrainingInProgress(false);
-              }, 10000);
-            }
-          } catch (err) {
-            console.error('Status check error:', err);
-          }
-        }, 3000);
-      } else {
-        setTrainingMessage('Training failed - see error');
-        setError(data.error || 'Failed to train model');
-      }
-    } catch (err) {
-      console.error('Training error:', err);
-      setTrainingMessage('Training error - see message');
-      setError(`Failed to train model: ${err.message}`);
-      setTrainingInProgress(false);
-    }
-  };
-
-  const handleTrainModalCancel = () => {
-    setShowTrainModal(false);
-    setTrainingModel(null);
-  };
-
   const getCurrentModelInfo = () => {
     if (!selectedModel) return null;
     return ollamaStatus.models.find(m => m.name === selectedModel);
@@ -378,23 +286,6 @@ const AssistantPanel = ({ isOpen, onClose, currentTabId, assistantFontSize }) =>
         canChat={testCanChat}
       />
 
-      {/* Model Training Modal */}
-      <TrainModelModal
-        modelName={trainingModel ? getModelDisplayName(trainingModel) : ''}
-        isOpen={showTrainModal}
-        onConfirm={handleTrainModalConfirm}
-        onCancel={handleTrainModalCancel}
-        isLoading={false}
-      />
-
-      {/* Model Training Status Bar */}
-      <TrainModelStatus
-        isActive={trainingInProgress}
-        progress={trainingProgress}
-        message={trainingMessage}
-        isTraining={trainingInProgress}
-      />
-
       <div className="assistant-header">
         <div className="assistant-title">
           <span className="assistant-icon">🤖</span>
@@ -1,6 +1,7 @@
 import React, { useState, useEffect } from 'react';
-import { X, ChevronLeft, ChevronRight, Clock, MessageSquare } from 'lucide-react';
+import { X, ChevronLeft, ChevronRight, Clock, MessageSquare, RotateCcw } from 'lucide-react';
 import './ConversationViewer.css';
+import { apiFetch } from '../config';
 
 /**
  * ConversationViewer - Modal for viewing LLM conversation snapshots
@@ -10,12 +11,13 @@ const ConversationViewer = ({ tabId, conversationId, onClose }) => {
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState(null);
   const [currentSnapshotIndex, setCurrentSnapshotIndex] = useState(0);
+  const [rollbackStatus, setRollbackStatus] = useState(null);
 
   useEffect(() => {
     const fetchConversation = async () => {
       try {
         setLoading(true);
-        const res = await fetch(`/api/am/llm/conversation/${tabId}/${conversationId}`);
+        const res = await apiFetch(`/api/am/llm/conversation/${tabId}/${conversationId}`);
         if (!res.ok) {
           throw new Error(`Failed to fetch conversation: ${res.status}`);
         }
@@ -38,6 +40,28 @@ const ConversationViewer = ({ tabId, conversationId, onClose }) => {
     }
   }, [tabId, conversationId]);
 
+  // Put the working tree back to the checkpoint taken when the conversation started
+  const handleRollback = async () => {
+    const res = await apiFetch(`/api/am/llm/conversation/${conversationId}/checkpoints/diff`);
+    const data = await res.json();
+    if (!data.success) {
+      setRollbackStatus(data.error || 'No checkpoint');
+      return;
+    }
+    const files = data.diff.files;
+    if (files.length === 0) {
+      setRollbackStatus('Working tree matches the checkpoint');
+      return;
+    }
+    const list = files.slice(0, 20).map(f => `${f.status} ${f.path}`).join('\n');
+    if (!window.confirm(`Roll back ${files.length} file(s) to the start of this conversation?\n\n${list}`)) {
+      return;
+    }
+    const restore = await apiFetch(`/api/am/llm/conversation/${conversationId}/checkpoints/restore`, { method: 'POST' });
+    const result = await restore.json();
+    setRollbackStatus(result.success ? `Rolled back (undo: ${result.undo.ref})` : result.error);
+  };
+
   const handlePrevious = () => {
     setCurrentSnapshotIndex(Math.max(0, currentSnapshotIndex - 1));
   };
@@ -127,6 +151,18 @@ const ConversationViewer = ({ tabId, conversationId, onClose }) => {
                 <Clock size={14} />
                 {snapshots.length} snapshots
               </span>
+              {conversation?.changes && (
+                <span className="meta-item" title={(conversation.changes.files || []).map(f => `${f.status} ${f.path}`).join('\n')}>
+                  {conversation.changes.files?.length || 0} files changed (+{conversation.changes.linesAdded} −{conversation.changes.linesDeleted})
+                </span>
+              )}
+              {conversation?.metadata?.checkpoint && (
+                <button className="meta-item" onClick={handleRollback} title="Restore the working tree to the start of this conversation">
+                  <RotateCcw size={14} />
+                  Roll back
+                </button>
+              )}
+              {rollbackStatus && <span className="meta-item">{rollbackStatus}</span>}
             </div>
           </div>
           <button className="close-button" onClick={onClose}>
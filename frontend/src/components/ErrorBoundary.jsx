@@ -1,4 +1,5 @@
 import React from 'react';
+import { apiFetch } from '../config';
 
 /**
  * ErrorBoundary - Catches React errors and prevents full app crash
@@ -38,7 +39,7 @@ class ErrorBoundary extends React.Component {
   logErrorToService = (error, errorInfo) => {
     try {
       // Send error to backend for logging
-      fetch('/api/log-error', {
+      apiFetch('/api/log-error', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
@@ -1,5 +1,6 @@
 import React, { useState } from 'react';
 import { Shield, ShieldAlert, Info } from 'lucide-react';
+import { apiFetch } from '../config';
 
 export default function FileAccessPrompt({ isOpen, onChoice }) {
   const [selectedMode, setSelectedMode] = useState('restricted');
@@ -8,7 +9,7 @@ export default function FileAccessPrompt({ isOpen, onChoice }) {
 
   const handleConfirm = async () => {
     try {
-      const response = await fetch('/api/files/access-mode', {
+      const response = await apiFetch('/api/files/access-mode', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ mode: selectedMode })
@@ -11,6 +11,7 @@ import {
   RefreshCw
 } from 'lucide-react';
 import './FileExplorer.css';
+import { apiFetch } from '../config';
 
 const getFileIcon = (fileName) => {
   const ext = fileName.split('.').pop()?.toLowerCase();
@@ -194,7 +195,7 @@ export default function FileExplorer({ currentPath, rootPath, onFileOpen, termin
         }
       }
       
-      const response = await fetch(`/api/files/stats?${params.toString()}`);
+      const response = await apiFetch(`/api/files/stats?${params.toString()}`);
       if (!response.ok) return null;
       
       const stats = await response.json();
@@ -248,7 +249,7 @@ export default function FileExplorer({ currentPath, rootPath, onFileOpen, termin
         }
       }
       
-      const response = await fetch(`/api/files/list?${params.toString()}`);
+      const response = await apiFetch(`/api/files/list?${params.toString()}`);
       if (!response.ok) {
         const errorText = await response.text();
         const errorMsg = errorText || `HTTP ${response.status}: ${response.statusText}`;
@@ -349,7 +350,7 @@ export default function FileExplorer({ currentPath, rootPath, onFileOpen, termin
         if (confirm(`Delete ${node.name}?`)) {
           try {
             const root = rootPath || '.';
-            const response = await fetch('/api/files/delete', {
+            const response = await apiFetch('/api/files/delete', {
               method: 'POST',
               headers: { 'Content-Type': 'application/json' },
               body: JSON.stringify({ path: node.path, rootPath: root })
@@ -5,6 +5,7 @@
  */
 
 import { performanceInstrumentation } from '../utils/performanceInstrumentation';
+import { apiFetch } from '../config';
 
 // Add this before the ws.onmessage handler:
 
@@ -85,7 +86,7 @@ ws.onmessage = (event) => {
           const cleanContent = stripAnsi(amLogBufferRef.current);
           if (cleanContent.trim()) {
             // Send to AM API
-            fetch('/api/am/log', {
+            apiFetch('/api/am/log', {
               method: 'POST',
               headers: { 'Content-Type': 'application/json' },
               body: JSON.stringify({
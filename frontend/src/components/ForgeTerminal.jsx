@@ -8,6 +8,7 @@ import { getTerminalTheme } from '../themes';
 import { logger } from '../utils/logger';
 import VisionOverlay from './vision/VisionOverlay';
 import { diagnosticCore } from '../utils/diagnosticCore';
+import { API_CONFIG, apiFetch } from '../config';
 
 // Debounce helper for resize events
 function debounce(fn, ms) {
@@ -397,9 +398,13 @@ const ForgeTerminal = forwardRef(function ForgeTerminal({
   const [activeVisionOverlay, setActiveVisionOverlay] = useState(null);
   const visionEnabledRef = useRef(visionEnabled);
 
-  // Keep autoRespond ref updated
+  // Keep autoRespond ref updated and tell the server, which answers prompts
+  // from the project's auto-respond policy
   useEffect(() => {
     autoRespondRef.current = autoRespond;
+    if (wsRef.current && wsRef.current.readyState === WebSocket.OPEN) {
+      wsRef.current.send(JSON.stringify({ type: 'AM_AUTO_RESPOND', autoRespond }));
+    }
   }, [autoRespond]);
 
   // Keep amEnabled ref updated
@@ -524,7 +529,7 @@ const ForgeTerminal = forwardRef(function ForgeTerminal({
 
         // Always log commands to AM for crash recovery
         if (command) {
-          fetch('/api/am/log', {
+          apiFetch('/api/am/log', {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify({
@@ -552,7 +557,7 @@ const ForgeTerminal = forwardRef(function ForgeTerminal({
         
         // Always log user input to AM for crash recovery
         if (sanitized) {
-          fetch('/api/am/log', {
+          apiFetch('/api/am/log', {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify({
@@ -645,6 +650,24 @@ const ForgeTerminal = forwardRef(function ForgeTerminal({
         // Dismiss overlay after action
         setActiveVisionOverlay(null);
       }
+    } else if (action.type === 'AM_LOOP_INTERVENE') {
+      if (wsRef.current && wsRef.current.readyState === WebSocket.OPEN) {
+        wsRef.current.send(JSON.stringify({ type: 'AM_LOOP_INTERVENE' }));
+        logger.terminal('Loop intervention requested', { tabId });
+      }
+    } else if (action.type === 'RESTORE_SESSION' && action.conversationId) {
+      return apiFetch(`/api/am/restore/launch/${action.conversationId}`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ tabId, provider: action.provider, submit: true })
+      })
+        .then(res => res.json())
+        .then(data => {
+          if (!data.success) {
+            throw new Error(data.error || 'restore failed');
+          }
+          logger.terminal('Session restored', { tabId, ...data.restore });
+        });
     } else if (action.type === 'SHOW_ERROR' && action.message) {
       // Show error via terminal write
       if (xtermRef.current) {
@@ -821,8 +844,8 @@ const ForgeTerminal = forwardRef(function ForgeTerminal({
 
     // Connect to WebSocket
     const connectWebSocket = () => {
-      const wsProtocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
-      let wsUrl = `${wsProtocol}//${window.location.host}/ws`;
+      // The configured backend's /ws, with the session token when it's on another origin
+      let wsUrl = API_CONFIG.getWSURL();
       
       // Add shell config query params
       const cfg = shellConfigRef.current;
@@ -840,7 +863,7 @@ const ForgeTerminal = forwardRef(function ForgeTerminal({
           if (cfg.psHomePath) params.set('psHome', cfg.psHomePath);
         }
       }
-      wsUrl += '?' + params.toString();
+      wsUrl += (wsUrl.includes('?') ? '&' : '?') + params.toString();
 
       const ws = new WebSocket(wsUrl);
       wsRef.current = ws;
@@ -869,6 +892,7 @@ const ForgeTerminal = forwardRef(function ForgeTerminal({
         const { cols, rows } = term;
         ws.send(JSON.stringify({ type: 'resize', cols, rows }));
         logger.terminal('Initial size sent', { tabId, cols, rows });
+        ws.send(JSON.stringify({ type: 'AM_AUTO_RESPOND', autoRespond: autoRespondRef.current }));
 
         // Restore directory if available
         if (currentDirectoryRef.current) {
@@ -974,7 +998,7 @@ const ForgeTerminal = forwardRef(function ForgeTerminal({
                 amLogQueueRef.current = [];
                 const cleanContent = stripAnsi(combined);
                 if (cleanContent.trim()) {
-                  fetch('/api/am/log', {
+                  apiFetch('/api/am/log', {
                     method: 'POST',
                     headers: { 'Content-Type': 'application/json' },
                     body: JSON.stringify({
@@ -1009,7 +1033,7 @@ const ForgeTerminal = forwardRef(function ForgeTerminal({
           lastOutputRef.current = buf.data;
 
           // Now do the expensive regex work
-          const { waiting, responseType, confidence } = detectCliPrompt(buf.data, false);
+          const { waiting } = detectCliPrompt(buf.data, false);
 
           if (waiting !== isWaiting) {
             setIsWaiting(waiting);
@@ -1028,20 +1052,7 @@ const ForgeTerminal = forwardRef(function ForgeTerminal({
             }
           }
 
-          // Auto-respond logic
-          if (waiting && autoRespondRef.current && ws.readyState === WebSocket.OPEN) {
-            if (responseType === 'enter') {
-              ws.send('\r');
-            } else {
-              ws.send('y\r');
-            }
-            buf.data = '';
-            lastOutputRef.current = '';
-            setIsWaiting(false);
-            if (onWaitingChange) {
-              onWaitingChange(false);
-            }
-          }
+          // Auto-respond answers are typed by the server (policy-checked and audited)
         });
       };
 
@@ -1168,7 +1179,7 @@ const ForgeTerminal = forwardRef(function ForgeTerminal({
                   .replace(/[\x00-\x08\x0b\x0c\x0e-\x1f]/g, ''); // Strip control chars except \r\n\t
                 
                 if (cleanInput.trim() || cleanInput.includes('\r') || cleanInput.includes('\n')) {
-                  fetch('/api/am/log', {
+                  apiFetch('/api/am/log', {
                     method: 'POST',
                     headers: { 'Content-Type': 'application/json' },
                     body: JSON.stringify({
@@ -1277,7 +1288,7 @@ const ForgeTerminal = forwardRef(function ForgeTerminal({
         }
         // Fire-and-forget flush
         flushData.forEach(data => {
-          fetch('/api/am/log', {
+          apiFetch('/api/am/log', {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify({
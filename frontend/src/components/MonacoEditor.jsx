@@ -2,6 +2,7 @@ import { useEffect, useRef, useState } from 'react';
 import Editor from '@monaco-editor/react';
 import { Save, X, Play } from 'lucide-react';
 import './MonacoEditor.css';
+import { apiFetch } from '../config';
 
 export default function MonacoEditor({ 
   file, 
@@ -39,7 +40,7 @@ export default function MonacoEditor({
       const requestBody = { path, rootPath };
       console.log('[MonacoEditor] Sending request:', JSON.stringify(requestBody, null, 2));
       
-      const response = await fetch('/api/files/read', {
+      const response = await apiFetch('/api/files/read', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify(requestBody)
@@ -97,7 +98,7 @@ export default function MonacoEditor({
     
     setSaving(true);
     try {
-      const response = await fetch('/api/files/write', {
+      const response = await apiFetch('/api/files/write', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
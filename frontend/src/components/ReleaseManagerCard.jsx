@@ -2,6 +2,7 @@ import React, { useState, useEffect, useCallback } from 'react';
 import { ChevronDown, ChevronUp, Copy, Play } from 'lucide-react';
 import { useVersionIncrement } from '../hooks/useVersionIncrement';
 import './ReleaseManagerCard.css';
+import { apiFetch } from '../config';
 
 const ReleaseManagerCard = ({ onExecuteCommand, onToast }) => {
   const [currentVersion, setCurrentVersion] = useState('v1.23.10');
@@ -17,7 +18,7 @@ const ReleaseManagerCard = ({ onExecuteCommand, onToast }) => {
     const fetchVersion = async () => {
       try {
         setLoading(true);
-        const response = await fetch('/api/version');
+        const response = await apiFetch('/api/version');
         if (response.ok) {
           const data = await response.json();
           const version = data.version || '1.23.10';
@@ -1,5 +1,6 @@
 import React, { useState, useEffect } from 'react';
 import { Settings, Terminal, Monitor, Monitor as DesktopIcon, Eye, Shield } from 'lucide-react';
+import { apiFetch } from '../config';
 
 const SettingsModal = ({ isOpen, onClose, shellConfig, onSave, onToast, devMode = false, onDevModeChange, amMasterEnabled = true, onAMMasterChange, amDefaultEnabled = true, onAMDefaultChange, visionConfig, onVisionConfigChange }) => {
   const [config, setConfig] = useState(shellConfig);
@@ -37,7 +38,7 @@ const SettingsModal = ({ isOpen, onClose, shellConfig, onSave, onToast, devMode
       setDefaultCards(defaultCardsData);
       
       // Get current commands
-      const res = await fetch('/api/commands');
+      const res = await apiFetch('/api/commands');
       const currentCommands = await res.json();
       const currentIds = new Set(currentCommands.map(c => c.id));
       
@@ -52,7 +53,7 @@ const SettingsModal = ({ isOpen, onClose, shellConfig, onSave, onToast, devMode
 
   const loadFileAccessMode = async () => {
     try {
-      const res = await fetch('/api/files/access-mode');
+      const res = await apiFetch('/api/files/access-mode');
       const data = await res.json();
       setFileAccessMode(data.mode || 'restricted');
     } catch (err) {
@@ -71,7 +72,7 @@ const SettingsModal = ({ isOpen, onClose, shellConfig, onSave, onToast, devMode
     
     setRestoringCards(true);
     try {
-      const res = await fetch('/api/commands/restore-defaults', {
+      const res = await apiFetch('/api/commands/restore-defaults', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ commandIds: cardsToRestore })
@@ -96,7 +97,7 @@ const SettingsModal = ({ isOpen, onClose, shellConfig, onSave, onToast, devMode
   const detectWSL = async () => {
     setLoading(true);
     try {
-      const res = await fetch('/api/wsl/detect');
+      const res = await apiFetch('/api/wsl/detect');
       const data = await res.json();
       setWslInfo(data);
       
@@ -118,7 +119,7 @@ const SettingsModal = ({ isOpen, onClose, shellConfig, onSave, onToast, devMode
   const handleSave = async () => {
     // Save file access mode
     try {
-      await fetch('/api/files/access-mode', {
+      await apiFetch('/api/files/access-mode', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ mode: fileAccessMode })
@@ -137,7 +138,7 @@ const SettingsModal = ({ isOpen, onClose, shellConfig, onSave, onToast, devMode
   const handleCreateDesktopShortcut = async () => {
     setCreatingShortcut(true);
     try {
-      const res = await fetch('/api/desktop-shortcut', { method: 'POST' });
+      const res = await apiFetch('/api/desktop-shortcut', { method: 'POST' });
       const data = await res.json();
       if (data.success) {
         if (onToast) onToast('Desktop shortcut created!', 'success', 3000);
@@ -361,7 +362,7 @@ const SettingsModal = ({ isOpen, onClose, shellConfig, onSave, onToast, devMode
               className="btn btn-primary"
               onClick={async () => {
                 try {
-                  const res = await fetch('/api/am/install-hooks', { method: 'POST' });
+                  const res = await apiFetch('/api/am/install-hooks', { method: 'POST' });
                   const data = await res.json();
                   if (data.success) {
                     if (onToast) onToast('Shell hooks installed (or instructions saved)', 'success', 4000);
@@ -1,5 +1,6 @@
 import React, { useState, useEffect } from 'react';
 import { Download, RefreshCw, ExternalLink, AlertTriangle, CheckCircle, Clock, ChevronDown, ChevronUp, History, Upload } from 'lucide-react';
+import { apiFetch } from '../config';
 
 const UpdateModal = ({ isOpen, onClose, updateInfo, currentVersion, onApplyUpdate }) => {
   const [isUpdating, setIsUpdating] = useState(false);
@@ -58,7 +59,7 @@ const UpdateModal = ({ isOpen, onClose, updateInfo, currentVersion, onApplyUpdat
         const controller = new AbortController();
         const timeout = setTimeout(() => controller.abort(), 15000); // 15 second timeout
         
-        const res = await fetch('/api/update/versions', { signal: controller.signal });
+        const res = await apiFetch('/api/update/versions', { signal: controller.signal });
         clearTimeout(timeout);
         
         if (!res.ok) {
@@ -109,7 +110,7 @@ const UpdateModal = ({ isOpen, onClose, updateInfo, currentVersion, onApplyUpdat
         const controller = new AbortController();
         const timeout = setTimeout(() => controller.abort(), 15000); // 15 second timeout
         
-        const res = await fetch('/api/update/check', { signal: controller.signal });
+        const res = await apiFetch('/api/update/check', { signal: controller.signal });
         clearTimeout(timeout);
         
         if (!res.ok) {
@@ -197,7 +198,7 @@ const UpdateModal = ({ isOpen, onClose, updateInfo, currentVersion, onApplyUpdat
     // Poll the version endpoint to detect when server is back
     const checkInterval = setInterval(async () => {
       try {
-        const res = await fetch('/api/version', {
+        const res = await apiFetch('/api/version', {
           cache: 'no-cache',
           headers: { 'Cache-Control': 'no-cache' }
         });
@@ -259,7 +260,7 @@ const UpdateModal = ({ isOpen, onClose, updateInfo, currentVersion, onApplyUpdat
     setErrorMessage('');
 
     try {
-      const res = await fetch('/api/update/apply', { method: 'POST' });
+      const res = await apiFetch('/api/update/apply', { method: 'POST' });
       const data = await res.json();
       
       if (data.success) {
@@ -293,7 +294,7 @@ const UpdateModal = ({ isOpen, onClose, updateInfo, currentVersion, onApplyUpdat
     setErrorMessage('');
 
     try {
-      const res = await fetch('/api/update/install-manual', {
+      const res = await apiFetch('/api/update/install-manual', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ filePath: installFromFileInput })
@@ -1,6 +1,7 @@
 import React, { useState, useEffect } from 'react';
 import { RefreshCw, Clock, GitBranch, MessageSquare, Play, Eye, X } from 'lucide-react';
 import './vision.css';
+import { apiFetch } from '../../config';
 
 /**
  * SessionRecoveryOverlay - Vision overlay for recovering previous sessions
@@ -52,35 +53,16 @@ export default function SessionRecoveryOverlay({
     }
   };
 
-  // Handle restore action
+  // Handle restore action - the terminal launches the CLI in the conversation's
+  // working directory and pastes the restore prompt once the CLI is ready
   const handleRestore = async (session, provider) => {
     setIsRestoring(true);
     try {
-      // Fetch full restore context
-      const response = await fetch(`/api/am/restore/context/${session.conversationId}`);
-      if (!response.ok) {
-        throw new Error('Failed to fetch restore context');
-      }
-      const context = await response.json();
-
-      // Build the restore command
-      // NOTE: Use standalone "copilot" not "gh copilot"
-      let command;
-      if (provider === 'copilot') {
-        // Just launch copilot - user can paste the context themselves
-        // The copilot CLI doesn't support command-line prompts
-        command = 'copilot';
-      } else if (provider === 'claude') {
-        command = `claude "${context.restorePrompt}"`;
-      }
-
-      // Inject command into terminal
-      onAction({
-        type: 'INJECT_COMMAND',
-        command: command,
-        context: context.fullContext
+      await onAction({
+        type: 'RESTORE_SESSION',
+        conversationId: session.conversationId,
+        provider: provider
       });
-
       onDismiss();
     } catch (err) {
       console.error('[SessionRecovery] Restore failed:', err);
@@ -96,7 +78,7 @@ export default function SessionRecoveryOverlay({
   // Handle preview action
   const handlePreview = async (session) => {
     try {
-      const response = await fetch(`/api/am/restore/context/${session.conversationId}`);
+      const response = await apiFetch(`/api/am/restore/context/${session.conversationId}`);
       if (!response.ok) {
         throw new Error('Failed to fetch context');
       }
@@ -98,6 +98,14 @@ export default function VisionOverlay({ activeOverlay, onAction, onDismiss }) {
           selectedIndex={selectedIndex}
         />
       )}
+      {activeOverlay.type === 'AM_LOOP_DETECTED' && (
+        <LoopDetectedOverlay 
+          data={activeOverlay.payload}
+          onAction={onAction}
+          onDismiss={onDismiss}
+          selectedIndex={selectedIndex}
+        />
+      )}
       {activeOverlay.type === 'SESSION_RECOVERY' && (
         <SessionRecoveryOverlay 
           data={activeOverlay.payload}
@@ -597,6 +605,81 @@ function StackTraceOverlay({ data, onAction, onDismiss, selectedIndex }) {
   );
 }
 
+/**
+ * LoopDetectedOverlay - AM found the running LLM agent repeating itself
+ */
+function LoopDetectedOverlay({ data, onAction, onDismiss }) {
+  const { provider, signals = [], intervened } = data;
+
+  const handleIntervene = () => {
+    onAction({ type: 'AM_LOOP_INTERVENE' });
+    onDismiss();
+  };
+
+  return (
+    <div className="vision-overlay loop-detected-overlay">
+      <div className="vision-overlay-header">
+        <div className="vision-overlay-title">
+          <span className="vision-git-icon">🔁</span>
+          <span>Agent Stuck in a Loop</span>
+          {provider && <span className="vision-branch-name">{provider}</span>}
+        </div>
+        <button className="vision-close-btn" onClick={onDismiss}>×</button>
+      </div>
+
+      <div className="vision-overlay-content">
+        {signals.map((signal, idx) => (
+          <div
+            key={idx}
+            style={{
+              background: '#422006',
+              border: '1px solid #f97316',
+              borderRadius: '8px',
+              padding: '10px 12px',
+              marginBottom: '10px',
+              fontSize: '0.85em',
+              color: '#fed7aa'
+            }}
+          >
+            <strong>{signal.detail}</strong>
+            {(signal.evidence || []).map((line, i) => (
+              <pre key={i} style={{ margin: '6px 0 0', whiteSpace: 'pre-wrap', color: '#a3a3a3' }}>{line}</pre>
+            ))}
+          </div>
+        ))}
+
+        <div className="vision-actions" style={{ marginTop: '15px' }}>
+          {intervened ? (
+            <div style={{ color: '#22c55e', fontSize: '0.9em' }}>Intervention prompt sent.</div>
+          ) : (
+            <button
+              className="vision-action-btn"
+              data-action="true"
+              onClick={handleIntervene}
+              style={{
+                width: '100%',
+                padding: '10px',
+                background: '#f97316',
+                border: 'none',
+                borderRadius: '6px',
+                color: '#000',
+                cursor: 'pointer',
+                fontWeight: 500
+              }}
+            >
+              🛑 Send Intervention Prompt
+            </button>
+          )}
+        </div>
+      </div>
+
+      <div className="vision-overlay-footer">
+        <span className="vision-hint">Enter Select • ESC Close</span>
+      </div>
+    </div>
+  );
+}
+
 function formatFileSize(bytes) {
   if (bytes < 1024) return `${bytes} B`;
   if (bytes < 1024 * 1024) return `${(bytes / 1024).toFixed(1)} KB`;
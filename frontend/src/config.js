@@ -1,6 +1,6 @@
 // API Configuration
 // Allows dynamic backend URL configuration for different deployment modes
-// Priority: VITE_API_BASE env var > localStorage > detected localhost
+// Priority: VITE_API_BASE env var > localStorage > the page's own origin
 
 const getAPIBase = () => {
   // 1. Check environment variable (set during build)
@@ -14,12 +14,8 @@ const getAPIBase = () => {
     return stored
   }
 
-  // 3. Try localhost (local development)
-  if (window.location.hostname === 'localhost' || window.location.hostname === '127.0.0.1') {
-    return `http://${window.location.hostname}:8333`
-  }
-
-  // 4. Return current origin (same-origin deployment)
+  // 3. Current origin: the backend serves the page on whatever -port and scheme
+  // (https with -tls) it was started with, and the Vite dev server proxies /api and /ws
   return window.location.origin
 }
 
@@ -34,14 +30,30 @@ const getWSBase = () => {
   return apiBase
 }
 
+// Session token for a backend on another origin, where the session cookie isn't sent.
+// Opening the frontend with the token link's ?token= stores it (see adoptLaunchToken).
+const getAPIToken = () => localStorage.getItem('forge_api_token') || ''
+
+const withToken = (url) => {
+  const token = getAPIToken()
+  if (!token) return url
+  return `${url}${url.includes('?') ? '&' : '?'}token=${encodeURIComponent(token)}`
+}
+
 export const API_CONFIG = {
   base: getAPIBase(),
   wsBase: getWSBase(),
+
+  // Headers that authenticate API calls to a cross-origin backend
+  authHeaders: () => {
+    const token = getAPIToken()
+    return token ? { 'X-Forge-Token': token } : {}
+  },
   
   // API endpoints
   getCommandsURL: () => `${API_CONFIG.base}/api/commands`,
   getConfigURL: () => `${API_CONFIG.base}/api/config`,
-  getWSURL: () => `${API_CONFIG.wsBase}/ws`,
+  getWSURL: () => withToken(`${API_CONFIG.wsBase}/ws`),
   getVersionURL: () => `${API_CONFIG.base}/api/version`,
   getUpdateCheckURL: () => `${API_CONFIG.base}/api/update/check`,
   getUpdateApplyURL: () => `${API_CONFIG.base}/api/update/apply`,
@@ -71,6 +83,41 @@ export const clearAPIBase = () => {
   localStorage.removeItem('forge_api_base')
 }
 
+export const setAPIToken = (token) => {
+  if (token) {
+    localStorage.setItem('forge_api_token', token)
+  } else {
+    localStorage.removeItem('forge_api_token')
+  }
+}
+
+// Keeps the ?token= the frontend was opened with and drops it from the address bar.
+// The backend does this itself for the pages it serves; this covers a frontend on
+// another origin and the Vite dev server.
+const adoptLaunchToken = () => {
+  const url = new URL(window.location.href)
+  const token = url.searchParams.get('token')
+  if (!token) return
+  setAPIToken(token)
+  url.searchParams.delete('token')
+  window.history.replaceState(window.history.state, '', url.pathname + url.search + url.hash)
+}
+
+if (typeof window !== 'undefined') {
+  adoptLaunchToken()
+}
+
+// fetch for Forge API paths: sends them to the configured backend with the session
+// token. Same-origin requests keep their relative URL and rely on the session cookie.
+export const apiFetch = (path, options = {}) => {
+  const base = getAPIBase()
+  const url = path.startsWith('/') && base !== window.location.origin ? `${base}${path}` : path
+  return fetch(url, {
+    ...options,
+    headers: { ...API_CONFIG.authHeaders(), ...options.headers },
+  })
+}
+
 // AM Monitoring Configuration
 const getAMPollingInterval = () => {
   // 1. Check environment variable (set during build)
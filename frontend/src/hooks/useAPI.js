@@ -1,5 +1,5 @@
 import { useState, useCallback } from 'react'
-import { API_CONFIG } from '../config'
+import { API_CONFIG, apiFetch } from '../config'
 
 // Hook for making API calls with automatic error handling and loading states
 export const useAPI = () => {
@@ -11,13 +11,12 @@ export const useAPI = () => {
     setError(null)
 
     try {
-      const url = `${API_CONFIG.base}${endpoint}`
-      const response = await fetch(url, {
+      const response = await apiFetch(endpoint, {
+        ...options,
         headers: {
           'Content-Type': 'application/json',
           ...options.headers,
         },
-        ...options,
       })
 
       if (!response.ok) {
@@ -1,4 +1,5 @@
 import { useState, useEffect, useCallback, useRef } from 'react';
+import { apiFetch } from '../config';
 
 /**
  * useSessionRecovery - Hook for detecting and managing recoverable sessions
@@ -34,7 +35,7 @@ export function useSessionRecovery({
     if (!enabled) return [];
     
     try {
-      const response = await fetch('/api/am/restore/sessions');
+      const response = await apiFetch('/api/am/restore/sessions');
       if (!response.ok) {
         throw new Error(`HTTP ${response.status}`);
       }
@@ -93,7 +94,7 @@ export function useSessionRecovery({
   const restoreSession = useCallback(async (conversationId, provider) => {
     try {
       // Fetch restore context
-      const response = await fetch(`/api/am/restore/context/${conversationId}`);
+      const response = await apiFetch(`/api/am/restore/context/${conversationId}`);
       if (!response.ok) {
         throw new Error(`HTTP ${response.status}`);
       }
@@ -1,6 +1,7 @@
 import { useState, useCallback, useMemo, useRef, useEffect } from 'react';
 import { themeOrder } from '../themes';
 import { logger } from '../utils/logger';
+import { apiFetch } from '../config';
 
 const MAX_TABS = 20;
 
@@ -107,7 +108,7 @@ async function saveSession(tabs, activeTabId) {
       activeTabId,
       tabIds: tabs.map(t => t.id)
     });
-    await fetch('/api/sessions', {
+    await apiFetch('/api/sessions', {
       method: 'POST',
       headers: { 'Content-Type': 'application/json' },
       body: JSON.stringify(session),
@@ -129,7 +130,7 @@ const debouncedSaveSession = debounce(saveSession, 500);
 async function loadSession() {
   try {
     logger.session('Loading session from backend');
-    const res = await fetch('/api/sessions');
+    const res = await apiFetch('/api/sessions');
     if (!res.ok) {
       logger.session('Session load failed - server error', { status: res.status });
       return { session: null, loadFailed: true };
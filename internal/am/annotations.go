@@ -0,0 +1,239 @@
+// User annotations on LLM conversations: tags, notes and starred turns.
+
+package am
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Annotations live in their own files so logger saves, journals and compaction never
+// rewrite them.
+const annotationsDir = "annotations"
+
+// ConversationAnnotations holds the user-editable fields of a conversation.
+type ConversationAnnotations struct {
+	ConversationID string    `json:"conversationId"`
+	Tags           []string  `json:"tags"`
+	Note           string    `json:"note,omitempty"`
+	StarredTurns   []int     `json:"starredTurns"` // Turn indexes; turns are append-only
+	UpdatedAt      time.Time `json:"updatedAt,omitempty"`
+}
+
+var annotationsMu sync.Mutex
+
+// GetAnnotations returns the annotations for a conversation; an unannotated
+// conversation yields an empty set.
+func GetAnnotations(amDir, convID string) (*ConversationAnnotations, error) {
+	annotationsMu.Lock()
+	defer annotationsMu.Unlock()
+	return readAnnotations(amDir, convID)
+}
+
+// SetAnnotations replaces a conversation's tags and note, keeping its starred turns.
+func SetAnnotations(amDir, convID string, tags []string, note string) (*ConversationAnnotations, error) {
+	annotationsMu.Lock()
+	defer annotationsMu.Unlock()
+
+	a, err := readAnnotations(amDir, convID)
+	if err != nil {
+		return nil, err
+	}
+	a.Tags = NormalizeTags(tags)
+	a.Note = strings.TrimSpace(note)
+	return a, writeAnnotations(amDir, a)
+}
+
+// StarTurn stars or unstars the turn at index.
+func StarTurn(amDir, convID string, index int, starred bool) (*ConversationAnnotations, error) {
+	if index < 0 {
+		return nil, fmt.Errorf("invalid turn index %d", index)
+	}
+
+	annotationsMu.Lock()
+	defer annotationsMu.Unlock()
+
+	a, err := readAnnotations(amDir, convID)
+	if err != nil {
+		return nil, err
+	}
+
+	turns := a.StarredTurns[:0]
+	for _, i := range a.StarredTurns {
+		if i != index {
+			turns = append(turns, i)
+		}
+	}
+	if starred {
+		turns = append(turns, index)
+		sort.Ints(turns)
+	}
+	a.StarredTurns = turns
+	return a, writeAnnotations(amDir, a)
+}
+
+// ListAnnotations returns every annotated conversation in amDir, keyed by conversation ID.
+func ListAnnotations(amDir string) (map[string]*ConversationAnnotations, error) {
+	if amDir == "" {
+		amDir = DefaultAMDir()
+	}
+
+	annotationsMu.Lock()
+	defer annotationsMu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(amDir, annotationsDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	all := make(map[string]*ConversationAnnotations, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var a ConversationAnnotations
+		if err := json.Unmarshal(data, &a); err != nil || a.ConversationID == "" {
+			continue
+		}
+		all[a.ConversationID] = &a
+	}
+	return all, nil
+}
+
+// TagCounts returns how many conversations carry each tag.
+func TagCounts(amDir string) (map[string]int, error) {
+	all, err := ListAnnotations(amDir)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, a := range all {
+		for _, tag := range a.Tags {
+			counts[tag]++
+		}
+	}
+	return counts, nil
+}
+
+// HasTags reports whether every one of tags is present. Nil annotations have no tags.
+func (a *ConversationAnnotations) HasTags(tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	if a == nil {
+		return false
+	}
+	have := make(map[string]bool, len(a.Tags))
+	for _, tag := range a.Tags {
+		have[tag] = true
+	}
+	for _, tag := range NormalizeTags(tags) {
+		if !have[tag] {
+			return false
+		}
+	}
+	return true
+}
+
+// IsStarred reports whether the turn at index is starred.
+func (a *ConversationAnnotations) IsStarred(index int) bool {
+	if a == nil {
+		return false
+	}
+	for _, i := range a.StarredTurns {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeTags lower-cases, trims and de-duplicates tags, collapsing inner whitespace
+// so "Decision  Record" and "decision record" are the same tag.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool)
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+func annotationsPath(amDir, convID string) string {
+	if amDir == "" {
+		amDir = DefaultAMDir()
+	}
+	return filepath.Join(amDir, annotationsDir, safeFileName(convID)+".json")
+}
+
+func readAnnotations(amDir, convID string) (*ConversationAnnotations, error) {
+	empty := &ConversationAnnotations{ConversationID: convID, Tags: []string{}, StarredTurns: []int{}}
+
+	data, err := os.ReadFile(annotationsPath(amDir, convID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return empty, nil
+		}
+		return nil, err
+	}
+
+	var a ConversationAnnotations
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, fmt.Errorf("corrupt annotations for %s: %w", convID, err)
+	}
+	a.ConversationID = convID
+	if a.Tags == nil {
+		a.Tags = []string{}
+	}
+	if a.StarredTurns == nil {
+		a.StarredTurns = []int{}
+	}
+	return &a, nil
+}
+
+// writeAnnotations saves a, removing the file once nothing is left to keep.
+func writeAnnotations(amDir string, a *ConversationAnnotations) error {
+	path := annotationsPath(amDir, a.ConversationID)
+	if len(a.Tags) == 0 && a.Note == "" && len(a.StarredTurns) == 0 {
+		a.UpdatedAt = time.Time{}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	a.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// removeAnnotations deletes a conversation's annotations along with the conversation.
+func removeAnnotations(amDir, convID string) error {
+	if err := os.Remove(annotationsPath(amDir, convID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
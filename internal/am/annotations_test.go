@@ -0,0 +1,79 @@
+package am
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAnnotations_TagsNotesAndStars(t *testing.T) {
+	dir := t.TempDir()
+
+	a, err := GetAnnotations(dir, "conv-a")
+	if err != nil || len(a.Tags) != 0 || a.Note != "" {
+		t.Fatalf("Expected empty annotations, got %+v (%v)", a, err)
+	}
+
+	if _, err := SetAnnotations(dir, "conv-a", []string{"Decision  Record", "decision record", " auth "}, "Chose JWT"); err != nil {
+		t.Fatalf("SetAnnotations failed: %v", err)
+	}
+	StarTurn(dir, "conv-a", 3, true)
+	StarTurn(dir, "conv-a", 1, true)
+	StarTurn(dir, "conv-a", 3, true)
+
+	a, _ = GetAnnotations(dir, "conv-a")
+	if !reflect.DeepEqual(a.Tags, []string{"auth", "decision record"}) {
+		t.Errorf("Tags not normalized: %v", a.Tags)
+	}
+	if !reflect.DeepEqual(a.StarredTurns, []int{1, 3}) || !a.IsStarred(3) || a.IsStarred(2) {
+		t.Errorf("Unexpected starred turns: %v", a.StarredTurns)
+	}
+
+	// Replacing tags keeps stars; unstarring everything and clearing removes the file
+	SetAnnotations(dir, "conv-a", nil, "")
+	StarTurn(dir, "conv-a", 1, false)
+	a, _ = StarTurn(dir, "conv-a", 3, false)
+	if len(a.StarredTurns) != 0 {
+		t.Errorf("Turns still starred: %v", a.StarredTurns)
+	}
+	if _, err := os.Stat(annotationsPath(dir, "conv-a")); !os.IsNotExist(err) {
+		t.Error("Empty annotations should not leave a file behind")
+	}
+}
+
+func TestAnnotations_SurviveConversationSaves(t *testing.T) {
+	dir := t.TempDir()
+	logger := &LLMLogger{tabID: "tab-1", conversations: make(map[string]*LLMConversation), amDir: dir}
+	conv := &LLMConversation{
+		ConversationID: "conv-saved", TabID: "tab-1", Provider: "claude", StartTime: time.Now(),
+		Turns: []ConversationTurn{{Role: "user", Content: "hello"}},
+	}
+	logger.saveConversation(conv)
+
+	SetAnnotations(dir, "conv-saved", []string{"dead end"}, "Approach abandoned")
+
+	conv.Turns = append(conv.Turns, ConversationTurn{Role: "assistant", Content: "hi"})
+	conv.Complete = true
+	logger.saveConversation(conv)
+
+	all, err := ListAnnotations(dir)
+	if err != nil {
+		t.Fatalf("ListAnnotations failed: %v", err)
+	}
+	if a := all["conv-saved"]; !a.HasTags([]string{"Dead End"}) || a.Note != "Approach abandoned" {
+		t.Errorf("Annotations lost after save: %+v", a)
+	}
+	if all["missing"].HasTags([]string{"dead end"}) {
+		t.Error("Unannotated conversation matched a tag filter")
+	}
+
+	// Annotation files are not mistaken for conversations
+	if files, _ := filepath.Glob(filepath.Join(dir, "*-conv-*.json")); len(files) != 1 {
+		t.Errorf("Expected one conversation file, got %v", files)
+	}
+	if counts, _ := TagCounts(dir); counts["dead end"] != 1 {
+		t.Errorf("Unexpected tag counts: %v", counts)
+	}
+}
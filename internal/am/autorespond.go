@@ -0,0 +1,344 @@
+// The server-side auto-respond engine for LLM CLI prompts.
+
+package am
+
+import (
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/llm"
+)
+
+const (
+	autoRespondSettle      = 400 * time.Millisecond // Screen must be still this long before a prompt is judged
+	autoRespondScreenBytes = 8 * 1024               // Rolling window of cleaned output searched for prompts
+	promptTailLines        = 12                     // A prompt's question must be this close to the bottom
+	promptContextLines     = 10                     // Lines above the question that describe the request
+)
+
+// ConfirmationPrompt is a question an LLM CLI is waiting on.
+type ConfirmationPrompt struct {
+	Question string // The line asking for confirmation
+	Kind     string // Prompt* constant
+	Subject  string // The command, path or URL the prompt is about
+	Menu     bool   // Answered by selecting from a menu rather than typing y/n
+}
+
+// AutoResponseRecord is the audit entry for one automatic answer.
+type AutoResponseRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	Question  string    `json:"question"`
+	Kind      string    `json:"kind"`
+	Subject   string    `json:"subject,omitempty"`
+	Decision  string    `json:"decision"`
+	Rule      string    `json:"rule,omitempty"`
+	Keys      string    `json:"keys"`             // Keystrokes written to the terminal
+	Policy    string    `json:"policy,omitempty"` // Policy file, or "builtin"
+}
+
+var (
+	questionPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\bdo you want to [^?]*\?`),
+		regexp.MustCompile(`(?i)\bwould you like to [^?]*\?`),
+		regexp.MustCompile(`(?i)\ballow (this|the) [^?]*\?`),
+		regexp.MustCompile(`(?i)\b(proceed|continue|run this command)\?`),
+		regexp.MustCompile(`(?i)\((y/n|yes/no)\)|\[(y/n|yes/no)\]`),
+		regexp.MustCompile(`\(Y\)es/\(N\)o`), // aider
+	}
+	menuOptionPattern = regexp.MustCompile(`(?i)^[›❯>●◉]?\s*1\.\s*yes\b|^[›❯>●◉]\s*yes\b`)
+
+	networkPattern = regexp.MustCompile(`(?i)\b(web ?fetch|web ?search|fetch|curl|wget|download|network)\b|https?://`)
+	commandPattern = regexp.MustCompile(`(?i)\b(bash|shell) command\b|\brun (this|the following|shell) command\b|^\$ `)
+	editPattern    = regexp.MustCompile(`(?i)\b(edit|edits|write|create|overwrite|update|apply|patch)\b`)
+	commandHeader  = regexp.MustCompile(`(?i)\b(bash|shell) command\b`)
+	editHeader     = regexp.MustCompile(`^(?:[●⏺•]\s*)?(?:Edit|MultiEdit|Write|Update|Create|NotebookEdit)\(([^)]*)\)`)
+	urlPattern     = regexp.MustCompile(`https?://[^\s│]+`)
+	pathPattern    = regexp.MustCompile(`(?:~|\.{1,2})?(?:[\w.@-]*/)*[\w@-][\w.@-]*\.[A-Za-z0-9]+`)
+	frameChars     = "│╭╮╰╯─┌┐└┘├┤ \t"
+)
+
+// DetectConfirmationPrompt finds a confirmation prompt at the bottom of cleaned
+// screen text, or returns nil when the CLI isn't waiting on one.
+func DetectConfirmationPrompt(screen string) *ConfirmationPrompt {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(screen, "\r", "\n"), "\n") {
+		if line = strings.Trim(line, frameChars); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	start := len(lines) - promptTailLines
+	if start < 0 {
+		start = 0
+	}
+
+	qi := -1
+	for i := len(lines) - 1; i >= start && qi < 0; i-- {
+		for _, re := range questionPatterns {
+			if re.MatchString(lines[i]) {
+				qi = i
+				break
+			}
+		}
+	}
+	if qi < 0 {
+		return nil
+	}
+
+	prompt := &ConfirmationPrompt{Question: lines[qi]}
+	for _, line := range lines[qi+1:] {
+		if menuOptionPattern.MatchString(line) {
+			prompt.Menu = true
+			break
+		}
+	}
+
+	from := qi - promptContextLines
+	if from < 0 {
+		from = 0
+	}
+	context := lines[from:qi]
+	block := strings.Join(append(append([]string(nil), context...), prompt.Question), "\n")
+
+	switch {
+	case networkPattern.MatchString(block):
+		prompt.Kind = PromptNetwork
+		prompt.Subject = urlPattern.FindString(block)
+	case commandPattern.MatchString(block):
+		prompt.Kind = PromptCommand
+		prompt.Subject = promptCommand(context)
+	case editPattern.MatchString(block):
+		prompt.Kind = PromptEdit
+		prompt.Subject = promptEditPath(prompt.Question, context)
+	default:
+		prompt.Kind = PromptTool
+	}
+	return prompt
+}
+
+// promptCommand picks the command line out of the lines describing a prompt: the
+// line after a "Bash command" header, else the last line before the question.
+func promptCommand(context []string) string {
+	for i, line := range context {
+		if commandHeader.MatchString(line) && i+1 < len(context) {
+			return strings.TrimPrefix(context[i+1], "$ ")
+		}
+	}
+	for i := len(context) - 1; i >= 0; i-- {
+		if !commandHeader.MatchString(context[i]) {
+			return strings.TrimPrefix(context[i], "$ ")
+		}
+	}
+	return ""
+}
+
+// promptEditPath picks the file an edit prompt is about from the question or the
+// tool header above it: an "Update(path)" line, or the path alone on the line before
+// the question (aider). Other paths on screen may belong to something else, so
+// without a header the subject stays empty and the prompt is left to the user.
+func promptEditPath(question string, context []string) string {
+	if path := pathPattern.FindString(question); path != "" {
+		return path
+	}
+	for i := len(context) - 1; i >= 0; i-- {
+		if m := editHeader.FindStringSubmatch(context[i]); m != nil {
+			return pathPattern.FindString(m[1])
+		}
+	}
+	if n := len(context); n > 0 {
+		if line := strings.TrimSpace(context[n-1]); pathPattern.FindString(line) == line {
+			return line
+		}
+	}
+	return ""
+}
+
+// Keys returns the keystrokes that give decision as the answer.
+func (p *ConfirmationPrompt) Keys(decision string) string {
+	if p.Menu {
+		// Menus open with "Yes" selected; Esc cancels
+		if decision == AutoAllow {
+			return "\r"
+		}
+		return "\x1b"
+	}
+	if decision == AutoAllow {
+		return "y\r"
+	}
+	return "n\r"
+}
+
+var autoRespondKilled atomic.Bool
+
+// SetAutoRespondKillSwitch stops (or resumes) every automatic answer, regardless of
+// per-tab auto-respond settings and policies.
+func SetAutoRespondKillSwitch(engaged bool) {
+	autoRespondKilled.Store(engaged)
+	log.Printf("[AutoRespond] Kill switch %s", map[bool]string{true: "engaged", false: "released"}[engaged])
+}
+
+// AutoRespondKillSwitchEngaged reports whether automatic answers are stopped.
+func AutoRespondKillSwitchEngaged() bool {
+	return autoRespondKilled.Load()
+}
+
+// AutoResponder answers confirmation prompts of a tab's active LLM conversation
+// from the project's policy while the tab has auto-respond enabled.
+type AutoResponder struct {
+	logger *LLMLogger
+	write  func(keys string) error
+	settle time.Duration
+
+	mu      sync.Mutex
+	screen  string
+	timer   *time.Timer
+	sent    []time.Time // Recent automatic answers, for rate limiting
+	lastAsk string      // Last prompt left to the user, to log it once
+	stopped bool
+}
+
+// NewAutoResponder creates a responder that types its answers with write.
+func NewAutoResponder(logger *LLMLogger, write func(keys string) error) *AutoResponder {
+	return &AutoResponder{logger: logger, write: write, settle: autoRespondSettle}
+}
+
+// Feed adds terminal output. Prompts are judged once the output settles.
+func (r *AutoResponder) Feed(output string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped || AutoRespondKillSwitchEngaged() || !r.logger.IsAutoRespond() {
+		r.screen = ""
+		return
+	}
+
+	r.screen += llm.CleanANSI(output)
+	if len(r.screen) > autoRespondScreenBytes {
+		r.screen = r.screen[len(r.screen)-autoRespondScreenBytes:]
+	}
+	r.scheduleLocked(r.settle)
+}
+
+// Stop disables the responder.
+func (r *AutoResponder) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopped = true
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+}
+
+func (r *AutoResponder) scheduleLocked(delay time.Duration) {
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.timer = time.AfterFunc(delay, r.evaluate)
+}
+
+// evaluate answers the prompt on screen, if policy and rate limits allow.
+func (r *AutoResponder) evaluate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped || AutoRespondKillSwitchEngaged() || !r.logger.IsAutoRespond() {
+		return
+	}
+
+	prompt := DetectConfirmationPrompt(r.screen)
+	if prompt == nil {
+		return
+	}
+	convID, dir := r.logger.activeConversationDir()
+	if convID == "" {
+		return
+	}
+
+	policy, err := LoadAutoRespondPolicy(dir)
+	if err != nil {
+		log.Printf("[AutoRespond] ⚠️ Invalid policy, leaving prompts to the user: %v", err)
+		return
+	}
+	if policy.Disabled {
+		return
+	}
+
+	decision, rule := policy.Decide(prompt, dir)
+	if decision == AutoAsk {
+		if prompt.Question != r.lastAsk {
+			log.Printf("[AutoRespond] Leaving %s prompt to the user (%s): %s", prompt.Kind, convID, prompt.Question)
+			r.lastAsk = prompt.Question
+		}
+		return
+	}
+
+	now := time.Now()
+	recent := r.sent[:0]
+	for _, t := range r.sent {
+		if now.Sub(t) < time.Minute {
+			recent = append(recent, t)
+		}
+	}
+	r.sent = recent
+	if len(r.sent) >= policy.MaxPerMinute {
+		if prompt.Question != r.lastAsk {
+			log.Printf("[AutoRespond] ⚠️ Rate limit of %d answers/minute reached for %s; leaving prompt to the user",
+				policy.MaxPerMinute, convID)
+			r.lastAsk = prompt.Question
+		}
+		return
+	}
+	if n := len(r.sent); n > 0 {
+		if wait := time.Duration(policy.MinIntervalMs)*time.Millisecond - now.Sub(r.sent[n-1]); wait > 0 {
+			r.scheduleLocked(wait)
+			return
+		}
+	}
+
+	keys := prompt.Keys(decision)
+	if err := r.write(keys); err != nil {
+		log.Printf("[AutoRespond] ❌ Failed to answer prompt: %v", err)
+		return
+	}
+	r.sent = append(r.sent, now)
+	r.screen = ""
+	r.lastAsk = ""
+
+	log.Printf("[AutoRespond] ✅ Answered %s prompt with %s (rule %q, %s): %s",
+		prompt.Kind, decision, rule, convID, prompt.Question)
+	r.logger.recordAutoResponse(convID, AutoResponseRecord{
+		Timestamp: now,
+		Question:  prompt.Question,
+		Kind:      prompt.Kind,
+		Subject:   prompt.Subject,
+		Decision:  decision,
+		Rule:      rule,
+		Keys:      keys,
+		Policy:    policy.Source,
+	})
+}
+
+// activeConversationDir returns the active conversation and where its CLI runs.
+func (l *LLMLogger) activeConversationDir() (string, string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	conv := l.conversations[l.activeConvID]
+	if conv == nil {
+		return "", ""
+	}
+	return conv.ConversationID, conv.GetWorkingDirectory()
+}
+
+// recordAutoResponse appends an automatic answer to a conversation's audit trail.
+func (l *LLMLogger) recordAutoResponse(convID string, rec AutoResponseRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	conv := l.conversations[convID]
+	if conv == nil {
+		return
+	}
+	conv.AutoRespond = true
+	conv.AutoResponses = append(conv.AutoResponses, rec)
+	l.saveConversation(conv)
+}
@@ -0,0 +1,311 @@
+// The allow/deny policy behind server-side auto-respond.
+
+package am
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/storage"
+)
+
+// Auto-respond decisions.
+const (
+	AutoAllow = "allow" // Answer yes
+	AutoDeny  = "deny"  // Answer no
+	AutoAsk   = "ask"   // Leave the prompt for the user
+)
+
+// Confirmation prompt kinds, the subject of policy rules.
+const (
+	PromptEdit    = "edit"    // Create, edit or overwrite a file
+	PromptCommand = "command" // Run a shell command
+	PromptNetwork = "network" // Fetch a URL or otherwise reach the network
+	PromptTool    = "tool"    // Any other tool use or confirmation
+)
+
+// projectPolicyFile is a policy shipped inside a repository, relative to its root.
+// It comes with the repository's content, so it only applies once the user trusts
+// it, see TrustProjectPolicy.
+var projectPolicyFile = filepath.Join(".forge", "autorespond.json")
+
+// AutoRespondRule matches confirmation prompts. The first matching rule decides.
+type AutoRespondRule struct {
+	Name     string `json:"name,omitempty"`
+	Kind     string `json:"kind,omitempty"`    // Prompt kind; empty matches any kind
+	Pattern  string `json:"pattern,omitempty"` // Regex matched against the prompt's subject
+	InRepo   bool   `json:"inRepo,omitempty"`  // Subject must be a path inside the project
+	Decision string `json:"decision"`          // allow, deny or ask
+
+	re *regexp.Regexp
+}
+
+// AutoRespondPolicy decides how confirmation prompts are answered in a project.
+type AutoRespondPolicy struct {
+	Disabled      bool              `json:"disabled,omitempty"`
+	Rules         []AutoRespondRule `json:"rules"`
+	Default       string            `json:"default,omitempty"`       // Decision when no rule matches (ask)
+	MaxPerMinute  int               `json:"maxPerMinute,omitempty"`  // Automatic answers allowed per minute
+	MinIntervalMs int               `json:"minIntervalMs,omitempty"` // Minimum gap between automatic answers
+	Source        string            `json:"source,omitempty"`        // File the policy was loaded from, or "builtin"
+
+	// UntrustedPolicy is set when the repository ships a policy the user hasn't
+	// trusted (or that changed since), which was ignored.
+	UntrustedPolicy string `json:"untrustedPolicy,omitempty"`
+}
+
+// DefaultAutoRespondPolicy allows edits inside the project, refuses rm and leaves
+// network access and everything else to the user.
+func DefaultAutoRespondPolicy() *AutoRespondPolicy {
+	p := &AutoRespondPolicy{
+		Rules: append(builtinDenyRules(),
+			AutoRespondRule{Name: "ask-network", Kind: PromptNetwork, Decision: AutoAsk},
+			AutoRespondRule{Name: "allow-repo-edits", Kind: PromptEdit, InRepo: true, Decision: AutoAllow},
+		),
+		Source: "builtin",
+	}
+	p.compile()
+	return p
+}
+
+// builtinDenyRules are refusals a repository's own policy can't override; they
+// run before its rules.
+func builtinDenyRules() []AutoRespondRule {
+	return []AutoRespondRule{
+		{Name: "deny-rm", Kind: PromptCommand, Pattern: `(^|[\s;&|(\x60])(\S*/)?rm\b`, Decision: AutoDeny},
+	}
+}
+
+// LoadAutoRespondPolicy returns the policy for a project directory, the first of:
+// the user's policy for the project (ProjectPolicyPath), the repository's own
+// .forge/autorespond.json if the user trusted it, ~/.forge/autorespond.json, and
+// the default policy.
+func LoadAutoRespondPolicy(projectDir string) (*AutoRespondPolicy, error) {
+	root := projectRoot(projectDir)
+	untrusted := ""
+
+	if root != "" {
+		if p, err := loadPolicyFile(ProjectPolicyPath(projectDir)); p != nil || err != nil {
+			return p, err
+		}
+
+		repoPath := filepath.Join(root, projectPolicyFile)
+		data, err := os.ReadFile(repoPath)
+		switch {
+		case err == nil && projectPolicyTrusted(root, data):
+			p, err := ParseAutoRespondPolicy(data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", repoPath, err)
+			}
+			p.Rules = append(builtinDenyRules(), p.Rules...)
+			p.compile()
+			p.Source = repoPath
+			return p, nil
+		case err == nil:
+			log.Printf("[AM Auto-Respond] Ignoring untrusted policy %s", repoPath)
+			untrusted = repoPath
+		case !os.IsNotExist(err):
+			return nil, err
+		}
+	}
+
+	p, err := loadPolicyFile(storage.GetAutoRespondPolicyPath())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		p = DefaultAutoRespondPolicy()
+	}
+	p.UntrustedPolicy = untrusted
+	return p, nil
+}
+
+// loadPolicyFile parses the policy at path; a missing file returns nil, nil.
+func loadPolicyFile(path string) (*AutoRespondPolicy, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p, err := ParseAutoRespondPolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	p.Source = path
+	return p, nil
+}
+
+// ProjectPolicyPath returns where the user's own policy for the project containing
+// dir lives: under ~/.forge, keyed by the project root, out of the repository's reach.
+func ProjectPolicyPath(dir string) string {
+	root := projectRoot(dir)
+	if root == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(root))
+	name := filepath.Base(root) + "-" + hex.EncodeToString(sum[:6]) + ".json"
+	return filepath.Join(storage.GetAutoRespondProjectsDir(), name)
+}
+
+// TrustProjectPolicy trusts (or, with trust false, stops trusting) the policy the
+// repository containing dir ships in .forge/autorespond.json. Trust covers the file
+// as it is now; any later change needs trusting again.
+func TrustProjectPolicy(dir string, trust bool) error {
+	root := projectRoot(dir)
+	if root == "" {
+		return fmt.Errorf("no project directory given")
+	}
+	trusted := loadPolicyTrust()
+	if trust {
+		data, err := os.ReadFile(filepath.Join(root, projectPolicyFile))
+		if err != nil {
+			return err
+		}
+		if _, err := ParseAutoRespondPolicy(data); err != nil {
+			return err
+		}
+		trusted[root] = policyDigest(data)
+	} else {
+		delete(trusted, root)
+	}
+
+	data, err := json.MarshalIndent(trusted, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := storage.GetAutoRespondTrustPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// projectPolicyTrusted reports whether the user trusted this exact policy file.
+func projectPolicyTrusted(root string, data []byte) bool {
+	return loadPolicyTrust()[root] == policyDigest(data)
+}
+
+// loadPolicyTrust reads the trusted repositories: project root -> policy digest.
+func loadPolicyTrust() map[string]string {
+	trusted := make(map[string]string)
+	data, err := os.ReadFile(storage.GetAutoRespondTrustPath())
+	if err == nil {
+		if err := json.Unmarshal(data, &trusted); err != nil {
+			log.Printf("[AM Auto-Respond] ⚠️ Invalid %s, trusting no repository policies: %v", storage.GetAutoRespondTrustPath(), err)
+			return make(map[string]string)
+		}
+	}
+	return trusted
+}
+
+func policyDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseAutoRespondPolicy parses and validates a JSON policy.
+func ParseAutoRespondPolicy(data []byte) (*AutoRespondPolicy, error) {
+	var p AutoRespondPolicy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	for i, rule := range p.Rules {
+		if !validDecision(rule.Decision) {
+			return nil, fmt.Errorf("rule %d: invalid decision %q", i, rule.Decision)
+		}
+		if rule.Pattern != "" {
+			if _, err := regexp.Compile(rule.Pattern); err != nil {
+				return nil, fmt.Errorf("rule %d: %w", i, err)
+			}
+		}
+	}
+	if p.Default != "" && !validDecision(p.Default) {
+		return nil, fmt.Errorf("invalid default decision %q", p.Default)
+	}
+	p.compile()
+	return &p, nil
+}
+
+func validDecision(decision string) bool {
+	return decision == AutoAllow || decision == AutoDeny || decision == AutoAsk
+}
+
+func (p *AutoRespondPolicy) compile() {
+	if p.Default == "" {
+		p.Default = AutoAsk
+	}
+	if p.MaxPerMinute <= 0 {
+		p.MaxPerMinute = 6
+	}
+	if p.MinIntervalMs <= 0 {
+		p.MinIntervalMs = 2000
+	}
+	for i := range p.Rules {
+		if p.Rules[i].Pattern != "" {
+			p.Rules[i].re = regexp.MustCompile(p.Rules[i].Pattern)
+		}
+	}
+}
+
+// Decide returns the decision for a prompt raised by a CLI running in projectDir,
+// and the name of the rule that made it ("" for the default).
+func (p *AutoRespondPolicy) Decide(prompt *ConfirmationPrompt, projectDir string) (string, string) {
+	for i, rule := range p.Rules {
+		if rule.Kind != "" && rule.Kind != prompt.Kind {
+			continue
+		}
+		if rule.re != nil && !rule.re.MatchString(prompt.Subject) {
+			continue
+		}
+		if rule.InRepo && !pathInProject(prompt.Subject, projectDir) {
+			continue
+		}
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("rule-%d", i+1)
+		}
+		return rule.Decision, name
+	}
+	return p.Default, ""
+}
+
+// projectRoot is the git root above dir, or dir itself outside a repository.
+func projectRoot(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	if root := findGitRoot(dir); root != "" {
+		return root
+	}
+	return dir
+}
+
+// pathInProject reports whether path, relative to the CLI's directory, resolves
+// inside the project. A leading ~ is the user's home directory.
+func pathInProject(path, projectDir string) bool {
+	root := projectRoot(projectDir)
+	if path == "" || root == "" {
+		return false
+	}
+	if strings.HasPrefix(path, "~") {
+		home, err := os.UserHomeDir()
+		if err != nil || (path != "~" && !strings.HasPrefix(path, "~/")) {
+			return false // ~user, or no home to expand to
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(projectDir, path)
+	}
+	rel, err := filepath.Rel(root, filepath.Clean(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,228 @@
+package am
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDetectConfirmationPrompt(t *testing.T) {
+	tests := []struct {
+		name    string
+		screen  string
+		kind    string
+		subject string
+		menu    bool
+	}{
+		{
+			name: "claude bash command",
+			screen: "╭──────────────────────────╮\n│ Bash command              │\n│   rm -rf build            │\n│   Remove build output     │\n" +
+				"│ Do you want to proceed?   │\n│ ❯ 1. Yes                  │\n│   2. No                   │\n╰──────────────────────────╯\n",
+			kind: PromptCommand, subject: "rm -rf build", menu: true,
+		},
+		{
+			name:   "claude edit",
+			screen: "● Update(src/app.go)\n│ Do you want to make this edit to src/app.go? │\n│ ❯ 1. Yes │\n│   2. No │\n",
+			kind:   PromptEdit, subject: "src/app.go", menu: true,
+		},
+		{
+			name:   "network fetch",
+			screen: "Fetch\n  https://example.com/docs\nDo you want to allow this fetch?\n❯ 1. Yes\n",
+			kind:   PromptNetwork, subject: "https://example.com/docs", menu: true,
+		},
+		{
+			name:   "aider create file",
+			screen: "notes/todo.md\nCreate new file? (Y)es/(N)o [Yes]: ",
+			kind:   PromptEdit, subject: "notes/todo.md",
+		},
+		{
+			name:   "edit without a path in the question or header",
+			screen: "Ran go test ./internal/app_test.go\nAll tests passed\nDo you want to apply these edits? (y/n)",
+			kind:   PromptEdit, subject: "",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := DetectConfirmationPrompt(tc.screen)
+			if p == nil {
+				t.Fatal("No prompt detected")
+			}
+			if p.Kind != tc.kind || p.Subject != tc.subject || p.Menu != tc.menu {
+				t.Errorf("Got kind=%s subject=%q menu=%v, want kind=%s subject=%q menu=%v",
+					p.Kind, p.Subject, p.Menu, tc.kind, tc.subject, tc.menu)
+			}
+		})
+	}
+
+	if p := DetectConfirmationPrompt("Do you want to proceed?\n" + "line\nline\nline\nline\nline\nline\nline\nline\nline\nline\nline\nline\n"); p != nil {
+		t.Errorf("Prompt scrolled off the bottom should be ignored, got %+v", p)
+	}
+	if p := DetectConfirmationPrompt("Compiling...\nDone in 2.1s\n"); p != nil {
+		t.Errorf("Unexpected prompt %+v", p)
+	}
+}
+
+func TestAutoRespondPolicy_Decide(t *testing.T) {
+	repo := t.TempDir()
+	os.Mkdir(filepath.Join(repo, ".git"), 0755)
+	cwd := filepath.Join(repo, "cmd")
+
+	policy := DefaultAutoRespondPolicy()
+	tests := []struct {
+		prompt   ConfirmationPrompt
+		decision string
+	}{
+		{ConfirmationPrompt{Kind: PromptEdit, Subject: "main.go"}, AutoAllow},
+		{ConfirmationPrompt{Kind: PromptEdit, Subject: "../internal/x.go"}, AutoAllow},
+		{ConfirmationPrompt{Kind: PromptEdit, Subject: "../../etc/hosts"}, AutoAsk},
+		{ConfirmationPrompt{Kind: PromptEdit, Subject: "~/.bashrc"}, AutoAsk},
+		{ConfirmationPrompt{Kind: PromptEdit, Subject: "~root/.bashrc"}, AutoAsk},
+		{ConfirmationPrompt{Kind: PromptEdit, Subject: ""}, AutoAsk},
+		{ConfirmationPrompt{Kind: PromptCommand, Subject: "rm -rf build"}, AutoDeny},
+		{ConfirmationPrompt{Kind: PromptCommand, Subject: "cd /tmp && sudo rm x"}, AutoDeny},
+		{ConfirmationPrompt{Kind: PromptCommand, Subject: "/bin/rm -rf x"}, AutoDeny},
+		{ConfirmationPrompt{Kind: PromptCommand, Subject: "find . -name '*.o' | xargs rm"}, AutoDeny},
+		{ConfirmationPrompt{Kind: PromptCommand, Subject: "command rm -f y"}, AutoDeny},
+		{ConfirmationPrompt{Kind: PromptCommand, Subject: "`rm` -rf z"}, AutoDeny},
+		{ConfirmationPrompt{Kind: PromptCommand, Subject: "go run ./cmd/format"}, AutoAsk},
+		{ConfirmationPrompt{Kind: PromptCommand, Subject: "go test ./..."}, AutoAsk},
+		{ConfirmationPrompt{Kind: PromptNetwork, Subject: "https://example.com"}, AutoAsk},
+	}
+	for _, tc := range tests {
+		if got, _ := policy.Decide(&tc.prompt, cwd); got != tc.decision {
+			t.Errorf("Decide(%s %q) = %s, want %s", tc.prompt.Kind, tc.prompt.Subject, got, tc.decision)
+		}
+	}
+
+	if _, err := ParseAutoRespondPolicy([]byte(`{"rules":[{"kind":"command","decision":"maybe"}]}`)); err == nil {
+		t.Error("Expected error for an invalid decision")
+	}
+	if _, err := ParseAutoRespondPolicy([]byte(`{"rules":[{"pattern":"(","decision":"deny"}]}`)); err == nil {
+		t.Error("Expected error for an invalid pattern")
+	}
+}
+
+func TestLoadAutoRespondPolicy_ProjectPolicies(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	repo := t.TempDir()
+	os.Mkdir(filepath.Join(repo, ".git"), 0755)
+
+	if p, err := LoadAutoRespondPolicy(repo); err != nil || p.Source != "builtin" {
+		t.Fatalf("Expected the builtin policy, got %+v (%v)", p, err)
+	}
+
+	// A policy committed to the repository is ignored until the user trusts it
+	repoPolicy := filepath.Join(repo, projectPolicyFile)
+	os.Mkdir(filepath.Join(repo, ".forge"), 0755)
+	os.WriteFile(repoPolicy, []byte(`{"rules":[{"decision":"allow"}],"maxPerMinute":2}`), 0644)
+	p, err := LoadAutoRespondPolicy(filepath.Join(repo, "pkg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Source != "builtin" || p.UntrustedPolicy != repoPolicy {
+		t.Fatalf("Untrusted repository policy applied: %+v", p)
+	}
+
+	if err := TrustProjectPolicy(repo, true); err != nil {
+		t.Fatal(err)
+	}
+	p, err = LoadAutoRespondPolicy(filepath.Join(repo, "pkg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Source != repoPolicy || p.MaxPerMinute != 2 {
+		t.Fatalf("Trusted repository policy not applied: %+v", p)
+	}
+	if got, _ := p.Decide(&ConfirmationPrompt{Kind: PromptCommand, Subject: "go test ./..."}, repo); got != AutoAllow {
+		t.Errorf("Repository rule not applied, got %s", got)
+	}
+	if got, rule := p.Decide(&ConfirmationPrompt{Kind: PromptCommand, Subject: "rm -rf build"}, repo); got != AutoDeny {
+		t.Errorf("Repository policy widened the builtin deny rules: %s by %s", got, rule)
+	}
+
+	// Changing the file revokes the trust
+	os.WriteFile(repoPolicy, []byte(`{"rules":[{"decision":"allow"}],"maxPerMinute":3}`), 0644)
+	if p, _ := LoadAutoRespondPolicy(repo); p.Source == repoPolicy {
+		t.Error("Modified repository policy still trusted")
+	}
+
+	// The user's own policy for the project, kept under ~/.forge, comes first
+	userPolicy := ProjectPolicyPath(repo)
+	os.MkdirAll(filepath.Dir(userPolicy), 0755)
+	os.WriteFile(userPolicy, []byte(`{"rules":[{"kind":"command","pattern":"^go test","decision":"allow"}]}`), 0644)
+	p, err = LoadAutoRespondPolicy(repo)
+	if err != nil || p.Source != userPolicy {
+		t.Fatalf("Expected the user's project policy, got %+v (%v)", p, err)
+	}
+}
+
+func TestAutoResponder_AnswersAndAudits(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	repo := t.TempDir()
+	os.Mkdir(filepath.Join(repo, ".git"), 0755)
+
+	logger := lifecycleLogger(t, true)
+	logger.conversations["conv-life"].Metadata = &ConversationMetadata{WorkingDirectory: repo}
+	logger.SetAutoRespond(true)
+
+	var mu sync.Mutex
+	var typed []string
+	r := NewAutoResponder(logger, func(keys string) error {
+		mu.Lock()
+		typed = append(typed, keys)
+		mu.Unlock()
+		return nil
+	})
+	r.settle = 10 * time.Millisecond
+	defer r.Stop()
+
+	waitTyped := func(n int) []string {
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			mu.Lock()
+			got := append([]string(nil), typed...)
+			mu.Unlock()
+			if len(got) >= n {
+				return got
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		return nil
+	}
+
+	r.Feed("\x1b[1m Bash command\x1b[0m\r\n   rm -rf build\r\n Do you want to proceed?\r\n ❯ 1. Yes\r\n   2. No\r\n")
+	if got := waitTyped(1); len(got) != 1 || got[0] != "\x1b" {
+		t.Fatalf("Expected the rm prompt to be cancelled, typed %q", got)
+	}
+
+	// Kill switch: nothing is answered while engaged
+	SetAutoRespondKillSwitch(true)
+	r.Feed("Do you want to make this edit to main.go?\n❯ 1. Yes\n")
+	time.Sleep(50 * time.Millisecond)
+	SetAutoRespondKillSwitch(false)
+	mu.Lock()
+	if len(typed) != 1 {
+		t.Errorf("Answered while the kill switch was engaged: %q", typed)
+	}
+	mu.Unlock()
+
+	conv := logger.conversations["conv-life"]
+	if len(conv.AutoResponses) != 1 {
+		t.Fatalf("Expected one audit record, got %+v", conv.AutoResponses)
+	}
+	rec := conv.AutoResponses[0]
+	if rec.Decision != AutoDeny || rec.Rule != "deny-rm" || rec.Subject != "rm -rf build" || rec.Keys != "\x1b" {
+		t.Errorf("Unexpected audit record %+v", rec)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(logger.amDir, "*-conv-*.json"))
+	if len(files) != 1 {
+		t.Fatalf("Expected one saved conversation, got %v", files)
+	}
+	saved, err := readConversationFile(files[0])
+	if err != nil || len(saved.AutoResponses) != 1 || !saved.AutoRespond {
+		t.Errorf("Audit trail not persisted: %+v (%v)", saved, err)
+	}
+}
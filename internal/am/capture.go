@@ -1,4 +1,5 @@
-// Package am provides conversation capture for LLM CLI sessions.
+// Conversation capture for LLM CLI sessions.
+
 package am
 
 import (
@@ -6,6 +7,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/llm"
 )
 
 // CaptureState represents the current state of conversation capture.
@@ -43,6 +46,9 @@ type CaptureMetrics struct {
 	UptimeSeconds           int64 `json:"uptimeSeconds"`
 	LayersOperational       int   `json:"layersOperational"`
 	LayersTotal             int   `json:"layersTotal"`
+
+	// Conversations closed per end method (process_exit, prompt_heuristic, ...)
+	EndMethods map[string]int `json:"endMethods,omitempty"`
 }
 
 // ConversationCapture manages real-time capture of LLM conversations.
@@ -214,37 +220,14 @@ func (c *ConversationCapture) flushAssistantTurnLocked() {
 }
 
 // detectPromptReappeared checks if CLI prompt has reappeared (end of response).
+// Prompts come from the provider's definition; providers without any fall back to
+// Copilot's.
 func (c *ConversationCapture) detectPromptReappeared(output string) bool {
-	// Provider-specific prompt patterns
-	patterns := map[string][]string{
-		"github-copilot": {
-			"\n‌",      // Copilot prompt char
-			"\n❯",      // Alternative prompt
-			"~/",       // Directory prompt often at end
-		},
-		"claude": {
-			"\n>",      // Claude prompt
-			"Claude >", // Claude prompt with name
-			"\n❯",      // Alternative
-		},
-		"aider": {
-			"\n>",      // Aider prompt
-			"aider>",   // Aider named prompt
-		},
-	}
-
-	providerPatterns, ok := patterns[c.provider]
-	if !ok {
-		providerPatterns = patterns["github-copilot"] // Default
+	def := llm.LookupProvider(c.provider)
+	if def == nil || len(def.ExitPrompts) == 0 {
+		def = llm.LookupProvider(string(llm.ProviderGitHubCopilot)) // Default
 	}
-
-	for _, pattern := range providerPatterns {
-		if strings.HasSuffix(output, pattern) || strings.Contains(output[max(0, len(output)-100):], pattern) {
-			return true
-		}
-	}
-
-	return false
+	return def != nil && def.PromptReturned(output)
 }
 
 // GetMetrics returns current capture metrics.
@@ -384,15 +367,8 @@ func removeTUIArtifacts(s string, provider string) string {
 	}
 
 	// Provider-specific cleanup
-	switch provider {
-	case "github-copilot":
-		// Remove Copilot TUI frames
-		result = regexp.MustCompile(`Welcome to GitHub Copilot.*?mistakes\.`).ReplaceAllString(result, "")
-		result = regexp.MustCompile(`●.*?\n`).ReplaceAllString(result, "") // Status lines
-	case "claude":
-		// Remove Claude TUI frames
-		result = regexp.MustCompile(`Claude Code v[\d.]+`).ReplaceAllString(result, "")
-		result = regexp.MustCompile(`Tips for getting started.*?\n`).ReplaceAllString(result, "")
+	if def := llm.LookupProvider(provider); def != nil {
+		result = def.Cleanup(result)
 	}
 
 	return result
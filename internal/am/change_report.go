@@ -0,0 +1,243 @@
+// Per-conversation reports of the files an LLM session changed.
+
+package am
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSnapshotFiles caps directories outside git that are snapshotted for change
+// reports; larger ones (a home directory, say) are skipped.
+const maxSnapshotFiles = 5000
+
+// Change report methods.
+const (
+	ChangesGit      = "git"      // Diffed against the repository checkpoint
+	ChangesSnapshot = "snapshot" // Diffed against a scratch snapshot of a plain directory
+)
+
+// snapshotSkipDirs are never walked or snapshotted in plain directories.
+var snapshotSkipDirs = []string{".git", "node_modules", ".venv", "venv", "__pycache__"}
+
+// FileChange is one path that differs between two states of a working tree.
+type FileChange struct {
+	Path    string `json:"path"`
+	Status  string `json:"status"` // git name-status letter: A, M, D or T
+	Added   int    `json:"added"`
+	Deleted int    `json:"deleted"`
+	Binary  bool   `json:"binary,omitempty"`
+}
+
+// ChangeReport summarizes what changed in a conversation's working directory
+// between the start and the end of the session.
+type ChangeReport struct {
+	Method        string       `json:"method"` // git or snapshot
+	Repo          string       `json:"repo"`
+	Base          string       `json:"base"` // Checkpoint ref the changes are relative to
+	Files         []FileChange `json:"files"`
+	FilesAdded    int          `json:"filesAdded"`
+	FilesModified int          `json:"filesModified"`
+	FilesDeleted  int          `json:"filesDeleted"`
+	LinesAdded    int          `json:"linesAdded"`
+	LinesDeleted  int          `json:"linesDeleted"`
+	Diff          string       `json:"diff,omitempty"` // Unified diff, cut at maxCheckpointPatch
+	Truncated     bool         `json:"truncated,omitempty"`
+	GeneratedAt   time.Time    `json:"generatedAt"`
+}
+
+// diffTrees compares two trees, returning the changed files with line counts and
+// the unified diff.
+func diffTrees(root string, gitEnv []string, from, to string) ([]FileChange, string, bool, error) {
+	out, err := runGit(root, gitEnv, "diff", "--no-renames", "--name-status", "-z", from, to)
+	if err != nil {
+		return nil, "", false, err
+	}
+	files := []FileChange{}
+	byPath := make(map[string]int)
+	fields := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		byPath[fields[i+1]] = len(files)
+		files = append(files, FileChange{Status: fields[i], Path: fields[i+1]})
+	}
+
+	// Entries are "added\tdeleted\tpath", with "-" counts for binary files
+	out, err = runGit(root, gitEnv, "diff", "--no-renames", "--numstat", "-z", from, to)
+	if err != nil {
+		return nil, "", false, err
+	}
+	for _, entry := range strings.Split(out, "\x00") {
+		parts := strings.SplitN(entry, "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		i, ok := byPath[parts[2]]
+		if !ok {
+			continue
+		}
+		if parts[0] == "-" {
+			files[i].Binary = true
+			continue
+		}
+		files[i].Added, _ = strconv.Atoi(parts[0])
+		files[i].Deleted, _ = strconv.Atoi(parts[1])
+	}
+
+	patch, err := runGit(root, gitEnv, "diff", "--no-renames", "--no-color", "--no-ext-diff", from, to)
+	if err != nil {
+		return nil, "", false, err
+	}
+	truncated := len(patch) > maxCheckpointPatch
+	if truncated {
+		patch = patch[:maxCheckpointPatch]
+	}
+	return files, patch, truncated, nil
+}
+
+// buildChangeReport diffs root's working tree against the checkpoint at baseRef.
+func buildChangeReport(root string, gitEnv []string, baseRef, method string) (*ChangeReport, error) {
+	base, err := runGit(root, gitEnv, "rev-parse", "--verify", "-q", baseRef+"^{tree}")
+	if err != nil {
+		return nil, err
+	}
+	current, err := snapshotTree(root, gitEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ChangeReport{Method: method, Repo: root, Base: baseRef, GeneratedAt: time.Now()}
+	report.Files, report.Diff, report.Truncated, err = diffTrees(root, gitEnv, base, current)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range report.Files {
+		switch f.Status {
+		case "A":
+			report.FilesAdded++
+		case "D":
+			report.FilesDeleted++
+		default:
+			report.FilesModified++
+		}
+		report.LinesAdded += f.Added
+		report.LinesDeleted += f.Deleted
+	}
+	return report, nil
+}
+
+// scratchGitDir is where a plain directory's snapshot is kept for a conversation.
+func (l *LLMLogger) scratchGitDir(convID string) string {
+	return filepath.Join(l.amDir, "snapshots", convID+".git")
+}
+
+// scratchEnv points git at gitDir while treating dir as its working tree.
+func scratchEnv(gitDir, dir string) []string {
+	return []string{"GIT_DIR=" + gitDir, "GIT_WORK_TREE=" + dir}
+}
+
+// snapshotable reports whether a directory outside git is small enough to
+// snapshot. Home and filesystem roots never are.
+func snapshotable(dir string) bool {
+	if dir == "" || dir == filepath.Dir(dir) {
+		return false
+	}
+	if home, err := os.UserHomeDir(); err == nil && filepath.Clean(dir) == filepath.Clean(home) {
+		return false
+	}
+
+	count := 0
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return filepath.SkipDir
+		}
+		if d.IsDir() {
+			for _, skip := range snapshotSkipDirs {
+				if d.Name() == skip {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if count++; count > maxSnapshotFiles {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return err == nil && count > 0 && count <= maxSnapshotFiles
+}
+
+// snapshotDirectory records dir in a scratch git dir, outside dir itself, so the
+// conversation's changes can be diffed when it ends.
+func snapshotDirectory(gitDir, dir, convID string) (*Checkpoint, error) {
+	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(gitDir), 0755); err != nil {
+			return nil, err
+		}
+		if _, err := runGit(filepath.Dir(gitDir), nil, "init", "-q", "--bare", gitDir); err != nil {
+			return nil, err
+		}
+		var exclude strings.Builder
+		for _, skip := range snapshotSkipDirs {
+			exclude.WriteString(skip + "/\n")
+		}
+		if err := os.WriteFile(filepath.Join(gitDir, "info", "exclude"), []byte(exclude.String()), 0644); err != nil {
+			return nil, err
+		}
+	}
+	return createCheckpoint(dir, scratchEnv(gitDir, dir), convID, CheckpointSessionStart)
+}
+
+// reportChangesLocked builds the change report of a conversation that just
+// ended, in the background, once its start checkpoint is in place. Called with
+// l.mu held.
+func (l *LLMLogger) reportChangesLocked(conv *LLMConversation) {
+	convID := conv.ConversationID
+	baseline := l.baselines[convID]
+	if baseline == nil {
+		return
+	}
+	delete(l.baselines, convID)
+	dir := conv.Metadata.ProcessCwd
+
+	pendingAsyncWrites.Add(1)
+	go func() {
+		defer pendingAsyncWrites.Done()
+		<-baseline
+
+		l.mu.Lock()
+		ref := ""
+		if c := l.conversations[convID]; c != nil && c.Metadata != nil {
+			ref = c.Metadata.Checkpoint
+		}
+		l.mu.Unlock()
+
+		var report *ChangeReport
+		var err error
+		if ref != "" {
+			report, err = buildChangeReport(findGitRoot(dir), nil, ref, ChangesGit)
+		} else {
+			gitDir := l.scratchGitDir(convID)
+			if _, statErr := os.Stat(gitDir); statErr != nil {
+				return
+			}
+			defer os.RemoveAll(gitDir)
+			report, err = buildChangeReport(dir, scratchEnv(gitDir, dir), checkpointRefPrefix+convID, ChangesSnapshot)
+		}
+		if err != nil {
+			log.Printf("[Checkpoint] ⚠️ Failed to build change report for %s: %v", convID, err)
+			return
+		}
+		log.Printf("[Checkpoint] ✅ %s changed %d file(s) (+%d -%d)", convID, len(report.Files), report.LinesAdded, report.LinesDeleted)
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if c := l.conversations[convID]; c != nil {
+			c.Changes = report
+			l.saveConversation(c)
+		}
+	}()
+}
@@ -0,0 +1,382 @@
+// Git working-tree checkpoints taken before LLM sessions.
+
+package am
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	checkpointRefPrefix = "refs/forge/checkpoints/"
+	checkpointTimeout   = 2 * time.Minute
+	maxCheckpointPatch  = 512 * 1024 // Larger diffs are truncated in API responses
+)
+
+// Checkpoint reasons. The session-start checkpoint lives at
+// refs/forge/checkpoints/<convId>; others get a suffixed ref next to it.
+const (
+	CheckpointSessionStart = "session-start" // Taken when the conversation started
+	CheckpointPreRestore   = "pre-restore"   // Taken before a restore, so it can be undone
+)
+
+var (
+	// ErrNotGitRepo is returned for conversations that didn't run inside a git repository.
+	ErrNotGitRepo = errors.New("not a git repository")
+	// ErrNoCheckpoint is returned when a conversation has no such checkpoint.
+	ErrNoCheckpoint = errors.New("checkpoint not found")
+
+	checkpointIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// Checkpoint is a snapshot of a repository's working tree, untracked files
+// included, stored as a commit under refs/forge/checkpoints.
+type Checkpoint struct {
+	Ref            string    `json:"ref"`
+	Commit         string    `json:"commit"`
+	Tree           string    `json:"tree"`
+	ConversationID string    `json:"conversationId"`
+	Reason         string    `json:"reason"`
+	Repo           string    `json:"repo"`
+	Created        time.Time `json:"created"`
+}
+
+// CheckpointDiff compares the current working tree against a checkpoint.
+type CheckpointDiff struct {
+	Checkpoint *Checkpoint  `json:"checkpoint"`
+	Files      []FileChange `json:"files"`
+	Patch      string       `json:"patch"`
+	Truncated  bool         `json:"truncated,omitempty"`
+}
+
+// CreateCheckpoint snapshots the working tree of the repository containing dir.
+// A temporary index is used, so the user's index, stash and HEAD are untouched.
+func CreateCheckpoint(dir, convID, reason string) (*Checkpoint, error) {
+	if !checkpointIDPattern.MatchString(convID) {
+		return nil, fmt.Errorf("invalid conversation ID %q", convID)
+	}
+	root := findGitRoot(dir)
+	if root == "" {
+		return nil, ErrNotGitRepo
+	}
+	return createCheckpoint(root, nil, convID, reason)
+}
+
+// createCheckpoint snapshots root into the git dir selected by gitEnv (the
+// repository's own when empty).
+func createCheckpoint(root string, gitEnv []string, convID, reason string) (*Checkpoint, error) {
+	tree, err := snapshotTree(root, gitEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	args := []string{"commit-tree", tree, "-m", fmt.Sprintf("forge checkpoint: %s (%s)", reason, convID)}
+	if head, err := runGit(root, gitEnv, "rev-parse", "--verify", "-q", "HEAD"); err == nil && head != "" {
+		args = append(args, "-p", head)
+	}
+	commit, err := runGit(root, append(append([]string(nil), checkpointIdentity...), gitEnv...), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := checkpointRefPrefix + convID
+	if reason != CheckpointSessionStart {
+		ref += "." + reason + "-" + strconv.FormatInt(now.UnixNano(), 10)
+	}
+	if _, err := runGit(root, gitEnv, "update-ref", ref, commit); err != nil {
+		return nil, err
+	}
+
+	return &Checkpoint{
+		Ref:            ref,
+		Commit:         commit,
+		Tree:           tree,
+		ConversationID: convID,
+		Reason:         reason,
+		Repo:           root,
+		Created:        now,
+	}, nil
+}
+
+// ListCheckpoints returns a conversation's checkpoints, oldest first.
+func ListCheckpoints(dir, convID string) ([]*Checkpoint, error) {
+	if !checkpointIDPattern.MatchString(convID) {
+		return nil, fmt.Errorf("invalid conversation ID %q", convID)
+	}
+	root := findGitRoot(dir)
+	if root == "" {
+		return nil, ErrNotGitRepo
+	}
+
+	out, err := runGit(root, nil, "for-each-ref", "--sort=creatordate",
+		"--format=%(refname)%09%(objectname)%09%(tree)%09%(creatordate:unix)",
+		checkpointRefPrefix+convID, checkpointRefPrefix+convID+".*")
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoints := []*Checkpoint{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 {
+			continue
+		}
+		cp := &Checkpoint{
+			Ref:            fields[0],
+			Commit:         fields[1],
+			Tree:           fields[2],
+			ConversationID: convID,
+			Reason:         CheckpointSessionStart,
+			Repo:           root,
+		}
+		// Suffixed refs are <convId>.<reason>-<unixnano>
+		if suffix, ok := strings.CutPrefix(fields[0], checkpointRefPrefix+convID+"."); ok {
+			if i := strings.LastIndex(suffix, "-"); i > 0 {
+				suffix = suffix[:i]
+			}
+			cp.Reason = suffix
+		}
+		if sec, err := strconv.ParseInt(fields[3], 10, 64); err == nil {
+			cp.Created = time.Unix(sec, 0)
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+	return checkpoints, nil
+}
+
+// findCheckpoint returns the conversation checkpoint at ref, or its session-start
+// checkpoint when ref is empty.
+func findCheckpoint(dir, convID, ref string) (*Checkpoint, error) {
+	checkpoints, err := ListCheckpoints(dir, convID)
+	if err != nil {
+		return nil, err
+	}
+	if ref == "" {
+		ref = checkpointRefPrefix + convID
+	}
+	for _, cp := range checkpoints {
+		if cp.Ref == ref || cp.Commit == ref {
+			return cp, nil
+		}
+	}
+	return nil, ErrNoCheckpoint
+}
+
+// DiffCheckpoint compares the current working tree, untracked files included,
+// against a checkpoint (the session-start one when ref is empty).
+func DiffCheckpoint(dir, convID, ref string) (*CheckpointDiff, error) {
+	cp, err := findCheckpoint(dir, convID, ref)
+	if err != nil {
+		return nil, err
+	}
+	current, err := snapshotTree(cp.Repo, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &CheckpointDiff{Checkpoint: cp}
+	diff.Files, diff.Patch, diff.Truncated, err = diffTrees(cp.Repo, nil, cp.Tree, current)
+	if err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+// RestoreCheckpoint puts the working tree back to a checkpoint (the session-start
+// one when ref is empty): changed and deleted files are restored and files created
+// since are removed. The current state is checkpointed first and returned, so the
+// restore itself can be undone. The user's index and HEAD are left alone.
+func RestoreCheckpoint(dir, convID, ref string) (*Checkpoint, error) {
+	cp, err := findCheckpoint(dir, convID, ref)
+	if err != nil {
+		return nil, err
+	}
+	backup, err := CreateCheckpoint(cp.Repo, convID, CheckpointPreRestore)
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint current state: %w", err)
+	}
+
+	added, err := runGit(cp.Repo, nil, "diff", "--no-renames", "--name-only", "--diff-filter=A", "-z", cp.Tree, backup.Tree)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range strings.Split(added, "\x00") {
+		if path == "" {
+			continue
+		}
+		full := filepath.Join(cp.Repo, filepath.FromSlash(path))
+		if err := os.Remove(full); err != nil && !os.IsNotExist(err) {
+			return backup, err
+		}
+		removeEmptyParents(filepath.Dir(full), cp.Repo)
+	}
+
+	err = withTempIndex(func(env []string) error {
+		if _, err := runGit(cp.Repo, env, "read-tree", cp.Tree); err != nil {
+			return err
+		}
+		_, err := runGit(cp.Repo, env, "checkout-index", "-a", "-f")
+		return err
+	})
+	if err != nil {
+		return backup, err
+	}
+
+	log.Printf("[Checkpoint] ✅ Restored %s to %s (undo: %s)", cp.Repo, cp.Ref, backup.Ref)
+	return backup, nil
+}
+
+// snapshotTree writes the working tree, untracked but not ignored files included,
+// as a git tree object and returns its ID. gitEnv selects a scratch git dir for
+// directories outside a repository.
+func snapshotTree(root string, gitEnv []string) (string, error) {
+	var tree string
+	err := withTempIndex(func(env []string) error {
+		// Start from the existing index so unchanged files aren't rehashed
+		if index, err := runGit(root, gitEnv, "rev-parse", "--git-path", "index"); err == nil {
+			if !filepath.IsAbs(index) {
+				index = filepath.Join(root, index)
+			}
+			if err := copyFile(index, strings.TrimPrefix(env[0], "GIT_INDEX_FILE=")); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		env = append(env, gitEnv...)
+		if _, err := runGit(root, env, "add", "-A"); err != nil {
+			return err
+		}
+		var err error
+		tree, err = runGit(root, env, "write-tree")
+		return err
+	})
+	return tree, err
+}
+
+// withTempIndex runs fn with GIT_INDEX_FILE pointing at a scratch index.
+func withTempIndex(fn func(env []string) error) error {
+	dir, err := os.MkdirTemp("", "forge-checkpoint-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	return fn([]string{"GIT_INDEX_FILE=" + filepath.Join(dir, "index")})
+}
+
+// checkpointIdentity lets commit-tree work where no git identity is configured.
+var checkpointIdentity = []string{
+	"GIT_AUTHOR_NAME=Forge Terminal", "GIT_AUTHOR_EMAIL=forge@localhost",
+	"GIT_COMMITTER_NAME=Forge Terminal", "GIT_COMMITTER_EMAIL=forge@localhost",
+}
+
+// runGit runs git in dir and returns its trimmed standard output.
+func runGit(dir string, env []string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), checkpointTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// removeEmptyParents removes dir and its parents, up to root, while they're empty.
+func removeEmptyParents(dir, root string) {
+	for dir != root && strings.HasPrefix(dir, root) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// checkpointConversationLocked checkpoints the repository a new conversation's CLI
+// runs in, in the background, and records the ref on the conversation. Outside a
+// repository the directory is snapshotted into a scratch git dir instead, which
+// only feeds the conversation's change report. Only the process's own directory is
+// used; the server's directory says nothing about where the agent works. Called
+// with l.mu held.
+func (l *LLMLogger) checkpointConversationLocked(conv *LLMConversation) {
+	if !l.checkpoints || conv.Metadata == nil || conv.Metadata.ProcessCwd == "" {
+		return
+	}
+	convID, dir := conv.ConversationID, conv.Metadata.ProcessCwd
+	root := findGitRoot(dir)
+	if root == "" && !snapshotable(dir) {
+		return
+	}
+
+	done := make(chan struct{})
+	if l.baselines == nil {
+		l.baselines = make(map[string]chan struct{})
+	}
+	l.baselines[convID] = done
+
+	go func() {
+		defer close(done)
+		if root == "" {
+			if _, err := snapshotDirectory(l.scratchGitDir(convID), dir, convID); err != nil {
+				log.Printf("[Checkpoint] ⚠️ Failed to snapshot %s for %s: %v", dir, convID, err)
+			}
+			return
+		}
+
+		cp, err := CreateCheckpoint(root, convID, CheckpointSessionStart)
+		if err != nil {
+			log.Printf("[Checkpoint] ⚠️ Failed to checkpoint %s for %s: %v", dir, convID, err)
+			return
+		}
+		log.Printf("[Checkpoint] ✅ Checkpointed %s for %s at %s (%s)", cp.Repo, convID, cp.Ref, cp.Commit[:12])
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		conv := l.conversations[convID]
+		if conv == nil {
+			return
+		}
+		// Async saves may still hold the old metadata; replace rather than mutate it
+		meta := ConversationMetadata{}
+		if conv.Metadata != nil {
+			meta = *conv.Metadata
+		}
+		meta.Checkpoint = cp.Ref
+		conv.Metadata = &meta
+		l.saveConversation(conv)
+	}()
+}
@@ -0,0 +1,171 @@
+package am
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func checkpointRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=t", "-c", "user.email=t@t", "commit", "-qm", "init"},
+	} {
+		if args[0] == "add" {
+			os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0644)
+			os.WriteFile(filepath.Join(repo, ".gitignore"), []byte("*.log\n"), 0644)
+		}
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	return repo
+}
+
+func TestCheckpoint_DiffAndRestore(t *testing.T) {
+	repo := checkpointRepo(t)
+	os.WriteFile(filepath.Join(repo, "notes.txt"), []byte("untracked\n"), 0644)
+	os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main // staged\n"), 0644)
+	exec.Command("git", "-C", repo, "add", "main.go").Run()
+	indexBefore, _ := exec.Command("git", "-C", repo, "diff", "--cached", "--name-only").Output()
+
+	cp, err := CreateCheckpoint(filepath.Join(repo, "sub"), "conv-1", CheckpointSessionStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Ref != "refs/forge/checkpoints/conv-1" {
+		t.Errorf("Unexpected ref %s", cp.Ref)
+	}
+	if indexAfter, _ := exec.Command("git", "-C", repo, "diff", "--cached", "--name-only").Output(); string(indexAfter) != string(indexBefore) {
+		t.Errorf("Index changed: %q -> %q", indexBefore, indexAfter)
+	}
+
+	// The agent trashes the tree
+	os.Remove(filepath.Join(repo, "notes.txt"))
+	os.WriteFile(filepath.Join(repo, "main.go"), []byte("broken\n"), 0644)
+	os.MkdirAll(filepath.Join(repo, "gen"), 0755)
+	os.WriteFile(filepath.Join(repo, "gen", "out.go"), []byte("package gen\n"), 0644)
+	os.WriteFile(filepath.Join(repo, "debug.log"), []byte("ignored\n"), 0644)
+
+	diff, err := DiffCheckpoint(repo, "conv-1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, f := range diff.Files {
+		got[f.Path] = f.Status
+	}
+	want := map[string]string{"main.go": "M", "notes.txt": "D", "gen/out.go": "A"}
+	if len(got) != len(want) {
+		t.Errorf("Diff files = %v, want %v", got, want)
+	}
+	for path, status := range want {
+		if got[path] != status {
+			t.Errorf("%s: status %q, want %q", path, got[path], status)
+		}
+	}
+
+	backup, err := RestoreCheckpoint(repo, "conv-1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repo, "main.go")); string(data) != "package main // staged\n" {
+		t.Errorf("main.go not restored: %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repo, "notes.txt")); string(data) != "untracked\n" {
+		t.Errorf("notes.txt not restored: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(repo, "gen")); !os.IsNotExist(err) {
+		t.Error("Files created after the checkpoint were not removed")
+	}
+	if _, err := os.Stat(filepath.Join(repo, "debug.log")); err != nil {
+		t.Error("Ignored files must be left alone")
+	}
+
+	list, err := ListCheckpoints(repo, "conv-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[1].Ref != backup.Ref || list[1].Reason != CheckpointPreRestore {
+		t.Fatalf("Unexpected checkpoints %+v", list)
+	}
+
+	// The pre-restore checkpoint undoes the restore
+	if _, err := RestoreCheckpoint(repo, "conv-1", backup.Ref); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repo, "gen", "out.go")); string(data) != "package gen\n" {
+		t.Errorf("Undo did not bring back gen/out.go: %q", data)
+	}
+
+	if _, err := DiffCheckpoint(repo, "conv-2", ""); err != ErrNoCheckpoint {
+		t.Errorf("Expected ErrNoCheckpoint, got %v", err)
+	}
+	if _, err := CreateCheckpoint(t.TempDir(), "conv-3", CheckpointSessionStart); err != ErrNotGitRepo {
+		t.Errorf("Expected ErrNotGitRepo, got %v", err)
+	}
+}
+
+func TestLLMLogger_ChangeReport(t *testing.T) {
+	repo := checkpointRepo(t)
+	plain := t.TempDir()
+	os.WriteFile(filepath.Join(plain, "todo.txt"), []byte("one\n"), 0644)
+
+	tests := []struct {
+		name   string
+		dir    string
+		method string
+	}{
+		{"git", repo, ChangesGit},
+		{"plain directory", plain, ChangesSnapshot},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			logger := lifecycleLogger(t, true)
+			logger.checkpoints = true
+			conv := logger.conversations["conv-life"]
+			conv.Metadata = &ConversationMetadata{ProcessCwd: tc.dir}
+
+			logger.mu.Lock()
+			logger.checkpointConversationLocked(conv)
+			baseline := logger.baselines["conv-life"]
+			logger.mu.Unlock()
+			if baseline == nil {
+				t.Fatal("No start checkpoint taken")
+			}
+			<-baseline
+
+			os.WriteFile(filepath.Join(tc.dir, "added.txt"), []byte("a\nb\n"), 0644)
+			logger.mu.Lock()
+			logger.endConversationLocked(EndProcessExit)
+			logger.mu.Unlock()
+			WaitForPendingWrites()
+
+			logger.mu.Lock()
+			report := conv.Changes
+			logger.mu.Unlock()
+			if report == nil {
+				t.Fatal("No change report")
+			}
+			if report.Method != tc.method || report.FilesAdded != 1 || report.LinesAdded != 2 ||
+				len(report.Files) != 1 || report.Files[0].Path != "added.txt" {
+				t.Errorf("Unexpected report %+v", report)
+			}
+			if !strings.Contains(report.Diff, "+b") {
+				t.Errorf("Diff missing the added lines:\n%s", report.Diff)
+			}
+			if _, err := os.Stat(logger.scratchGitDir("conv-life")); !os.IsNotExist(err) {
+				t.Error("Scratch snapshot left behind")
+			}
+		})
+	}
+}
@@ -1,14 +1,16 @@
-// Package am provides context building for session restoration.
+// Context building for session restoration.
+
 package am
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
+	"log"
 	"path/filepath"
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/prompts"
 )
 
 // RestoreContext represents all information needed to restore a conversation.
@@ -138,14 +140,23 @@ func (cb *ContextBuilder) buildSummary(conv *LLMConversation) string {
 	return sb.String()
 }
 
-// buildRestorePrompt creates a prompt for continuing the conversation.
+// buildRestorePrompt creates a prompt for continuing the conversation
+// from the "restore" prompt template.
 func (cb *ContextBuilder) buildRestorePrompt(conv *LLMConversation) string {
 	if len(conv.Turns) == 0 {
 		return ""
 	}
 
-	var sb strings.Builder
+	prompt, err := prompts.Render(prompts.Restore, cb.RestorePromptData(conv))
+	if err != nil {
+		log.Printf("[AM] Failed to render restore prompt for %s: %v", conv.ConversationID, err)
+		return ""
+	}
+	return prompt
+}
 
+// RestorePromptData returns the variables available to the "restore" prompt template.
+func (cb *ContextBuilder) RestorePromptData(conv *LLMConversation) map[string]interface{} {
 	// Find last meaningful exchange
 	var lastUserPrompt string
 	var lastAssistantResponse string
@@ -163,17 +174,22 @@ func (cb *ContextBuilder) buildRestorePrompt(conv *LLMConversation) string {
 		}
 	}
 
-	// Build restore prompt
-	if lastUserPrompt != "" {
-		sb.WriteString("I was working on: ")
-		sb.WriteString(truncate(lastUserPrompt, 200))
-
-		if lastAssistantResponse != "" {
-			sb.WriteString("\n\nYou had started helping with this. Please continue from where we left off.")
-		}
+	data := map[string]interface{}{
+		"LastUserPrompt":        truncate(lastUserPrompt, 200),
+		"LastAssistantResponse": truncate(lastAssistantResponse, 500),
+		"HasAssistantResponse":  lastAssistantResponse != "",
+		"Provider":              conv.Provider,
+		"Project":               conv.GetProjectName(),
+		"WorkingDirectory":      "",
+		"GitBranch":             "",
+		"TurnCount":             len(conv.Turns),
+		"Complete":              conv.Complete,
 	}
-
-	return sb.String()
+	if conv.Metadata != nil {
+		data["WorkingDirectory"] = conv.Metadata.WorkingDirectory
+		data["GitBranch"] = conv.Metadata.GitBranch
+	}
+	return data
 }
 
 // buildFullContext creates the complete conversation context for injection.
@@ -218,26 +234,10 @@ func (cb *ContextBuilder) buildFullContext(conv *LLMConversation) string {
 
 // GetRecoverableSessions finds all sessions that can be recovered.
 func (cb *ContextBuilder) GetRecoverableSessions() ([]*RecoverableSession, error) {
-	// Support both new and legacy file patterns
-	patterns := []string{
-		filepath.Join(cb.amDir, "*-conv-*.json"),     // New format
-		filepath.Join(cb.amDir, "llm-conv-*.json"),   // Legacy format
-	}
-	
-	allFiles := make(map[string]bool)
-	for _, pattern := range patterns {
-		files, err := filepath.Glob(pattern)
-		if err != nil {
-			continue
-		}
-		for _, file := range files {
-			allFiles[file] = true
-		}
-	}
-
 	var sessions []*RecoverableSession
 
-	for file := range allFiles {
+	// New and legacy naming, including archives compressed by retention
+	for _, file := range conversationFiles(cb.amDir) {
 		conv, err := cb.loadConversation(file)
 		if err != nil {
 			continue
@@ -275,50 +275,30 @@ func (cb *ContextBuilder) GetRecoverableSessions() ([]*RecoverableSession, error
 
 // GetRestoreContextByID retrieves restore context for a specific conversation.
 func (cb *ContextBuilder) GetRestoreContextByID(conversationID string) (*RestoreContext, error) {
-	// Find the conversation file
-	pattern := filepath.Join(cb.amDir, fmt.Sprintf("llm-conv-*-%s.json", conversationID))
-	files, err := filepath.Glob(pattern)
-	if err != nil {
-		return nil, err
+	// Files are named after their conversation; read those before the rest
+	files := conversationFiles(cb.amDir)
+	named := func(file string) bool {
+		file = strings.TrimSuffix(file, ".gz")
+		return strings.HasSuffix(file, "-"+conversationID+".json") ||
+			strings.HasSuffix(file, "-"+conversationShortID(conversationID)+".json")
 	}
+	sort.SliceStable(files, func(i, j int) bool { return named(files[i]) && !named(files[j]) })
 
-	// If not found with suffix, try loading all and matching
-	if len(files) == 0 {
-		allPattern := filepath.Join(cb.amDir, "llm-conv-*.json")
-		allFiles, _ := filepath.Glob(allPattern)
-		for _, f := range allFiles {
-			conv, err := cb.loadConversation(f)
-			if err != nil {
-				continue
-			}
-			if conv.ConversationID == conversationID {
-				return cb.BuildRestoreContext(conv), nil
-			}
+	for _, f := range files {
+		conv, err := cb.loadConversation(f)
+		if err != nil {
+			continue
+		}
+		if conv.ConversationID == conversationID {
+			return cb.BuildRestoreContext(conv), nil
 		}
-		return nil, fmt.Errorf("conversation not found: %s", conversationID)
-	}
-
-	conv, err := cb.loadConversation(files[0])
-	if err != nil {
-		return nil, err
 	}
-
-	return cb.BuildRestoreContext(conv), nil
+	return nil, fmt.Errorf("conversation not found: %s", conversationID)
 }
 
-// loadConversation loads a conversation from a JSON file.
+// loadConversation loads a conversation from a JSON file and its journal.
 func (cb *ContextBuilder) loadConversation(path string) (*LLMConversation, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-
-	var conv LLMConversation
-	if err := json.Unmarshal(data, &conv); err != nil {
-		return nil, err
-	}
-
-	return &conv, nil
+	return readConversationFile(path)
 }
 
 // MarkAsRestored marks a conversation as restored (complete).
@@ -333,18 +313,27 @@ func (cb *ContextBuilder) MarkAsRestored(conversationID string) error {
 			session.Conversation.Complete = true
 			session.Conversation.EndTime = time.Now()
 
-			data, err := json.MarshalIndent(session.Conversation, "", "  ")
-			if err != nil {
-				return err
-			}
-
-			return os.WriteFile(session.FilePath, data, 0644)
+			// Rewrites the full file so a leftover journal can't reopen the session
+			return compactConversation(session.FilePath, session.Conversation)
 		}
 	}
 
 	return fmt.Errorf("conversation not found: %s", conversationID)
 }
 
+// LinkRestoredConversation records that newID was started from oldID's restore
+// context, linking the two conversations in both directions.
+func (cb *ContextBuilder) LinkRestoredConversation(oldID, newID string) error {
+	if err := updateConversation(cb.amDir, oldID, func(conv *LLMConversation) {
+		addConversationLink(conv, ConversationLink{ConversationID: newID, Relation: LinkRestoredAs})
+	}); err != nil {
+		return err
+	}
+	return updateConversation(cb.amDir, newID, func(conv *LLMConversation) {
+		addConversationLink(conv, ConversationLink{ConversationID: oldID, Relation: LinkRestoredFrom})
+	})
+}
+
 // truncate truncates a string to maxLen with ellipsis.
 func truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
@@ -231,6 +231,41 @@ func TestContextBuilder_MarkAsRestored(t *testing.T) {
 	}
 }
 
+func TestContextBuilder_LinkRestoredConversation(t *testing.T) {
+	amDir := t.TempDir()
+	for _, id := range []string{"conv-old", "conv-new"} {
+		storeConversation(amDir, &LLMConversation{
+			ConversationID: id,
+			TabID:          "tab-restore",
+			Provider:       "claude",
+			StartTime:      time.Now(),
+			Turns:          []ConversationTurn{{Role: "user", Content: "hello", Timestamp: time.Now()}},
+		})
+	}
+
+	cb := NewContextBuilder(amDir)
+	if err := cb.LinkRestoredConversation("conv-old", "conv-new"); err != nil {
+		t.Fatalf("LinkRestoredConversation failed: %v", err)
+	}
+	// Linking twice must not duplicate the links
+	if err := cb.LinkRestoredConversation("conv-old", "conv-new"); err != nil {
+		t.Fatal(err)
+	}
+
+	old, _ := FindConversation(amDir, "conv-old")
+	if len(old.Links) != 1 || linkedConversation(old, LinkRestoredAs) != "conv-new" {
+		t.Errorf("Old conversation links = %+v", old.Links)
+	}
+	restored, _ := FindConversation(amDir, "conv-new")
+	if len(restored.Links) != 1 || linkedConversation(restored, LinkRestoredFrom) != "conv-old" {
+		t.Errorf("New conversation links = %+v", restored.Links)
+	}
+
+	if err := cb.LinkRestoredConversation("conv-missing", "conv-new"); err == nil {
+		t.Error("Expected error for unknown conversation")
+	}
+}
+
 func TestTruncate(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -0,0 +1,364 @@
+// Export of logged conversations as fine-tuning datasets.
+
+package am
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Dataset formats supported by ExportDataset.
+const (
+	DatasetFormatChat   = "chat"   // {"messages": [...]} per conversation (OpenAI/Ollama)
+	DatasetFormatAlpaca = "alpaca" // {"instruction", "input", "output"} per exchange
+)
+
+// DefaultValidationSplit is the fraction of examples held out for validation.
+const DefaultValidationSplit = 0.1
+
+// DatasetOptions selects which conversations are exported and how.
+type DatasetOptions struct {
+	Format          string    `json:"format"`
+	Providers       []string  `json:"providers,omitempty"`
+	Project         string    `json:"project,omitempty"`
+	MinConfidence   float64   `json:"minConfidence,omitempty"`
+	Since           time.Time `json:"since,omitempty"`
+	Until           time.Time `json:"until,omitempty"`
+	ValidationSplit float64   `json:"validationSplit"`
+	NoRedact        bool      `json:"noRedact,omitempty"`
+}
+
+// DatasetManifest describes an exported dataset. It is written next to the data files.
+type DatasetManifest struct {
+	Format                string         `json:"format"`
+	CreatedAt             time.Time      `json:"createdAt"`
+	Options               DatasetOptions `json:"options"`
+	ConversationsScanned  int            `json:"conversationsScanned"`
+	ConversationsExported int            `json:"conversationsExported"`
+	ConversationsSkipped  map[string]int `json:"conversationsSkipped"`
+	ExchangesDropped      int            `json:"exchangesDropped"`
+	Examples              int            `json:"examples"`
+	Duplicates            int            `json:"duplicates"`
+	TrainExamples         int            `json:"trainExamples"`
+	ValidationExamples    int            `json:"validationExamples"`
+	Redactions            int            `json:"redactions"`
+	Providers             map[string]int `json:"providers"`
+	Files                 []string       `json:"files"`
+}
+
+// ChatMessage is one message in a chat-format example.
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatExample is one line of a chat-format dataset.
+type ChatExample struct {
+	Messages []ChatMessage `json:"messages"`
+}
+
+// AlpacaExample is one line of an Alpaca-format dataset.
+type AlpacaExample struct {
+	Instruction string `json:"instruction"`
+	Input       string `json:"input"`
+	Output      string `json:"output"`
+}
+
+// exchange is a user prompt and the assistant reply that followed it.
+type exchange struct {
+	prompt     string
+	reply      string
+	confidence float64
+}
+
+// secretPatterns match credentials that must never end up in a training set.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}`),
+	regexp.MustCompile(`\b(?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36}\b`),
+	regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}`),
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[A-Z0-9]{16}\b`),
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+	regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/-]{20,}=*`),
+	regexp.MustCompile(`(?i)\b([A-Z0-9_]*(?:api[_-]?key|secret|token|passw(?:or)?d))(\s*[:=]\s*)["']?[^\s"'\[]{6,}["']?`),
+}
+
+// RedactSecrets replaces credentials in text with a placeholder and reports how many were found.
+func RedactSecrets(text string) (string, int) {
+	count := 0
+	for i, re := range secretPatterns {
+		text = re.ReplaceAllStringFunc(text, func(match string) string {
+			count++
+			if i == len(secretPatterns)-1 {
+				// Keep the variable name so the example still reads naturally
+				sub := re.FindStringSubmatch(match)
+				return sub[1] + sub[2] + "[REDACTED]"
+			}
+			return "[REDACTED]"
+		})
+	}
+	return text, count
+}
+
+// ExportDataset writes completed conversations from amDir to outDir as train.jsonl,
+// valid.jsonl and manifest.json. Examples are deduplicated, secrets are redacted, and
+// the train/validation split is derived from each example's content so re-exports are stable.
+func ExportDataset(amDir, outDir string, opts DatasetOptions) (*DatasetManifest, error) {
+	if opts.Format == "" {
+		opts.Format = DatasetFormatChat
+	}
+	if opts.Format != DatasetFormatChat && opts.Format != DatasetFormatAlpaca {
+		return nil, fmt.Errorf("unsupported dataset format: %s", opts.Format)
+	}
+	if opts.ValidationSplit < 0 || opts.ValidationSplit >= 1 {
+		return nil, fmt.Errorf("validation split must be in [0, 1)")
+	}
+
+	conversations, err := GetAllConversations(amDir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(conversations, func(i, j int) bool {
+		return conversations[i].StartTime.Before(conversations[j].StartTime)
+	})
+
+	manifest := &DatasetManifest{
+		Format:               opts.Format,
+		CreatedAt:            time.Now(),
+		Options:              opts,
+		ConversationsScanned: len(conversations),
+		ConversationsSkipped: make(map[string]int),
+		Providers:            make(map[string]int),
+	}
+
+	seen := make(map[string]bool)
+	var train, valid [][]byte
+
+	for _, conv := range conversations {
+		if reason := skipReason(conv, opts); reason != "" {
+			manifest.ConversationsSkipped[reason]++
+			continue
+		}
+
+		exchanges := conversationExchanges(conv)
+		kept := exchanges[:0]
+		for _, ex := range exchanges {
+			if ex.confidence < opts.MinConfidence {
+				manifest.ExchangesDropped++
+				continue
+			}
+			if !opts.NoRedact {
+				var n, m int
+				ex.prompt, n = RedactSecrets(ex.prompt)
+				ex.reply, m = RedactSecrets(ex.reply)
+				manifest.Redactions += n + m
+			}
+			kept = append(kept, ex)
+		}
+		if len(kept) == 0 {
+			manifest.ConversationsSkipped["no usable exchanges"]++
+			continue
+		}
+
+		lines, err := encodeExamples(opts.Format, kept)
+		if err != nil {
+			return nil, err
+		}
+
+		exported := false
+		for _, line := range lines {
+			sum := sha256.Sum256(line)
+			key := hex.EncodeToString(sum[:])
+			if seen[key] {
+				manifest.Duplicates++
+				continue
+			}
+			seen[key] = true
+			exported = true
+
+			if isValidationExample(sum, opts.ValidationSplit) {
+				valid = append(valid, line)
+			} else {
+				train = append(train, line)
+			}
+		}
+		if exported {
+			manifest.ConversationsExported++
+			manifest.Providers[conv.Provider]++
+		}
+	}
+
+	manifest.TrainExamples = len(train)
+	manifest.ValidationExamples = len(valid)
+	manifest.Examples = len(train) + len(valid)
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create dataset directory: %w", err)
+	}
+	if err := writeJSONLines(filepath.Join(outDir, "train.jsonl"), train); err != nil {
+		return nil, err
+	}
+	manifest.Files = append(manifest.Files, "train.jsonl")
+	if opts.ValidationSplit > 0 {
+		if err := writeJSONLines(filepath.Join(outDir, "valid.jsonl"), valid); err != nil {
+			return nil, err
+		}
+		manifest.Files = append(manifest.Files, "valid.jsonl")
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(outDir, "manifest.json"), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	log.Printf("[AM Dataset] Exported %d examples (%d train, %d valid) from %d/%d conversations to %s",
+		manifest.Examples, manifest.TrainExamples, manifest.ValidationExamples,
+		manifest.ConversationsExported, manifest.ConversationsScanned, outDir)
+	return manifest, nil
+}
+
+// skipReason returns why conv is excluded by opts, or "" if it should be exported.
+func skipReason(conv *LLMConversation, opts DatasetOptions) string {
+	if !conv.Complete {
+		return "incomplete"
+	}
+	if len(opts.Providers) > 0 {
+		match := false
+		for _, p := range opts.Providers {
+			if strings.EqualFold(p, conv.Provider) {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return "provider"
+		}
+	}
+	if opts.Project != "" && !strings.EqualFold(opts.Project, conv.GetProjectName()) {
+		return "project"
+	}
+	if !opts.Since.IsZero() && conv.StartTime.Before(opts.Since) {
+		return "date range"
+	}
+	if !opts.Until.IsZero() && conv.StartTime.After(opts.Until) {
+		return "date range"
+	}
+	return ""
+}
+
+// conversationExchanges pairs each user prompt with the assistant reply that follows it.
+// Consecutive turns from the same role are merged, since output is often flushed in pieces.
+// An exchange's confidence is the lowest ParseConfidence of its reply turns; replies
+// captured without a confidence score count as 0.
+func conversationExchanges(conv *LLMConversation) []exchange {
+	var exchanges []exchange
+	var current *exchange
+
+	for _, turn := range conv.Turns {
+		content := strings.TrimSpace(turn.Content)
+		if content == "" {
+			continue
+		}
+
+		switch turn.Role {
+		case "user":
+			if current != nil && current.reply != "" {
+				exchanges = append(exchanges, *current)
+				current = nil
+			}
+			if current == nil {
+				current = &exchange{prompt: content, confidence: 1}
+			} else {
+				current.prompt += "\n" + content
+			}
+		case "assistant":
+			if current == nil {
+				continue // Output before the first prompt (banners, restored screens)
+			}
+			if current.reply == "" {
+				current.reply = content
+			} else {
+				current.reply += "\n" + content
+			}
+			if turn.ParseConfidence < current.confidence {
+				current.confidence = turn.ParseConfidence
+			}
+		}
+	}
+	if current != nil && current.reply != "" {
+		exchanges = append(exchanges, *current)
+	}
+	return exchanges
+}
+
+// encodeExamples renders exchanges as JSON lines in the given format.
+// Chat produces one example per conversation; Alpaca one per exchange.
+func encodeExamples(format string, exchanges []exchange) ([][]byte, error) {
+	var lines [][]byte
+
+	if format == DatasetFormatChat {
+		example := ChatExample{}
+		for _, ex := range exchanges {
+			example.Messages = append(example.Messages,
+				ChatMessage{Role: "user", Content: ex.prompt},
+				ChatMessage{Role: "assistant", Content: ex.reply})
+		}
+		line, err := json.Marshal(example)
+		if err != nil {
+			return nil, err
+		}
+		return append(lines, line), nil
+	}
+
+	for _, ex := range exchanges {
+		line, err := json.Marshal(AlpacaExample{Instruction: ex.prompt, Output: ex.reply})
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// isValidationExample assigns an example to the validation split based on its hash.
+func isValidationExample(sum [32]byte, split float64) bool {
+	if split <= 0 {
+		return false
+	}
+	bucket := binary.BigEndian.Uint64(sum[:8]) % 10000
+	return float64(bucket) < split*10000
+}
+
+// writeJSONLines writes one JSON document per line.
+func writeJSONLines(path string, lines [][]byte) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Base(path), err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for _, line := range lines {
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return f.Close()
+}
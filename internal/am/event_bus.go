@@ -1,17 +1,40 @@
-// Package am provides the Artificial Memory multi-layer capture system.
+// The Artificial Memory multi-layer capture system.
+
 package am
 
 import (
+	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// Topic identifies what a LayerEvent reports.
+type Topic string
+
+// Event topics published by the capture pipeline.
+const (
+	TopicLLMStart        Topic = "LLM_START"
+	TopicLLMEnd          Topic = "LLM_END"
+	TopicUserInput       Topic = "USER_INPUT"
+	TopicAssistantOutput Topic = "ASSISTANT_OUTPUT"
+	TopicParseFailure    Topic = "PARSE_FAILURE"
+	TopicLowConfidence   Topic = "LOW_CONFIDENCE"
+	TopicLoopDetected    Topic = "LOOP_DETECTED"
+)
+
+const (
+	defaultSubscriberBuffer = 256
+	eventHistorySize        = 1024 // Recent events kept in memory for replay
+)
+
 // EventBus is the global event bus instance for inter-layer communication.
 var EventBus = NewEventBusInstance()
 
 // LayerEvent represents an event from any AM layer.
 type LayerEvent struct {
-	Type      string                 `json:"type"`
+	Seq       uint64                 `json:"seq"` // Publish order; continues across restarts when journaled
+	Type      Topic                  `json:"type"`
 	Layer     int                    `json:"layer"`
 	TabID     string                 `json:"tabId,omitempty"`
 	ConvID    string                 `json:"convId,omitempty"`
@@ -20,39 +43,290 @@ type LayerEvent struct {
 	Metadata  map[string]interface{} `json:"metadata,omitempty"`
 }
 
-// EventBusInstance manages event subscriptions and publishing.
+// Subscription delivers events to one handler from its own goroutine, in publish
+// order, through a bounded queue.
+type Subscription struct {
+	bus     *EventBusInstance
+	handler func(*LayerEvent)
+	topics  map[Topic]bool // nil for every topic
+	queue   chan *LayerEvent
+	done    chan struct{}
+	once    sync.Once
+	dropped atomic.Uint64
+}
+
+// Unsubscribe stops delivery. Events still queued are discarded.
+func (s *Subscription) Unsubscribe() {
+	s.once.Do(func() {
+		s.bus.mutex.Lock()
+		delete(s.bus.subscribers, s)
+		s.bus.mutex.Unlock()
+		close(s.done)
+	})
+}
+
+// Dropped returns how many events were discarded because the handler fell behind.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+func (s *Subscription) wants(topic Topic) bool {
+	return s.topics == nil || s.topics[topic]
+}
+
+func (s *Subscription) run() {
+	for {
+		select {
+		case event := <-s.queue:
+			s.handler(event)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// EventBusInstance manages event subscriptions and publishing. Events get a
+// sequence number, are kept in a bounded history and, when a journal is
+// enabled, appended to it in the background. Publish never blocks: callers hold
+// their own locks while publishing.
 type EventBusInstance struct {
-	subscribers []func(*LayerEvent)
-	mutex       sync.RWMutex
+	mutex       sync.Mutex
+	seq         uint64
+	subscribers map[*Subscription]struct{}
+	history     []*LayerEvent // Oldest first, at most eventHistorySize
+	journal     *journalWriter
 }
 
 // NewEventBusInstance creates a new event bus.
 func NewEventBusInstance() *EventBusInstance {
 	return &EventBusInstance{
-		subscribers: make([]func(*LayerEvent), 0),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe calls handler for every event on the given topics, or on all topics
+// when none are given.
+func (eb *EventBusInstance) Subscribe(handler func(*LayerEvent), topics ...Topic) *Subscription {
+	return eb.SubscribeBuffered(defaultSubscriberBuffer, handler, topics...)
+}
+
+// SubscribeBuffered is Subscribe with a queue of the given size. Events published
+// while the queue is full are dropped.
+func (eb *EventBusInstance) SubscribeBuffered(buffer int, handler func(*LayerEvent), topics ...Topic) *Subscription {
+	if buffer <= 0 {
+		buffer = defaultSubscriberBuffer
+	}
+	s := &Subscription{
+		bus:     eb,
+		handler: handler,
+		queue:   make(chan *LayerEvent, buffer),
+		done:    make(chan struct{}),
+	}
+	if len(topics) > 0 {
+		s.topics = make(map[Topic]bool, len(topics))
+		for _, t := range topics {
+			s.topics[t] = true
+		}
 	}
+
+	eb.mutex.Lock()
+	eb.subscribers[s] = struct{}{}
+	eb.mutex.Unlock()
+
+	go s.run()
+	return s
 }
 
-// Subscribe adds a handler for layer events.
-func (eb *EventBusInstance) Subscribe(handler func(*LayerEvent)) {
+// Publish sends an event to all subscribers of its topic.
+func (eb *EventBusInstance) Publish(event *LayerEvent) {
 	eb.mutex.Lock()
 	defer eb.mutex.Unlock()
-	eb.subscribers = append(eb.subscribers, handler)
+
+	eb.seq++
+	event.Seq = eb.seq
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	eb.history = append(eb.history, event)
+	if len(eb.history) > eventHistorySize {
+		eb.history = eb.history[len(eb.history)-eventHistorySize:]
+	}
+	if eb.journal != nil {
+		eb.journal.enqueue(event)
+	}
+
+	// Queued under the lock so every subscriber sees publish order
+	for s := range eb.subscribers {
+		if !s.wants(event.Type) {
+			continue
+		}
+		select {
+		case s.queue <- event:
+		default:
+			if s.dropped.Add(1) == 1 {
+				log.Printf("[AM Events] ⚠️ Subscriber fell behind; dropping %s events", event.Type)
+			}
+		}
+	}
 }
 
-// Publish sends an event to all subscribers.
-func (eb *EventBusInstance) Publish(event *LayerEvent) {
-	eb.mutex.RLock()
-	defer eb.mutex.RUnlock()
+// Cursor returns the sequence number of the last published event.
+func (eb *EventBusInstance) Cursor() uint64 {
+	eb.mutex.Lock()
+	defer eb.mutex.Unlock()
+	return eb.seq
+}
+
+// Replay returns up to limit events published after cursor, on the given topics
+// (all when none), oldest first. Events come from the journal when one is
+// enabled, otherwise from the in-memory history.
+func (eb *EventBusInstance) Replay(cursor uint64, limit int, topics ...Topic) ([]*LayerEvent, error) {
+	filter := func(e *LayerEvent) bool {
+		if len(topics) == 0 {
+			return true
+		}
+		for _, t := range topics {
+			if e.Type == t {
+				return true
+			}
+		}
+		return false
+	}
+
+	eb.mutex.Lock()
+	journal := eb.journal
+	history := append([]*LayerEvent(nil), eb.history...)
+	eb.mutex.Unlock()
 
-	for _, handler := range eb.subscribers {
-		go handler(event)
+	if journal != nil {
+		journal.flush()
+		return journal.journal.Read(cursor, limit, filter)
+	}
+	var events []*LayerEvent
+	for _, e := range history {
+		if e.Seq > cursor && filter(e) {
+			events = append(events, e)
+			if limit > 0 && len(events) == limit {
+				break
+			}
+		}
+	}
+	return events, nil
+}
+
+// EnableJournal appends every event to j from now on and continues its sequence.
+func (eb *EventBusInstance) EnableJournal(j *EventJournal) {
+	eb.mutex.Lock()
+	previous := eb.journal
+	if last := j.LastSeq(); last > eb.seq {
+		eb.seq = last
+	}
+	eb.journal = newJournalWriter(j)
+	eb.mutex.Unlock()
+
+	if previous != nil {
+		previous.close()
+	}
+}
+
+// DisableJournal stops journaling, writes events still queued and closes the journal.
+func (eb *EventBusInstance) DisableJournal() {
+	eb.mutex.Lock()
+	previous := eb.journal
+	eb.journal = nil
+	eb.mutex.Unlock()
+
+	if previous != nil {
+		previous.close()
 	}
 }
 
 // Reset clears all subscribers (for testing).
 func (eb *EventBusInstance) Reset() {
 	eb.mutex.Lock()
-	defer eb.mutex.Unlock()
-	eb.subscribers = make([]func(*LayerEvent), 0)
+	subs := make([]*Subscription, 0, len(eb.subscribers))
+	for s := range eb.subscribers {
+		subs = append(subs, s)
+	}
+	eb.mutex.Unlock()
+	for _, s := range subs {
+		s.Unsubscribe()
+	}
+}
+
+// journalWriter appends events to an EventJournal from its own goroutine, in the
+// order they were queued, so publishers never wait on the disk.
+type journalWriter struct {
+	journal *EventJournal
+
+	mu      sync.Mutex
+	cond    sync.Cond
+	queue   []*LayerEvent
+	writing bool // A batch taken from queue is being written
+	closed  bool
+	done    chan struct{}
+}
+
+func newJournalWriter(j *EventJournal) *journalWriter {
+	w := &journalWriter{journal: j, done: make(chan struct{})}
+	w.cond.L = &w.mu
+	go w.run()
+	return w
+}
+
+func (w *journalWriter) enqueue(event *LayerEvent) {
+	w.mu.Lock()
+	w.queue = append(w.queue, event)
+	w.mu.Unlock()
+	w.cond.Broadcast()
+}
+
+func (w *journalWriter) run() {
+	defer close(w.done)
+	for {
+		w.mu.Lock()
+		for len(w.queue) == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if len(w.queue) == 0 {
+			w.mu.Unlock()
+			return
+		}
+		batch := w.queue
+		w.queue = nil
+		w.writing = true
+		w.mu.Unlock()
+
+		for _, event := range batch {
+			if err := w.journal.append(event); err != nil {
+				log.Printf("[AM Events] ⚠️ Failed to journal event %d: %v", event.Seq, err)
+			}
+		}
+
+		w.mu.Lock()
+		w.writing = false
+		w.mu.Unlock()
+		w.cond.Broadcast()
+	}
+}
+
+// flush waits until every queued event has been written.
+func (w *journalWriter) flush() {
+	w.mu.Lock()
+	for len(w.queue) > 0 || w.writing {
+		w.cond.Wait()
+	}
+	w.mu.Unlock()
+}
+
+// close writes the events still queued and closes the journal.
+func (w *journalWriter) close() {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+	w.cond.Broadcast()
+
+	<-w.done
+	w.journal.Close()
 }
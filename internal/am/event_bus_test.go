@@ -0,0 +1,124 @@
+package am
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEventBus_OrderedTopicDelivery(t *testing.T) {
+	bus := NewEventBusInstance()
+	defer bus.Reset()
+
+	var mu sync.Mutex
+	var got []uint64
+	done := make(chan struct{})
+	bus.Subscribe(func(e *LayerEvent) {
+		mu.Lock()
+		got = append(got, e.Seq)
+		if len(got) == 50 {
+			close(done)
+		}
+		mu.Unlock()
+	}, TopicLLMEnd)
+
+	for i := 0; i < 100; i++ {
+		topic := TopicLLMStart
+		if i%2 == 1 {
+			topic = TopicLLMEnd
+		}
+		bus.Publish(&LayerEvent{Type: topic})
+	}
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for events")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for i, seq := range got {
+		if seq != uint64(2*i+2) {
+			t.Fatalf("Event %d has seq %d; want ordered LLM_END events only: %v", i, seq, got)
+		}
+	}
+}
+
+func TestEventBus_UnsubscribeAndDropWhenFull(t *testing.T) {
+	bus := NewEventBusInstance()
+	defer bus.Reset()
+
+	started := make(chan struct{}, 1)
+	block := make(chan struct{})
+	slow := bus.SubscribeBuffered(1, func(*LayerEvent) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-block
+	})
+	calls := 0
+	var mu sync.Mutex
+	gone := bus.Subscribe(func(*LayerEvent) { mu.Lock(); calls++; mu.Unlock() })
+	gone.Unsubscribe()
+
+	// One event is being handled, one fills the queue, the rest are dropped
+	bus.Publish(&LayerEvent{Type: TopicUserInput})
+	<-started
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		bus.Publish(&LayerEvent{Type: TopicUserInput})
+	}
+	elapsed := time.Since(start)
+	close(block)
+	if slow.Dropped() != 2 {
+		t.Errorf("Dropped = %d, want 2", slow.Dropped())
+	}
+	if elapsed > 50*time.Millisecond {
+		t.Errorf("Publish waited for the slow subscriber (%v)", elapsed)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 0 {
+		t.Errorf("Unsubscribed handler called %d times", calls)
+	}
+}
+
+func TestEventBus_JournalReplay(t *testing.T) {
+	dir := t.TempDir()
+	journal, err := OpenEventJournal(dir, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus := NewEventBusInstance()
+	bus.EnableJournal(journal)
+	for i := 0; i < 5; i++ {
+		bus.Publish(&LayerEvent{Type: TopicLLMStart, ConvID: "conv-1"})
+		bus.Publish(&LayerEvent{Type: TopicLLMEnd, ConvID: "conv-1"})
+	}
+	bus.DisableJournal()
+
+	// A restarted bus continues the sequence and replays from disk
+	journal, err = OpenEventJournal(dir, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus = NewEventBusInstance()
+	bus.EnableJournal(journal)
+	defer bus.DisableJournal()
+	if bus.Cursor() != 10 {
+		t.Fatalf("Cursor = %d after restart, want 10", bus.Cursor())
+	}
+	bus.Publish(&LayerEvent{Type: TopicLLMEnd, ConvID: "conv-2"})
+
+	events, err := bus.Replay(4, 0, TopicLLMEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 4 || events[0].Seq != 6 || events[3].Seq != 11 || events[3].ConvID != "conv-2" {
+		t.Errorf("Unexpected replay %+v", events)
+	}
+	if events, _ := bus.Replay(0, 3); len(events) != 3 || events[2].Seq != 3 {
+		t.Errorf("Limited replay = %+v", events)
+	}
+}
@@ -0,0 +1,225 @@
+// A JSONL journal of AM events for replay after the fact.
+
+package am
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/storage"
+)
+
+const (
+	eventJournalPrefix           = "events-"
+	eventJournalExt              = ".jsonl"
+	defaultEventJournalRetention = 7 // Days of journal files kept
+)
+
+// EventJournalConfig controls the event journal. It is read from ~/.forge/eventjournal.json.
+type EventJournalConfig struct {
+	Enabled       bool `json:"enabled"`
+	RetentionDays int  `json:"retentionDays,omitempty"` // Daily files older than this are deleted
+}
+
+// LoadEventJournalConfig reads ~/.forge/eventjournal.json. The journal is off
+// when the file is missing or invalid.
+func LoadEventJournalConfig() *EventJournalConfig {
+	c := &EventJournalConfig{}
+	data, err := os.ReadFile(storage.GetEventJournalConfigPath())
+	if err == nil {
+		if err := json.Unmarshal(data, c); err != nil {
+			log.Printf("[AM Events] ⚠️ Invalid %s, journal disabled: %v", storage.GetEventJournalConfigPath(), err)
+			c = &EventJournalConfig{}
+		}
+	}
+	if c.RetentionDays <= 0 {
+		c.RetentionDays = defaultEventJournalRetention
+	}
+	return c
+}
+
+// EventJournal appends events to one JSONL file per day.
+type EventJournal struct {
+	dir       string
+	retention time.Duration
+
+	mu      sync.Mutex
+	file    *os.File
+	day     string
+	lastSeq uint64
+}
+
+// OpenEventJournal opens the journal in dir, deleting files older than
+// retentionDays and picking up the sequence where the newest file ends.
+func OpenEventJournal(dir string, retentionDays int) (*EventJournal, error) {
+	if retentionDays <= 0 {
+		retentionDays = defaultEventJournalRetention
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create event journal directory: %w", err)
+	}
+	j := &EventJournal{dir: dir, retention: time.Duration(retentionDays) * 24 * time.Hour}
+	j.prune(time.Now())
+
+	files, err := j.files()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(files) - 1; i >= 0 && j.lastSeq == 0; i-- {
+		err := readJournalFile(files[i], func(e *LayerEvent) bool {
+			if e.Seq > j.lastSeq {
+				j.lastSeq = e.Seq
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return j, nil
+}
+
+// LastSeq returns the sequence number of the newest journaled event.
+func (j *EventJournal) LastSeq() uint64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.lastSeq
+}
+
+// append writes one event, switching to a new file when the day changes.
+func (j *EventJournal) append(event *LayerEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	day := now.Format("2006-01-02")
+	if j.file == nil || day != j.day {
+		if j.file != nil {
+			j.file.Close()
+			j.file = nil
+			j.prune(now)
+		}
+		f, err := os.OpenFile(filepath.Join(j.dir, eventJournalPrefix+day+eventJournalExt),
+			os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		j.file, j.day = f, day
+	}
+
+	if _, err := j.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	j.lastSeq = event.Seq
+	return nil
+}
+
+// Read returns up to limit journaled events after cursor that match filter,
+// oldest first. A limit of zero or less returns them all.
+func (j *EventJournal) Read(cursor uint64, limit int, filter func(*LayerEvent) bool) ([]*LayerEvent, error) {
+	files, err := j.files()
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*LayerEvent
+	for _, path := range files {
+		err := readJournalFile(path, func(e *LayerEvent) bool {
+			if e.Seq > cursor && (filter == nil || filter(e)) {
+				events = append(events, e)
+			}
+			return limit <= 0 || len(events) < limit
+		})
+		if err != nil {
+			return nil, err
+		}
+		if limit > 0 && len(events) >= limit {
+			break
+		}
+	}
+	return events, nil
+}
+
+// Close closes the current journal file.
+func (j *EventJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+// files lists the journal files, oldest first.
+func (j *EventJournal) files() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(j.dir, eventJournalPrefix+"*"+eventJournalExt))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files) // Dated names sort chronologically
+	return files, nil
+}
+
+func (j *EventJournal) prune(now time.Time) {
+	files, err := j.files()
+	if err != nil {
+		return
+	}
+	cutoff := now.Add(-j.retention).Format("2006-01-02")
+	for _, path := range files {
+		day := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), eventJournalPrefix), eventJournalExt)
+		if day < cutoff {
+			if err := os.Remove(path); err == nil {
+				log.Printf("[AM Events] Pruned journal %s", filepath.Base(path))
+			}
+		}
+	}
+}
+
+// readJournalFile calls fn for each event in a journal file until fn returns
+// false. Lines that do not parse, such as a write cut short by a crash, are skipped.
+func readJournalFile(path string, fn func(*LayerEvent) bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			var e LayerEvent
+			if json.Unmarshal(line, &e) == nil && e.Seq > 0 {
+				if !fn(&e) {
+					return nil
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
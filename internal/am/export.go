@@ -0,0 +1,446 @@
+// Markdown, HTML and JSONL exports of LLM conversations.
+
+package am
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export formats.
+const (
+	ExportMarkdown = "md"
+	ExportHTML     = "html"
+	ExportJSONL    = "jsonl"
+)
+
+// ExportOptions controls how conversations are rendered.
+type ExportOptions struct {
+	Format           string
+	IncludeSnapshots bool // Raw screen snapshots are large and usually noise in docs
+}
+
+// ExportFilter selects conversations for a bulk export. Zero values match everything.
+type ExportFilter struct {
+	Project  string
+	Provider string
+	Since    time.Time
+	Until    time.Time
+}
+
+// exportRecord is one line of a JSONL export.
+type exportRecord struct {
+	Type             string                `json:"type"` // conversation, turn, snapshot
+	ConversationID   string                `json:"conversationId"`
+	Provider         string                `json:"provider,omitempty"`
+	Project          string                `json:"project,omitempty"`
+	CommandType      string                `json:"commandType,omitempty"`
+	StartTime        *time.Time            `json:"startTime,omitempty"`
+	EndTime          *time.Time            `json:"endTime,omitempty"`
+	Metadata         *ConversationMetadata `json:"metadata,omitempty"`
+	Index            int                   `json:"index,omitempty"`
+	Role             string                `json:"role,omitempty"`
+	Content          string                `json:"content,omitempty"`
+	Timestamp        *time.Time            `json:"timestamp,omitempty"`
+	SequenceNumber   int                   `json:"sequenceNumber,omitempty"`
+	CleanedContent   string                `json:"cleanedContent,omitempty"`
+	DiffFromPrevious string                `json:"diffFromPrevious,omitempty"`
+}
+
+// ValidExportFormat reports whether format is one ExportConversation understands.
+func ValidExportFormat(format string) bool {
+	switch format {
+	case ExportMarkdown, ExportHTML, ExportJSONL:
+		return true
+	}
+	return false
+}
+
+// ExportContentType returns the MIME type for an export format.
+func ExportContentType(format string) string {
+	switch format {
+	case ExportHTML:
+		return "text/html; charset=utf-8"
+	case ExportJSONL:
+		return "application/x-ndjson"
+	default:
+		return "text/markdown; charset=utf-8"
+	}
+}
+
+// ExportFilename returns a descriptive file name for an exported conversation.
+func ExportFilename(conv *LLMConversation, format string) string {
+	return fmt.Sprintf("%s-%s-%s.%s", conv.GetProjectName(), conv.StartTime.Format("2006-01-02-1504"),
+		safeFileName(conv.ConversationID), format)
+}
+
+// ExportConversation renders one conversation to w.
+func ExportConversation(w io.Writer, conv *LLMConversation, opts ExportOptions) error {
+	switch opts.Format {
+	case ExportMarkdown:
+		return exportMarkdown(w, conv, opts)
+	case ExportHTML:
+		return exportHTML(w, conv, opts)
+	case ExportJSONL:
+		return exportJSONL(w, conv, opts)
+	}
+	return fmt.Errorf("unsupported export format %q (use md, html or jsonl)", opts.Format)
+}
+
+// ExportConversations writes a zip of every conversation in amDir matching filter,
+// one file per conversation, oldest first. It returns how many were exported.
+func ExportConversations(w io.Writer, amDir string, filter ExportFilter, opts ExportOptions) (int, error) {
+	if !ValidExportFormat(opts.Format) {
+		return 0, fmt.Errorf("unsupported export format %q (use md, html or jsonl)", opts.Format)
+	}
+
+	conversations, err := GetAllConversations(amDir)
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(conversations, func(i, j int) bool {
+		return conversations[i].StartTime.Before(conversations[j].StartTime)
+	})
+
+	zw := zip.NewWriter(w)
+	names := make(map[string]bool)
+	count := 0
+	for _, conv := range conversations {
+		if !filter.matches(conv) {
+			continue
+		}
+
+		name := ExportFilename(conv, opts.Format)
+		for i := 2; names[name]; i++ {
+			name = fmt.Sprintf("%s-%d.%s", strings.TrimSuffix(ExportFilename(conv, opts.Format), "."+opts.Format), i, opts.Format)
+		}
+		names[name] = true
+
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: conv.StartTime})
+		if err != nil {
+			return count, err
+		}
+		if err := ExportConversation(f, conv, opts); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, zw.Close()
+}
+
+func (f ExportFilter) matches(conv *LLMConversation) bool {
+	if f.Project != "" && !strings.EqualFold(conv.GetProjectName(), f.Project) {
+		return false
+	}
+	if f.Provider != "" && !strings.EqualFold(conv.Provider, f.Provider) {
+		return false
+	}
+	if !f.Since.IsZero() && conv.StartTime.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && conv.StartTime.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// exportDetails returns the header fields shown above the turns, in display order.
+func exportDetails(conv *LLMConversation) [][2]string {
+	details := [][2]string{
+		{"Conversation", conv.ConversationID},
+		{"Provider", conv.Provider},
+	}
+	if conv.CommandType != "" {
+		details = append(details, [2]string{"Command", conv.CommandType})
+	}
+	details = append(details, [2]string{"Started", formatExportTime(conv.StartTime)})
+	if !conv.EndTime.IsZero() {
+		details = append(details, [2]string{"Ended", formatExportTime(conv.EndTime)})
+	}
+	if conv.Metadata != nil {
+		if conv.Metadata.WorkingDirectory != "" {
+			details = append(details, [2]string{"Working directory", conv.Metadata.WorkingDirectory})
+		}
+		if conv.Metadata.GitBranch != "" {
+			details = append(details, [2]string{"Git branch", conv.Metadata.GitBranch})
+		}
+	}
+	return details
+}
+
+func exportMarkdown(w io.Writer, conv *LLMConversation, opts ExportOptions) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s conversation\n\n", exportTitle(conv))
+	for _, d := range exportDetails(conv) {
+		fmt.Fprintf(&b, "- **%s:** `%s`\n", d[0], d[1])
+	}
+
+	for _, turn := range conv.Turns {
+		fmt.Fprintf(&b, "\n## %s", roleLabel(turn.Role))
+		if !turn.Timestamp.IsZero() {
+			fmt.Fprintf(&b, " · %s", formatExportTime(turn.Timestamp))
+		}
+		b.WriteString("\n\n")
+		b.WriteString(closeFences(strings.TrimSpace(turn.Content)))
+		b.WriteString("\n")
+	}
+
+	if c := conv.Changes; c != nil {
+		fmt.Fprintf(&b, "\n## Changes\n\n%d file(s) changed: %d added, %d modified, %d deleted · +%d −%d lines\n",
+			len(c.Files), c.FilesAdded, c.FilesModified, c.FilesDeleted, c.LinesAdded, c.LinesDeleted)
+		if len(c.Files) > 0 {
+			b.WriteString("\n| File | Status | Added | Deleted |\n|---|---|---|---|\n")
+			for _, f := range c.Files {
+				added, deleted := strconv.Itoa(f.Added), strconv.Itoa(f.Deleted)
+				if f.Binary {
+					added, deleted = "binary", "binary"
+				}
+				fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", f.Path, f.Status, added, deleted)
+			}
+		}
+		if c.Diff != "" {
+			fence := fenceFor(c.Diff)
+			fmt.Fprintf(&b, "\n%sdiff\n%s\n%s\n", fence, strings.TrimRight(c.Diff, "\n"), fence)
+			if c.Truncated {
+				b.WriteString("\n_Diff truncated._\n")
+			}
+		}
+	}
+
+	if opts.IncludeSnapshots && len(conv.ScreenSnapshots) > 0 {
+		b.WriteString("\n## Screen snapshots\n")
+		for _, snap := range conv.ScreenSnapshots {
+			fence := fenceFor(snap.CleanedContent)
+			fmt.Fprintf(&b, "\n### Snapshot %d · %s\n\n%s\n%s\n%s\n",
+				snap.SequenceNumber, formatExportTime(snap.Timestamp), fence, snap.CleanedContent, fence)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func exportHTML(w io.Writer, conv *LLMConversation, opts ExportOptions) error {
+	var b strings.Builder
+	title := html.EscapeString(exportTitle(conv) + " conversation")
+	b.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n", title)
+	b.WriteString(`<style>
+body { font-family: -apple-system, "Segoe UI", sans-serif; max-width: 860px; margin: 2em auto; padding: 0 1em; color: #1f2328; }
+dl { display: grid; grid-template-columns: max-content 1fr; gap: .25em 1em; }
+dt { font-weight: 600; }
+.turn { border-left: 4px solid #d0d7de; padding: .25em 1em; margin: 1.5em 0; }
+.turn.user { border-color: #0969da; }
+.turn.assistant { border-color: #1a7f37; }
+.meta { color: #59636e; font-size: .85em; }
+.text { white-space: pre-wrap; }
+pre { background: #f6f8fa; padding: 1em; overflow-x: auto; }
+</style>
+</head>
+<body>
+`)
+	fmt.Fprintf(&b, "<h1>%s</h1>\n<dl>\n", title)
+	for _, d := range exportDetails(conv) {
+		fmt.Fprintf(&b, "<dt>%s</dt><dd><code>%s</code></dd>\n", html.EscapeString(d[0]), html.EscapeString(d[1]))
+	}
+	b.WriteString("</dl>\n")
+
+	for _, turn := range conv.Turns {
+		fmt.Fprintf(&b, "<section class=\"turn %s\">\n<h2>%s</h2>\n",
+			html.EscapeString(strings.ToLower(turn.Role)), html.EscapeString(roleLabel(turn.Role)))
+		if !turn.Timestamp.IsZero() {
+			fmt.Fprintf(&b, "<p class=\"meta\">%s</p>\n", html.EscapeString(formatExportTime(turn.Timestamp)))
+		}
+		writeHTMLContent(&b, strings.TrimSpace(turn.Content))
+		b.WriteString("</section>\n")
+	}
+
+	if opts.IncludeSnapshots && len(conv.ScreenSnapshots) > 0 {
+		b.WriteString("<h2>Screen snapshots</h2>\n")
+		for _, snap := range conv.ScreenSnapshots {
+			fmt.Fprintf(&b, "<h3>Snapshot %d · %s</h3>\n<pre>%s</pre>\n",
+				snap.SequenceNumber, html.EscapeString(formatExportTime(snap.Timestamp)), html.EscapeString(snap.CleanedContent))
+		}
+	}
+
+	b.WriteString("</body>\n</html>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeHTMLContent renders turn text, turning ``` fences into <pre><code> blocks.
+func writeHTMLContent(b *strings.Builder, content string) {
+	var text, code []string
+	inCode := false
+	lang := ""
+
+	flushText := func() {
+		if joined := strings.TrimSpace(strings.Join(text, "\n")); joined != "" {
+			fmt.Fprintf(b, "<div class=\"text\">%s</div>\n", html.EscapeString(joined))
+		}
+		text = nil
+	}
+	flushCode := func() {
+		class := ""
+		if lang != "" {
+			class = fmt.Sprintf(" class=\"language-%s\"", html.EscapeString(lang))
+		}
+		fmt.Fprintf(b, "<pre><code%s>%s</code></pre>\n", class, html.EscapeString(strings.Join(code, "\n")))
+		code = nil
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			if inCode {
+				flushCode()
+			} else {
+				flushText()
+				lang = strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))
+			}
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			code = append(code, line)
+		} else {
+			text = append(text, line)
+		}
+	}
+
+	if inCode {
+		flushCode()
+	}
+	flushText()
+}
+
+func exportJSONL(w io.Writer, conv *LLMConversation, opts ExportOptions) error {
+	enc := json.NewEncoder(w)
+
+	header := exportRecord{
+		Type:           "conversation",
+		ConversationID: conv.ConversationID,
+		Provider:       conv.Provider,
+		Project:        conv.GetProjectName(),
+		CommandType:    conv.CommandType,
+		StartTime:      timePtr(conv.StartTime),
+		EndTime:        timePtr(conv.EndTime),
+		Metadata:       conv.Metadata,
+	}
+	if err := enc.Encode(header); err != nil {
+		return err
+	}
+
+	for i, turn := range conv.Turns {
+		rec := exportRecord{
+			Type:           "turn",
+			ConversationID: conv.ConversationID,
+			Provider:       turn.Provider,
+			Index:          i,
+			Role:           turn.Role,
+			Content:        turn.Content,
+			Timestamp:      timePtr(turn.Timestamp),
+		}
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+
+	if opts.IncludeSnapshots {
+		for _, snap := range conv.ScreenSnapshots {
+			rec := exportRecord{
+				Type:             "snapshot",
+				ConversationID:   conv.ConversationID,
+				SequenceNumber:   snap.SequenceNumber,
+				Timestamp:        timePtr(snap.Timestamp),
+				CleanedContent:   snap.CleanedContent,
+				DiffFromPrevious: snap.DiffFromPrevious,
+			}
+			if err := enc.Encode(rec); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func exportTitle(conv *LLMConversation) string {
+	if conv.Provider == "" {
+		return "LLM"
+	}
+	return strings.ToUpper(conv.Provider[:1]) + conv.Provider[1:]
+}
+
+func roleLabel(role string) string {
+	switch strings.ToLower(role) {
+	case "user":
+		return "User"
+	case "assistant":
+		return "Assistant"
+	case "":
+		return "Unknown"
+	}
+	return strings.ToUpper(role[:1]) + role[1:]
+}
+
+func formatExportTime(t time.Time) string {
+	return t.Format("2006-01-02 15:04:05 MST")
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// closeFences appends a closing ``` when content leaves a code block open, so a
+// truncated capture doesn't swallow the rest of the document.
+func closeFences(content string) string {
+	open := false
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			open = !open
+		}
+	}
+	if open {
+		return content + "\n```"
+	}
+	return content
+}
+
+// fenceFor returns a backtick fence longer than any backtick run in content.
+func fenceFor(content string) string {
+	longest, run := 0, 0
+	for _, c := range content {
+		if c == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	if longest < 3 {
+		return "```"
+	}
+	return strings.Repeat("`", longest+1)
+}
+
+func safeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '-'
+	}, name)
+}
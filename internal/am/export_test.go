@@ -0,0 +1,158 @@
+package am
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func exportFixture() *LLMConversation {
+	start := time.Date(2025, 6, 2, 14, 30, 0, 0, time.UTC)
+	return &LLMConversation{
+		ConversationID: "conv-export-1",
+		Provider:       "claude",
+		StartTime:      start,
+		EndTime:        start.Add(5 * time.Minute),
+		Metadata:       &ConversationMetadata{WorkingDirectory: "/home/dev/forge", GitBranch: "feature/export"},
+		Turns: []ConversationTurn{
+			{Role: "user", Content: "Fix the <script> escaping", Timestamp: start},
+			{Role: "assistant", Content: "Use this:\n```go\nhtml.EscapeString(s)\n```\nThen test it.", Timestamp: start.Add(time.Minute)},
+			{Role: "assistant", Content: "Truncated:\n```bash\nnpm test", Timestamp: start.Add(2 * time.Minute)},
+		},
+		ScreenSnapshots: []ScreenSnapshot{{SequenceNumber: 1, Timestamp: start, CleanedContent: "SCREEN-CONTENT"}},
+	}
+}
+
+func TestExportConversation_Markdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportConversation(&buf, exportFixture(), ExportOptions{Format: ExportMarkdown}); err != nil {
+		t.Fatalf("ExportConversation failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# Claude conversation",
+		"**Working directory:** `/home/dev/forge`",
+		"**Git branch:** `feature/export`",
+		"## User · 2025-06-02 14:30:00 UTC",
+		"```go\nhtml.EscapeString(s)\n```",
+		"npm test\n```", // Unterminated fence is closed
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Markdown missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "SCREEN-CONTENT") {
+		t.Error("Snapshots included without being requested")
+	}
+
+	if strings.Contains(out, "## Changes") {
+		t.Error("Changes section without a change report")
+	}
+
+	conv := exportFixture()
+	conv.Changes = &ChangeReport{
+		Files: []FileChange{
+			{Path: "main.go", Status: "M", Added: 3, Deleted: 1},
+			{Path: "logo.png", Status: "A", Binary: true},
+		},
+		FilesAdded: 1, FilesModified: 1, LinesAdded: 3, LinesDeleted: 1,
+		Diff: "diff --git a/main.go b/main.go\n+fixed\n",
+	}
+	buf.Reset()
+	ExportConversation(&buf, conv, ExportOptions{Format: ExportMarkdown})
+	for _, want := range []string{
+		"2 file(s) changed: 1 added, 1 modified, 0 deleted · +3 −1 lines",
+		"| `main.go` | M | 3 | 1 |",
+		"| `logo.png` | A | binary | binary |",
+		"```diff\ndiff --git a/main.go b/main.go\n+fixed\n```",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Markdown missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	ExportConversation(&buf, exportFixture(), ExportOptions{Format: ExportMarkdown, IncludeSnapshots: true})
+	if !strings.Contains(buf.String(), "SCREEN-CONTENT") {
+		t.Error("Snapshots missing when requested")
+	}
+}
+
+func TestExportConversation_HTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportConversation(&buf, exportFixture(), ExportOptions{Format: ExportHTML}); err != nil {
+		t.Fatalf("ExportConversation failed: %v", err)
+	}
+	out := buf.String()
+
+	if strings.Contains(out, "<script>") || !strings.Contains(out, "&lt;script&gt;") {
+		t.Error("Turn content not escaped")
+	}
+	if !strings.Contains(out, `<pre><code class="language-go">html.EscapeString(s)</code></pre>`) {
+		t.Errorf("Code block not rendered:\n%s", out)
+	}
+	if !strings.Contains(out, "feature/export") {
+		t.Error("Git branch missing")
+	}
+}
+
+func TestExportConversation_JSONL(t *testing.T) {
+	var buf bytes.Buffer
+	opts := ExportOptions{Format: ExportJSONL, IncludeSnapshots: true}
+	if err := ExportConversation(&buf, exportFixture(), opts); err != nil {
+		t.Fatalf("ExportConversation failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected header, 3 turns and 1 snapshot, got %d lines", len(lines))
+	}
+	var header, turn exportRecord
+	json.Unmarshal([]byte(lines[0]), &header)
+	json.Unmarshal([]byte(lines[2]), &turn)
+	if header.Type != "conversation" || header.Metadata.GitBranch != "feature/export" || header.Project != "forge" {
+		t.Errorf("Unexpected header: %+v", header)
+	}
+	if turn.Type != "turn" || turn.Role != "assistant" || !strings.Contains(turn.Content, "```go") {
+		t.Errorf("Unexpected turn: %+v", turn)
+	}
+
+	if err := ExportConversation(&buf, exportFixture(), ExportOptions{Format: "pdf"}); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+}
+
+func TestExportConversations_ZipFiltersByProjectAndDate(t *testing.T) {
+	dir := t.TempDir()
+	conv := exportFixture()
+	writeTestConversation(t, dir, conv)
+
+	other := exportFixture()
+	other.ConversationID = "conv-export-2"
+	other.Metadata = &ConversationMetadata{WorkingDirectory: "/home/dev/other"}
+	writeTestConversation(t, dir, other)
+
+	late := exportFixture()
+	late.ConversationID = "conv-export-3"
+	late.StartTime = conv.StartTime.Add(72 * time.Hour)
+	writeTestConversation(t, dir, late)
+
+	var buf bytes.Buffer
+	filter := ExportFilter{Project: "forge", Until: conv.StartTime.Add(24 * time.Hour)}
+	count, err := ExportConversations(&buf, dir, filter, ExportOptions{Format: ExportMarkdown})
+	if err != nil || count != 1 {
+		t.Fatalf("ExportConversations() = %d, %v; want 1", count, err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Invalid zip: %v", err)
+	}
+	if len(zr.File) != 1 || zr.File[0].Name != "forge-2025-06-02-1430-conv-export-1.md" {
+		t.Errorf("Unexpected zip entries: %v", zr.File)
+	}
+}
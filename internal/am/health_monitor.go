@@ -1,4 +1,5 @@
-// Package am provides health monitoring for the AM system.
+// Health monitoring for the AM system.
+
 package am
 
 import (
@@ -22,19 +23,35 @@ type ContentValidation struct {
 
 // LayerStatus represents the status of a single AM layer.
 type LayerStatus struct {
-	LayerID     int       `json:"layerId"`
-	Name        string    `json:"name"`
-	Status      string    `json:"status"` // HEALTHY, UNKNOWN, STALE, CRITICAL
-	LastActive  time.Time `json:"lastActive,omitempty"`
-	Description string    `json:"description,omitempty"`
+	LayerID     int            `json:"layerId"`
+	Name        string         `json:"name"`
+	Status      string         `json:"status"` // HEALTHY, UNKNOWN, STALE, CRITICAL
+	LastActive  time.Time      `json:"lastActive,omitempty"`
+	Description string         `json:"description,omitempty"`
+	EndMethods  map[string]int `json:"endMethods,omitempty"` // Conversations closed per end method
+}
+
+// ConversationEnding records how a conversation was closed.
+type ConversationEnding struct {
+	ConversationID string    `json:"conversationId"`
+	TabID          string    `json:"tabId"`
+	Provider       string    `json:"provider,omitempty"`
+	Method         string    `json:"method"`
+	EndedAt        time.Time `json:"endedAt"`
 }
 
+// maxRecentEndings bounds the endings kept for the health report.
+const maxRecentEndings = 20
+
 // SystemHealth represents the complete health status.
 type SystemHealth struct {
 	Status     string             `json:"status"` // HEALTHY, DEGRADED, FAILED
 	Metrics    *CaptureMetrics    `json:"metrics"`
 	Layers     []LayerStatus      `json:"layers,omitempty"`
 	Validation *ContentValidation `json:"validation,omitempty"`
+	Retention  *RetentionReport   `json:"retention,omitempty"`
+	// RecentEndings lists the last conversations closed, newest first
+	RecentEndings []ConversationEnding `json:"recentEndings,omitempty"`
 }
 
 // HealthMonitor tracks the health of the AM capture pipeline.
@@ -42,6 +59,8 @@ type HealthMonitor struct {
 	mutex     sync.RWMutex
 	metrics   *CaptureMetrics
 	startTime time.Time
+	retention *RetentionReport     // Last janitor pass
+	endings   []ConversationEnding // Most recent last, capped at maxRecentEndings
 }
 
 // NewHealthMonitor creates a new health monitor.
@@ -63,31 +82,49 @@ func (hm *HealthMonitor) handleEvent(event *LayerEvent) {
 	defer hm.mutex.Unlock()
 
 	switch event.Type {
-	case "LLM_START":
+	case TopicLLMStart:
 		hm.metrics.ConversationsActive++
 		log.Printf("[Health] Conversation started (active=%d)",
 			hm.metrics.ConversationsActive)
 
-	case "LLM_END":
+	case TopicLLMEnd:
 		hm.metrics.ConversationsComplete++
 		if hm.metrics.ConversationsActive > 0 {
 			hm.metrics.ConversationsActive--
 		}
-		log.Printf("[Health] Conversation ended (active=%d, complete=%d)",
-			hm.metrics.ConversationsActive, hm.metrics.ConversationsComplete)
+		method, _ := event.Metadata["endMethod"].(string)
+		if method == "" {
+			method = EndManual
+		}
+		if hm.metrics.EndMethods == nil {
+			hm.metrics.EndMethods = make(map[string]int)
+		}
+		hm.metrics.EndMethods[method]++
+		hm.endings = append(hm.endings, ConversationEnding{
+			ConversationID: event.ConvID,
+			TabID:          event.TabID,
+			Provider:       event.Provider,
+			Method:         method,
+			EndedAt:        event.Timestamp,
+		})
+		if len(hm.endings) > maxRecentEndings {
+			hm.endings = hm.endings[len(hm.endings)-maxRecentEndings:]
+		}
+		log.Printf("[Health] Conversation ended by %s (active=%d, complete=%d)",
+			method, hm.metrics.ConversationsActive, hm.metrics.ConversationsComplete)
 
-	case "USER_INPUT":
+	case TopicUserInput:
 		hm.metrics.InputTurnsDetected++
 		hm.metrics.LastCaptureTime = time.Now()
 
-	case "ASSISTANT_OUTPUT":
+	case TopicAssistantOutput:
 		hm.metrics.OutputTurnsDetected++
 		hm.metrics.LastCaptureTime = time.Now()
 
-	case "PARSE_FAILURE":
+	case TopicParseFailure:
 		hm.metrics.InputParseFailures++
 
-	case "LOW_CONFIDENCE":
+	case TopicLowConfidence:
 		hm.metrics.LowConfidenceParses++
 	}
 }
@@ -95,7 +132,7 @@ func (hm *HealthMonitor) handleEvent(event *LayerEvent) {
 // RecordInputCapture records a successful user input capture.
 func (hm *HealthMonitor) RecordInputCapture() {
 	EventBus.Publish(&LayerEvent{
-		Type:      "USER_INPUT",
+		Type:      TopicUserInput,
 		Timestamp: time.Now(),
 	})
 }
@@ -103,7 +140,7 @@ func (hm *HealthMonitor) RecordInputCapture() {
 // RecordOutputCapture records a successful assistant output capture.
 func (hm *HealthMonitor) RecordOutputCapture() {
 	EventBus.Publish(&LayerEvent{
-		Type:      "ASSISTANT_OUTPUT",
+		Type:      TopicAssistantOutput,
 		Timestamp: time.Now(),
 	})
 }
@@ -111,7 +148,7 @@ func (hm *HealthMonitor) RecordOutputCapture() {
 // RecordParseFailure records a parse failure.
 func (hm *HealthMonitor) RecordParseFailure() {
 	EventBus.Publish(&LayerEvent{
-		Type:      "PARSE_FAILURE",
+		Type:      TopicParseFailure,
 		Timestamp: time.Now(),
 	})
 }
@@ -119,11 +156,21 @@ func (hm *HealthMonitor) RecordParseFailure() {
 // RecordLowConfidence records a low-confidence parse.
 func (hm *HealthMonitor) RecordLowConfidence() {
 	EventBus.Publish(&LayerEvent{
-		Type:      "LOW_CONFIDENCE",
+		Type:      TopicLowConfidence,
 		Timestamp: time.Now(),
 	})
 }
 
+// RecordRetention records the outcome of a retention janitor pass.
+func (hm *HealthMonitor) RecordRetention(report *RetentionReport) {
+	hm.mutex.Lock()
+	defer hm.mutex.Unlock()
+	hm.retention = report
+	if len(report.Errors) > 0 {
+		log.Printf("[Health] Retention pass had %d error(s)", len(report.Errors))
+	}
+}
+
 // GetSystemHealth returns the current system health.
 func (hm *HealthMonitor) GetSystemHealth() *SystemHealth {
 	hm.mutex.RLock()
@@ -146,6 +193,7 @@ func (hm *HealthMonitor) GetSystemHealth() *SystemHealth {
 		RecoverableConversations: hm.metrics.RecoverableConversations,
 		LowConfidenceParses:      hm.metrics.LowConfidenceParses,
 		LastCaptureTime:          hm.metrics.LastCaptureTime,
+		EndMethods:               hm.endMethods(),
 		// Additional metrics expected by tests
 		ConversationsStarted:    hm.metrics.ConversationsActive + hm.metrics.ConversationsComplete,
 		TotalEventsProcessed:    hm.metrics.InputTurnsDetected + hm.metrics.OutputTurnsDetected,
@@ -168,13 +216,34 @@ func (hm *HealthMonitor) GetSystemHealth() *SystemHealth {
 	// Build layer status information
 	layers := hm.buildLayerStatus()
 
+	// Newest ending first
+	var endings []ConversationEnding
+	for i := len(hm.endings) - 1; i >= 0; i-- {
+		endings = append(endings, hm.endings[i])
+	}
+
 	return &SystemHealth{
-		Status:  status,
-		Metrics: metrics,
-		Layers:  layers,
+		Status:        status,
+		Metrics:       metrics,
+		Layers:        layers,
+		Retention:     hm.retention,
+		RecentEndings: endings,
 	}
 }
 
+// endMethods returns a copy of the per-method conversation end counts.
+// Must be called with the mutex held.
+func (hm *HealthMonitor) endMethods() map[string]int {
+	if len(hm.metrics.EndMethods) == 0 {
+		return nil
+	}
+	counts := make(map[string]int, len(hm.metrics.EndMethods))
+	for method, n := range hm.metrics.EndMethods {
+		counts[method] = n
+	}
+	return counts
+}
+
 // buildLayerStatus creates status for each AM layer
 func (hm *HealthMonitor) buildLayerStatus() []LayerStatus {
 	now := time.Now()
@@ -202,7 +271,8 @@ func (hm *HealthMonitor) buildLayerStatus() []LayerStatus {
 			LayerID:     2,
 			Name:        "Command Detection",
 			Status:      getStatus(captureHealthy),
-			Description: "LLM command detection layer",
+			Description: "LLM command detection and process lifecycle layer",
+			EndMethods:  hm.endMethods(),
 		},
 		{
 			LayerID:     3,
@@ -243,6 +313,7 @@ func (hm *HealthMonitor) GetMetrics() *CaptureMetrics {
 		RecoverableConversations: hm.metrics.RecoverableConversations,
 		LowConfidenceParses:      hm.metrics.LowConfidenceParses,
 		LastCaptureTime:          hm.metrics.LastCaptureTime,
+		EndMethods:               hm.endMethods(),
 	}
 
 	// Calculate snapshot count from active conversations
@@ -308,18 +379,12 @@ var ansiArtifacts = regexp.MustCompile(`\[\??[0-9;]*[a-zA-Z]|\x1b`)
 
 // ValidateConversationContent checks if a conversation file has valid, clean content.
 func ValidateConversationContent(filePath string) (bool, string) {
-	data, err := os.ReadFile(filePath)
-	if err != nil {
+	if _, err := os.Stat(filePath); err != nil {
 		return false, "failed to read file: " + err.Error()
 	}
 
-	var conv struct {
-		Turns []struct {
-			Content string `json:"content"`
-		} `json:"turns"`
-	}
-
-	if err := json.Unmarshal(data, &conv); err != nil {
+	conv, err := readConversationFile(filePath)
+	if err != nil {
 		return false, "failed to parse JSON: " + err.Error()
 	}
 
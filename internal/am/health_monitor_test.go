@@ -274,3 +274,34 @@ func TestHealthMonitor_GetSystemHealth_OnlyCountsIncompleteConversations(t *test
 		t.Errorf("Expected HEALTHY status, got %s", health.Status)
 	}
 }
+
+func TestHealthMonitor_ReportsConversationEndMethods(t *testing.T) {
+	SetTestMode(true)
+	defer SetTestMode(false)
+	SetTestConversations(map[string]*LLMConversation{})
+
+	hm := NewHealthMonitor()
+	for i, method := range []string{EndProcessExit, EndProcessExit, EndPromptHeuristic} {
+		hm.handleEvent(&LayerEvent{Type: "LLM_START", TabID: "tab-1"})
+		hm.handleEvent(&LayerEvent{
+			Type:      "LLM_END",
+			TabID:     "tab-1",
+			ConvID:    "conv-" + string(rune('a'+i)),
+			Provider:  "claude",
+			Timestamp: time.Now(),
+			Metadata:  map[string]interface{}{"endMethod": method},
+		})
+	}
+
+	health := hm.GetSystemHealth()
+	if health.Metrics.EndMethods[EndProcessExit] != 2 || health.Metrics.EndMethods[EndPromptHeuristic] != 1 {
+		t.Errorf("Unexpected end method counts: %v", health.Metrics.EndMethods)
+	}
+	if len(health.RecentEndings) != 3 || health.RecentEndings[0].ConversationID != "conv-c" ||
+		health.RecentEndings[0].Method != EndPromptHeuristic {
+		t.Errorf("Unexpected recent endings: %+v", health.RecentEndings)
+	}
+	if layer := health.Layers[1]; layer.EndMethods[EndProcessExit] != 2 {
+		t.Errorf("Layer 2 should report end methods, got %+v", layer)
+	}
+}
@@ -0,0 +1,380 @@
+// Append-only journal persistence for LLM conversations.
+
+package am
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Compaction thresholds: a journal is folded into the conversation file once it holds
+// this many records or bytes, when the conversation completes, or on first save after start.
+const (
+	maxJournalRecords = 256
+	maxJournalBytes   = 4 * 1024 * 1024
+)
+
+// Journal record types.
+const (
+	journalTurn         = "turn"
+	journalSnapshot     = "snapshot"
+	journalMeta         = "meta"
+	journalAutoResponse = "autoresponse"
+)
+
+// journalRecord is one line of a conversation journal.
+type journalRecord struct {
+	Type         string              `json:"type"`
+	Time         time.Time           `json:"time"`
+	Index        int                 `json:"index"` // Position of a turn or auto-response record in its list
+	Turn         *ConversationTurn   `json:"turn,omitempty"`
+	Snapshot     *ScreenSnapshot     `json:"snapshot,omitempty"`
+	Meta         *conversationMeta   `json:"meta,omitempty"`
+	AutoResponse *AutoResponseRecord `json:"autoResponse,omitempty"`
+}
+
+// conversationMeta is the mutable, non-list part of a conversation.
+type conversationMeta struct {
+	Provider       string                `json:"provider"`
+	CommandType    string                `json:"commandType"`
+	EndTime        time.Time             `json:"endTime,omitempty"`
+	Complete       bool                  `json:"complete"`
+	AutoRespond    bool                  `json:"autoRespond"`
+	TUICaptureMode bool                  `json:"tuiCaptureMode,omitempty"`
+	ProcessPID     int                   `json:"processPID,omitempty"`
+	EndMethod      string                `json:"endMethod,omitempty"`
+	Metadata       *ConversationMetadata `json:"metadata,omitempty"`
+	Recovery       *ConversationRecovery `json:"recovery,omitempty"`
+	Links          []ConversationLink    `json:"links,omitempty"`
+	Changes        *ChangeReport         `json:"changes,omitempty"`
+}
+
+// journalState tracks what of a conversation is already on disk.
+type journalState struct {
+	mu            sync.Mutex
+	turns         int
+	autoResponses int
+	lastSnapshot  time.Time
+	meta          []byte
+	complete      bool
+	records       int
+	bytes         int64
+}
+
+var (
+	journalStates   = make(map[string]*journalState) // by conversation file path
+	journalStatesMu sync.Mutex
+)
+
+// journalPath returns the journal that accompanies a conversation file.
+func journalPath(convPath string) string {
+	return strings.TrimSuffix(convPath, ".json") + ".journal.jsonl"
+}
+
+// journalConversationPath is the inverse of journalPath: "X.journal.jsonl" belongs
+// to X.json and "X.json.gz.journal.jsonl" to the archive X.json.gz.
+func journalConversationPath(journal string) string {
+	path := strings.TrimSuffix(journal, ".journal.jsonl")
+	if strings.HasSuffix(path, ".json.gz") {
+		return path
+	}
+	return path + ".json"
+}
+
+// forgetJournalState drops what is known about a conversation file's journal, once
+// the file is finished with, deleted or archived.
+func forgetJournalState(path string) {
+	journalStatesMu.Lock()
+	delete(journalStates, path)
+	journalStatesMu.Unlock()
+}
+
+// persistConversation saves conv to path. The first save of a conversation in this
+// process, its completion, and a full journal write the whole file; everything else
+// appends only what changed since the previous save. Saves of stale copies (fewer
+// turns than already persisted) are ignored. It returns the bytes written and whether
+// the file was compacted.
+func persistConversation(path string, conv *LLMConversation) (int, bool, error) {
+	journalStatesMu.Lock()
+	state, ok := journalStates[path]
+	if !ok {
+		state = &journalState{}
+		journalStates[path] = state
+	}
+	journalStatesMu.Unlock()
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	meta, err := json.Marshal(metaOf(conv))
+	if err != nil {
+		return 0, false, err
+	}
+
+	// Async saves carry copies that can arrive out of order; never go backwards
+	if ok && !conv.Complete && (len(conv.Turns) < state.turns || state.complete && len(conv.Turns) == state.turns) {
+		return 0, false, nil
+	}
+
+	if !ok && !conv.Complete {
+		// A late async copy of a conversation that already completed, arriving after
+		// its state was dropped. Saves queued behind this one share the state, so it
+		// must describe the file before it is dropped
+		if disk, err := readConversationFile(path); err == nil && disk.Complete {
+			state.turns = len(disk.Turns)
+			state.complete = true
+			forgetJournalState(path)
+			return 0, false, nil
+		}
+	}
+
+	if !ok || conv.Complete ||
+		state.records >= maxJournalRecords || state.bytes >= maxJournalBytes {
+		n, err := compactLocked(path, conv, state, meta)
+		if err == nil && conv.Complete {
+			// Saves already waiting on this state still see it as complete
+			forgetJournalState(path)
+		}
+		return n, true, err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	now := time.Now()
+	records := 0
+
+	for i := state.turns; i < len(conv.Turns); i++ {
+		turn := conv.Turns[i]
+		enc.Encode(journalRecord{Type: journalTurn, Time: now, Index: i, Turn: &turn})
+		records++
+	}
+	for i := state.autoResponses; i < len(conv.AutoResponses); i++ {
+		rec := conv.AutoResponses[i]
+		enc.Encode(journalRecord{Type: journalAutoResponse, Time: now, Index: i, AutoResponse: &rec})
+		records++
+	}
+	lastSnapshot := state.lastSnapshot
+	for i := range conv.ScreenSnapshots {
+		snap := conv.ScreenSnapshots[i]
+		if snap.Timestamp.After(state.lastSnapshot) {
+			enc.Encode(journalRecord{Type: journalSnapshot, Time: now, Snapshot: &snap})
+			records++
+			lastSnapshot = snap.Timestamp
+		}
+	}
+	if !bytes.Equal(meta, state.meta) {
+		var m conversationMeta
+		json.Unmarshal(meta, &m)
+		enc.Encode(journalRecord{Type: journalMeta, Time: now, Meta: &m})
+		records++
+	}
+
+	if records == 0 {
+		return 0, false, nil
+	}
+
+	f, err := os.OpenFile(journalPath(path), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, false, err
+	}
+	n, err := f.Write(buf.Bytes())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// A partial line is discarded on replay; rewrite everything next time
+		state.records = maxJournalRecords
+		return n, false, err
+	}
+
+	state.turns = len(conv.Turns)
+	state.autoResponses = len(conv.AutoResponses)
+	state.lastSnapshot = lastSnapshot
+	state.meta = meta
+	state.records += records
+	state.bytes += int64(n)
+	return n, false, nil
+}
+
+// compactConversation writes conv as the full conversation file and drops its journal.
+// Use it for any out-of-band rewrite of a conversation file.
+func compactConversation(path string, conv *LLMConversation) error {
+	forgetJournalState(path)
+
+	_, err := compactLocked(path, conv, &journalState{}, nil)
+	return err
+}
+
+// compactLocked atomically replaces the conversation file and removes the journal.
+// A crash between the two steps is harmless: replay skips records already in the file.
+// Archives compressed by retention stay compressed.
+func compactLocked(path string, conv *LLMConversation, state *journalState, meta []byte) (int, error) {
+	data, err := json.MarshalIndent(conv, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if strings.HasSuffix(path, ".gz") {
+		if data, err = gzipConversationData(data, filepath.Base(strings.TrimSuffix(path, ".gz"))); err != nil {
+			return 0, err
+		}
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+	if err := os.Remove(journalPath(path)); err != nil && !os.IsNotExist(err) {
+		return len(data), err
+	}
+
+	state.turns = len(conv.Turns)
+	state.autoResponses = len(conv.AutoResponses)
+	state.lastSnapshot = time.Time{}
+	if n := len(conv.ScreenSnapshots); n > 0 {
+		state.lastSnapshot = conv.ScreenSnapshots[n-1].Timestamp
+	}
+	state.meta = meta
+	state.complete = conv.Complete
+	state.records = 0
+	state.bytes = 0
+	return len(data), nil
+}
+
+// replayJournal applies the journal next to path, if any, to conv. Records already
+// reflected in conv are skipped, and a torn final line from a crash is ignored.
+func replayJournal(path string, conv *LLMConversation) error {
+	f, err := os.Open(journalPath(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	var lastSnapshot time.Time
+	if n := len(conv.ScreenSnapshots); n > 0 {
+		lastSnapshot = conv.ScreenSnapshots[n-1].Timestamp
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var rec journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			log.Printf("[LLM Logger] ⚠️ Ignoring unreadable journal tail in %s: %v", filepath.Base(path), err)
+			break
+		}
+
+		switch rec.Type {
+		case journalTurn:
+			if rec.Turn != nil && rec.Index == len(conv.Turns) {
+				conv.Turns = append(conv.Turns, *rec.Turn)
+			}
+		case journalSnapshot:
+			if rec.Snapshot != nil && rec.Snapshot.Timestamp.After(lastSnapshot) {
+				conv.ScreenSnapshots = append(conv.ScreenSnapshots, *rec.Snapshot)
+				lastSnapshot = rec.Snapshot.Timestamp
+			}
+		case journalMeta:
+			if rec.Meta != nil {
+				applyMeta(conv, rec.Meta)
+			}
+		case journalAutoResponse:
+			if rec.AutoResponse != nil && rec.Index == len(conv.AutoResponses) {
+				conv.AutoResponses = append(conv.AutoResponses, *rec.AutoResponse)
+			}
+		}
+	}
+
+	if len(conv.ScreenSnapshots) > maxSnapshotsPerConversation {
+		conv.ScreenSnapshots = conv.ScreenSnapshots[len(conv.ScreenSnapshots)-maxSnapshotsPerConversation:]
+	}
+	return scanner.Err()
+}
+
+// CompactJournals folds every leftover journal in amDir into its conversation file.
+// Journals only survive a process that exited mid-conversation, so this is crash recovery.
+func CompactJournals(amDir string) (int, error) {
+	if amDir == "" {
+		amDir = DefaultAMDir()
+	}
+
+	journals, err := filepath.Glob(filepath.Join(amDir, "*.journal.jsonl"))
+	if err != nil {
+		return 0, err
+	}
+
+	compacted := 0
+	for _, journal := range journals {
+		path := journalConversationPath(journal)
+		conv, err := readConversationFile(path)
+		if err != nil {
+			log.Printf("[LLM Logger] ⚠️ Cannot recover journal %s: %v", filepath.Base(journal), err)
+			continue
+		}
+		if err := compactConversation(path, conv); err != nil {
+			log.Printf("[LLM Logger] ⚠️ Failed to compact %s: %v", filepath.Base(path), err)
+			continue
+		}
+		compacted++
+	}
+
+	if compacted > 0 {
+		log.Printf("[LLM Logger] Recovered %d conversation journal(s)", compacted)
+	}
+	return compacted, nil
+}
+
+// conversationModTime returns the later of the conversation file's and its journal's
+// modification times, i.e. when the conversation last changed on disk.
+func conversationModTime(path string) time.Time {
+	mod := fileModTime(path)
+	if jmod := fileModTime(journalPath(path)); jmod.After(mod) {
+		mod = jmod
+	}
+	return mod
+}
+
+func metaOf(conv *LLMConversation) conversationMeta {
+	return conversationMeta{
+		Provider:       conv.Provider,
+		CommandType:    conv.CommandType,
+		EndTime:        conv.EndTime,
+		Complete:       conv.Complete,
+		AutoRespond:    conv.AutoRespond,
+		TUICaptureMode: conv.TUICaptureMode,
+		ProcessPID:     conv.ProcessPID,
+		EndMethod:      conv.EndMethod,
+		Metadata:       conv.Metadata,
+		Recovery:       conv.Recovery,
+		Links:          conv.Links,
+		Changes:        conv.Changes,
+	}
+}
+
+func applyMeta(conv *LLMConversation, meta *conversationMeta) {
+	conv.Provider = meta.Provider
+	conv.CommandType = meta.CommandType
+	conv.EndTime = meta.EndTime
+	conv.Complete = meta.Complete
+	conv.AutoRespond = meta.AutoRespond
+	conv.TUICaptureMode = meta.TUICaptureMode
+	conv.ProcessPID = meta.ProcessPID
+	conv.EndMethod = meta.EndMethod
+	conv.Metadata = meta.Metadata
+	conv.Recovery = meta.Recovery
+	conv.Links = meta.Links
+	conv.Changes = meta.Changes
+}
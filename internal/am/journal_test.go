@@ -0,0 +1,182 @@
+package am
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func journalConversation() *LLMConversation {
+	return &LLMConversation{
+		ConversationID: "conv-journal",
+		TabID:          "tab-1",
+		Provider:       "claude",
+		StartTime:      time.Now(),
+		Turns:          []ConversationTurn{{Role: "user", Content: "first", Timestamp: time.Now()}},
+	}
+}
+
+func readBaseFile(t *testing.T, path string) LLMConversation {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	var conv LLMConversation
+	if err := json.Unmarshal(data, &conv); err != nil {
+		t.Fatalf("Failed to parse %s: %v", path, err)
+	}
+	return conv
+}
+
+func TestJournal_AppendsAndReplays(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "proj-conv-journal.json")
+	conv := journalConversation()
+
+	if _, compacted, err := persistConversation(path, conv); err != nil || !compacted {
+		t.Fatalf("First save should write the full file: compacted=%v err=%v", compacted, err)
+	}
+
+	conv.Turns = append(conv.Turns, ConversationTurn{Role: "assistant", Content: "second", Timestamp: time.Now()})
+	conv.ScreenSnapshots = append(conv.ScreenSnapshots, ScreenSnapshot{Timestamp: time.Now(), CleanedContent: "screen"})
+	conv.AutoRespond = true
+	if _, compacted, err := persistConversation(path, conv); err != nil || compacted {
+		t.Fatalf("Later save should append: compacted=%v err=%v", compacted, err)
+	}
+
+	if base := readBaseFile(t, path); len(base.Turns) != 1 {
+		t.Errorf("Base file rewritten on append: %d turns", len(base.Turns))
+	}
+	if _, err := os.Stat(journalPath(path)); err != nil {
+		t.Fatalf("Journal not written: %v", err)
+	}
+
+	got, err := readConversationFile(path)
+	if err != nil {
+		t.Fatalf("readConversationFile failed: %v", err)
+	}
+	if len(got.Turns) != 2 || len(got.ScreenSnapshots) != 1 || !got.AutoRespond {
+		t.Errorf("Replay incomplete: %d turns, %d snapshots, autoRespond=%v",
+			len(got.Turns), len(got.ScreenSnapshots), got.AutoRespond)
+	}
+
+	// A save with nothing new appends nothing
+	info, _ := os.Stat(journalPath(path))
+	persistConversation(path, conv)
+	if after, _ := os.Stat(journalPath(path)); after.Size() != info.Size() {
+		t.Errorf("Unchanged save grew the journal from %d to %d bytes", info.Size(), after.Size())
+	}
+}
+
+func TestJournal_IgnoresTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "proj-conv-journal.json")
+	conv := journalConversation()
+	persistConversation(path, conv)
+
+	conv.Turns = append(conv.Turns, ConversationTurn{Role: "assistant", Content: "kept"})
+	persistConversation(path, conv)
+
+	f, err := os.OpenFile(journalPath(path), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"type":"turn","index":2,"turn":{"role":"user","cont`)
+	f.Close()
+
+	got, err := readConversationFile(path)
+	if err != nil {
+		t.Fatalf("readConversationFile failed: %v", err)
+	}
+	if len(got.Turns) != 2 || got.Turns[1].Content != "kept" {
+		t.Errorf("Expected records before the torn line, got %+v", got.Turns)
+	}
+}
+
+func TestJournal_CompactsOnCompleteAndIgnoresStaleCopies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "proj-conv-journal.json")
+	conv := journalConversation()
+	persistConversation(path, conv)
+
+	stale := *conv
+	stale.Turns = append([]ConversationTurn(nil), conv.Turns...)
+
+	conv.Turns = append(conv.Turns, ConversationTurn{Role: "assistant", Content: "done"})
+	persistConversation(path, conv)
+	conv.Complete = true
+	if _, compacted, _ := persistConversation(path, conv); !compacted {
+		t.Error("Completing a conversation should compact it")
+	}
+	if _, err := os.Stat(journalPath(path)); !os.IsNotExist(err) {
+		t.Errorf("Journal should be removed after compaction, stat err = %v", err)
+	}
+	journalStatesMu.Lock()
+	_, tracked := journalStates[path]
+	journalStatesMu.Unlock()
+	if tracked {
+		t.Error("Journal state should be dropped once the conversation is complete")
+	}
+
+	// An async save of an older copy arriving late must not undo completion
+	persistConversation(path, &stale)
+	got, _ := readConversationFile(path)
+	if !got.Complete || len(got.Turns) != 2 {
+		t.Errorf("Stale copy overwrote conversation: complete=%v turns=%d", got.Complete, len(got.Turns))
+	}
+}
+
+func TestCompactJournals_RecoversAfterCrash(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "proj-conv-journal.json")
+	conv := journalConversation()
+	persistConversation(path, conv)
+	conv.Turns = append(conv.Turns, ConversationTurn{Role: "assistant", Content: "unsaved"})
+	persistConversation(path, conv)
+
+	// Simulate a restart: the process forgets what it persisted
+	forgetJournalState(path)
+
+	n, err := CompactJournals(dir)
+	if err != nil || n != 1 {
+		t.Fatalf("CompactJournals() = %d, %v; want 1", n, err)
+	}
+	if base := readBaseFile(t, path); len(base.Turns) != 2 {
+		t.Errorf("Recovered file has %d turns, want 2", len(base.Turns))
+	}
+	if _, err := os.Stat(journalPath(path)); !os.IsNotExist(err) {
+		t.Error("Journal left behind after recovery")
+	}
+}
+
+func TestCompactJournals_RecoversArchivedConversation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "proj-conv-journal.json")
+	conv := journalConversation()
+	persistConversation(path, conv)
+	if _, err := compressConversationFile(path); err != nil {
+		t.Fatal(err)
+	}
+	forgetJournalState(path)
+
+	archive := path + ".gz"
+	persistConversation(archive, conv)
+	conv.Turns = append(conv.Turns, ConversationTurn{Role: "assistant", Content: "unsaved"})
+	persistConversation(archive, conv)
+	forgetJournalState(archive)
+
+	n, err := CompactJournals(dir)
+	if err != nil || n != 1 {
+		t.Fatalf("CompactJournals() = %d, %v; want 1", n, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Recovery wrote %s instead of the archive", filepath.Base(path))
+	}
+	got, err := readConversationFile(archive)
+	if err != nil || len(got.Turns) != 2 {
+		t.Fatalf("Recovered archive = %+v, %v; want 2 turns", got, err)
+	}
+	if _, err := os.Stat(journalPath(archive)); !os.IsNotExist(err) {
+		t.Error("Journal left behind after recovery")
+	}
+}
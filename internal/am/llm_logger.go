@@ -1,4 +1,5 @@
-// Package am provides LLM conversation logging.
+// LLM conversation logging.
+
 package am
 
 import (
@@ -7,11 +8,13 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/mikejsmith1985/forge-terminal/internal/llm"
+	"github.com/mikejsmith1985/forge-terminal/internal/metrics"
 )
 
 // Memory limits to prevent unbounded growth
@@ -37,7 +40,7 @@ type ConversationTurn struct {
 	Timestamp       time.Time `json:"timestamp"`
 	Provider        string    `json:"provider"`
 	Raw             string    `json:"raw,omitempty"`             // Raw PTY data for debugging
-	CaptureMethod   string    `json:"captureMethod,omitempty"`   // "pty_input", "pty_output", "tui_snapshot"
+	CaptureMethod   string    `json:"captureMethod,omitempty"`   // "pty_input", "pty_output", "tui_snapshot", "native_transcript"
 	ParseConfidence float64   `json:"parseConfidence,omitempty"` // 0.0-1.0 for output parsing
 }
 
@@ -54,8 +57,25 @@ type ConversationMetadata struct {
 	WorkingDirectory string `json:"workingDirectory,omitempty"`
 	GitBranch        string `json:"gitBranch,omitempty"`
 	ShellType        string `json:"shellType,omitempty"`
+	ProcessCwd       string `json:"processCwd,omitempty"`     // Working directory of the LLM process
+	TranscriptPath   string `json:"transcriptPath,omitempty"` // Native transcript an import was read from
+	Checkpoint       string `json:"checkpoint,omitempty"`     // Git ref of the working tree checkpoint taken at start
+}
+
+// ConversationLink relates a conversation to another one.
+type ConversationLink struct {
+	ConversationID string `json:"conversationId"`
+	Relation       string `json:"relation"`
 }
 
+// Conversation link relations.
+const (
+	LinkNativeTranscript = "native_transcript" // The CLI's own transcript of this conversation
+	LinkScreenCapture    = "screen_capture"    // The screen-captured conversation a transcript was imported for
+	LinkRestoredAs       = "restored_as"       // The conversation this one was restored into
+	LinkRestoredFrom     = "restored_from"     // The conversation whose context seeded this one
+)
+
 // LLMConversation represents a complete LLM conversation session.
 type LLMConversation struct {
 	ConversationID  string                `json:"conversationId"`
@@ -72,8 +92,24 @@ type LLMConversation struct {
 	TUICaptureMode  bool                  `json:"tuiCaptureMode,omitempty"`
 	ScreenSnapshots []ScreenSnapshot      `json:"screenSnapshots,omitempty"`
 	ProcessPID      int                   `json:"processPID,omitempty"`
+	EndMethod       string                `json:"endMethod,omitempty"` // How the conversation was closed (End* constants)
+	Links           []ConversationLink    `json:"links,omitempty"`
+	AutoResponses   []AutoResponseRecord  `json:"autoResponses,omitempty"` // Audit trail of automatic answers
+	Changes         *ChangeReport         `json:"changes,omitempty"`       // Files changed during the session
 }
 
+// Conversation end methods, recorded in LLMConversation.EndMethod and LLM_END events.
+// Process-based methods are authoritative; the prompt heuristic is only used where
+// the PTY's foreground process can't be tracked.
+const (
+	EndProcessExit      = "process_exit"      // Tracked LLM process exited
+	EndForegroundReturn = "foreground_return" // PTY foreground process group returned to the shell
+	EndPromptHeuristic  = "prompt_heuristic"  // Shell prompt pattern seen in output
+	EndTurnLimit        = "turn_limit"        // maxTurnsPerConversation reached
+	EndSessionClose     = "session_close"     // Terminal session closed
+	EndManual           = "manual"            // Ended explicitly through the API
+)
+
 // LLMLogger manages LLM conversation logging for a tab.
 type LLMLogger struct {
 	mu                sync.Mutex
@@ -95,6 +131,9 @@ type LLMLogger struct {
 	lastScreen        string
 	snapshotCount     int
 	onProcessCallback func(pid int, provider string) // Callback when Layer 3 detects process
+	processTracking   bool                           // Foreground process tracking ends conversations
+	checkpoints       bool                           // Checkpoint the git working tree when conversations start
+	baselines         map[string]chan struct{}       // Closed once a conversation's start checkpoint is taken
 }
 
 var (
@@ -128,6 +167,7 @@ func GetLLMLogger(tabID string, amDir string) *LLMLogger {
 		tabID:         tabID,
 		conversations: make(map[string]*LLMConversation),
 		amDir:         amDir,
+		checkpoints:   true,
 	}
 
 	// Load existing conversations from disk
@@ -214,6 +254,7 @@ func (l *LLMLogger) StartConversationFromProcess(provider string, cmdType string
 		ScreenSnapshots: []ScreenSnapshot{},
 		Metadata:        l.captureMetadata(),
 	}
+	conv.Metadata.ProcessCwd = processWorkingDir(pid)
 
 	// Add initial turn noting process start
 	conv.Turns = append(conv.Turns, ConversationTurn{
@@ -232,9 +273,10 @@ func (l *LLMLogger) StartConversationFromProcess(provider string, cmdType string
 	l.lastScreen = ""
 
 	l.saveConversation(conv)
+	l.checkpointConversationLocked(conv)
 
 	EventBus.Publish(&LayerEvent{
-		Type:      "LLM_START",
+		Type:      TopicLLMStart,
 		Layer:     1,
 		TabID:     l.tabID,
 		ConvID:    convID,
@@ -276,8 +318,10 @@ func (l *LLMLogger) StartConversation(detected *llm.DetectedCommand) string {
 		StartTime:      time.Now(),
 		Turns:          []ConversationTurn{},
 		Complete:       false,
+		ProcessPID:     detected.PID,
 		Metadata:       l.captureMetadata(),
 	}
+	conv.Metadata.ProcessCwd = processWorkingDir(detected.PID)
 	log.Printf("[LLM Logger] Created conversation struct")
 
 	if detected.Prompt != "" {
@@ -288,6 +332,7 @@ func (l *LLMLogger) StartConversation(detected *llm.DetectedCommand) string {
 			Timestamp: time.Now(),
 			Provider:  string(detected.Provider),
 		})
+		metrics.AMTurns.WithLabelValues("user").Inc()
 		log.Printf("[LLM Logger] Initial turn added, total turns: %d", len(conv.Turns))
 	} else {
 		log.Printf("[LLM Logger] No initial prompt provided")
@@ -308,10 +353,11 @@ func (l *LLMLogger) StartConversation(detected *llm.DetectedCommand) string {
 	log.Printf("[LLM Logger] Saving conversation to disk...")
 	l.saveConversation(conv)
 	log.Printf("[LLM Logger] ✓ Conversation saved")
+	l.checkpointConversationLocked(conv)
 
 	log.Printf("[LLM Logger] Publishing LLM_START event...")
 	EventBus.Publish(&LayerEvent{
-		Type:      "LLM_START",
+		Type:      TopicLLMStart,
 		Layer:     1,
 		TabID:     l.tabID,
 		ConvID:    convID,
@@ -335,11 +381,11 @@ func (l *LLMLogger) AddOutput(rawOutput string) {
 		return
 	}
 
-	// CRITICAL: Detect if shell prompt returned (LLM TUI exited)
-	// This ends the conversation to prevent unbounded growth
-	if l.detectShellPromptReturn(rawOutput) {
+	// Fallback: Detect if shell prompt returned (LLM TUI exited). Only used when the
+	// terminal can't track the foreground process, which ends conversations reliably
+	if !l.processTracking && l.detectShellPromptReturn(rawOutput) {
 		log.Printf("[LLM Logger] 🛑 Shell prompt detected - ending conversation %s", l.activeConvID)
-		l.endConversationLocked()
+		l.endConversationLocked(EndPromptHeuristic)
 		return
 	}
 
@@ -427,7 +473,7 @@ func (l *LLMLogger) detectShellPromptReturn(output string) bool {
 
 // endConversationLocked ends the active conversation.
 // Must be called with lock held.
-func (l *LLMLogger) endConversationLocked() {
+func (l *LLMLogger) endConversationLocked(method string) {
 	if l.activeConvID == "" {
 		return
 	}
@@ -445,24 +491,28 @@ func (l *LLMLogger) endConversationLocked() {
 
 	conv.Complete = true
 	conv.EndTime = time.Now()
+	conv.EndMethod = method
 	l.saveConversation(conv)
+	l.reportChangesLocked(conv)
 
 	EventBus.Publish(&LayerEvent{
-		Type:      "LLM_END",
+		Type:      TopicLLMEnd,
 		Layer:     1,
 		TabID:     l.tabID,
 		ConvID:    l.activeConvID,
+		Provider:  conv.Provider,
 		Timestamp: time.Now(),
 		Metadata: map[string]interface{}{
 			"tuiMode":   l.tuiCaptureMode,
 			"snapshots": len(conv.ScreenSnapshots),
 			"turns":     len(conv.Turns),
 			"autoEnded": true,
+			"endMethod": method,
 		},
 	})
 
-	log.Printf("[LLM Logger] Ended conversation %s (TUI:%v, snapshots:%d, turns:%d)",
-		l.activeConvID, l.tuiCaptureMode, len(conv.ScreenSnapshots), len(conv.Turns))
+	log.Printf("[LLM Logger] Ended conversation %s by %s (TUI:%v, snapshots:%d, turns:%d)",
+		l.activeConvID, method, l.tuiCaptureMode, len(conv.ScreenSnapshots), len(conv.Turns))
 
 	l.activeConvID = ""
 	l.tuiCaptureMode = false
@@ -532,6 +582,9 @@ func (l *LLMLogger) saveScreenSnapshotLocked() {
 		ProcessPID:      conv.ProcessPID,
 		Metadata:        conv.Metadata,
 		Recovery:        conv.Recovery,
+		Links:           conv.Links,
+		Changes:         conv.Changes,
+		AutoResponses:   append([]AutoResponseRecord(nil), conv.AutoResponses...),
 		Turns:           append([]ConversationTurn(nil), conv.Turns...),
 		ScreenSnapshots: append([]ScreenSnapshot(nil), conv.ScreenSnapshots...),
 	}
@@ -615,17 +668,7 @@ func (l *LLMLogger) parseLatestSnapshotToTurns(conv *LLMConversation, snapshot S
 	content := snapshot.CleanedContent
 
 	// Provider-specific assistant response detection
-	var response string
-	switch conv.Provider {
-	case "github-copilot":
-		response = l.extractCopilotResponseFromSnapshot(content)
-	case "claude":
-		response = l.extractClaudeResponseFromSnapshot(content)
-	case "aider":
-		response = l.extractAiderResponseFromSnapshot(content)
-	default:
-		response = l.extractGenericResponseFromSnapshot(content)
-	}
+	response := l.extractResponseFromSnapshot(content, llm.LookupProvider(conv.Provider))
 
 	// If we found a response and it's not a duplicate of the last turn
 	if response != "" && len(response) > 20 {
@@ -647,6 +690,7 @@ func (l *LLMLogger) parseLatestSnapshotToTurns(conv *LLMConversation, snapshot S
 			CaptureMethod:   "tui_snapshot",
 			ParseConfidence: 0.75,
 		})
+		metrics.AMTurns.WithLabelValues("assistant").Inc()
 
 		log.Printf("[LLM Logger] ✨ Extracted assistant response from snapshot #%d (%d chars)",
 			snapshot.SequenceNumber, len(response))
@@ -663,108 +707,35 @@ func min(a, b int) int {
 
 // extractCopilotResponseFromSnapshot extracts assistant response from Copilot TUI screen.
 func (l *LLMLogger) extractCopilotResponseFromSnapshot(content string) string {
-	lines := strings.Split(content, "\n")
-	var response strings.Builder
-	inResponse := false
-
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-
-		// Skip empty lines and UI chrome
-		if len(trimmed) == 0 ||
-			strings.Contains(trimmed, "────") ||
-			strings.Contains(trimmed, "Ctrl+") ||
-			strings.Contains(trimmed, "Welcome to GitHub Copilot") ||
-			strings.Contains(trimmed, "Enter @ to mention") {
-			continue
-		}
-
-		// Skip the user prompt line
-		if strings.HasPrefix(trimmed, ">") && len(trimmed) < 100 {
-			inResponse = false
-			continue
-		}
-
-		// Skip status lines
-		if strings.Contains(trimmed, "gpt-") || strings.Contains(trimmed, "claude-") {
-			continue
-		}
-
-		// Collect substantial content lines (likely assistant response)
-		if len(trimmed) > 30 {
-			if inResponse && response.Len() > 0 {
-				response.WriteString("\n")
-			}
-			response.WriteString(trimmed)
-			inResponse = true
-		}
-	}
-
-	return strings.TrimSpace(response.String())
+	return l.extractResponseFromSnapshot(content, llm.LookupProvider(string(llm.ProviderGitHubCopilot)))
 }
 
-// extractClaudeResponseFromSnapshot extracts assistant response from Claude TUI screen.
-func (l *LLMLogger) extractClaudeResponseFromSnapshot(content string) string {
+// extractResponseFromSnapshot collects the substantial content lines of a screen,
+// skipping the provider's UI chrome and user prompt lines. Unknown providers (nil def)
+// only skip ">" prompts.
+func (l *LLMLogger) extractResponseFromSnapshot(content string, def *llm.ProviderDefinition) string {
 	lines := strings.Split(content, "\n")
+	minLength := def.ResponseLineLength()
 	var response strings.Builder
 
 	for _, line := range lines {
 		trimmed := strings.TrimSpace(line)
-
-		// Skip UI elements
-		if len(trimmed) == 0 ||
-			strings.Contains(trimmed, "Claude") ||
-			strings.HasPrefix(trimmed, ">") {
+		if len(trimmed) == 0 {
 			continue
 		}
 
-		// Collect content
-		if len(trimmed) > 20 {
-			if response.Len() > 0 {
-				response.WriteString("\n")
+		if def == nil {
+			if strings.HasPrefix(trimmed, ">") {
+				continue
 			}
-			response.WriteString(trimmed)
-		}
-	}
-
-	return strings.TrimSpace(response.String())
-}
-
-// extractAiderResponseFromSnapshot extracts assistant response from Aider output.
-func (l *LLMLogger) extractAiderResponseFromSnapshot(content string) string {
-	lines := strings.Split(content, "\n")
-	var response strings.Builder
-
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-
-		// Skip prompts
-		if strings.HasPrefix(trimmed, ">") || len(trimmed) == 0 {
+		} else if def.IsChrome(trimmed) {
+			continue
+		} else if _, isPrompt := def.UserPrompt(trimmed); isPrompt {
 			continue
 		}
 
-		// Collect responses
-		if len(trimmed) > 15 {
-			if response.Len() > 0 {
-				response.WriteString("\n")
-			}
-			response.WriteString(trimmed)
-		}
-	}
-
-	return strings.TrimSpace(response.String())
-}
-
-// extractGenericResponseFromSnapshot attempts generic response extraction.
-func (l *LLMLogger) extractGenericResponseFromSnapshot(content string) string {
-	lines := strings.Split(content, "\n")
-	var response strings.Builder
-
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-
-		// Collect any substantial content
-		if len(trimmed) > 25 && !strings.HasPrefix(trimmed, ">") {
+		// Collect substantial content lines (likely assistant response)
+		if len(trimmed) > minLength {
 			if response.Len() > 0 {
 				response.WriteString("\n")
 			}
@@ -819,7 +790,7 @@ func (l *LLMLogger) flushUserInputLocked() {
 	// MEMORY LIMIT: Cap turns to prevent unbounded growth
 	if len(conv.Turns) >= maxTurnsPerConversation {
 		log.Printf("[LLM Logger] ⚠️ Turn limit reached (%d), ending conversation", len(conv.Turns))
-		l.endConversationLocked()
+		l.endConversationLocked(EndTurnLimit)
 		return
 	}
 
@@ -837,6 +808,7 @@ func (l *LLMLogger) flushUserInputLocked() {
 		Raw:           raw,
 		CaptureMethod: "pty_input",
 	})
+	metrics.AMTurns.WithLabelValues("user").Inc()
 
 	// Update recovery info
 	if conv.Recovery == nil {
@@ -912,6 +884,8 @@ func (l *LLMLogger) FlushOutput() {
 		return
 	}
 
+	metrics.AMParseConfidence.Observe(confidence)
+
 	// Handle low confidence
 	if confidence < 0.8 {
 		log.Printf("[LLM Logger] ⚠️ Low parse confidence (%.2f) for assistant output", confidence)
@@ -930,6 +904,7 @@ func (l *LLMLogger) FlushOutput() {
 		CaptureMethod:   "pty_output",
 		ParseConfidence: confidence,
 	})
+	metrics.AMTurns.WithLabelValues("assistant").Inc()
 
 	l.outputBuffer = ""
 	
@@ -951,6 +926,11 @@ func (l *LLMLogger) FlushOutput() {
 
 // EndConversation marks the active conversation as complete.
 func (l *LLMLogger) EndConversation() {
+	l.EndConversationBy(EndManual)
+}
+
+// EndConversationBy marks the active conversation as complete, recording how it ended.
+func (l *LLMLogger) EndConversationBy(method string) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
@@ -975,6 +955,7 @@ func (l *LLMLogger) EndConversation() {
 		// Add parsed turns to conversation
 		for _, turn := range parsedTurns {
 			conv.Turns = append(conv.Turns, turn)
+			metrics.AMTurns.WithLabelValues(turn.Role).Inc()
 		}
 
 		log.Printf("[LLM Logger] Parsed %d turns from TUI snapshots", len(parsedTurns))
@@ -990,29 +971,33 @@ func (l *LLMLogger) EndConversation() {
 				Timestamp: time.Now(),
 				Provider:  conv.Provider,
 			})
+			metrics.AMTurns.WithLabelValues("assistant").Inc()
 		}
 		l.outputBuffer = ""
 	}
 
 	conv.Complete = true
 	conv.EndTime = time.Now()
+	conv.EndMethod = method
 	l.saveConversation(conv)
 
 	EventBus.Publish(&LayerEvent{
-		Type:      "LLM_END",
+		Type:      TopicLLMEnd,
 		Layer:     1,
 		TabID:     l.tabID,
 		ConvID:    l.activeConvID,
+		Provider:  conv.Provider,
 		Timestamp: time.Now(),
 		Metadata: map[string]interface{}{
 			"tuiMode":   l.tuiCaptureMode,
 			"snapshots": len(conv.ScreenSnapshots),
 			"turns":     len(conv.Turns),
+			"endMethod": method,
 		},
 	})
 
-	log.Printf("[LLM Logger] Ended conversation %s (TUI:%v, snapshots:%d, turns:%d)",
-		l.activeConvID, l.tuiCaptureMode, len(conv.ScreenSnapshots), len(conv.Turns))
+	log.Printf("[LLM Logger] Ended conversation %s by %s (TUI:%v, snapshots:%d, turns:%d)",
+		l.activeConvID, method, l.tuiCaptureMode, len(conv.ScreenSnapshots), len(conv.Turns))
 
 	l.activeConvID = ""
 	l.tuiCaptureMode = false
@@ -1039,6 +1024,55 @@ func (l *LLMLogger) GetActiveConversationID() string {
 	return l.activeConvID
 }
 
+// AttachProcess records the PID of the LLM process behind the active conversation
+// when it was started from the typed command line, before the process existed.
+// It returns false if there is no active conversation or it already has a PID.
+func (l *LLMLogger) AttachProcess(pid int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	conv := l.conversations[l.activeConvID]
+	if conv == nil || conv.ProcessPID != 0 || pid <= 0 {
+		return false
+	}
+	conv.ProcessPID = pid
+	if conv.Metadata != nil {
+		conv.Metadata.ProcessCwd = processWorkingDir(pid)
+	}
+	log.Printf("[LLM Logger] Attached process PID %d to conversation %s", pid, conv.ConversationID)
+	l.saveConversation(conv)
+	return true
+}
+
+// ActiveProcessPID returns the PID tracked for the active conversation, or 0.
+func (l *LLMLogger) ActiveProcessPID() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if conv := l.conversations[l.activeConvID]; conv != nil {
+		return conv.ProcessPID
+	}
+	return 0
+}
+
+// SetProcessTracking records whether the terminal tracks this tab's foreground
+// process. While it does, process exit ends conversations and the shell prompt
+// heuristic is disabled.
+func (l *LLMLogger) SetProcessTracking(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.processTracking = enabled
+}
+
+// GetActiveProvider returns the provider of the active conversation, or "".
+func (l *LLMLogger) GetActiveProvider() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if conv := l.conversations[l.activeConvID]; conv != nil {
+		return conv.Provider
+	}
+	return ""
+}
+
 // GetConversations returns all conversations for this tab.
 func (l *LLMLogger) GetConversations() []*LLMConversation {
 	l.mu.Lock()
@@ -1088,23 +1122,18 @@ func (l *LLMLogger) GetConversations() []*LLMConversation {
 		}
 
 		for file := range allFiles {
-			data, err := os.ReadFile(file)
+			conv, err := readConversationFile(file)
 			if err != nil {
 				continue
 			}
 
-			var conv LLMConversation
-			if err := json.Unmarshal(data, &conv); err != nil {
-				continue
-			}
-
 			// Only add if not already in memory AND belongs to this tab
 			if !inMemory[conv.ConversationID] && conv.TabID == l.tabID {
 				log.Printf("[LLM Logger]   From disk: ID=%s provider=%s type=%s complete=%v turns=%d snapshots=%d",
 					conv.ConversationID, conv.Provider, conv.CommandType, conv.Complete, len(conv.Turns), len(conv.ScreenSnapshots))
-				convs = append(convs, &conv)
+				convs = append(convs, conv)
 				// Also add to in-memory map for future calls
-				l.conversations[conv.ConversationID] = &conv
+				l.conversations[conv.ConversationID] = conv
 			}
 		}
 	}
@@ -1131,6 +1160,7 @@ func (l *LLMLogger) GetConversation(convID string) *LLMConversation {
 		patterns := []string{
 			filepath.Join(l.amDir, "*-conv-*.json"),
 			filepath.Join(l.amDir, fmt.Sprintf("llm-conv-%s-%s.json", l.tabID, convID)), // Legacy exact match
+			filepath.Join(l.amDir, "*-conv-*.json.gz"),                                    // Compressed by retention
 		}
 
 		for _, pattern := range patterns {
@@ -1140,22 +1170,17 @@ func (l *LLMLogger) GetConversation(convID string) *LLMConversation {
 			}
 
 			for _, file := range files {
-				data, err := os.ReadFile(file)
+				diskConv, err := readConversationFile(file)
 				if err != nil {
 					continue
 				}
 
-				var diskConv LLMConversation
-				if err := json.Unmarshal(data, &diskConv); err != nil {
-					continue
-				}
-
 				// Check if this is the conversation we're looking for AND belongs to this tab
 				if diskConv.ConversationID == convID && diskConv.TabID == l.tabID {
 					log.Printf("[LLM Logger] ✓ Loaded conversation %s from disk", convID)
 					// Cache in memory for future calls
-					l.conversations[convID] = &diskConv
-					return &diskConv
+					l.conversations[convID] = diskConv
+					return diskConv
 				}
 			}
 		}
@@ -1171,38 +1196,120 @@ func GetAllConversations(amDir string) ([]*LLMConversation, error) {
 		amDir = DefaultAMDir()
 	}
 
+	conversations := make([]*LLMConversation, 0)
+	for _, file := range conversationFiles(amDir) {
+		conv, err := readConversationFile(file)
+		if err != nil {
+			continue
+		}
+		conversations = append(conversations, conv)
+	}
+
+	return conversations, nil
+}
+
+// conversationFiles lists every conversation file in amDir, new and legacy naming.
+func conversationFiles(amDir string) []string {
 	patterns := []string{
-		filepath.Join(amDir, "*-conv-*.json"),   // New format
-		filepath.Join(amDir, "llm-conv-*.json"), // Legacy format
+		filepath.Join(amDir, "*-conv-*.json"),      // New format
+		filepath.Join(amDir, "llm-conv-*.json"),    // Legacy format
+		filepath.Join(amDir, "*-conv-*.json.gz"),   // Compressed by retention
+		filepath.Join(amDir, "llm-conv-*.json.gz"), // Compressed legacy
 	}
 
-	allFiles := make(map[string]bool)
+	seen := make(map[string]bool)
+	var files []string
 	for _, pattern := range patterns {
-		files, err := filepath.Glob(pattern)
+		matches, err := filepath.Glob(pattern)
 		if err != nil {
 			continue
 		}
-		for _, file := range files {
-			allFiles[file] = true
+		for _, file := range matches {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
 		}
 	}
+	return files
+}
 
-	conversations := make([]*LLMConversation, 0)
-	for file := range allFiles {
-		data, err := os.ReadFile(file)
-		if err != nil {
-			continue
-		}
+// readConversationFile loads one conversation file, replaying its journal if present.
+// Archives compressed by retention are read transparently.
+func readConversationFile(path string) (*LLMConversation, error) {
+	data, err := readConversationData(path)
+	if err != nil {
+		return nil, err
+	}
 
-		var conv LLMConversation
-		if err := json.Unmarshal(data, &conv); err != nil {
-			continue
+	var conv LLMConversation
+	if err := json.Unmarshal(data, &conv); err != nil {
+		return nil, err
+	}
+	if err := replayJournal(path, &conv); err != nil {
+		log.Printf("[LLM Logger] ⚠️ Journal replay for %s incomplete: %v", filepath.Base(path), err)
+	}
+	return &conv, nil
+}
+
+// FindConversation looks up a conversation by ID without knowing which tab owns it.
+// Live conversations held by a logger take precedence over the copy on disk; they
+// are returned as a copy, since the logger keeps appending to them.
+func FindConversation(amDir, convID string) (*LLMConversation, error) {
+	llmLoggersMu.RLock()
+	for _, logger := range llmLoggers {
+		logger.mu.Lock()
+		conv, ok := logger.conversations[convID]
+		if ok {
+			conv = conv.clone()
+		}
+		logger.mu.Unlock()
+		if ok {
+			llmLoggersMu.RUnlock()
+			return conv, nil
 		}
+	}
+	llmLoggersMu.RUnlock()
 
-		conversations = append(conversations, &conv)
+	conversations, err := GetAllConversations(amDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, conv := range conversations {
+		if conv.ConversationID == convID {
+			return conv, nil
+		}
 	}
 
-	return conversations, nil
+	return nil, fmt.Errorf("conversation not found: %s", convID)
+}
+
+// clone returns a deep copy of the conversation. The caller must hold the owning
+// logger's lock.
+func (conv *LLMConversation) clone() *LLMConversation {
+	c := *conv
+	c.Turns = append([]ConversationTurn(nil), conv.Turns...)
+	c.ScreenSnapshots = append([]ScreenSnapshot(nil), conv.ScreenSnapshots...)
+	c.Links = append([]ConversationLink(nil), conv.Links...)
+	c.AutoResponses = append([]AutoResponseRecord(nil), conv.AutoResponses...)
+	if conv.Metadata != nil {
+		metadata := *conv.Metadata
+		c.Metadata = &metadata
+	}
+	if conv.Recovery != nil {
+		recovery := *conv.Recovery
+		if conv.Recovery.InProgressTurn != nil {
+			turn := *conv.Recovery.InProgressTurn
+			recovery.InProgressTurn = &turn
+		}
+		c.Recovery = &recovery
+	}
+	if conv.Changes != nil {
+		changes := *conv.Changes
+		changes.Files = append([]FileChange(nil), conv.Changes.Files...)
+		c.Changes = &changes
+	}
+	return &c
 }
 
 // GetActiveConversations returns all active conversations across all tabs.
@@ -1243,48 +1350,58 @@ func (l *LLMLogger) saveConversationAsync(conv *LLMConversation) {
 	filename := l.generateConversationFilename(conv)
 	filePath := filepath.Join(l.amDir, filename)
 
-	data, err := json.MarshalIndent(conv, "", "  ")
+	// Only changes since the last save are appended to the conversation's journal
+	n, compacted, err := persistConversation(filePath, conv)
 	if err != nil {
-		log.Printf("[LLM Logger] ❌ Failed to marshal conversation %s: %v", conv.ConversationID, err)
+		log.Printf("[LLM Logger] ❌ Failed to write conversation to %s: %v", filePath, err)
 		return
 	}
 
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		log.Printf("[LLM Logger] ❌ Failed to write conversation to %s: %v", filePath, err)
-		return
+	if compacted {
+		log.Printf("[LLM Logger] ✅ Async saved conversation %s to %s (%d bytes)",
+			conv.ConversationID, filename, n)
 	}
 
-	log.Printf("[LLM Logger] ✅ Async saved conversation %s to %s (%d bytes)",
-		conv.ConversationID, filename, len(data))
+	if idx := GetSearchIndex(l.amDir); idx != nil {
+		idx.Enqueue(conv, filePath)
+	}
 }
 
 func (l *LLMLogger) saveConversation(conv *LLMConversation) {
-	if l.amDir == "" {
+	storeConversation(l.amDir, conv)
+}
+
+// storeConversation persists conv under amDir with project-based naming and
+// queues it for search indexing.
+func storeConversation(amDir string, conv *LLMConversation) {
+	if amDir == "" {
 		log.Printf("[LLM Logger] ⚠️ saveConversation skipped: amDir is empty")
 		return
 	}
 
-	if err := os.MkdirAll(l.amDir, 0755); err != nil {
-		log.Printf("[LLM Logger] ❌ Failed to create AM dir %s: %v", l.amDir, err)
+	if err := os.MkdirAll(amDir, 0755); err != nil {
+		log.Printf("[LLM Logger] ❌ Failed to create AM dir %s: %v", amDir, err)
 		return
 	}
 
-	filename := l.generateConversationFilename(conv)
-	filePath := filepath.Join(l.amDir, filename)
+	filename := conversationFilename(conv)
+	filePath := filepath.Join(amDir, filename)
 
-	data, err := json.MarshalIndent(conv, "", "  ")
+	n, compacted, err := persistConversation(filePath, conv)
 	if err != nil {
-		log.Printf("[LLM Logger] ❌ Failed to marshal conversation %s: %v", conv.ConversationID, err)
+		log.Printf("[LLM Logger] ❌ Failed to write conversation to %s: %v", filePath, err)
 		return
 	}
 
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		log.Printf("[LLM Logger] ❌ Failed to write conversation to %s: %v", filePath, err)
-		return
+	if compacted {
+		log.Printf("[LLM Logger] ✅ Saved conversation %s to %s (%d bytes, %d turns, %d snapshots)",
+			conv.ConversationID, filename, n, len(conv.Turns), len(conv.ScreenSnapshots))
 	}
 
-	log.Printf("[LLM Logger] ✅ Saved conversation %s to %s (%d bytes, %d turns, %d snapshots)",
-		conv.ConversationID, filename, len(data), len(conv.Turns), len(conv.ScreenSnapshots))
+	// Keep full-text search current without rescanning the AM dir
+	if idx := GetSearchIndex(amDir); idx != nil {
+		idx.Enqueue(conv, filePath)
+	}
 }
 
 // loadConversationsFromDisk loads existing conversations from disk for this tab.
@@ -1315,29 +1432,22 @@ func (l *LLMLogger) loadConversationsFromDisk() {
 	loadedCount := 0
 	incompleteCount := 0
 
-	// MEMORY OPTIMIZATION: Only load recent conversations (last 24 hours)
+	// MEMORY OPTIMIZATION: Only load recent conversations (retention reload window, 24h by default)
 	// Older conversations can be loaded on-demand via GetConversation
-	cutoffTime := time.Now().Add(-24 * time.Hour)
+	cutoffTime := time.Now().Add(-LoadRetentionPolicy(l.amDir).reloadWindow())
 
 	for file := range allFiles {
-		// Check file modification time first to skip old files
-		info, err := os.Stat(file)
-		if err != nil || info.ModTime().Before(cutoffTime) {
+		// Check modification time first to skip old files; an active journal counts
+		if conversationModTime(file).Before(cutoffTime) {
 			continue
 		}
 
-		data, err := os.ReadFile(file)
+		conv, err := readConversationFile(file)
 		if err != nil {
 			log.Printf("[LLM Logger] Failed to read %s: %v", file, err)
 			continue
 		}
 
-		var conv LLMConversation
-		if err := json.Unmarshal(data, &conv); err != nil {
-			log.Printf("[LLM Logger] Failed to unmarshal %s: %v", file, err)
-			continue
-		}
-
 		// Skip conversations that don't belong to this tab
 		if conv.TabID != l.tabID {
 			continue
@@ -1345,7 +1455,7 @@ func (l *LLMLogger) loadConversationsFromDisk() {
 
 		// Only load incomplete conversations or very recent complete ones
 		if !conv.Complete || conv.EndTime.After(cutoffTime) {
-			l.conversations[conv.ConversationID] = &conv
+			l.conversations[conv.ConversationID] = conv
 			loadedCount++
 
 			// Only restore active state for incomplete conversations
@@ -1508,6 +1618,19 @@ func findGitRoot(dir string) string {
 	}
 }
 
+// processWorkingDir returns the working directory of a running process, or "" if
+// it can't be read (no PID, exited, or no procfs).
+func processWorkingDir(pid int) string {
+	if pid <= 0 {
+		return ""
+	}
+	cwd, err := os.Readlink(filepath.Join("/proc", strconv.Itoa(pid), "cwd"))
+	if err != nil {
+		return ""
+	}
+	return cwd
+}
+
 // detectShell attempts to detect the current shell type.
 func detectShell() string {
 	// Check SHELL environment variable
@@ -1608,6 +1731,11 @@ func normalizePath(path string) string {
 // generateConversationFilename creates a filename for a conversation using project-based naming.
 // New format: {project}-conv-{timestamp}-{short-id}.json
 func (l *LLMLogger) generateConversationFilename(conv *LLMConversation) string {
+	return conversationFilename(conv)
+}
+
+// conversationFilename is generateConversationFilename for callers without a logger.
+func conversationFilename(conv *LLMConversation) string {
 	// Detect project from metadata
 	project := "adhoc"
 	if conv.Metadata != nil && conv.Metadata.WorkingDirectory != "" {
@@ -1617,21 +1745,17 @@ func (l *LLMLogger) generateConversationFilename(conv *LLMConversation) string {
 	// Format timestamp as YYYY-MM-DD-HHmm
 	timestamp := conv.StartTime.Format("2006-01-02-1504")
 
-	// Get short ID (first 8 chars of conversation ID)
-	shortID := conv.ConversationID
-	if strings.HasPrefix(shortID, "conv-") {
-		// Remove "conv-" prefix
-		shortID = shortID[5:]
-		// Take first 8 chars if longer
-		if len(shortID) > 8 {
-			shortID = shortID[:8]
-		}
-	} else if len(shortID) > 8 {
-		// No prefix but still long, truncate
+	return fmt.Sprintf("%s-conv-%s-%s.json", project, timestamp, conversationShortID(conv.ConversationID))
+}
+
+// conversationShortID is the part of a conversation ID its filename carries: the
+// first 8 characters after any "conv-" prefix.
+func conversationShortID(convID string) string {
+	shortID := strings.TrimPrefix(convID, "conv-")
+	if len(shortID) > 8 {
 		shortID = shortID[:8]
 	}
-
-	return fmt.Sprintf("%s-conv-%s-%s.json", project, timestamp, shortID)
+	return shortID
 }
 
 // GetProjectName returns the project name for a conversation (derived from metadata).
@@ -1641,3 +1765,15 @@ func (conv *LLMConversation) GetProjectName() string {
 	}
 	return "adhoc"
 }
+
+// GetWorkingDirectory returns where the CLI ran: the process's own working directory
+// when it was tracked, otherwise the directory captured at start.
+func (conv *LLMConversation) GetWorkingDirectory() string {
+	if conv.Metadata == nil {
+		return ""
+	}
+	if conv.Metadata.ProcessCwd != "" {
+		return conv.Metadata.ProcessCwd
+	}
+	return conv.Metadata.WorkingDirectory
+}
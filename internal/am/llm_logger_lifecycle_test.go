@@ -0,0 +1,98 @@
+package am
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func lifecycleLogger(t *testing.T, tracking bool) *LLMLogger {
+	t.Helper()
+	logger := &LLMLogger{
+		tabID:           "tab-lifecycle",
+		conversations:   make(map[string]*LLMConversation),
+		amDir:           t.TempDir(),
+		processTracking: tracking,
+	}
+	logger.conversations["conv-life"] = &LLMConversation{
+		ConversationID: "conv-life",
+		TabID:          "tab-lifecycle",
+		Provider:       "claude",
+		StartTime:      time.Now(),
+		ProcessPID:     4321,
+	}
+	logger.activeConvID = "conv-life"
+	return logger
+}
+
+func TestLLMLogger_PromptHeuristicIsFallbackOnly(t *testing.T) {
+	tracked := lifecycleLogger(t, true)
+	tracked.AddOutput("dev@host:~/project$ ")
+	if tracked.GetActiveConversationID() != "conv-life" {
+		t.Error("Prompt-like output should not end a conversation while its process is tracked")
+	}
+
+	untracked := lifecycleLogger(t, false)
+	untracked.AddOutput("dev@host:~/project$ ")
+	if untracked.GetActiveConversationID() != "" {
+		t.Fatal("Prompt heuristic should end the conversation without process tracking")
+	}
+	if method := untracked.conversations["conv-life"].EndMethod; method != EndPromptHeuristic {
+		t.Errorf("EndMethod = %q, want %q", method, EndPromptHeuristic)
+	}
+}
+
+func TestLLMLogger_EndConversationByRecordsMethod(t *testing.T) {
+	logger := lifecycleLogger(t, true)
+	if pid := logger.ActiveProcessPID(); pid != 4321 {
+		t.Errorf("ActiveProcessPID() = %d, want 4321", pid)
+	}
+
+	logger.EndConversationBy(EndProcessExit)
+	if logger.ActiveProcessPID() != 0 {
+		t.Error("No process should be tracked after the conversation ends")
+	}
+
+	files, _ := filepath.Glob(filepath.Join(logger.amDir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("Expected one saved conversation, got %v", files)
+	}
+	saved, err := readConversationFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !saved.Complete || saved.EndMethod != EndProcessExit {
+		t.Errorf("Saved conversation complete=%v endMethod=%q", saved.Complete, saved.EndMethod)
+	}
+}
+
+func TestFindConversation_ReturnsCopyOfLiveConversation(t *testing.T) {
+	logger := lifecycleLogger(t, true)
+	live := logger.conversations["conv-life"]
+	live.Metadata = &ConversationMetadata{WorkingDirectory: "/work"}
+	live.Turns = append(make([]ConversationTurn, 0, 4), ConversationTurn{Role: "user", Content: "hi"})
+
+	llmLoggersMu.Lock()
+	llmLoggers[logger.tabID] = logger
+	llmLoggersMu.Unlock()
+	defer RemoveLLMLogger(logger.tabID)
+
+	found, err := FindConversation(logger.amDir, "conv-life")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found == live || found.Metadata == live.Metadata {
+		t.Fatal("FindConversation returned the logger's live conversation")
+	}
+
+	// The logger keeps writing to its conversation; the copy must not change
+	logger.mu.Lock()
+	live.Turns = append(live.Turns, ConversationTurn{Role: "assistant", Content: "hello"})
+	live.Turns[0].Content = "edited"
+	live.Metadata.WorkingDirectory = "/elsewhere"
+	logger.mu.Unlock()
+
+	if len(found.Turns) != 1 || found.Turns[0].Content != "hi" || found.Metadata.WorkingDirectory != "/work" {
+		t.Errorf("Copy changed with the live conversation: %+v, %+v", found.Turns, found.Metadata)
+	}
+}
@@ -1,4 +1,5 @@
-// Package am provides session recovery for terminal interruptions.
+// Session recovery for terminal interruptions.
+
 package am
 
 import (
@@ -0,0 +1,393 @@
+// Stuck-loop detection for running LLM agents.
+
+package am
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/storage"
+)
+
+// Loop signal kinds.
+const (
+	LoopSimilarResponses = "similar_responses" // Consecutive responses are near-identical
+	LoopRepeatedCommand  = "repeated_command"  // The same command keeps being run
+	LoopNoFileChanges    = "no_file_changes"   // Commands run for many turns without editing a file
+)
+
+// loopRefireTurns is how many new turns, snapshots or auto-responses a conversation
+// needs before it can be flagged again.
+const loopRefireTurns = 4
+
+const defaultLoopIntervention = "STOP. You appear to be stuck in a loop: you are repeating the same " +
+	"steps without making progress.\n\n1. State in one sentence what keeps failing\n2. State the actual goal\n" +
+	"3. Propose ONE different next step\n4. Wait for my confirmation before proceeding\n\n" +
+	"Do not repeat previous attempts."
+
+// LoopDetectionConfig tunes loop detection. It is read from ~/.forge/loopdetect.json.
+type LoopDetectionConfig struct {
+	Disabled            bool    `json:"disabled,omitempty"`
+	Window              int     `json:"window,omitempty"`              // Recent steps examined
+	SimilarityThreshold float64 `json:"similarityThreshold,omitempty"` // Responses this similar (0-1) count as repeats
+	RepeatThreshold     int     `json:"repeatThreshold,omitempty"`     // Repeats that make a loop
+	StaleTurns          int     `json:"staleTurns,omitempty"`          // Steps of commands without file changes that make a loop
+	Intervention        string  `json:"intervention,omitempty"`        // Prompt pasted into the CLI to break the loop
+	AutoIntervene       bool    `json:"autoIntervene,omitempty"`       // Paste the intervention as soon as a loop is found
+}
+
+// LoopSignal is one piece of evidence that an agent is looping.
+type LoopSignal struct {
+	Kind     string   `json:"kind"`
+	Detail   string   `json:"detail"`
+	Count    int      `json:"count"`
+	Evidence []string `json:"evidence,omitempty"` // Excerpts of the repeated output or commands
+}
+
+// LoopDetection reports a conversation that looks stuck.
+type LoopDetection struct {
+	ConversationID string       `json:"conversationId"`
+	TabID          string       `json:"tabId"`
+	Provider       string       `json:"provider"`
+	Signals        []LoopSignal `json:"signals"`
+	Steps          int          `json:"steps"` // Steps seen when the loop was detected
+	DetectedAt     time.Time    `json:"detectedAt"`
+}
+
+var (
+	loopCommandPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^\s*(?:[●⏺]\s*)?Bash\((.+)\)\s*$`),
+		regexp.MustCompile(`(?m)^\s*\$ (.+)$`),
+	}
+	loopEditPattern = regexp.MustCompile(`(?m)\b(?:Update|Write|Edit|MultiEdit|Create)\(([^)]+)\)|\bApplied edit to (\S+)`)
+	loopDigits      = regexp.MustCompile(`[0-9]+`)
+)
+
+// DefaultLoopDetectionConfig returns the built-in thresholds.
+func DefaultLoopDetectionConfig() *LoopDetectionConfig {
+	c := &LoopDetectionConfig{}
+	c.applyDefaults()
+	return c
+}
+
+// LoadLoopDetectionConfig reads ~/.forge/loopdetect.json, falling back to the
+// defaults when it is missing or invalid.
+func LoadLoopDetectionConfig() *LoopDetectionConfig {
+	c := &LoopDetectionConfig{}
+	data, err := os.ReadFile(storage.GetLoopDetectionConfigPath())
+	if err == nil {
+		if err := json.Unmarshal(data, c); err != nil {
+			log.Printf("[Loop Detector] ⚠️ Invalid %s, using defaults: %v", storage.GetLoopDetectionConfigPath(), err)
+			c = &LoopDetectionConfig{}
+		}
+	}
+	c.applyDefaults()
+	return c
+}
+
+func (c *LoopDetectionConfig) applyDefaults() {
+	if c.Window <= 0 {
+		c.Window = 8
+	}
+	if c.SimilarityThreshold <= 0 || c.SimilarityThreshold > 1 {
+		c.SimilarityThreshold = 0.85
+	}
+	if c.RepeatThreshold < 2 {
+		c.RepeatThreshold = 3
+	}
+	if c.StaleTurns <= 0 {
+		c.StaleTurns = 8
+	}
+	if c.Intervention == "" {
+		c.Intervention = defaultLoopIntervention
+	}
+}
+
+// loopSteps returns the agent's recent output, one entry per step: assistant turns,
+// or screen snapshot diffs for TUI conversations that produced few turns.
+func loopSteps(conv *LLMConversation, window int) []string {
+	var steps []string
+	for _, turn := range conv.Turns {
+		if turn.Role == "assistant" && strings.TrimSpace(turn.Content) != "" {
+			steps = append(steps, turn.Content)
+		}
+	}
+	if len(steps) < window {
+		var diffs []string
+		for _, snap := range conv.ScreenSnapshots {
+			if diff := strings.TrimSpace(snap.DiffFromPrevious); diff != "" {
+				diffs = append(diffs, diff)
+			}
+		}
+		if len(diffs) > len(steps) {
+			steps = diffs
+		}
+	}
+	if len(steps) > window {
+		steps = steps[len(steps)-window:]
+	}
+	return steps
+}
+
+// DetectLoop examines a conversation's recent steps for repetition. It returns nil
+// when the agent appears to be making progress.
+func DetectLoop(conv *LLMConversation, cfg *LoopDetectionConfig) *LoopDetection {
+	if conv == nil || cfg.Disabled {
+		return nil
+	}
+	steps := loopSteps(conv, cfg.Window)
+
+	var signals []LoopSignal
+	if s := similarResponses(steps, cfg); s != nil {
+		signals = append(signals, *s)
+	}
+
+	commands := make([][]string, len(steps))
+	var all []string
+	for i, step := range steps {
+		commands[i] = stepCommands(step)
+		all = append(all, commands[i]...)
+	}
+	// Commands confirmed through auto-respond are commands too
+	for _, rec := range conv.AutoResponses {
+		if rec.Kind == PromptCommand && rec.Subject != "" && rec.Decision == AutoAllow {
+			all = append(all, rec.Subject)
+		}
+	}
+	if s := repeatedCommand(all, cfg.RepeatThreshold); s != nil {
+		signals = append(signals, *s)
+	}
+	if s := noFileChanges(steps, commands, cfg.StaleTurns); s != nil {
+		signals = append(signals, *s)
+	}
+
+	if len(signals) == 0 {
+		return nil
+	}
+	return &LoopDetection{
+		ConversationID: conv.ConversationID,
+		TabID:          conv.TabID,
+		Provider:       conv.Provider,
+		Signals:        signals,
+		Steps:          len(steps),
+		DetectedAt:     time.Now(),
+	}
+}
+
+// similarResponses reports a run of near-identical consecutive steps ending at the
+// latest one.
+func similarResponses(steps []string, cfg *LoopDetectionConfig) *LoopSignal {
+	run := 1
+	lowest := 1.0
+	for i := len(steps) - 1; i > 0; i-- {
+		sim := textSimilarity(steps[i], steps[i-1])
+		if sim < cfg.SimilarityThreshold {
+			break
+		}
+		run++
+		if sim < lowest {
+			lowest = sim
+		}
+	}
+	if run < cfg.RepeatThreshold {
+		return nil
+	}
+	return &LoopSignal{
+		Kind:     LoopSimilarResponses,
+		Detail:   fmt.Sprintf("Last %d responses are at least %.0f%% similar", run, lowest*100),
+		Count:    run,
+		Evidence: []string{truncate(strings.TrimSpace(steps[len(steps)-1]), 200)},
+	}
+}
+
+// repeatedCommand reports the most repeated command if it ran at least threshold times.
+func repeatedCommand(commands []string, threshold int) *LoopSignal {
+	counts := make(map[string]int)
+	for _, cmd := range commands {
+		counts[cmd]++
+	}
+	var top string
+	for cmd, n := range counts {
+		if n > counts[top] || n == counts[top] && cmd < top {
+			top = cmd
+		}
+	}
+	if top == "" || counts[top] < threshold {
+		return nil
+	}
+	return &LoopSignal{
+		Kind:     LoopRepeatedCommand,
+		Detail:   fmt.Sprintf("`%s` ran %d times", truncate(top, 80), counts[top]),
+		Count:    counts[top],
+		Evidence: []string{top},
+	}
+}
+
+// noFileChanges reports an agent that kept running commands over the last stale
+// steps without touching a file.
+func noFileChanges(steps []string, commands [][]string, stale int) *LoopSignal {
+	if len(steps) < stale {
+		return nil
+	}
+	active := 0
+	distinct := make(map[string]bool)
+	for i := len(steps) - stale; i < len(steps); i++ {
+		if loopEditPattern.MatchString(steps[i]) {
+			return nil
+		}
+		if len(commands[i]) > 0 {
+			active++
+		}
+		for _, cmd := range commands[i] {
+			distinct[cmd] = true
+		}
+	}
+	// Exploration runs many different commands; a stuck agent reruns a few
+	if active*2 < stale || len(distinct)*2 > active {
+		return nil
+	}
+	evidence := make([]string, 0, len(distinct))
+	for cmd := range distinct {
+		evidence = append(evidence, cmd)
+	}
+	sort.Strings(evidence)
+	return &LoopSignal{
+		Kind:     LoopNoFileChanges,
+		Detail:   fmt.Sprintf("%d steps ran commands without changing any file", stale),
+		Count:    stale,
+		Evidence: evidence,
+	}
+}
+
+func stepCommands(step string) []string {
+	var cmds []string
+	for _, re := range loopCommandPatterns {
+		for _, m := range re.FindAllStringSubmatch(step, -1) {
+			if cmd := strings.TrimSpace(m[1]); cmd != "" {
+				cmds = append(cmds, cmd)
+			}
+		}
+	}
+	return cmds
+}
+
+// textSimilarity is the Jaccard similarity of the texts' word bigrams, ignoring
+// case and numbers (timings and counters differ between otherwise identical runs).
+func textSimilarity(a, b string) float64 {
+	sa, sb := bigrams(a), bigrams(b)
+	if len(sa) == 0 || len(sb) == 0 {
+		if normalizeWhitespace(strings.ToLower(a)) == normalizeWhitespace(strings.ToLower(b)) {
+			return 1
+		}
+		return 0
+	}
+	shared := 0
+	for g := range sa {
+		if sb[g] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(sa)+len(sb)-shared)
+}
+
+func bigrams(s string) map[string]bool {
+	words := strings.Fields(loopDigits.ReplaceAllString(strings.ToLower(s), "#"))
+	set := make(map[string]bool)
+	for i := 1; i < len(words); i++ {
+		set[words[i-1]+" "+words[i]] = true
+	}
+	return set
+}
+
+// LoopMonitor checks a tab's active conversation for loops, reporting each loop once.
+type LoopMonitor struct {
+	logger *LLMLogger
+
+	mu           sync.Mutex
+	lastConv     string
+	lastProgress int
+}
+
+// NewLoopMonitor creates a monitor for a tab's logger.
+func NewLoopMonitor(logger *LLMLogger) *LoopMonitor {
+	return &LoopMonitor{logger: logger}
+}
+
+// Check returns a new loop in the active conversation, or nil. A conversation is
+// flagged again only after it has produced more steps.
+func (m *LoopMonitor) Check(cfg *LoopDetectionConfig) *LoopDetection {
+	conv, progress := m.logger.activeConversationTail(cfg.Window)
+	if conv == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if conv.ConversationID == m.lastConv && progress < m.lastProgress+loopRefireTurns {
+		return nil
+	}
+
+	detection := DetectLoop(conv, cfg)
+	if detection == nil {
+		return nil
+	}
+	m.lastConv, m.lastProgress = conv.ConversationID, progress
+
+	kinds := make([]string, len(detection.Signals))
+	for i, s := range detection.Signals {
+		kinds[i] = s.Kind
+	}
+	log.Printf("[Loop Detector] ⚠️ Loop detected in %s (%s)", conv.ConversationID, strings.Join(kinds, ", "))
+	EventBus.Publish(&LayerEvent{
+		Type:      TopicLoopDetected,
+		Layer:     2,
+		TabID:     conv.TabID,
+		ConvID:    conv.ConversationID,
+		Provider:  conv.Provider,
+		Timestamp: detection.DetectedAt,
+		Metadata:  map[string]interface{}{"signals": kinds},
+	})
+	return detection
+}
+
+// activeConversationTail copies the active conversation with only its most recent
+// turns, snapshots and auto-responses, enough for loop detection without copying
+// the whole history. progress counts everything the conversation has recorded.
+func (l *LLMLogger) activeConversationTail(window int) (*LLMConversation, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	conv := l.conversations[l.activeConvID]
+	if conv == nil {
+		return nil, 0
+	}
+
+	tail := &LLMConversation{
+		ConversationID: conv.ConversationID,
+		TabID:          conv.TabID,
+		Provider:       conv.Provider,
+	}
+	responses := conv.AutoResponses
+	if len(responses) > window {
+		responses = responses[len(responses)-window:]
+	}
+	tail.AutoResponses = append([]AutoResponseRecord(nil), responses...)
+	// Turns include user and system turns; keep enough to cover window assistant turns
+	turns := conv.Turns
+	if n := 3 * window; len(turns) > n {
+		turns = turns[len(turns)-n:]
+	}
+	tail.Turns = append([]ConversationTurn(nil), turns...)
+	snaps := conv.ScreenSnapshots
+	if len(snaps) > window {
+		snaps = snaps[len(snaps)-window:]
+	}
+	tail.ScreenSnapshots = append([]ScreenSnapshot(nil), snaps...)
+	return tail, len(conv.Turns) + len(conv.ScreenSnapshots) + len(conv.AutoResponses)
+}
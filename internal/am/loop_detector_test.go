@@ -0,0 +1,94 @@
+package am
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func loopConversation(responses ...string) *LLMConversation {
+	conv := &LLMConversation{ConversationID: "conv-loop", TabID: "tab-loop", Provider: "claude", StartTime: time.Now()}
+	for _, r := range responses {
+		conv.Turns = append(conv.Turns,
+			ConversationTurn{Role: "user", Content: "continue"},
+			ConversationTurn{Role: "assistant", Content: r})
+	}
+	return conv
+}
+
+func hasSignal(d *LoopDetection, kind string) bool {
+	if d == nil {
+		return false
+	}
+	for _, s := range d.Signals {
+		if s.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDetectLoop_SimilarResponses(t *testing.T) {
+	cfg := DefaultLoopDetectionConfig()
+	var responses []string
+	for i := 0; i < 3; i++ {
+		responses = append(responses, fmt.Sprintf("The test still fails after %dms. Let me fix the assertion in parser_test.go and run the tests again.", 100+i*37))
+	}
+	d := DetectLoop(loopConversation(responses...), cfg)
+	if !hasSignal(d, LoopSimilarResponses) {
+		t.Fatalf("Expected similar responses, got %+v", d)
+	}
+
+	varied := loopConversation(
+		"I added the parser for the header block.",
+		"Now the tokenizer handles escaped quotes correctly.",
+		"All tests pass; the refactor is complete.",
+	)
+	if d := DetectLoop(varied, cfg); d != nil {
+		t.Errorf("Progressing conversation flagged: %+v", d)
+	}
+}
+
+func TestDetectLoop_RepeatedCommandWithoutEdits(t *testing.T) {
+	cfg := DefaultLoopDetectionConfig()
+	cfg.StaleTurns = 4
+
+	var responses []string
+	for i, note := range []string{"Checking the failure", "Trying once more", "Maybe it was flaky", "Let me look again"} {
+		responses = append(responses, fmt.Sprintf("%s (attempt %d).\n● Bash(go test ./internal/am/...)\n  FAIL", note, i))
+	}
+	d := DetectLoop(loopConversation(responses...), cfg)
+	if !hasSignal(d, LoopRepeatedCommand) || !hasSignal(d, LoopNoFileChanges) {
+		t.Fatalf("Expected repeated command and no file changes, got %+v", d)
+	}
+
+	// An edit in the window means the agent is still changing things
+	responses[2] += "\n● Update(internal/am/journal.go)"
+	if d := DetectLoop(loopConversation(responses...), cfg); hasSignal(d, LoopNoFileChanges) {
+		t.Errorf("Edit ignored: %+v", d)
+	}
+}
+
+func TestLoopMonitor_ReportsOnceUntilProgress(t *testing.T) {
+	logger := lifecycleLogger(t, true)
+	conv := logger.conversations["conv-life"]
+	for i := 0; i < 3; i++ {
+		conv.Turns = append(conv.Turns, ConversationTurn{Role: "assistant", Content: "Running go test again to check the failing case."})
+	}
+
+	monitor := NewLoopMonitor(logger)
+	cfg := DefaultLoopDetectionConfig()
+	if monitor.Check(cfg) == nil {
+		t.Fatal("Expected a loop")
+	}
+	if monitor.Check(cfg) != nil {
+		t.Error("The same loop was reported twice")
+	}
+
+	for i := 0; i < loopRefireTurns; i++ {
+		conv.Turns = append(conv.Turns, ConversationTurn{Role: "assistant", Content: "Running go test again to check the failing case."})
+	}
+	if monitor.Check(cfg) == nil {
+		t.Error("A loop that kept going should be reported again")
+	}
+}
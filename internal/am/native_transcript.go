@@ -0,0 +1,524 @@
+// Importers for the transcripts LLM CLIs write themselves.
+
+package am
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/llm"
+)
+
+// transcriptSlack widens a conversation's time window when matching transcripts,
+// covering clock skew and the gap between detection and the CLI's first write.
+const transcriptSlack = 2 * time.Minute
+
+// nativeImportDelay gives a CLI time to flush its transcript after it exits.
+const nativeImportDelay = 3 * time.Second
+
+// ErrNoTranscript is returned when no native transcript matches a conversation.
+var ErrNoTranscript = errors.New("no matching native transcript")
+
+// NativeTranscript is a CLI's own record of a conversation.
+type NativeTranscript struct {
+	Path  string
+	Turns []ConversationTurn
+}
+
+// TranscriptImporter reads the transcripts one LLM CLI writes.
+type TranscriptImporter interface {
+	// Import returns the transcript of a session run in cwd between start and end,
+	// holding only the turns inside that window, or ErrNoTranscript.
+	Import(cwd string, start, end time.Time) (*NativeTranscript, error)
+}
+
+var (
+	transcriptImportersMu sync.RWMutex
+	transcriptImporters   = map[string]TranscriptImporter{
+		string(llm.ProviderClaude): ClaudeTranscriptImporter{},
+		string(llm.ProviderAider):  AiderTranscriptImporter{},
+	}
+)
+
+// RegisterTranscriptImporter sets the importer for a provider ID, replacing any
+// existing one. A nil importer disables imports for the provider.
+func RegisterTranscriptImporter(provider string, importer TranscriptImporter) {
+	transcriptImportersMu.Lock()
+	defer transcriptImportersMu.Unlock()
+	if importer == nil {
+		delete(transcriptImporters, provider)
+		return
+	}
+	transcriptImporters[provider] = importer
+}
+
+func transcriptImporterFor(provider string) TranscriptImporter {
+	transcriptImportersMu.RLock()
+	defer transcriptImportersMu.RUnlock()
+	return transcriptImporters[provider]
+}
+
+// ImportNativeTranscript finds the CLI's own transcript for a screen-captured
+// conversation and stores it as a separate conversation linked both ways to the
+// original. Importing again refreshes the same linked conversation.
+func ImportNativeTranscript(amDir, convID string) (*LLMConversation, error) {
+	if amDir == "" {
+		amDir = DefaultAMDir()
+	}
+
+	source, err := FindConversation(amDir, convID)
+	if err != nil {
+		return nil, err
+	}
+	conv := copyConversationHeader(source)
+	if linkedConversation(conv, LinkScreenCapture) != "" {
+		return nil, fmt.Errorf("conversation %s is already a native transcript", convID)
+	}
+
+	importer := transcriptImporterFor(conv.Provider)
+	if importer == nil {
+		return nil, fmt.Errorf("no transcript importer for provider %q", conv.Provider)
+	}
+	cwd := conv.GetWorkingDirectory()
+	if cwd == "" {
+		return nil, fmt.Errorf("conversation %s has no working directory", convID)
+	}
+
+	end := conv.EndTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+	transcript, err := importer.Import(cwd, conv.StartTime.Add(-transcriptSlack), end.Add(transcriptSlack))
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := ConversationMetadata{}
+	if conv.Metadata != nil {
+		metadata = *conv.Metadata
+	}
+	metadata.TranscriptPath = transcript.Path
+
+	// StartTime and the ID are fixed by the original, so a re-import overwrites the
+	// same file instead of adding another copy
+	imported := &LLMConversation{
+		ConversationID: nativeConversationID(conv.ConversationID),
+		TabID:          conv.TabID,
+		Provider:       conv.Provider,
+		CommandType:    conv.CommandType,
+		StartTime:      conv.StartTime,
+		EndTime:        conv.EndTime,
+		Turns:          transcript.Turns,
+		Complete:       true,
+		Metadata:       &metadata,
+		Links:          []ConversationLink{{ConversationID: conv.ConversationID, Relation: LinkScreenCapture}},
+	}
+	if imported.EndTime.IsZero() {
+		imported.EndTime = transcript.Turns[len(transcript.Turns)-1].Timestamp
+	}
+	storeConversation(amDir, imported)
+
+	err = updateConversation(amDir, conv.ConversationID, func(c *LLMConversation) {
+		addConversationLink(c, ConversationLink{ConversationID: imported.ConversationID, Relation: LinkNativeTranscript})
+	})
+	if err != nil {
+		return imported, fmt.Errorf("imported transcript but failed to link %s: %w", convID, err)
+	}
+
+	log.Printf("[AM Transcripts] ✅ Imported %d turns for %s from %s",
+		len(imported.Turns), convID, transcript.Path)
+	return imported, nil
+}
+
+// copyConversationHeader copies the fields an import needs from a conversation.
+func copyConversationHeader(conv *LLMConversation) *LLMConversation {
+	header := &LLMConversation{
+		ConversationID: conv.ConversationID,
+		TabID:          conv.TabID,
+		Provider:       conv.Provider,
+		CommandType:    conv.CommandType,
+		StartTime:      conv.StartTime,
+		EndTime:        conv.EndTime,
+		Links:          append([]ConversationLink(nil), conv.Links...),
+	}
+	if conv.Metadata != nil {
+		metadata := *conv.Metadata
+		header.Metadata = &metadata
+	}
+	return header
+}
+
+// nativeConversationID derives the imported conversation's ID from the original's.
+func nativeConversationID(convID string) string {
+	sum := sha1.Sum([]byte(convID + "|" + LinkNativeTranscript))
+	return "conv-" + hex.EncodeToString(sum[:8])
+}
+
+// linkedConversation returns the first conversation linked with relation, or "".
+func linkedConversation(conv *LLMConversation, relation string) string {
+	for _, link := range conv.Links {
+		if link.Relation == relation {
+			return link.ConversationID
+		}
+	}
+	return ""
+}
+
+func addConversationLink(conv *LLMConversation, link ConversationLink) {
+	for _, existing := range conv.Links {
+		if existing == link {
+			return
+		}
+	}
+	conv.Links = append(conv.Links, link)
+}
+
+// updateConversation applies fn to a conversation wherever it lives, in a tab's
+// logger or only on disk, and saves the result.
+func updateConversation(amDir, convID string, fn func(*LLMConversation)) error {
+	llmLoggersMu.RLock()
+	for _, logger := range llmLoggers {
+		logger.mu.Lock()
+		conv, ok := logger.conversations[convID]
+		if ok {
+			fn(conv)
+			logger.saveConversation(conv)
+		}
+		logger.mu.Unlock()
+		if ok {
+			llmLoggersMu.RUnlock()
+			return nil
+		}
+	}
+	llmLoggersMu.RUnlock()
+
+	for _, file := range conversationFiles(amDir) {
+		conv, err := readConversationFile(file)
+		if err != nil || conv.ConversationID != convID {
+			continue
+		}
+		fn(conv)
+		if err := compactConversation(file, conv); err != nil {
+			return err
+		}
+		if idx := GetSearchIndex(amDir); idx != nil {
+			idx.Enqueue(conv, file)
+		}
+		return nil
+	}
+	return fmt.Errorf("conversation not found: %s", convID)
+}
+
+// NativeTranscriptImporter imports native transcripts as conversations end.
+type NativeTranscriptImporter struct {
+	amDir   string
+	delay   time.Duration
+	stopped atomic.Bool
+}
+
+// StartNativeTranscriptImporter imports the native transcript of every conversation
+// whose provider has an importer, shortly after the conversation ends.
+func StartNativeTranscriptImporter(amDir string) *NativeTranscriptImporter {
+	if amDir == "" {
+		amDir = DefaultAMDir()
+	}
+	i := &NativeTranscriptImporter{amDir: amDir, delay: nativeImportDelay}
+	EventBus.Subscribe(i.handleEvent, TopicLLMEnd)
+	return i
+}
+
+// Stop disables further imports.
+func (i *NativeTranscriptImporter) Stop() {
+	i.stopped.Store(true)
+}
+
+func (i *NativeTranscriptImporter) handleEvent(event *LayerEvent) {
+	if i.stopped.Load() || transcriptImporterFor(event.Provider) == nil {
+		return
+	}
+	convID := event.ConvID
+	time.AfterFunc(i.delay, func() {
+		if i.stopped.Load() {
+			return
+		}
+		if _, err := ImportNativeTranscript(i.amDir, convID); err != nil {
+			if errors.Is(err, ErrNoTranscript) {
+				log.Printf("[AM Transcripts] No native transcript for %s", convID)
+			} else {
+				log.Printf("[AM Transcripts] ⚠️ Import for %s failed: %v", convID, err)
+			}
+		}
+	})
+}
+
+// ============================================================================
+// CLAUDE CODE
+// ============================================================================
+
+// ClaudeTranscriptImporter reads Claude Code's JSONL session transcripts from
+// ~/.claude/projects/<cwd with non-alphanumerics as dashes>/<session>.jsonl.
+type ClaudeTranscriptImporter struct {
+	// ConfigDir overrides the Claude config directory ($CLAUDE_CONFIG_DIR or ~/.claude).
+	ConfigDir string
+}
+
+var claudeProjectNameRe = regexp.MustCompile(`[^A-Za-z0-9]`)
+
+type claudeTranscriptEntry struct {
+	Type        string    `json:"type"`
+	Timestamp   time.Time `json:"timestamp"`
+	Cwd         string    `json:"cwd"`
+	IsMeta      bool      `json:"isMeta"`
+	IsSidechain bool      `json:"isSidechain"`
+	Message     struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+// Import picks the session file with the most turns inside the window.
+func (c ClaudeTranscriptImporter) Import(cwd string, start, end time.Time) (*NativeTranscript, error) {
+	configDir := c.ConfigDir
+	if configDir == "" {
+		configDir = os.Getenv("CLAUDE_CONFIG_DIR")
+	}
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		configDir = filepath.Join(home, ".claude")
+	}
+
+	projectDir := filepath.Join(configDir, "projects", claudeProjectNameRe.ReplaceAllString(cwd, "-"))
+	files, _ := filepath.Glob(filepath.Join(projectDir, "*.jsonl"))
+
+	var best *NativeTranscript
+	for _, file := range files {
+		// A session written to before the window can't hold it
+		if info, err := os.Stat(file); err != nil || info.ModTime().Before(start) {
+			continue
+		}
+		turns, err := parseClaudeTranscript(file, cwd, start, end)
+		if err != nil {
+			log.Printf("[AM Transcripts] ⚠️ Skipping %s: %v", file, err)
+			continue
+		}
+		if len(turns) > 0 && (best == nil || len(turns) > len(best.Turns)) {
+			best = &NativeTranscript{Path: file, Turns: turns}
+		}
+	}
+	if best == nil {
+		return nil, ErrNoTranscript
+	}
+	return best, nil
+}
+
+// parseClaudeTranscript converts the user and assistant messages of one session
+// file into turns. Tool calls, tool results, sidechains and meta entries are
+// skipped; consecutive assistant messages (one per content block) are merged.
+func parseClaudeTranscript(path, cwd string, start, end time.Time) ([]ConversationTurn, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var turns []ConversationTurn
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry claudeTranscriptEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if (entry.Type != "user" && entry.Type != "assistant") || entry.IsMeta || entry.IsSidechain {
+			continue
+		}
+		if entry.Cwd != "" && entry.Cwd != cwd {
+			continue
+		}
+		if entry.Timestamp.Before(start) || entry.Timestamp.After(end) {
+			continue
+		}
+
+		text := strings.TrimSpace(claudeContentText(entry.Message.Content))
+		if text == "" || isClaudeCommandEcho(text) {
+			continue
+		}
+		turns = appendNativeTurn(turns, ConversationTurn{
+			Role:      entry.Type,
+			Content:   text,
+			Timestamp: entry.Timestamp,
+			Provider:  string(llm.ProviderClaude),
+		})
+	}
+	return turns, scanner.Err()
+}
+
+// claudeContentText returns the text of a message's content, which is either a
+// string or a list of typed blocks.
+func claudeContentText(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+
+	var blocks []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return ""
+	}
+	var parts []string
+	for _, block := range blocks {
+		if block.Type == "text" && strings.TrimSpace(block.Text) != "" {
+			parts = append(parts, block.Text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// isClaudeCommandEcho reports slash-command bookkeeping Claude Code logs as user
+// messages, e.g. <command-name>/clear</command-name>.
+func isClaudeCommandEcho(text string) bool {
+	return strings.HasPrefix(text, "<command-") || strings.HasPrefix(text, "<local-command-")
+}
+
+// ============================================================================
+// AIDER
+// ============================================================================
+
+// AiderTranscriptImporter reads .aider.chat.history.md, which Aider appends to
+// in the git root it runs in (or the working directory outside a repo).
+type AiderTranscriptImporter struct{}
+
+const aiderHistoryFile = ".aider.chat.history.md"
+
+var aiderSessionRe = regexp.MustCompile(`^# aider chat started at (\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})\s*$`)
+
+// Import returns the last session that started inside the window.
+func (AiderTranscriptImporter) Import(cwd string, start, end time.Time) (*NativeTranscript, error) {
+	var candidates []string
+	if root := findGitRoot(cwd); root != "" {
+		candidates = append(candidates, filepath.Join(root, aiderHistoryFile))
+	}
+	candidates = append(candidates, filepath.Join(cwd, aiderHistoryFile))
+
+	for _, path := range candidates {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		sessions := parseAiderHistory(string(data))
+		sort.SliceStable(sessions, func(a, b int) bool { return sessions[a].started.Before(sessions[b].started) })
+		for i := len(sessions) - 1; i >= 0; i-- {
+			session := sessions[i]
+			if session.started.Before(start) || session.started.After(end) || len(session.turns) == 0 {
+				continue
+			}
+			return &NativeTranscript{Path: path, Turns: session.turns}, nil
+		}
+	}
+	return nil, ErrNoTranscript
+}
+
+type aiderSession struct {
+	started time.Time
+	turns   []ConversationTurn
+}
+
+// parseAiderHistory splits Aider's Markdown history into sessions. "#### " lines
+// are the user's messages, "> " lines are Aider's own tool output, and everything
+// else is the model's reply. The file has no per-message times, so turns carry
+// their session's start time.
+func parseAiderHistory(content string) []aiderSession {
+	var sessions []aiderSession
+	var current *aiderSession
+	var role string
+	var buf []string
+
+	flush := func() {
+		text := strings.TrimSpace(strings.Join(buf, "\n"))
+		buf = nil
+		if current == nil || text == "" {
+			return
+		}
+		current.turns = appendNativeTurn(current.turns, ConversationTurn{
+			Role:      role,
+			Content:   text,
+			Timestamp: current.started,
+			Provider:  string(llm.ProviderAider),
+		})
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if m := aiderSessionRe.FindStringSubmatch(line); m != nil {
+			flush()
+			role = ""
+			started, err := time.ParseInLocation("2006-01-02 15:04:05", m[1], time.Local)
+			if err != nil {
+				current = nil
+				continue
+			}
+			sessions = append(sessions, aiderSession{started: started})
+			current = &sessions[len(sessions)-1]
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "#### ") || line == "####":
+			if role != "user" {
+				flush()
+				role = "user"
+			}
+			buf = append(buf, strings.TrimPrefix(strings.TrimPrefix(line, "####"), " "))
+		case strings.HasPrefix(line, ">"):
+			// Tool output ends the user's message but isn't part of either side
+			if role == "user" {
+				flush()
+				role = ""
+			}
+		default:
+			if role != "assistant" {
+				if strings.TrimSpace(line) == "" {
+					continue
+				}
+				flush()
+				role = "assistant"
+			}
+			buf = append(buf, line)
+		}
+	}
+	flush()
+	return sessions
+}
+
+// appendNativeTurn adds a transcript turn, merging it into the previous turn when
+// both come from the same side.
+func appendNativeTurn(turns []ConversationTurn, turn ConversationTurn) []ConversationTurn {
+	if turn.Role == "" {
+		return turns
+	}
+	turn.CaptureMethod = "native_transcript"
+	turn.ParseConfidence = 1.0
+	if n := len(turns); n > 0 && turns[n-1].Role == turn.Role {
+		turns[n-1].Content += "\n\n" + turn.Content
+		return turns
+	}
+	return append(turns, turn)
+}
@@ -0,0 +1,157 @@
+package am
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClaudeTranscriptImporter_MatchesCwdAndWindow(t *testing.T) {
+	configDir := t.TempDir()
+	cwd := "/home/dev/my.project"
+	projectDir := filepath.Join(configDir, "projects", "-home-dev-my-project")
+	os.MkdirAll(projectDir, 0755)
+
+	session := `{"type":"summary","summary":"Fix tests"}
+{"type":"user","timestamp":"2026-03-01T10:00:00Z","cwd":"/home/dev/my.project","message":{"role":"user","content":"fix the failing test"}}
+{"type":"assistant","timestamp":"2026-03-01T10:00:05Z","cwd":"/home/dev/my.project","message":{"role":"assistant","content":[{"type":"thinking","thinking":"hmm"},{"type":"text","text":"Looking at the test."}]}}
+{"type":"assistant","timestamp":"2026-03-01T10:00:06Z","cwd":"/home/dev/my.project","message":{"role":"assistant","content":[{"type":"tool_use","name":"Bash","input":{}}]}}
+{"type":"user","timestamp":"2026-03-01T10:00:07Z","cwd":"/home/dev/my.project","message":{"role":"user","content":[{"type":"tool_result","content":"ok"}]}}
+{"type":"assistant","timestamp":"2026-03-01T10:00:09Z","cwd":"/home/dev/my.project","message":{"role":"assistant","content":[{"type":"text","text":"Fixed it."}]}}
+{"type":"user","timestamp":"2026-03-01T10:00:10Z","isMeta":true,"message":{"role":"user","content":"Caveat: local commands"}}
+{"type":"user","timestamp":"2026-03-01T10:00:11Z","message":{"role":"user","content":"<command-name>/exit</command-name>"}}
+{"type":"user","timestamp":"2026-03-01T12:00:00Z","message":{"role":"user","content":"later session"}}
+`
+	os.WriteFile(filepath.Join(projectDir, "abc.jsonl"), []byte(session), 0644)
+	os.WriteFile(filepath.Join(projectDir, "other.jsonl"),
+		[]byte(`{"type":"user","timestamp":"2026-03-01T10:00:01Z","message":{"role":"user","content":"hi"}}`+"\n"), 0644)
+
+	start := time.Date(2026, 3, 1, 9, 59, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 1, 10, 5, 0, 0, time.UTC)
+	transcript, err := ClaudeTranscriptImporter{ConfigDir: configDir}.Import(cwd, start, end)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if filepath.Base(transcript.Path) != "abc.jsonl" {
+		t.Errorf("Expected the session with most turns, got %s", transcript.Path)
+	}
+	if len(transcript.Turns) != 2 {
+		t.Fatalf("Expected 2 turns, got %+v", transcript.Turns)
+	}
+	if transcript.Turns[0].Content != "fix the failing test" || transcript.Turns[1].Content != "Looking at the test.\n\nFixed it." {
+		t.Errorf("Unexpected turns: %+v", transcript.Turns)
+	}
+	for _, turn := range transcript.Turns {
+		if turn.CaptureMethod != "native_transcript" || turn.ParseConfidence != 1.0 {
+			t.Errorf("Unexpected capture method %q / confidence %v", turn.CaptureMethod, turn.ParseConfidence)
+		}
+	}
+
+	if _, err := (ClaudeTranscriptImporter{ConfigDir: configDir}).Import("/elsewhere", start, end); err != ErrNoTranscript {
+		t.Errorf("Expected ErrNoTranscript for another cwd, got %v", err)
+	}
+}
+
+func TestParseAiderHistory(t *testing.T) {
+	history := `
+# aider chat started at 2026-03-01 09:00:00
+
+#### old question
+
+old answer
+
+# aider chat started at 2026-03-01 10:00:00
+
+> Aider v0.50.0
+> Model: sonnet
+
+#### add a --verbose flag
+#### to the CLI
+
+I'll add the flag.
+
+` + "```go\nflag.Bool(\"verbose\", false, \"\")\n```" + `
+
+> Applied edit to main.go
+
+#### thanks
+
+You're welcome.
+`
+	sessions := parseAiderHistory(history)
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(sessions))
+	}
+	turns := sessions[1].turns
+	if len(turns) != 4 {
+		t.Fatalf("Expected 4 turns, got %+v", turns)
+	}
+	if turns[0].Role != "user" || turns[0].Content != "add a --verbose flag\nto the CLI" {
+		t.Errorf("Unexpected user turn %+v", turns[0])
+	}
+	if turns[1].Role != "assistant" || !strings.Contains(turns[1].Content, "flag.Bool") || strings.Contains(turns[1].Content, "Applied edit") {
+		t.Errorf("Unexpected assistant turn %q", turns[1].Content)
+	}
+	if turns[3].Content != "You're welcome." {
+		t.Errorf("Unexpected final turn %+v", turns[3])
+	}
+}
+
+func TestImportNativeTranscript_LinksConversations(t *testing.T) {
+	amDir := t.TempDir()
+	cwd := t.TempDir()
+	started := time.Now().Add(-5 * time.Minute).Truncate(time.Second)
+
+	history := "# aider chat started at " + started.Add(10*time.Second).Format("2006-01-02 15:04:05") +
+		"\n\n#### rename foo to bar\n\nDone, renamed foo to bar.\n"
+	os.WriteFile(filepath.Join(cwd, aiderHistoryFile), []byte(history), 0644)
+
+	original := &LLMConversation{
+		ConversationID: "conv-screen",
+		TabID:          "tab-native",
+		Provider:       "aider",
+		StartTime:      started,
+		EndTime:        started.Add(2 * time.Minute),
+		Complete:       true,
+		Metadata:       &ConversationMetadata{WorkingDirectory: "/not/used", ProcessCwd: cwd},
+		Turns:          []ConversationTurn{{Role: "assistant", Content: "Done, renamd fo", ParseConfidence: 0.4}},
+	}
+	storeConversation(amDir, original)
+
+	imported, err := ImportNativeTranscript(amDir, "conv-screen")
+	if err != nil {
+		t.Fatalf("ImportNativeTranscript failed: %v", err)
+	}
+	if len(imported.Turns) != 2 || imported.Metadata.TranscriptPath != filepath.Join(cwd, aiderHistoryFile) {
+		t.Errorf("Unexpected import %+v", imported)
+	}
+	if linkedConversation(imported, LinkScreenCapture) != "conv-screen" {
+		t.Errorf("Import not linked to the original: %+v", imported.Links)
+	}
+
+	reloaded, err := FindConversation(amDir, "conv-screen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if linkedConversation(reloaded, LinkNativeTranscript) != imported.ConversationID {
+		t.Errorf("Original not linked to the import: %+v", reloaded.Links)
+	}
+
+	// Re-importing refreshes the same conversation
+	again, err := ImportNativeTranscript(amDir, "conv-screen")
+	if err != nil || again.ConversationID != imported.ConversationID {
+		t.Fatalf("Re-import = %v, %v", again, err)
+	}
+	if n := len(conversationFiles(amDir)); n != 2 {
+		t.Errorf("Expected 2 conversation files after re-import, got %d", n)
+	}
+	if reloaded, _ := FindConversation(amDir, "conv-screen"); len(reloaded.Links) != 1 {
+		t.Errorf("Link duplicated: %+v", reloaded.Links)
+	}
+
+	if _, err := ImportNativeTranscript(amDir, imported.ConversationID); err == nil {
+		t.Error("Importing an import should fail")
+	}
+}
@@ -1,4 +1,5 @@
-// Package am provides tests for project-based naming functionality.
+// Tests for project-based naming functionality.
+
 package am
 
 import (
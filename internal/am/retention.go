@@ -0,0 +1,519 @@
+// Retention, compression and quotas for stored conversations.
+
+package am
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Retention actions and the reasons behind them.
+const (
+	RetentionCompress = "compress"
+	RetentionDelete   = "delete"
+
+	ReasonAge          = "max_age"
+	ReasonProjectQuota = "project_quota"
+	ReasonTotalSize    = "max_total_size"
+	ReasonCompressAge  = "compress_after"
+)
+
+// retentionFile is the policy file inside the AM directory.
+const retentionFile = "retention.json"
+
+// RetentionPolicy bounds how much conversation history is kept in the AM directory.
+// Zero values disable the corresponding limit.
+type RetentionPolicy struct {
+	MaxAgeDays        int `json:"maxAgeDays"`
+	MaxTotalMB        int `json:"maxTotalMB"`
+	CompressAfterDays int `json:"compressAfterDays"`
+
+	// ProjectQuotaMB caps each project's conversations; "*" applies to projects
+	// without an entry of their own.
+	ProjectQuotaMB map[string]int `json:"projectQuotaMB,omitempty"`
+
+	// Conversations changed within ReloadWindowHours are reloaded into tabs on start
+	// and are never pruned or compressed, since they may still be active.
+	ReloadWindowHours      int `json:"reloadWindowHours"`
+	JanitorIntervalMinutes int `json:"janitorIntervalMinutes"`
+}
+
+// RetentionAction is one file the janitor compresses or deletes.
+type RetentionAction struct {
+	File    string    `json:"file"`
+	Project string    `json:"project"`
+	Action  string    `json:"action"`
+	Reason  string    `json:"reason"`
+	Bytes   int64     `json:"bytes"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// RetentionReport describes one janitor pass, or what a pass would do when DryRun is set.
+type RetentionReport struct {
+	RanAt        time.Time         `json:"ranAt"`
+	DryRun       bool              `json:"dryRun"`
+	Policy       RetentionPolicy   `json:"policy"`
+	Files        int               `json:"files"`
+	TotalBytes   int64             `json:"totalBytes"`
+	ProjectBytes map[string]int64  `json:"projectBytes"`
+	Actions      []RetentionAction `json:"actions"`
+	Compressed   int               `json:"compressed"`
+	Deleted      int               `json:"deleted"`
+	FreedBytes   int64             `json:"freedBytes"`
+	Errors       []string          `json:"errors,omitempty"`
+}
+
+// storedConversation is a conversation file as seen by the janitor.
+type storedConversation struct {
+	path       string
+	project    string
+	bytes      int64
+	modTime    time.Time
+	compressed bool
+	journaled  bool
+}
+
+// DefaultRetentionPolicy returns the policy used when none is configured.
+func DefaultRetentionPolicy() RetentionPolicy {
+	return RetentionPolicy{
+		MaxAgeDays:             90,
+		MaxTotalMB:             1024,
+		CompressAfterDays:      7,
+		ReloadWindowHours:      24,
+		JanitorIntervalMinutes: 360,
+	}
+}
+
+// LoadRetentionPolicy reads the policy for amDir, falling back to defaults.
+func LoadRetentionPolicy(amDir string) RetentionPolicy {
+	if amDir == "" {
+		amDir = DefaultAMDir()
+	}
+
+	policy := DefaultRetentionPolicy()
+	data, err := os.ReadFile(filepath.Join(amDir, retentionFile))
+	if err != nil {
+		return policy
+	}
+	if err := json.Unmarshal(data, &policy); err != nil {
+		log.Printf("[AM Retention] ⚠️ Ignoring invalid %s: %v", retentionFile, err)
+		return DefaultRetentionPolicy()
+	}
+	return policy
+}
+
+// SaveRetentionPolicy validates and stores the policy for amDir.
+func SaveRetentionPolicy(amDir string, policy RetentionPolicy) error {
+	if amDir == "" {
+		amDir = DefaultAMDir()
+	}
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(amDir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(policy, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(amDir, retentionFile), data, 0644)
+}
+
+// Validate rejects negative limits.
+func (p RetentionPolicy) Validate() error {
+	if p.MaxAgeDays < 0 || p.MaxTotalMB < 0 || p.CompressAfterDays < 0 ||
+		p.ReloadWindowHours < 0 || p.JanitorIntervalMinutes < 0 {
+		return fmt.Errorf("retention limits must not be negative")
+	}
+	for project, quota := range p.ProjectQuotaMB {
+		if quota < 0 {
+			return fmt.Errorf("quota for %s must not be negative", project)
+		}
+	}
+	return nil
+}
+
+// reloadWindow is how far back tabs reload conversations, and how long they are protected.
+func (p RetentionPolicy) reloadWindow() time.Duration {
+	if p.ReloadWindowHours <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(p.ReloadWindowHours) * time.Hour
+}
+
+// projectQuota returns the quota for project in bytes, or 0 for none.
+func (p RetentionPolicy) projectQuota(project string) int64 {
+	quota, ok := p.ProjectQuotaMB[project]
+	if !ok {
+		quota = p.ProjectQuotaMB["*"]
+	}
+	return int64(quota) * 1024 * 1024
+}
+
+// PlanRetention reports what applying policy to amDir would do, without changing anything.
+// Deletions are planned against current file sizes, so a real pass that compresses
+// first may delete fewer files.
+func PlanRetention(amDir string, policy RetentionPolicy, now time.Time) (*RetentionReport, error) {
+	if amDir == "" {
+		amDir = DefaultAMDir()
+	}
+
+	stored, err := scanStoredConversations(amDir)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RetentionReport{
+		RanAt:        now,
+		DryRun:       true,
+		Policy:       policy,
+		Files:        len(stored),
+		ProjectBytes: make(map[string]int64),
+		Actions:      []RetentionAction{},
+	}
+	for _, s := range stored {
+		report.TotalBytes += s.bytes
+		report.ProjectBytes[s.project] += s.bytes
+	}
+
+	// Oldest first, so size limits drop the least recent history
+	sort.Slice(stored, func(i, j int) bool { return stored[i].modTime.Before(stored[j].modTime) })
+
+	protectedAfter := now.Add(-policy.reloadWindow())
+	deleted := make(map[string]bool)
+	remove := func(s storedConversation, reason string) {
+		deleted[s.path] = true
+		report.Actions = append(report.Actions, newRetentionAction(amDir, s, RetentionDelete, reason))
+	}
+	prunable := func(s storedConversation) bool {
+		return !deleted[s.path] && !s.journaled && s.modTime.Before(protectedAfter)
+	}
+
+	if policy.MaxAgeDays > 0 {
+		cutoff := now.AddDate(0, 0, -policy.MaxAgeDays)
+		for _, s := range stored {
+			if prunable(s) && s.modTime.Before(cutoff) {
+				remove(s, ReasonAge)
+			}
+		}
+	}
+
+	projectBytes := make(map[string]int64)
+	var totalBytes int64
+	for _, s := range stored {
+		if !deleted[s.path] {
+			projectBytes[s.project] += s.bytes
+			totalBytes += s.bytes
+		}
+	}
+
+	if len(policy.ProjectQuotaMB) > 0 {
+		for _, s := range stored {
+			quota := policy.projectQuota(s.project)
+			if quota > 0 && projectBytes[s.project] > quota && prunable(s) {
+				remove(s, ReasonProjectQuota)
+				projectBytes[s.project] -= s.bytes
+				totalBytes -= s.bytes
+			}
+		}
+	}
+
+	if limit := int64(policy.MaxTotalMB) * 1024 * 1024; limit > 0 {
+		for _, s := range stored {
+			if totalBytes <= limit {
+				break
+			}
+			if prunable(s) {
+				remove(s, ReasonTotalSize)
+				totalBytes -= s.bytes
+			}
+		}
+	}
+
+	if policy.CompressAfterDays > 0 {
+		cutoff := now.AddDate(0, 0, -policy.CompressAfterDays)
+		for _, s := range stored {
+			if prunable(s) && !s.compressed && s.modTime.Before(cutoff) {
+				report.Actions = append(report.Actions, newRetentionAction(amDir, s, RetentionCompress, ReasonCompressAge))
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// ApplyRetention compresses and prunes conversations in amDir according to policy.
+// Compression runs first so size limits see the compressed sizes.
+func ApplyRetention(amDir string, policy RetentionPolicy) (*RetentionReport, error) {
+	if amDir == "" {
+		amDir = DefaultAMDir()
+	}
+
+	now := time.Now()
+	plan, err := PlanRetention(amDir, policy, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []string
+	var actions []RetentionAction
+	compressed := 0
+	var freed int64
+
+	for _, action := range plan.Actions {
+		if action.Action != RetentionCompress {
+			continue
+		}
+		path := filepath.Join(amDir, action.File)
+		size, err := compressConversationFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", action.File, err))
+			continue
+		}
+		forgetJournalState(path)
+		freed += action.Bytes - size
+		compressed++
+		actions = append(actions, action)
+	}
+
+	// Re-plan against the compressed sizes before deleting anything
+	if compressed > 0 {
+		if plan, err = PlanRetention(amDir, policy, now); err != nil {
+			return nil, err
+		}
+	}
+
+	deleted := 0
+	for _, action := range plan.Actions {
+		if action.Action != RetentionDelete {
+			continue
+		}
+		path := filepath.Join(amDir, action.File)
+		// The ID names the annotations sidecar; file names only carry a short form
+		conv, readErr := readConversationFile(path)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, fmt.Sprintf("%s: %v", action.File, err))
+			continue
+		}
+		forgetJournalState(path)
+		if readErr == nil && conv.ConversationID != "" {
+			if err := removeAnnotations(amDir, conv.ConversationID); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: annotations: %v", action.File, err))
+			}
+		}
+		freed += action.Bytes
+		deleted++
+		actions = append(actions, action)
+	}
+
+	report := plan
+	report.RanAt = now
+	report.DryRun = false
+	report.Actions = actions
+	report.Compressed = compressed
+	report.Deleted = deleted
+	report.FreedBytes = freed
+	report.Errors = errs
+	if actions == nil {
+		report.Actions = []RetentionAction{}
+	}
+
+	if compressed+deleted > 0 {
+		if idx := GetSearchIndex(amDir); idx != nil {
+			idx.Rescan()
+		}
+		log.Printf("[AM Retention] Compressed %d and deleted %d conversation(s), freed %d bytes",
+			compressed, deleted, freed)
+	}
+	return report, nil
+}
+
+// scanStoredConversations lists every conversation file with its size and project.
+func scanStoredConversations(amDir string) ([]storedConversation, error) {
+	if _, err := os.Stat(amDir); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var stored []storedConversation
+	for _, path := range conversationFiles(amDir) {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		s := storedConversation{
+			path:       path,
+			project:    projectFromFilename(filepath.Base(path)),
+			bytes:      info.Size(),
+			modTime:    conversationModTime(path),
+			compressed: strings.HasSuffix(path, ".gz"),
+		}
+		if journal, err := os.Stat(journalPath(path)); err == nil {
+			s.bytes += journal.Size()
+			s.journaled = true
+		}
+		stored = append(stored, s)
+	}
+	return stored, nil
+}
+
+func newRetentionAction(amDir string, s storedConversation, action, reason string) RetentionAction {
+	rel, err := filepath.Rel(amDir, s.path)
+	if err != nil {
+		rel = filepath.Base(s.path)
+	}
+	return RetentionAction{
+		File:    rel,
+		Project: s.project,
+		Action:  action,
+		Reason:  reason,
+		Bytes:   s.bytes,
+		ModTime: s.modTime,
+	}
+}
+
+// projectFromFilename recovers the project from "<project>-conv-<timestamp>-<id>.json".
+func projectFromFilename(name string) string {
+	if strings.HasPrefix(name, "llm-conv-") {
+		return "legacy"
+	}
+	if i := strings.Index(name, "-conv-"); i > 0 {
+		return name[:i]
+	}
+	return "adhoc"
+}
+
+// compressConversationFile replaces path with a gzip archive at path+".gz" and returns
+// the archive's size. The original is only removed once the archive is complete.
+func compressConversationFile(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	compressed, err := gzipConversationData(data, filepath.Base(path))
+	if err != nil {
+		return 0, err
+	}
+
+	archive := path + ".gz"
+	tmp := archive + ".tmp"
+	if err := os.WriteFile(tmp, compressed, 0644); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, archive); err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+
+	// Keep the archive's age so it is pruned on the original's schedule
+	if info, err := os.Stat(path); err == nil {
+		os.Chtimes(archive, info.ModTime(), info.ModTime())
+	}
+	if err := os.Remove(path); err != nil {
+		return 0, err
+	}
+	return int64(len(compressed)), nil
+}
+
+// gzipConversationData compresses a conversation file's JSON for an archive; name
+// is the file's original name.
+func gzipConversationData(data []byte, name string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Name = name
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// readConversationData returns the JSON of a conversation file, decompressing archives.
+func readConversationData(path string) ([]byte, error) {
+	if !strings.HasSuffix(path, ".gz") {
+		return os.ReadFile(path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// RetentionJanitor applies the retention policy periodically and reports to the health monitor.
+type RetentionJanitor struct {
+	amDir   string
+	monitor *HealthMonitor
+	stop    chan struct{}
+	once    sync.Once
+}
+
+// StartRetentionJanitor runs a retention pass shortly after start and then every
+// JanitorIntervalMinutes, re-reading the policy each time. monitor may be nil.
+func StartRetentionJanitor(amDir string, monitor *HealthMonitor) *RetentionJanitor {
+	if amDir == "" {
+		amDir = DefaultAMDir()
+	}
+	j := &RetentionJanitor{amDir: amDir, monitor: monitor, stop: make(chan struct{})}
+	go j.run()
+	return j
+}
+
+// Stop ends the janitor. It is safe to call twice.
+func (j *RetentionJanitor) Stop() {
+	j.once.Do(func() { close(j.stop) })
+}
+
+func (j *RetentionJanitor) run() {
+	// Let startup settle before touching the disk
+	timer := time.NewTimer(time.Minute)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-j.stop:
+			return
+		case <-timer.C:
+		}
+
+		policy := LoadRetentionPolicy(j.amDir)
+		report, err := ApplyRetention(j.amDir, policy)
+		if err != nil {
+			log.Printf("[AM Retention] ❌ Janitor pass failed: %v", err)
+			report = &RetentionReport{RanAt: time.Now(), Policy: policy, Errors: []string{err.Error()}}
+		}
+		if j.monitor != nil {
+			j.monitor.RecordRetention(report)
+		}
+
+		interval := time.Duration(policy.JanitorIntervalMinutes) * time.Minute
+		if interval <= 0 {
+			interval = time.Duration(DefaultRetentionPolicy().JanitorIntervalMinutes) * time.Minute
+		}
+		timer.Reset(interval)
+	}
+}
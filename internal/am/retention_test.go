@@ -0,0 +1,201 @@
+package am
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeAgedConversation writes a conversation file of roughly size bytes, last modified age ago.
+func writeAgedConversation(t *testing.T, dir, project, id string, size int, age time.Duration) string {
+	t.Helper()
+	conv := &LLMConversation{
+		ConversationID: id,
+		Provider:       "claude",
+		Complete:       true,
+		Turns:          []ConversationTurn{{Role: "user", Content: strings.Repeat("x", size)}},
+	}
+	path := filepath.Join(dir, project+"-conv-2025-01-01-0000-"+id+".json")
+	if err := compactConversation(path, conv); err != nil {
+		t.Fatal(err)
+	}
+	mod := time.Now().Add(-age)
+	if err := os.Chtimes(path, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func actionsByFile(report *RetentionReport) map[string]string {
+	actions := make(map[string]string)
+	for _, a := range report.Actions {
+		actions[a.File] = a.Action + ":" + a.Reason
+	}
+	return actions
+}
+
+func TestPlanRetention_LimitsAndProtection(t *testing.T) {
+	dir := t.TempDir()
+	day := 24 * time.Hour
+	const mb = 1024 * 1024
+
+	writeAgedConversation(t, dir, "alpha", "ancient", 1000, 100*day)
+	writeAgedConversation(t, dir, "alpha", "old", mb, 20*day)
+	writeAgedConversation(t, dir, "alpha", "newer", mb, 10*day)
+	writeAgedConversation(t, dir, "beta", "big", 2*mb, 30*day)
+	writeAgedConversation(t, dir, "beta", "recent", 2*mb, time.Hour)
+
+	policy := RetentionPolicy{
+		MaxAgeDays:        90,
+		MaxTotalMB:        4,
+		CompressAfterDays: 5,
+		ProjectQuotaMB:    map[string]int{"alpha": 2},
+		ReloadWindowHours: 24,
+	}
+	report, err := PlanRetention(dir, policy, time.Now())
+	if err != nil {
+		t.Fatalf("PlanRetention failed: %v", err)
+	}
+
+	got := actionsByFile(report)
+	want := map[string]string{
+		"alpha-conv-2025-01-01-0000-ancient.json": "delete:" + ReasonAge,
+		"alpha-conv-2025-01-01-0000-old.json":     "delete:" + ReasonProjectQuota,
+		"beta-conv-2025-01-01-0000-big.json":      "delete:" + ReasonTotalSize,
+		"alpha-conv-2025-01-01-0000-newer.json":   "compress:" + ReasonCompressAge,
+	}
+	for file, action := range want {
+		if got[file] != action {
+			t.Errorf("%s: got %q, want %q", file, got[file], action)
+		}
+	}
+	if action, ok := got["beta-conv-2025-01-01-0000-recent.json"]; ok {
+		t.Errorf("Conversation inside the reload window was planned for %s", action)
+	}
+	if report.Files != 5 || report.ProjectBytes["beta"] < 4*mb {
+		t.Errorf("Unexpected usage summary: files=%d projects=%v", report.Files, report.ProjectBytes)
+	}
+
+	// A dry run changes nothing
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(files) != 5 {
+		t.Errorf("Dry run touched files: %d remain", len(files))
+	}
+}
+
+func TestApplyRetention_CompressedArchivesStayReadable(t *testing.T) {
+	dir := t.TempDir()
+	path := writeAgedConversation(t, dir, "alpha", "archived", 5000, 10*24*time.Hour)
+	writeAgedConversation(t, dir, "alpha", "expired", 10, 200*24*time.Hour)
+
+	report, err := ApplyRetention(dir, RetentionPolicy{MaxAgeDays: 90, CompressAfterDays: 7})
+	if err != nil {
+		t.Fatalf("ApplyRetention failed: %v", err)
+	}
+	if report.Compressed != 1 || report.Deleted != 1 || report.FreedBytes <= 0 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Original file should be replaced by its archive")
+	}
+	if _, err := os.Stat(path + ".gz"); err != nil {
+		t.Fatalf("Archive missing: %v", err)
+	}
+
+	conv, err := FindConversation(dir, "archived")
+	if err != nil || len(conv.Turns) != 1 || len(conv.Turns[0].Content) != 5000 {
+		t.Fatalf("Archived conversation not readable: %v", err)
+	}
+	if all, _ := GetAllConversations(dir); len(all) != 1 {
+		t.Errorf("GetAllConversations returned %d conversations, want 1", len(all))
+	}
+
+	// Archives are not compressed again
+	again, _ := PlanRetention(dir, RetentionPolicy{CompressAfterDays: 7}, time.Now())
+	if len(again.Actions) != 0 {
+		t.Errorf("Archive planned again: %+v", again.Actions)
+	}
+}
+
+func TestApplyRetention_RemovesAnnotationsWithConversation(t *testing.T) {
+	dir := t.TempDir()
+	writeAgedConversation(t, dir, "alpha", "expired", 10, 200*24*time.Hour)
+	writeAgedConversation(t, dir, "alpha", "kept", 10, 24*time.Hour)
+	for _, id := range []string{"expired", "kept"} {
+		if _, err := SetAnnotations(dir, id, []string{"review"}, "note"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := ApplyRetention(dir, RetentionPolicy{MaxAgeDays: 90})
+	if err != nil || report.Deleted != 1 {
+		t.Fatalf("ApplyRetention() = %+v, %v; want one deletion", report, err)
+	}
+	if _, err := os.Stat(annotationsPath(dir, "expired")); !os.IsNotExist(err) {
+		t.Error("Annotations of a deleted conversation were left behind")
+	}
+	if _, err := os.Stat(annotationsPath(dir, "kept")); err != nil {
+		t.Errorf("Annotations of a kept conversation were removed: %v", err)
+	}
+}
+
+func TestRetentionPolicy_LoadSave(t *testing.T) {
+	dir := t.TempDir()
+	if got := LoadRetentionPolicy(dir); got.MaxAgeDays != DefaultRetentionPolicy().MaxAgeDays {
+		t.Errorf("Expected defaults without a policy file, got %+v", got)
+	}
+
+	policy := RetentionPolicy{MaxAgeDays: 30, ProjectQuotaMB: map[string]int{"*": 50}}
+	if err := SaveRetentionPolicy(dir, policy); err != nil {
+		t.Fatalf("SaveRetentionPolicy failed: %v", err)
+	}
+	if got := LoadRetentionPolicy(dir); got.MaxAgeDays != 30 || got.projectQuota("anything") != 50*1024*1024 {
+		t.Errorf("Policy not round-tripped: %+v", got)
+	}
+
+	if err := SaveRetentionPolicy(dir, RetentionPolicy{MaxTotalMB: -1}); err == nil {
+		t.Error("Expected negative limits to be rejected")
+	}
+}
+
+func TestApplyRetention_ArchivesStayRestorable(t *testing.T) {
+	dir := t.TempDir()
+	path := writeAgedConversation(t, dir, "alpha", "conv-interrupted", 100, 10*24*time.Hour)
+	conv, _ := readConversationFile(path)
+	conv.Complete = false
+	if err := compactConversation(path, conv); err != nil {
+		t.Fatal(err)
+	}
+	mod := time.Now().Add(-10 * 24 * time.Hour)
+	os.Chtimes(path, mod, mod)
+
+	if _, err := ApplyRetention(dir, RetentionPolicy{CompressAfterDays: 7}); err != nil {
+		t.Fatalf("ApplyRetention failed: %v", err)
+	}
+	archive := path + ".gz"
+	if _, err := os.Stat(archive); err != nil {
+		t.Fatalf("Archive missing: %v", err)
+	}
+
+	cb := NewContextBuilder(dir)
+	if sessions, _ := cb.GetRecoverableSessions(); len(sessions) != 1 || sessions[0].FilePath != archive {
+		t.Fatalf("Archived session not recoverable: %+v", sessions)
+	}
+	if ctx, err := cb.GetRestoreContextByID("conv-interrupted"); err != nil || ctx == nil {
+		t.Fatalf("GetRestoreContextByID failed: %v", err)
+	}
+
+	// Updates rewrite the archive in place, still compressed
+	if err := updateConversation(dir, "conv-interrupted", func(c *LLMConversation) { c.Complete = true }); err != nil {
+		t.Fatalf("updateConversation failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Update should not leave an uncompressed copy")
+	}
+	updated, err := readConversationFile(archive)
+	if err != nil || !updated.Complete {
+		t.Fatalf("Archive not updated: %v", err)
+	}
+}
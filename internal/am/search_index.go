@@ -0,0 +1,844 @@
+// Full-text search over logged LLM conversations.
+
+package am
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// searchIndexVersion is bumped whenever the on-disk format changes; older segments are rebuilt.
+const searchIndexVersion = 2
+
+// searchFlushDelay batches index writes so bursts of saves cost one disk write.
+const searchFlushDelay = 2 * time.Second
+
+// snippetRadius is how many characters of context surround the first match in a snippet.
+const snippetRadius = 80
+
+// SearchFilter narrows a search to conversations and turns matching every set field.
+type SearchFilter struct {
+	Provider string
+	Project  string
+	TabID    string
+	Since    time.Time
+	Until    time.Time
+}
+
+// SearchHit is one matching turn.
+type SearchHit struct {
+	ConversationID string    `json:"conversationId"`
+	TabID          string    `json:"tabId"`
+	Provider       string    `json:"provider"`
+	Project        string    `json:"project"`
+	TurnIndex      int       `json:"turnIndex"`
+	Role           string    `json:"role"`
+	Timestamp      time.Time `json:"timestamp"`
+	Score          int       `json:"score"`
+	Snippet        string    `json:"snippet"` // HTML-escaped, matches wrapped in <mark>
+}
+
+// SearchResults is one page of hits.
+type SearchResults struct {
+	Query  string      `json:"query"`
+	Total  int         `json:"total"`
+	Offset int         `json:"offset"`
+	Limit  int         `json:"limit"`
+	Hits   []SearchHit `json:"hits"`
+}
+
+// posting records where a term occurs in one turn.
+type posting struct {
+	Turn      int   `json:"t"`
+	Positions []int `json:"p"`
+}
+
+// turnKey identifies one turn of one conversation.
+type turnKey struct {
+	conv string
+	turn int
+}
+
+// indexedTurn is the per-turn metadata needed to filter and rank without loading files.
+type indexedTurn struct {
+	Role      string    `json:"role"`
+	Timestamp time.Time `json:"ts"`
+}
+
+// indexedConversation is the per-conversation metadata kept in the index.
+type indexedConversation struct {
+	File      string        `json:"file"`
+	ModTime   time.Time     `json:"modTime"` // file mtime when indexed; a newer file is reindexed
+	TabID     string        `json:"tabId"`
+	Provider  string        `json:"provider"`
+	Project   string        `json:"project"`
+	StartTime time.Time     `json:"startTime"`
+	Turns     []indexedTurn `json:"turns"`
+
+	// Postings are this conversation's share of the inverted index, by term
+	Postings map[string][]posting `json:"postings"`
+}
+
+// searchSegment is the on-disk form of one conversation's entry. Each conversation has
+// its own segment, so a flush only writes the conversations that changed.
+type searchSegment struct {
+	Version      int                  `json:"version"`
+	ID           string               `json:"id"`
+	Conversation *indexedConversation `json:"conversation"`
+}
+
+// SearchIndex is an inverted index over AM conversation turns, persisted under the AM dir.
+// Updates are queued and applied by a background worker so saving a conversation never
+// waits on tokenization or disk writes.
+type SearchIndex struct {
+	amDir string
+	dir   string // Segment files, one per conversation
+
+	mu            sync.RWMutex
+	conversations map[string]*indexedConversation
+	postings      map[string]map[string][]posting // term -> conversation ID -> occurrences
+	dirty         map[string]bool                 // Conversations whose segments are out of date
+
+	pendingMu sync.Mutex
+	pending   map[string]*LLMConversation // latest copy per conversation awaiting indexing
+	files     map[string]string           // conversation file per pending conversation
+	wake      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	idle      sync.Cond
+
+	flushTimer *time.Timer
+}
+
+var (
+	searchIndexes   = make(map[string]*SearchIndex)
+	searchIndexesMu sync.Mutex
+)
+
+// OpenSearchIndex loads the index for amDir, rebuilding it if it is missing or corrupt
+// and reindexing conversations written since it was last saved. The index is registered
+// so that saveConversation keeps it up to date.
+func OpenSearchIndex(amDir string) (*SearchIndex, error) {
+	if amDir == "" {
+		amDir = DefaultAMDir()
+	}
+
+	searchIndexesMu.Lock()
+	if idx, ok := searchIndexes[amDir]; ok {
+		searchIndexesMu.Unlock()
+		return idx, nil
+	}
+	idx := &SearchIndex{
+		amDir:   amDir,
+		dir:     filepath.Join(amDir, "search-index", "segments"),
+		pending: make(map[string]*LLMConversation),
+		files:   make(map[string]string),
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	idx.idle.L = &idx.pendingMu
+	idx.resetLocked()
+	searchIndexes[amDir] = idx
+	searchIndexesMu.Unlock()
+
+	// The single-file index of version 1, replaced by segments
+	os.Remove(filepath.Join(amDir, "search-index", "index.json"))
+
+	// Hold the index lock while loading so early searches see a complete index;
+	// saves that arrive meanwhile queue up and are applied afterwards.
+	idx.mu.Lock()
+	if err := idx.loadLocked(); err != nil {
+		log.Printf("[AM Search] Index unusable (%v), rebuilding", err)
+		os.RemoveAll(idx.dir)
+		idx.resetLocked()
+	}
+	updated := idx.reconcileLocked()
+	idx.mu.Unlock()
+
+	if updated > 0 {
+		if err := idx.Flush(); err != nil {
+			log.Printf("[AM Search] ⚠️ Failed to save index: %v", err)
+		}
+	}
+	log.Printf("[AM Search] Index ready: %d conversations, %d terms (%d reindexed)",
+		len(idx.conversations), len(idx.postings), updated)
+
+	go idx.worker()
+	return idx, nil
+}
+
+// GetSearchIndex returns the open index for amDir, or nil if none has been opened.
+func GetSearchIndex(amDir string) *SearchIndex {
+	if amDir == "" {
+		amDir = DefaultAMDir()
+	}
+	searchIndexesMu.Lock()
+	defer searchIndexesMu.Unlock()
+	return searchIndexes[amDir]
+}
+
+// Close flushes pending updates and unregisters the index. It is safe to call twice.
+func (idx *SearchIndex) Close() error {
+	searchIndexesMu.Lock()
+	if searchIndexes[idx.amDir] == idx {
+		delete(searchIndexes, idx.amDir)
+	}
+	searchIndexesMu.Unlock()
+
+	idx.Wait()
+	idx.closeOnce.Do(func() { close(idx.done) })
+
+	idx.mu.Lock()
+	if idx.flushTimer != nil {
+		idx.flushTimer.Stop()
+		idx.flushTimer = nil
+	}
+	idx.mu.Unlock()
+	return idx.Flush()
+}
+
+// Enqueue schedules conv for (re)indexing. It copies what the index needs, so the
+// caller may keep mutating conv.
+func (idx *SearchIndex) Enqueue(conv *LLMConversation, file string) {
+	snapshot := &LLMConversation{
+		ConversationID: conv.ConversationID,
+		TabID:          conv.TabID,
+		Provider:       conv.Provider,
+		StartTime:      conv.StartTime,
+		Metadata:       conv.Metadata,
+		Turns:          append([]ConversationTurn(nil), conv.Turns...),
+	}
+
+	idx.pendingMu.Lock()
+	idx.pending[conv.ConversationID] = snapshot
+	idx.files[conv.ConversationID] = file
+	idx.pendingMu.Unlock()
+
+	select {
+	case idx.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Wait blocks until every queued update has been applied.
+func (idx *SearchIndex) Wait() {
+	idx.pendingMu.Lock()
+	for len(idx.pending) > 0 {
+		idx.idle.Wait()
+	}
+	idx.pendingMu.Unlock()
+}
+
+// Flush writes the segments of conversations changed since the last flush.
+func (idx *SearchIndex) Flush() error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if len(idx.dirty) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(idx.dir, 0755); err != nil {
+		return err
+	}
+	for id := range idx.dirty {
+		if err := idx.writeSegmentLocked(id); err != nil {
+			return err
+		}
+		delete(idx.dirty, id)
+	}
+	return nil
+}
+
+// writeSegmentLocked writes convID's segment, or removes it if the conversation is
+// no longer indexed.
+func (idx *SearchIndex) writeSegmentLocked(convID string) error {
+	path := filepath.Join(idx.dir, segmentName(convID))
+	entry, ok := idx.conversations[convID]
+	if !ok {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(searchSegment{Version: searchIndexVersion, ID: convID, Conversation: entry})
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// segmentName is the segment file for a conversation; IDs aren't necessarily safe
+// as file names.
+func segmentName(convID string) string {
+	sum := sha256.Sum256([]byte(convID))
+	return hex.EncodeToString(sum[:12]) + ".json"
+}
+
+// Count returns the number of indexed conversations.
+func (idx *SearchIndex) Count() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.conversations)
+}
+
+// Search finds turns containing every term and "quoted phrase" in query.
+func (idx *SearchIndex) Search(query string, filter SearchFilter, offset, limit int) (*SearchResults, error) {
+	clauses := parseQuery(query)
+	if len(clauses) == 0 {
+		return nil, fmt.Errorf("query has no searchable terms")
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	idx.mu.RLock()
+	hits := idx.matchLocked(clauses, filter)
+	idx.mu.RUnlock()
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Timestamp.After(hits[j].Timestamp)
+	})
+
+	results := &SearchResults{Query: query, Total: len(hits), Offset: offset, Limit: limit, Hits: []SearchHit{}}
+	if offset >= len(hits) {
+		return results, nil
+	}
+	end := offset + limit
+	if end > len(hits) {
+		end = len(hits)
+	}
+	results.Hits = hits[offset:end]
+
+	// Snippets need turn text, which is only loaded for the page being returned
+	terms := make(map[string]bool)
+	for _, clause := range clauses {
+		for _, term := range clause {
+			terms[term] = true
+		}
+	}
+	convs := make(map[string]*LLMConversation)
+	for i := range results.Hits {
+		hit := &results.Hits[i]
+		conv, ok := convs[hit.ConversationID]
+		if !ok {
+			conv = idx.loadConversation(hit.ConversationID)
+			convs[hit.ConversationID] = conv
+		}
+		if conv != nil && hit.TurnIndex < len(conv.Turns) {
+			hit.Snippet = buildSnippet(conv.Turns[hit.TurnIndex].Content, terms)
+		}
+	}
+	return results, nil
+}
+
+// matchLocked returns unranked hits for clauses. Each clause is a phrase of one or more
+// terms; a turn matches when it contains every clause.
+func (idx *SearchIndex) matchLocked(clauses [][]string, filter SearchFilter) []SearchHit {
+	var matched map[turnKey]int
+	for _, clause := range clauses {
+		found := make(map[turnKey]int)
+		for k, count := range idx.phraseMatchesLocked(clause) {
+			if matched != nil {
+				if _, ok := matched[k]; !ok {
+					continue
+				}
+			}
+			found[k] = count
+		}
+		if matched == nil {
+			matched = found
+		} else {
+			for k := range matched {
+				if c, ok := found[k]; ok {
+					matched[k] += c
+				} else {
+					delete(matched, k)
+				}
+			}
+		}
+		if len(matched) == 0 {
+			return nil
+		}
+	}
+
+	var hits []SearchHit
+	for key, score := range matched {
+		conv := idx.conversations[key.conv]
+		if conv == nil || key.turn >= len(conv.Turns) {
+			continue
+		}
+		turn := conv.Turns[key.turn]
+		ts := turn.Timestamp
+		if ts.IsZero() {
+			ts = conv.StartTime
+		}
+		if !filter.matches(conv, ts) {
+			continue
+		}
+		hits = append(hits, SearchHit{
+			ConversationID: key.conv,
+			TabID:          conv.TabID,
+			Provider:       conv.Provider,
+			Project:        conv.Project,
+			TurnIndex:      key.turn,
+			Role:           turn.Role,
+			Timestamp:      ts,
+			Score:          score,
+		})
+	}
+	return hits
+}
+
+// phraseMatchesLocked returns, per turn, how often the phrase occurs.
+func (idx *SearchIndex) phraseMatchesLocked(phrase []string) map[turnKey]int {
+	// Positions of the first term, then narrowed by each following term
+	current := make(map[turnKey][]int)
+	for conv, postings := range idx.postings[phrase[0]] {
+		for _, p := range postings {
+			current[turnKey{conv, p.Turn}] = p.Positions
+		}
+	}
+
+	for offset := 1; offset < len(phrase) && len(current) > 0; offset++ {
+		next := make(map[turnKey]map[int]bool)
+		for conv, postings := range idx.postings[phrase[offset]] {
+			for _, p := range postings {
+				k := turnKey{conv, p.Turn}
+				if _, ok := current[k]; !ok {
+					continue
+				}
+				set := make(map[int]bool, len(p.Positions))
+				for _, pos := range p.Positions {
+					set[pos] = true
+				}
+				next[k] = set
+			}
+		}
+
+		narrowed := make(map[turnKey][]int)
+		for k, starts := range current {
+			set, ok := next[k]
+			if !ok {
+				continue
+			}
+			var kept []int
+			for _, start := range starts {
+				if set[start+offset] {
+					kept = append(kept, start)
+				}
+			}
+			if len(kept) > 0 {
+				narrowed[k] = kept
+			}
+		}
+		current = narrowed
+	}
+
+	result := make(map[turnKey]int, len(current))
+	for k, starts := range current {
+		result[k] = len(starts)
+	}
+	return result
+}
+
+// matches reports whether a turn at ts in conv passes the filter.
+func (f SearchFilter) matches(conv *indexedConversation, ts time.Time) bool {
+	if f.Provider != "" && !strings.EqualFold(f.Provider, conv.Provider) {
+		return false
+	}
+	if f.Project != "" && !strings.EqualFold(f.Project, conv.Project) {
+		return false
+	}
+	if f.TabID != "" && f.TabID != conv.TabID {
+		return false
+	}
+	if !f.Since.IsZero() && ts.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && ts.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// worker applies queued updates and schedules flushes.
+func (idx *SearchIndex) worker() {
+	for {
+		select {
+		case <-idx.done:
+			return
+		case <-idx.wake:
+		}
+
+		for {
+			idx.pendingMu.Lock()
+			var conv *LLMConversation
+			var file string
+			for id, c := range idx.pending {
+				conv, file = c, idx.files[id]
+				break
+			}
+			idx.pendingMu.Unlock()
+			if conv == nil {
+				break
+			}
+
+			idx.mu.Lock()
+			idx.indexLocked(conv, file)
+			idx.scheduleFlushLocked()
+			idx.mu.Unlock()
+
+			idx.pendingMu.Lock()
+			// A newer copy may have been queued while we were indexing this one
+			if idx.pending[conv.ConversationID] == conv {
+				delete(idx.pending, conv.ConversationID)
+				delete(idx.files, conv.ConversationID)
+			}
+			if len(idx.pending) == 0 {
+				idx.idle.Broadcast()
+			}
+			idx.pendingMu.Unlock()
+		}
+	}
+}
+
+// scheduleFlushLocked arranges for the index to be written shortly.
+func (idx *SearchIndex) scheduleFlushLocked() {
+	if idx.flushTimer != nil {
+		return
+	}
+	idx.flushTimer = time.AfterFunc(searchFlushDelay, func() {
+		idx.mu.Lock()
+		idx.flushTimer = nil
+		idx.mu.Unlock()
+		if err := idx.Flush(); err != nil {
+			log.Printf("[AM Search] ⚠️ Failed to save index: %v", err)
+		}
+	})
+}
+
+// indexLocked replaces conv's postings with fresh ones.
+func (idx *SearchIndex) indexLocked(conv *LLMConversation, file string) {
+	idx.removeLocked(conv.ConversationID)
+
+	entry := &indexedConversation{
+		File:      file,
+		ModTime:   conversationModTime(file),
+		TabID:     conv.TabID,
+		Provider:  conv.Provider,
+		Project:   conv.GetProjectName(),
+		StartTime: conv.StartTime,
+		Turns:     make([]indexedTurn, len(conv.Turns)),
+		Postings:  make(map[string][]posting),
+	}
+
+	for i, turn := range conv.Turns {
+		entry.Turns[i] = indexedTurn{Role: turn.Role, Timestamp: turn.Timestamp}
+
+		positions := make(map[string][]int)
+		for pos, tok := range tokenize(turn.Content) {
+			positions[tok.term] = append(positions[tok.term], pos)
+		}
+		for term, pos := range positions {
+			entry.Postings[term] = append(entry.Postings[term], posting{Turn: i, Positions: pos})
+		}
+	}
+	idx.addLocked(conv.ConversationID, entry)
+	idx.dirty[conv.ConversationID] = true
+}
+
+// addLocked adds an entry's postings to the inverted index.
+func (idx *SearchIndex) addLocked(convID string, entry *indexedConversation) {
+	for term, postings := range entry.Postings {
+		byConv := idx.postings[term]
+		if byConv == nil {
+			byConv = make(map[string][]posting)
+			idx.postings[term] = byConv
+		}
+		byConv[convID] = postings
+	}
+	idx.conversations[convID] = entry
+}
+
+// removeLocked drops every posting for convID. It touches only the conversation's own
+// terms, so its cost doesn't grow with the rest of the index.
+func (idx *SearchIndex) removeLocked(convID string) {
+	entry, ok := idx.conversations[convID]
+	if !ok {
+		return
+	}
+	for term := range entry.Postings {
+		delete(idx.postings[term], convID)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	delete(idx.conversations, convID)
+	idx.dirty[convID] = true
+}
+
+// resetLocked empties the index.
+func (idx *SearchIndex) resetLocked() {
+	idx.conversations = make(map[string]*indexedConversation)
+	idx.postings = make(map[string]map[string][]posting)
+	idx.dirty = make(map[string]bool)
+}
+
+// loadLocked reads the index segments, failing if the index is missing, or any
+// segment is unreadable, from another version or inconsistent.
+func (idx *SearchIndex) loadLocked() error {
+	entries, err := os.ReadDir(idx.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue // Including .tmp files left by an interrupted flush
+		}
+		data, err := os.ReadFile(filepath.Join(idx.dir, e.Name()))
+		if err != nil {
+			return err
+		}
+
+		var seg searchSegment
+		if err := json.Unmarshal(data, &seg); err != nil {
+			return fmt.Errorf("corrupt segment %s: %w", e.Name(), err)
+		}
+		if seg.Version != searchIndexVersion {
+			return fmt.Errorf("segment version %d, want %d", seg.Version, searchIndexVersion)
+		}
+		if seg.ID == "" || seg.Conversation == nil || e.Name() != segmentName(seg.ID) {
+			return fmt.Errorf("corrupt segment %s", e.Name())
+		}
+		for term, postings := range seg.Conversation.Postings {
+			for _, p := range postings {
+				if p.Turn < 0 || p.Turn >= len(seg.Conversation.Turns) {
+					return fmt.Errorf("corrupt segment %s: dangling posting for %q", e.Name(), term)
+				}
+			}
+		}
+		idx.addLocked(seg.ID, seg.Conversation)
+	}
+	return nil
+}
+
+// reconcileLocked indexes conversation files that changed since they were indexed and
+// drops conversations whose files are gone. It returns how many entries changed.
+func (idx *SearchIndex) reconcileLocked() int {
+	indexedFiles := make(map[string]string) // file -> conversation ID
+	for id, conv := range idx.conversations {
+		indexedFiles[conv.File] = id
+	}
+
+	changed := 0
+	seen := make(map[string]bool)
+	for _, file := range conversationFiles(idx.amDir) {
+		seen[file] = true
+		if id, ok := indexedFiles[file]; ok && idx.conversations[id].ModTime.Equal(conversationModTime(file)) {
+			continue
+		}
+
+		conv, err := readConversationFile(file)
+		if err != nil {
+			continue
+		}
+		idx.indexLocked(conv, file)
+		changed++
+	}
+
+	for file, id := range indexedFiles {
+		// A compressed file is indexed under its new name; don't drop it with the old one
+		if !seen[file] && idx.conversations[id] != nil && idx.conversations[id].File == file {
+			idx.removeLocked(id)
+			changed++
+		}
+	}
+	return changed
+}
+
+// Rescan reconciles the index with the conversation files on disk, picking up files
+// that were compressed or deleted outside the logger. It returns how many entries changed.
+func (idx *SearchIndex) Rescan() int {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	changed := idx.reconcileLocked()
+	if changed > 0 {
+		idx.scheduleFlushLocked()
+	}
+	return changed
+}
+
+// loadConversation returns the current turns of a conversation, preferring live loggers.
+func (idx *SearchIndex) loadConversation(convID string) *LLMConversation {
+	llmLoggersMu.RLock()
+	for _, logger := range llmLoggers {
+		logger.mu.Lock()
+		conv, ok := logger.conversations[convID]
+		var turns []ConversationTurn
+		if ok {
+			turns = append(turns, conv.Turns...)
+		}
+		logger.mu.Unlock()
+		if ok {
+			llmLoggersMu.RUnlock()
+			return &LLMConversation{ConversationID: convID, Turns: turns}
+		}
+	}
+	llmLoggersMu.RUnlock()
+
+	idx.mu.RLock()
+	entry, ok := idx.conversations[convID]
+	idx.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	conv, err := readConversationFile(entry.File)
+	if err != nil {
+		return nil
+	}
+	return conv
+}
+
+// fileModTime returns the modification time of path, or the zero time if it is missing.
+func fileModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// token is a normalized word and its byte range in the source text.
+type token struct {
+	term       string
+	start, end int
+}
+
+// tokenize splits text into lowercase words of letters and digits.
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range text {
+		word := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if word && start < 0 {
+			start = i
+		} else if !word && start >= 0 {
+			tokens = append(tokens, token{strings.ToLower(text[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{strings.ToLower(text[start:]), start, len(text)})
+	}
+	return tokens
+}
+
+// parseQuery splits a query into clauses: each "quoted phrase" is one clause, and every
+// other word is a clause of its own.
+func parseQuery(query string) [][]string {
+	var clauses [][]string
+	parts := strings.Split(query, `"`)
+	for i, part := range parts {
+		var terms []string
+		for _, tok := range tokenize(part) {
+			terms = append(terms, tok.term)
+		}
+		if len(terms) == 0 {
+			continue
+		}
+		if i%2 == 1 {
+			clauses = append(clauses, terms) // inside quotes
+			continue
+		}
+		for _, term := range terms {
+			clauses = append(clauses, []string{term})
+		}
+	}
+	return clauses
+}
+
+// buildSnippet returns an HTML-escaped excerpt around the first match with matches marked.
+func buildSnippet(text string, terms map[string]bool) string {
+	tokens := tokenize(text)
+	first := -1
+	for _, tok := range tokens {
+		if terms[tok.term] {
+			first = tok.start
+			break
+		}
+	}
+	if first < 0 {
+		first = 0
+	}
+
+	start := first - snippetRadius
+	if start < 0 {
+		start = 0
+	}
+	end := first + snippetRadius
+	if end > len(text) {
+		end = len(text)
+	}
+	// Don't cut through a word or a multi-byte character
+	for start > 0 && isWordByte(text, start-1) {
+		start--
+	}
+	for end < len(text) && isWordByte(text, end) {
+		end++
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, tok := range tokens {
+		if tok.start < start || tok.end > end || !terms[tok.term] {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:tok.start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[tok.start:tok.end]))
+		b.WriteString("</mark>")
+		pos = tok.end
+	}
+	b.WriteString(html.EscapeString(text[pos:end]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// isWordByte reports whether the byte at i is part of a word or a multi-byte rune.
+func isWordByte(text string, i int) bool {
+	c := text[i]
+	return c >= 0x80 || c == '_' || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
@@ -0,0 +1,216 @@
+package am
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func searchFixtures(t *testing.T) string {
+	dir := t.TempDir()
+	start := time.Date(2025, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	writeTestConversation(t, dir, &LLMConversation{
+		ConversationID: "conv-one", TabID: "tab-1", Provider: "claude", StartTime: start,
+		Metadata: &ConversationMetadata{WorkingDirectory: "/home/dev/forge"},
+		Turns: []ConversationTurn{
+			{Role: "user", Content: "Why does the websocket reconnect loop?", Timestamp: start},
+			{Role: "assistant", Content: "The reconnect loop happens because the <timer> is never cleared.", Timestamp: start.Add(time.Minute)},
+		},
+	})
+	writeTestConversation(t, dir, &LLMConversation{
+		ConversationID: "conv-two", TabID: "tab-2", Provider: "aider", StartTime: start.Add(24 * time.Hour),
+		Turns: []ConversationTurn{
+			{Role: "user", Content: "loop over the files and reconnect each", Timestamp: start.Add(24 * time.Hour)},
+		},
+	})
+	return dir
+}
+
+func openTestIndex(t *testing.T, dir string) *SearchIndex {
+	t.Helper()
+	idx, err := OpenSearchIndex(dir)
+	if err != nil {
+		t.Fatalf("OpenSearchIndex failed: %v", err)
+	}
+	t.Cleanup(func() { idx.Close() })
+	return idx
+}
+
+func TestSearchIndex_QueriesAndFilters(t *testing.T) {
+	idx := openTestIndex(t, searchFixtures(t))
+
+	tests := []struct {
+		name   string
+		query  string
+		filter SearchFilter
+		want   []string // conversation IDs of hits, any order
+	}{
+		{"single term", "reconnect", SearchFilter{}, []string{"conv-one", "conv-one", "conv-two"}},
+		{"all terms required", "reconnect timer", SearchFilter{}, []string{"conv-one"}},
+		{"phrase", `"reconnect loop"`, SearchFilter{}, []string{"conv-one", "conv-one"}},
+		{"phrase order matters", `"loop reconnect"`, SearchFilter{}, nil},
+		{"provider filter", "reconnect", SearchFilter{Provider: "Aider"}, []string{"conv-two"}},
+		{"project filter", "reconnect", SearchFilter{Project: "forge"}, []string{"conv-one", "conv-one"}},
+		{"tab filter", "loop", SearchFilter{TabID: "tab-2"}, []string{"conv-two"}},
+		{"date filter", "reconnect", SearchFilter{Since: time.Date(2025, 5, 2, 0, 0, 0, 0, time.UTC)}, []string{"conv-two"}},
+	}
+
+	for _, tt := range tests {
+		results, err := idx.Search(tt.query, tt.filter, 0, 10)
+		if err != nil {
+			t.Fatalf("%s: Search failed: %v", tt.name, err)
+		}
+		var got []string
+		for _, hit := range results.Hits {
+			got = append(got, hit.ConversationID)
+		}
+		if results.Total != len(tt.want) || strings.Count(strings.Join(got, ","), "conv-one") != strings.Count(strings.Join(tt.want, ","), "conv-one") {
+			t.Errorf("%s: got %v (total %d), want %v", tt.name, got, results.Total, tt.want)
+		}
+	}
+
+	if _, err := idx.Search(`"" !!`, SearchFilter{}, 0, 10); err == nil {
+		t.Error("Expected error for query without terms")
+	}
+}
+
+func TestSearchIndex_SnippetsAndPagination(t *testing.T) {
+	idx := openTestIndex(t, searchFixtures(t))
+
+	page, err := idx.Search("timer", SearchFilter{}, 0, 10)
+	if err != nil || len(page.Hits) != 1 {
+		t.Fatalf("Expected one hit, got %+v (%v)", page, err)
+	}
+	snippet := page.Hits[0].Snippet
+	if !strings.Contains(snippet, "&lt;<mark>timer</mark>&gt;") {
+		t.Errorf("Snippet not escaped and highlighted: %q", snippet)
+	}
+
+	first, _ := idx.Search("reconnect", SearchFilter{}, 0, 2)
+	second, _ := idx.Search("reconnect", SearchFilter{}, 2, 2)
+	if len(first.Hits) != 2 || len(second.Hits) != 1 || first.Total != 3 {
+		t.Errorf("Pagination wrong: %d + %d of %d", len(first.Hits), len(second.Hits), first.Total)
+	}
+	if empty, _ := idx.Search("reconnect", SearchFilter{}, 10, 2); len(empty.Hits) != 0 {
+		t.Errorf("Expected empty page past the end, got %d hits", len(empty.Hits))
+	}
+}
+
+func TestSearchIndex_IncrementalUpdateFromSave(t *testing.T) {
+	dir := searchFixtures(t)
+	idx := openTestIndex(t, dir)
+
+	logger := &LLMLogger{
+		tabID:         "tab-3",
+		conversations: make(map[string]*LLMConversation),
+		amDir:         dir,
+	}
+	conv := &LLMConversation{
+		ConversationID: "conv-three", TabID: "tab-3", Provider: "copilot", StartTime: time.Now(),
+		Turns: []ConversationTurn{{Role: "user", Content: "kubernetes rollout stuck", Timestamp: time.Now()}},
+	}
+	logger.saveConversation(conv)
+	idx.Wait()
+
+	results, _ := idx.Search("kubernetes", SearchFilter{}, 0, 10)
+	if results.Total != 1 || results.Hits[0].ConversationID != "conv-three" {
+		t.Fatalf("Saved conversation not searchable: %+v", results)
+	}
+
+	// Re-saving replaces old postings rather than adding to them
+	conv.Turns[0].Content = "helm upgrade stuck"
+	logger.saveConversation(conv)
+	idx.Wait()
+	if results, _ := idx.Search("kubernetes", SearchFilter{}, 0, 10); results.Total != 0 {
+		t.Errorf("Stale postings remain after re-save: %+v", results.Hits)
+	}
+	if results, _ := idx.Search("helm", SearchFilter{}, 0, 10); results.Total != 1 {
+		t.Errorf("Updated turn not indexed")
+	}
+}
+
+func TestSearchIndex_RebuildsWhenMissingOrCorrupt(t *testing.T) {
+	dir := searchFixtures(t)
+
+	idx, err := OpenSearchIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	segments, _ := filepath.Glob(filepath.Join(dir, "search-index", "segments", "*.json"))
+	if len(segments) != 2 {
+		t.Fatalf("Expected a segment per conversation, got %v", segments)
+	}
+
+	// A file written while the index was closed is picked up on reopen
+	writeTestConversation(t, dir, &LLMConversation{
+		ConversationID: "conv-late", Provider: "claude", StartTime: time.Now(),
+		Turns: []ConversationTurn{{Role: "user", Content: "terraform drift"}},
+	})
+	idx = openTestIndex(t, dir)
+	if results, _ := idx.Search("terraform", SearchFilter{}, 0, 10); results.Total != 1 {
+		t.Errorf("Conversation written while closed was not indexed")
+	}
+	idx.Close()
+
+	if err := os.WriteFile(segments[0], []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	idx = openTestIndex(t, dir)
+	if idx.Count() != 3 {
+		t.Errorf("Expected rebuild to index 3 conversations, got %d", idx.Count())
+	}
+	if results, _ := idx.Search("websocket", SearchFilter{}, 0, 10); results.Total != 1 {
+		t.Errorf("Rebuilt index cannot find existing content")
+	}
+}
+
+func TestSearchIndex_FlushWritesOnlyChangedSegments(t *testing.T) {
+	dir := searchFixtures(t)
+	idx := openTestIndex(t, dir)
+	if err := idx.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	segments := filepath.Join(dir, "search-index", "segments")
+	one := filepath.Join(segments, segmentName("conv-one"))
+	two := filepath.Join(segments, segmentName("conv-two"))
+
+	// If a flush rewrote every segment, the removed one would come back
+	if err := os.Remove(one); err != nil {
+		t.Fatal(err)
+	}
+	idx.Enqueue(&LLMConversation{
+		ConversationID: "conv-two", TabID: "tab-2", Provider: "aider", StartTime: time.Now(),
+		Turns: []ConversationTurn{{Role: "user", Content: "ansible inventory"}},
+	}, filepath.Join(dir, "test-conv-conv-two.json"))
+	idx.Wait()
+	if err := idx.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(one); !os.IsNotExist(err) {
+		t.Error("Unchanged segment was rewritten")
+	}
+	if data, err := os.ReadFile(two); err != nil || !strings.Contains(string(data), "ansible") {
+		t.Errorf("Changed segment not written: %v", err)
+	}
+
+	// Deleted conversations lose their segment
+	os.Remove(filepath.Join(dir, "test-conv-conv-two.json"))
+	if idx.Rescan() != 1 {
+		t.Fatal("Rescan missed the deleted conversation")
+	}
+	if err := idx.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(two); !os.IsNotExist(err) {
+		t.Error("Segment of a deleted conversation remains")
+	}
+	if results, _ := idx.Search("ansible", SearchFilter{}, 0, 10); results.Total != 0 {
+		t.Errorf("Deleted conversation still found: %+v", results.Hits)
+	}
+}
@@ -1,4 +1,5 @@
-// Package am provides the main AM system orchestration.
+// Package am provides Artificial Memory, the multi-layer capture system that records
+// LLM CLI conversations in terminal sessions so they can be searched, exported and restored.
 package am
 
 import (
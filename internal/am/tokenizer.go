@@ -0,0 +1,89 @@
+// Approximate token counting for LLM usage accounting.
+
+package am
+
+import (
+	"regexp"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tokenizer counts the tokens a provider's model would see in text.
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+// TokenizerFunc adapts a function to the Tokenizer interface.
+type TokenizerFunc func(text string) int
+
+// CountTokens calls f(text).
+func (f TokenizerFunc) CountTokens(text string) int { return f(text) }
+
+var (
+	tokenizersMu sync.RWMutex
+	tokenizers   = make(map[string]Tokenizer)
+)
+
+// RegisterTokenizer sets the tokenizer used for a provider's conversations, or
+// removes it when t is nil. Unregistered providers use HeuristicTokenizer.
+func RegisterTokenizer(provider string, t Tokenizer) {
+	tokenizersMu.Lock()
+	defer tokenizersMu.Unlock()
+	if t == nil {
+		delete(tokenizers, provider)
+		return
+	}
+	tokenizers[provider] = t
+}
+
+// TokenizerFor returns the tokenizer registered for a provider, or the heuristic.
+func TokenizerFor(provider string) Tokenizer {
+	tokenizersMu.RLock()
+	defer tokenizersMu.RUnlock()
+	if t, ok := tokenizers[provider]; ok {
+		return t
+	}
+	return HeuristicTokenizer{}
+}
+
+// pretokenPattern splits text the way byte-pair tokenizers do before merging:
+// contractions, words, digit runs, punctuation runs and whitespace.
+var pretokenPattern = regexp.MustCompile(`'(?:s|t|re|ve|m|ll|d)| ?\pL+| ?\pN+| ?[^\s\pL\pN]+|\s+`)
+
+// HeuristicTokenizer approximates byte-pair encoding without a vocabulary: common
+// English words are about one token per four letters, digits group in threes,
+// punctuation in pairs, and non-Latin scripts cost about a token per character.
+type HeuristicTokenizer struct{}
+
+// CountTokens returns the approximate token count of text.
+func (HeuristicTokenizer) CountTokens(text string) int {
+	tokens := 0
+	for _, piece := range pretokenPattern.FindAllString(text, -1) {
+		r, _ := utf8.DecodeRuneInString(piece)
+		if r == ' ' && len(piece) > 1 {
+			piece = piece[1:] // A leading space merges into the following token
+			r, _ = utf8.DecodeRuneInString(piece)
+		}
+		n := utf8.RuneCountInString(piece)
+		switch {
+		case unicode.IsSpace(r):
+			tokens++
+		case unicode.IsLetter(r):
+			if len(piece) == n { // ASCII
+				tokens += ceilDiv(n, 4)
+			} else {
+				tokens += n
+			}
+		case unicode.IsDigit(r):
+			tokens += ceilDiv(n, 3)
+		default:
+			tokens += ceilDiv(n, 2)
+		}
+	}
+	return tokens
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
@@ -1,4 +1,5 @@
-// Package am provides TUI screen snapshot parsing for conversation recovery.
+// TUI screen snapshot parsing for conversation recovery.
+
 package am
 
 import (
@@ -7,10 +8,16 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/llm"
 )
 
 // parseScreenSnapshotsToTurns extracts conversation turns from TUI screen snapshots.
-// This enables post-crash recovery by analyzing screen diffs.
+// This enables post-crash recovery by analyzing screen diffs. How a screen is read
+// comes from the provider's registered definition:
+//   - providers with response markers are read by marker ("Claude:", "You:")
+//   - other TUI providers are read by layout: prompt line plus content area
+//   - line-based CLIs only yield the user prompts they echo
 func (l *LLMLogger) parseScreenSnapshotsToTurns(snapshots []ScreenSnapshot, provider string) []ConversationTurn {
 	if len(snapshots) == 0 {
 		return []ConversationTurn{}
@@ -18,119 +25,88 @@ func (l *LLMLogger) parseScreenSnapshotsToTurns(snapshots []ScreenSnapshot, prov
 
 	log.Printf("[TUI Parser] Parsing %d snapshots for provider: %s", len(snapshots), provider)
 
-	switch provider {
-	case "github-copilot":
-		return parseCopilotSnapshots(snapshots)
-	case "claude":
-		return parseClaudeSnapshots(snapshots)
-	case "aider":
-		return parseAiderSnapshots(snapshots)
-	default:
+	def := llm.LookupProvider(provider)
+	switch {
+	case def == nil:
 		return parseGenericTUISnapshots(snapshots)
+	case len(def.ResponseMarkers) > 0:
+		return parseMarkedSnapshots(snapshots, def)
+	case def.TUI:
+		return parseLayoutSnapshots(snapshots, def)
+	default:
+		return parseLineSnapshots(snapshots, def)
+	}
+}
+
+// snapshotTurn builds a turn recovered from a screen snapshot.
+func snapshotTurn(role, content string, snapshot ScreenSnapshot, def *llm.ProviderDefinition, confidence float64) ConversationTurn {
+	return ConversationTurn{
+		Role:            role,
+		Content:         content,
+		Timestamp:       snapshot.Timestamp,
+		Provider:        string(def.ID),
+		CaptureMethod:   "tui_snapshot",
+		ParseConfidence: confidence,
 	}
 }
 
-// parseCopilotSnapshots extracts turns from GitHub Copilot CLI TUI snapshots.
-func parseCopilotSnapshots(snapshots []ScreenSnapshot) []ConversationTurn {
+// parseLayoutSnapshots extracts turns from TUIs such as GitHub Copilot CLI, where
+// user input follows a prompt marker and responses fill the main content area.
+func parseLayoutSnapshots(snapshots []ScreenSnapshot, def *llm.ProviderDefinition) []ConversationTurn {
 	turns := []ConversationTurn{}
-	
-	// GitHub Copilot TUI patterns
-	// User input typically appears after ">" prompt
-	// AI responses appear in the main content area
-	
+
 	for i, snapshot := range snapshots {
 		content := snapshot.CleanedContent
-		
-		// Try to identify if this snapshot contains a user prompt
-		userPrompt := extractCopilotUserPrompt(content)
-		if userPrompt != "" {
-			turns = append(turns, ConversationTurn{
-				Role:            "user",
-				Content:         userPrompt,
-				Timestamp:       snapshot.Timestamp,
-				Provider:        "github-copilot",
-				CaptureMethod:   "tui_snapshot",
-				ParseConfidence: 0.7,
-			})
-			log.Printf("[TUI Parser] Copilot turn %d: user prompt detected", i)
+
+		if userPrompt := extractLayoutUserPrompt(content, def); userPrompt != "" {
+			turns = append(turns, snapshotTurn("user", userPrompt, snapshot, def, 0.7))
+			log.Printf("[TUI Parser] %s turn %d: user prompt detected", def.Name, i)
 		}
-		
-		// Try to identify if this snapshot contains an AI response
-		aiResponse := extractCopilotAIResponse(content)
-		if aiResponse != "" {
-			turns = append(turns, ConversationTurn{
-				Role:            "assistant",
-				Content:         aiResponse,
-				Timestamp:       snapshot.Timestamp,
-				Provider:        "github-copilot",
-				CaptureMethod:   "tui_snapshot",
-				ParseConfidence: 0.7,
-			})
-			log.Printf("[TUI Parser] Copilot turn %d: AI response detected", i)
+
+		if aiResponse := extractLayoutAIResponse(content, def); aiResponse != "" {
+			turns = append(turns, snapshotTurn("assistant", aiResponse, snapshot, def, 0.7))
+			log.Printf("[TUI Parser] %s turn %d: AI response detected", def.Name, i)
 		}
 	}
-	
-	log.Printf("[TUI Parser] Extracted %d turns from Copilot snapshots", len(turns))
+
+	log.Printf("[TUI Parser] Extracted %d turns from %s snapshots", len(turns), def.Name)
 	return turns
 }
 
-// extractCopilotUserPrompt tries to extract user input from Copilot TUI.
-func extractCopilotUserPrompt(content string) string {
-	// Look for content after ">" prompt line
-	lines := strings.Split(content, "\n")
-	for i, line := range lines {
+// extractLayoutUserPrompt returns the first non-chrome prompt line of a screen.
+func extractLayoutUserPrompt(content string, def *llm.ProviderDefinition) string {
+	for _, line := range strings.Split(content, "\n") {
 		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, ">") && len(trimmed) > 2 {
-			// Found prompt line, extract content after ">"
-			prompt := strings.TrimSpace(trimmed[1:])
-			if len(prompt) > 3 && !strings.Contains(prompt, "Enter @") {
-				return prompt
-			}
-		}
-		// Alternative: Look for "Question:" or "Prompt:" labels
-		if strings.Contains(trimmed, "Question:") || strings.Contains(trimmed, "Prompt:") {
-			if i+1 < len(lines) {
-				return strings.TrimSpace(lines[i+1])
-			}
+		prompt, ok := def.UserPrompt(trimmed)
+		if ok && len(prompt) > 3 && !def.IsChrome(trimmed) {
+			return prompt
 		}
 	}
 	return ""
 }
 
-// extractCopilotAIResponse tries to extract AI response from Copilot TUI.
-func extractCopilotAIResponse(content string) string {
-	// Copilot responses often appear in the main content area
-	// Look for multi-line content blocks
-	lines := strings.Split(content, "\n")
+// extractLayoutAIResponse joins the content lines of a screen that are neither
+// chrome nor prompts.
+func extractLayoutAIResponse(content string, def *llm.ProviderDefinition) string {
 	var response strings.Builder
-	inResponseBlock := false
-	
-	for _, line := range lines {
+
+	for _, line := range strings.Split(content, "\n") {
 		trimmed := strings.TrimSpace(line)
-		
-		// Skip UI chrome
-		if strings.Contains(trimmed, "────") || 
-		   strings.Contains(trimmed, "Ctrl+") ||
-		   strings.Contains(trimmed, "GitHub Copilot") ||
-		   len(trimmed) == 0 {
+		if len(trimmed) == 0 || def.IsChrome(trimmed) {
 			continue
 		}
-		
-		// Skip prompt lines
-		if strings.HasPrefix(trimmed, ">") && len(trimmed) < 100 {
+		if _, isPrompt := def.UserPrompt(trimmed); isPrompt && len(trimmed) < 100 {
 			continue
 		}
-		
-		// Collect content lines
+
 		if len(trimmed) > 10 && !strings.HasPrefix(trimmed, "│") {
-			if inResponseBlock {
+			if response.Len() > 0 {
 				response.WriteString(" ")
 			}
 			response.WriteString(trimmed)
-			inResponseBlock = true
 		}
 	}
-	
+
 	result := response.String()
 	if len(result) > 20 {
 		return result
@@ -138,74 +114,44 @@ func extractCopilotAIResponse(content string) string {
 	return ""
 }
 
-// parseClaudeSnapshots extracts turns from Claude CLI TUI snapshots.
-func parseClaudeSnapshots(snapshots []ScreenSnapshot) []ConversationTurn {
+// parseMarkedSnapshots extracts turns from TUIs that label speakers, such as Claude CLI.
+func parseMarkedSnapshots(snapshots []ScreenSnapshot, def *llm.ProviderDefinition) []ConversationTurn {
 	turns := []ConversationTurn{}
-	
+
 	for i, snapshot := range snapshots {
 		content := snapshot.CleanedContent
-		
-		// Claude TUI patterns (similar to Copilot but different markers)
-		userPrompt := extractGenericUserPrompt(content, []string{">", "You:", "User:"})
-		if userPrompt != "" {
-			turns = append(turns, ConversationTurn{
-				Role:            "user",
-				Content:         userPrompt,
-				Timestamp:       snapshot.Timestamp,
-				Provider:        "claude",
-				CaptureMethod:   "tui_snapshot",
-				ParseConfidence: 0.7,
-			})
-			log.Printf("[TUI Parser] Claude turn %d: user prompt detected", i)
+
+		if userPrompt := extractGenericUserPrompt(content, def.UserPromptMarkers); userPrompt != "" {
+			turns = append(turns, snapshotTurn("user", userPrompt, snapshot, def, 0.7))
+			log.Printf("[TUI Parser] %s turn %d: user prompt detected", def.Name, i)
 		}
-		
-		aiResponse := extractGenericAIResponse(content, []string{"Claude:", "Assistant:", "AI:"})
-		if aiResponse != "" {
-			turns = append(turns, ConversationTurn{
-				Role:            "assistant",
-				Content:         aiResponse,
-				Timestamp:       snapshot.Timestamp,
-				Provider:        "claude",
-				CaptureMethod:   "tui_snapshot",
-				ParseConfidence: 0.7,
-			})
-			log.Printf("[TUI Parser] Claude turn %d: AI response detected", i)
+
+		if aiResponse := extractGenericAIResponse(content, def.ResponseMarkers); aiResponse != "" {
+			turns = append(turns, snapshotTurn("assistant", aiResponse, snapshot, def, 0.7))
+			log.Printf("[TUI Parser] %s turn %d: AI response detected", def.Name, i)
 		}
 	}
-	
-	log.Printf("[TUI Parser] Extracted %d turns from Claude snapshots", len(turns))
+
+	log.Printf("[TUI Parser] Extracted %d turns from %s snapshots", len(turns), def.Name)
 	return turns
 }
 
-// parseAiderSnapshots extracts turns from Aider CLI (traditional CLI, not TUI).
-func parseAiderSnapshots(snapshots []ScreenSnapshot) []ConversationTurn {
-	// Aider is line-based, not full-screen TUI
-	// But we still capture snapshots, so parse them
+// parseLineSnapshots extracts user turns from line-based CLIs such as Aider, which
+// echo each prompt after a marker and print responses directly.
+func parseLineSnapshots(snapshots []ScreenSnapshot, def *llm.ProviderDefinition) []ConversationTurn {
 	turns := []ConversationTurn{}
-	
+
 	for i, snapshot := range snapshots {
-		content := snapshot.CleanedContent
-		
-		// Aider shows "> " for user input
-		// And shows responses directly
-		lines := strings.Split(content, "\n")
-		for _, line := range lines {
-			trimmed := strings.TrimSpace(line)
-			if strings.HasPrefix(trimmed, "> ") && len(trimmed) > 3 {
-				turns = append(turns, ConversationTurn{
-					Role:            "user",
-					Content:         strings.TrimSpace(trimmed[2:]),
-					Timestamp:       snapshot.Timestamp,
-					Provider:        "aider",
-					CaptureMethod:   "tui_snapshot",
-					ParseConfidence: 0.9,
-				})
+		for _, line := range strings.Split(snapshot.CleanedContent, "\n") {
+			prompt, ok := def.UserPrompt(strings.TrimSpace(line))
+			if ok && prompt != "" {
+				turns = append(turns, snapshotTurn("user", prompt, snapshot, def, 0.9))
 			}
 		}
-		
-		log.Printf("[TUI Parser] Aider snapshot %d: parsed %d turns", i, len(turns))
+
+		log.Printf("[TUI Parser] %s snapshot %d: parsed %d turns", def.Name, i, len(turns))
 	}
-	
+
 	return turns
 }
 
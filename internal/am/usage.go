@@ -0,0 +1,409 @@
+// Token and cost accounting for LLM conversations.
+
+package am
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/llm"
+	"github.com/mikejsmith1985/forge-terminal/internal/storage"
+)
+
+// Usage report intervals.
+const (
+	UsageHourly = "hour"
+	UsageDaily  = "day"
+	UsageWeekly = "week"
+)
+
+// Usage report groupings.
+const (
+	UsageByProvider     = "provider"
+	UsageByProject      = "project"
+	UsageByConversation = "conversation"
+)
+
+const (
+	defaultUsageWindow    = 30 * 24 * time.Hour
+	maxUsageBuckets       = 2000 // Larger empty ranges aren't zero-filled
+	topUsageConversations = 20
+)
+
+// Pricing is what a provider charges per million tokens.
+type Pricing struct {
+	InputPerMillion  float64 `json:"inputPerMillion"`
+	OutputPerMillion float64 `json:"outputPerMillion"`
+}
+
+// PricingTable holds per-provider prices, keyed by provider ID or alias.
+type PricingTable struct {
+	Currency  string             `json:"currency,omitempty"`
+	Providers map[string]Pricing `json:"providers"`
+	Source    string             `json:"source,omitempty"` // File the table was loaded from, or "builtin"
+}
+
+// DefaultPricingTable prices Claude at Sonnet list rates. Copilot is billed per
+// seat and Aider by whichever model it's pointed at, so neither has a default.
+func DefaultPricingTable() *PricingTable {
+	return &PricingTable{
+		Currency: "USD",
+		Providers: map[string]Pricing{
+			string(llm.ProviderClaude): {InputPerMillion: 3, OutputPerMillion: 15},
+		},
+		Source: "builtin",
+	}
+}
+
+// LoadPricingTable returns the built-in prices overlaid with ~/.forge/pricing.json.
+func LoadPricingTable() *PricingTable {
+	table := DefaultPricingTable()
+	path := storage.GetPricingConfigPath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return table
+	}
+
+	var file PricingTable
+	if err := json.Unmarshal(data, &file); err != nil {
+		log.Printf("[AM Usage] ⚠️ Invalid %s, using built-in prices: %v", path, err)
+		return table
+	}
+	if file.Currency != "" {
+		table.Currency = file.Currency
+	}
+	for provider, price := range file.Providers {
+		table.Providers[providerKey(provider)] = price
+	}
+	table.Source = path
+	return table
+}
+
+// For returns a provider's prices.
+func (t *PricingTable) For(provider string) (Pricing, bool) {
+	if p, ok := t.Providers[providerKey(provider)]; ok {
+		return p, true
+	}
+	p, ok := t.Providers[provider]
+	return p, ok
+}
+
+// providerKey resolves aliases ("copilot") to provider IDs ("github-copilot").
+func providerKey(name string) string {
+	if def := llm.LookupProvider(name); def != nil {
+		return string(def.ID)
+	}
+	return name
+}
+
+// TokenUsage is a token count and what it cost.
+type TokenUsage struct {
+	InputTokens  int     `json:"inputTokens"`
+	OutputTokens int     `json:"outputTokens"`
+	Cost         float64 `json:"cost"`
+}
+
+func (u *TokenUsage) add(o TokenUsage) {
+	u.InputTokens += o.InputTokens
+	u.OutputTokens += o.OutputTokens
+	u.Cost += o.Cost
+}
+
+// TurnUsage is the approximate usage of one conversation turn.
+type TurnUsage struct {
+	Index     int       `json:"index"`
+	Role      string    `json:"role"`
+	Timestamp time.Time `json:"timestamp"`
+	TokenUsage
+}
+
+// ConversationUsage rolls up a conversation's turns.
+type ConversationUsage struct {
+	ConversationID string    `json:"conversationId"`
+	Provider       string    `json:"provider"`
+	Project        string    `json:"project"`
+	StartTime      time.Time `json:"startTime"`
+	TokenUsage
+	Priced bool        `json:"priced"` // False when the provider has no prices; Cost is then 0
+	Turns  []TurnUsage `json:"turns,omitempty"`
+}
+
+// ComputeUsage counts a conversation's tokens with its provider's tokenizer and
+// prices them. User turns are input and assistant turns output; Forge's own
+// system notes aren't sent to the model and are skipped. Counts are estimates:
+// the context a CLI resends with each request isn't visible in the terminal.
+func ComputeUsage(conv *LLMConversation, pricing *PricingTable) *ConversationUsage {
+	usage := &ConversationUsage{
+		ConversationID: conv.ConversationID,
+		Provider:       conv.Provider,
+		Project:        conv.GetProjectName(),
+		StartTime:      conv.StartTime,
+	}
+	price, priced := pricing.For(conv.Provider)
+	usage.Priced = priced
+	tokenizer := TokenizerFor(providerKey(conv.Provider))
+
+	for i, turn := range conv.Turns {
+		if turn.Role == "system" {
+			continue
+		}
+		tokens := tokenizer.CountTokens(turn.Content)
+		tu := TurnUsage{Index: i, Role: turn.Role, Timestamp: turn.Timestamp}
+		if tu.Timestamp.IsZero() {
+			tu.Timestamp = conv.StartTime
+		}
+		if turn.Role == "assistant" {
+			tu.OutputTokens = tokens
+			tu.Cost = float64(tokens) * price.OutputPerMillion / 1e6
+		} else {
+			tu.InputTokens = tokens
+			tu.Cost = float64(tokens) * price.InputPerMillion / 1e6
+		}
+		usage.add(tu.TokenUsage)
+		usage.Turns = append(usage.Turns, tu)
+	}
+	return usage
+}
+
+// UsageQuery selects and shapes a usage report. Zero values mean the last 30
+// days, daily buckets, no grouping and no filters.
+type UsageQuery struct {
+	Since    time.Time
+	Until    time.Time
+	Interval string // hour, day or week
+	GroupBy  string // provider, project, conversation, or "" for totals
+	Provider string
+	Project  string
+}
+
+// UsagePoint is one bucket of a usage time series.
+type UsagePoint struct {
+	Time time.Time `json:"time"`
+	Key  string    `json:"key,omitempty"` // Group, when the series is grouped
+	TokenUsage
+}
+
+// UsageGroup is the usage of one provider, project, day or conversation.
+type UsageGroup struct {
+	Key           string `json:"key"`
+	Conversations int    `json:"conversations"`
+	TokenUsage
+}
+
+// UsageReport rolls usage up over a time range.
+type UsageReport struct {
+	Since         time.Time    `json:"since"`
+	Until         time.Time    `json:"until"`
+	Interval      string       `json:"interval"`
+	GroupBy       string       `json:"groupBy,omitempty"`
+	Currency      string       `json:"currency"`
+	Pricing       string       `json:"pricing"` // Where prices came from
+	Total         TokenUsage   `json:"total"`
+	Conversations int          `json:"conversations"`
+	Unpriced      []string     `json:"unpriced,omitempty"` // Providers with usage but no prices
+	Series        []UsagePoint `json:"series"`
+	ByProvider    []UsageGroup `json:"byProvider"`
+	ByProject     []UsageGroup `json:"byProject"`
+	ByDay         []UsageGroup `json:"byDay"`
+	Top           []UsageGroup `json:"topConversations"` // Most expensive conversations
+}
+
+// ValidateUsageQuery fills in defaults and rejects unknown intervals or groupings.
+func ValidateUsageQuery(q *UsageQuery) error {
+	if q.Until.IsZero() {
+		q.Until = time.Now()
+	}
+	if q.Since.IsZero() {
+		q.Since = q.Until.Add(-defaultUsageWindow)
+	}
+	if !q.Since.Before(q.Until) {
+		return fmt.Errorf("since must be before until")
+	}
+	switch q.Interval {
+	case "":
+		q.Interval = UsageDaily
+	case UsageHourly, UsageDaily, UsageWeekly:
+	default:
+		return fmt.Errorf("unsupported interval %q (use hour, day or week)", q.Interval)
+	}
+	switch q.GroupBy {
+	case "", UsageByProvider, UsageByProject, UsageByConversation:
+	default:
+		return fmt.Errorf("unsupported groupBy %q (use provider, project or conversation)", q.GroupBy)
+	}
+	return nil
+}
+
+// BuildUsageReport rolls up the usage of every conversation in amDir that had
+// turns in the query's range. Turns are bucketed by their own timestamps, in UTC.
+func BuildUsageReport(amDir string, q UsageQuery) (*UsageReport, error) {
+	if err := ValidateUsageQuery(&q); err != nil {
+		return nil, err
+	}
+	conversations, err := GetAllConversations(amDir)
+	if err != nil {
+		return nil, err
+	}
+	pricing := LoadPricingTable()
+
+	report := &UsageReport{
+		Since:    q.Since,
+		Until:    q.Until,
+		Interval: q.Interval,
+		GroupBy:  q.GroupBy,
+		Currency: pricing.Currency,
+		Pricing:  pricing.Source,
+	}
+	type pointKey struct {
+		t   time.Time
+		key string
+	}
+	points := make(map[pointKey]*TokenUsage)
+	groups := map[string]map[string]*UsageGroup{"provider": {}, "project": {}, "day": {}, "conversation": {}}
+	unpriced := make(map[string]bool)
+
+	addGroup := func(dim, key string, u TokenUsage, first bool) {
+		g := groups[dim][key]
+		if g == nil {
+			g = &UsageGroup{Key: key}
+			groups[dim][key] = g
+		}
+		g.add(u)
+		if first {
+			g.Conversations++
+		}
+	}
+
+	for _, conv := range conversations {
+		if q.Provider != "" && providerKey(conv.Provider) != providerKey(q.Provider) {
+			continue
+		}
+		usage := ComputeUsage(conv, pricing)
+		if q.Project != "" && usage.Project != q.Project {
+			continue
+		}
+
+		counted := false
+		days := make(map[string]bool)
+		for _, turn := range usage.Turns {
+			if turn.Timestamp.Before(q.Since) || !turn.Timestamp.Before(q.Until) {
+				continue
+			}
+			key := ""
+			switch q.GroupBy {
+			case UsageByProvider:
+				key = conv.Provider
+			case UsageByProject:
+				key = usage.Project
+			case UsageByConversation:
+				key = conv.ConversationID
+			}
+			pk := pointKey{usageBucket(turn.Timestamp, q.Interval), key}
+			if points[pk] == nil {
+				points[pk] = &TokenUsage{}
+			}
+			points[pk].add(turn.TokenUsage)
+
+			day := turn.Timestamp.UTC().Format("2006-01-02")
+			addGroup("provider", conv.Provider, turn.TokenUsage, !counted)
+			addGroup("project", usage.Project, turn.TokenUsage, !counted)
+			addGroup("conversation", conv.ConversationID, turn.TokenUsage, !counted)
+			addGroup("day", day, turn.TokenUsage, !days[day])
+			days[day] = true
+
+			report.Total.add(turn.TokenUsage)
+			counted = true
+		}
+		if counted {
+			report.Conversations++
+			if !usage.Priced {
+				unpriced[conv.Provider] = true
+			}
+		}
+	}
+
+	// Ungrouped series are zero-filled so charts get evenly spaced points
+	if q.GroupBy == "" {
+		start, end := usageBucket(q.Since, q.Interval), q.Until
+		for t, n := start, 0; t.Before(end) && n < maxUsageBuckets; t, n = nextUsageBucket(t, q.Interval), n+1 {
+			if points[pointKey{t, ""}] == nil {
+				points[pointKey{t, ""}] = &TokenUsage{}
+			}
+		}
+	}
+	report.Series = make([]UsagePoint, 0, len(points))
+	for pk, u := range points {
+		report.Series = append(report.Series, UsagePoint{Time: pk.t, Key: pk.key, TokenUsage: *u})
+	}
+	sort.Slice(report.Series, func(i, j int) bool {
+		a, b := report.Series[i], report.Series[j]
+		if !a.Time.Equal(b.Time) {
+			return a.Time.Before(b.Time)
+		}
+		return a.Key < b.Key
+	})
+
+	report.ByProvider = sortedUsageGroups(groups["provider"], false)
+	report.ByProject = sortedUsageGroups(groups["project"], false)
+	report.ByDay = sortedUsageGroups(groups["day"], true)
+	report.Top = sortedUsageGroups(groups["conversation"], false)
+	if len(report.Top) > topUsageConversations {
+		report.Top = report.Top[:topUsageConversations]
+	}
+	for provider := range unpriced {
+		report.Unpriced = append(report.Unpriced, provider)
+	}
+	sort.Strings(report.Unpriced)
+	return report, nil
+}
+
+// sortedUsageGroups orders groups by key, or by cost then tokens, highest first.
+func sortedUsageGroups(groups map[string]*UsageGroup, byKey bool) []UsageGroup {
+	out := make([]UsageGroup, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if byKey {
+			return a.Key < b.Key
+		}
+		if a.Cost != b.Cost {
+			return a.Cost > b.Cost
+		}
+		if ta, tb := a.InputTokens+a.OutputTokens, b.InputTokens+b.OutputTokens; ta != tb {
+			return ta > tb
+		}
+		return a.Key < b.Key
+	})
+	return out
+}
+
+// usageBucket returns the start of the UTC bucket containing t. Weeks start on Monday.
+func usageBucket(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	switch interval {
+	case UsageHourly:
+		return t.Truncate(time.Hour)
+	case UsageWeekly:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+func nextUsageBucket(t time.Time, interval string) time.Time {
+	switch interval {
+	case UsageHourly:
+		return t.Add(time.Hour)
+	case UsageWeekly:
+		return t.AddDate(0, 0, 7)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
@@ -0,0 +1,133 @@
+package am
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHeuristicTokenizer(t *testing.T) {
+	tok := HeuristicTokenizer{}
+	tests := []struct {
+		text     string
+		min, max int
+	}{
+		{"", 0, 0},
+		{"hello", 1, 2},
+		{"The quick brown fox jumps over the lazy dog.", 9, 14},
+		{"func main() { fmt.Println(12345) }", 10, 20},
+		{"日本語のテキスト", 6, 10},
+	}
+	for _, tc := range tests {
+		if got := tok.CountTokens(tc.text); got < tc.min || got > tc.max {
+			t.Errorf("CountTokens(%q) = %d, want %d-%d", tc.text, got, tc.min, tc.max)
+		}
+	}
+
+	long := strings.Repeat("refactor the parser and update the tests ", 100)
+	if got := tok.CountTokens(long); got < 600 || got > 1200 {
+		t.Errorf("Long text counted as %d tokens", got)
+	}
+}
+
+func TestComputeUsage_TokenizerAndPricing(t *testing.T) {
+	RegisterTokenizer("claude", TokenizerFunc(func(text string) int { return len(text) }))
+	defer RegisterTokenizer("claude", nil)
+
+	conv := &LLMConversation{
+		ConversationID: "conv-usage",
+		Provider:       "claude",
+		StartTime:      time.Now(),
+		Turns: []ConversationTurn{
+			{Role: "system", Content: "LLM process started"},
+			{Role: "user", Content: "0123456789"},
+			{Role: "assistant", Content: "01234567890123456789"},
+		},
+	}
+	pricing := &PricingTable{Providers: map[string]Pricing{"claude": {InputPerMillion: 1e6, OutputPerMillion: 2e6}}}
+	u := ComputeUsage(conv, pricing)
+	if u.InputTokens != 10 || u.OutputTokens != 20 || len(u.Turns) != 2 {
+		t.Errorf("Unexpected usage %+v", u)
+	}
+	if !u.Priced || math.Abs(u.Cost-50) > 1e-9 {
+		t.Errorf("Cost = %v (priced %v), want 50", u.Cost, u.Priced)
+	}
+
+	conv.Provider = "aider"
+	if u := ComputeUsage(conv, pricing); u.Priced || u.Cost != 0 || u.InputTokens == 0 {
+		t.Errorf("Unpriced provider: %+v", u)
+	}
+}
+
+func TestBuildUsageReport(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	forgeDir := filepath.Join(os.Getenv("HOME"), ".forge")
+	os.MkdirAll(forgeDir, 0755)
+	os.WriteFile(filepath.Join(forgeDir, "pricing.json"),
+		[]byte(`{"currency":"EUR","providers":{"copilot":{"inputPerMillion":1000000,"outputPerMillion":1000000}}}`), 0644)
+	RegisterTokenizer("claude", TokenizerFunc(func(string) int { return 1 }))
+	RegisterTokenizer("github-copilot", TokenizerFunc(func(string) int { return 1 }))
+	defer RegisterTokenizer("claude", nil)
+	defer RegisterTokenizer("github-copilot", nil)
+
+	amDir := t.TempDir()
+	day1 := time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	save := func(id, provider, dir string, times ...time.Time) {
+		conv := &LLMConversation{ConversationID: id, Provider: provider, StartTime: times[0], Complete: true,
+			Metadata: &ConversationMetadata{WorkingDirectory: dir}}
+		for i, ts := range times {
+			role := "user"
+			if i%2 == 1 {
+				role = "assistant"
+			}
+			conv.Turns = append(conv.Turns, ConversationTurn{Role: role, Content: "x", Timestamp: ts})
+		}
+		storeConversation(amDir, conv)
+	}
+	save("conv-a", "claude", "/work/alpha", day1, day1.Add(time.Minute))
+	save("conv-b", "github-copilot", "/work/beta", day1, day2, day2.Add(time.Minute))
+	save("conv-old", "claude", "/work/alpha", day1.AddDate(0, -2, 0))
+
+	q := UsageQuery{Since: day1, Until: day1.AddDate(0, 0, 3)}
+	report, err := BuildUsageReport(amDir, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Currency != "EUR" || report.Conversations != 2 {
+		t.Errorf("Unexpected report header %+v", report)
+	}
+	if report.Total.InputTokens != 3 || report.Total.OutputTokens != 2 || math.Abs(report.Total.Cost-3) > 1e-3 {
+		t.Errorf("Total = %+v", report.Total)
+	}
+	// Buckets from Mar 3 through the partial day of Mar 6, empty ones included
+	if len(report.Series) != 4 || report.Series[0].InputTokens+report.Series[0].OutputTokens != 3 ||
+		report.Series[2].InputTokens+report.Series[2].OutputTokens != 0 {
+		t.Errorf("Series = %+v", report.Series)
+	}
+	if len(report.ByProvider) != 2 || report.ByProvider[0].Key != "github-copilot" || report.ByProvider[0].Conversations != 1 {
+		t.Errorf("ByProvider = %+v", report.ByProvider)
+	}
+	if len(report.ByProject) != 2 || len(report.ByDay) != 2 || report.ByDay[0].Conversations != 2 {
+		t.Errorf("ByProject = %+v, ByDay = %+v", report.ByProject, report.ByDay)
+	}
+	if len(report.Unpriced) != 0 {
+		t.Errorf("Claude has built-in prices, got unpriced %v", report.Unpriced)
+	}
+
+	q.GroupBy, q.Provider = UsageByProject, "copilot"
+	report, err = BuildUsageReport(amDir, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Series) != 2 || report.Series[0].Key != "beta" || report.Conversations != 1 {
+		t.Errorf("Grouped, filtered series = %+v", report.Series)
+	}
+
+	if _, err := BuildUsageReport(amDir, UsageQuery{Interval: "minute"}); err == nil {
+		t.Error("Expected an error for an unsupported interval")
+	}
+}
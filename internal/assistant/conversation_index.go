@@ -0,0 +1,353 @@
+// Package assistant provides indexing of AM conversations for RAG retrieval.
+package assistant
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/am"
+	"github.com/mikejsmith1985/forge-terminal/internal/metrics"
+)
+
+// conversationChunkSize is the approximate chunk size (in tokens) for conversation text.
+const conversationChunkSize = 400
+
+// ConversationFilter restricts conversation retrieval. Zero values match everything.
+type ConversationFilter struct {
+	Project  string    `json:"project,omitempty"`
+	Provider string    `json:"provider,omitempty"`
+	Since    time.Time `json:"since,omitempty"`
+	Until    time.Time `json:"until,omitempty"`
+}
+
+// Matches reports whether an indexed conversation chunk passes the filter.
+func (f ConversationFilter) Matches(doc *Document) bool {
+	if f.Project != "" && !strings.EqualFold(doc.Metadata["project"], f.Project) {
+		return false
+	}
+	if f.Provider != "" && !strings.EqualFold(doc.Metadata["provider"], f.Provider) {
+		return false
+	}
+	if f.Since.IsZero() && f.Until.IsZero() {
+		return true
+	}
+
+	started, err := time.Parse(time.RFC3339, doc.Metadata["startTime"])
+	if err != nil {
+		return false
+	}
+	if !f.Since.IsZero() && started.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && started.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// ConversationHit is a conversation chunk returned from a search.
+type ConversationHit struct {
+	ConversationID string    `json:"conversationId"`
+	TabID          string    `json:"tabId"`
+	Project        string    `json:"project"`
+	Provider       string    `json:"provider"`
+	StartTime      time.Time `json:"startTime"`
+	Link           string    `json:"link"`
+	Snippet        string    `json:"snippet"`
+	Similarity     float32   `json:"similarity"`
+}
+
+// ConversationIndex embeds AM conversations into a vector store kept apart from documentation.
+type ConversationIndex struct {
+	mu               sync.Mutex
+	embeddingsClient *EmbeddingsClient
+	store            *VectorStore
+	path             string
+}
+
+// NewConversationIndex creates a conversation index persisted at path.
+// An existing index file is loaded if present; an empty path keeps the index in memory only.
+func NewConversationIndex(embeddingsClient *EmbeddingsClient, path string) *ConversationIndex {
+	idx := &ConversationIndex{
+		embeddingsClient: embeddingsClient,
+		store:            NewVectorStore(),
+		path:             path,
+	}
+
+	if path != "" {
+		if _, err := os.Stat(path); err == nil {
+			if err := idx.store.Load(path); err != nil {
+				log.Printf("[RAG] Warning: failed to load conversation index %s: %v", path, err)
+			}
+		}
+	}
+
+	return idx
+}
+
+// ConversationLink returns the API path for a conversation's detail view.
+func ConversationLink(convID string) string {
+	return "/api/am/llm/conversation/" + convID
+}
+
+// conversationSource is the vector store source used for all chunks of a conversation.
+func conversationSource(convID string) string {
+	return "am:" + convID
+}
+
+// IndexConversation (re)indexes a single conversation, replacing any chunks from a
+// previous pass, and saves the index.
+func (ci *ConversationIndex) IndexConversation(ctx context.Context, conv *am.LLMConversation) error {
+	if err := ci.indexConversation(ctx, conv); err != nil {
+		return err
+	}
+
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	return ci.saveLocked()
+}
+
+// indexConversation replaces a conversation's chunks without saving. Chunks are
+// embedded before ci.mu is taken, so searches don't wait on the embeddings model.
+func (ci *ConversationIndex) indexConversation(ctx context.Context, conv *am.LLMConversation) error {
+	if conv == nil || conv.ConversationID == "" {
+		return fmt.Errorf("conversation is required")
+	}
+
+	source := conversationSource(conv.ConversationID)
+	metadata := map[string]string{
+		"type":           "am_conversation",
+		"conversationId": conv.ConversationID,
+		"tabId":          conv.TabID,
+		"project":        conv.GetProjectName(),
+		"provider":       conv.Provider,
+		"startTime":      conv.StartTime.UTC().Format(time.RFC3339),
+		"endTime":        conversationEndTime(conv),
+		"turns":          strconv.Itoa(len(conv.Turns)),
+		"link":           ConversationLink(conv.ConversationID),
+	}
+
+	chunks := chunkConversation(conv)
+	docs := make([]Document, 0, len(chunks))
+	for i, chunk := range chunks {
+		docMetadata := make(map[string]string, len(metadata))
+		for k, v := range metadata {
+			docMetadata[k] = v
+		}
+
+		docs = append(docs, Document{
+			ID:       fmt.Sprintf("%s#%d", source, i),
+			Content:  chunk,
+			Source:   source,
+			Vector:   ci.embed(ctx, chunk),
+			Metadata: docMetadata,
+		})
+	}
+
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+
+	ci.store.RemoveBySource(source)
+	for i, doc := range docs {
+		if err := ci.store.Index(doc); err != nil {
+			return fmt.Errorf("failed to index chunk %d of %s: %w", i, conv.ConversationID, err)
+		}
+	}
+	return nil
+}
+
+// indexed reports whether the index holds conv as it is now: chunks embedded at
+// the same end time and turn count.
+func (ci *ConversationIndex) indexed(conv *am.LLMConversation) bool {
+	empty := len(chunkConversation(conv)) == 0
+
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+
+	doc := ci.store.GetDocument(conversationSource(conv.ConversationID) + "#0")
+	if doc == nil {
+		// Conversations with nothing to embed have no chunks
+		return empty
+	}
+	return doc.Metadata["endTime"] == conversationEndTime(conv) &&
+		doc.Metadata["turns"] == strconv.Itoa(len(conv.Turns))
+}
+
+// conversationEndTime is the end time recorded with a conversation's chunks, "" while
+// it is still running.
+func conversationEndTime(conv *am.LLMConversation) string {
+	if conv.EndTime.IsZero() {
+		return ""
+	}
+	return conv.EndTime.UTC().Format(time.RFC3339Nano)
+}
+
+// IndexAll indexes the conversations in amDir that are new or have changed since
+// they were last indexed, saves the index once, and returns how many were indexed.
+func (ci *ConversationIndex) IndexAll(ctx context.Context, amDir string) (int, error) {
+	conversations, err := am.GetAllConversations(amDir)
+	if err != nil {
+		return 0, err
+	}
+
+	indexed, unchanged := 0, 0
+	defer func() {
+		if indexed == 0 {
+			return
+		}
+		ci.mu.Lock()
+		defer ci.mu.Unlock()
+		if err := ci.saveLocked(); err != nil {
+			log.Printf("[RAG] Warning: failed to save conversation index: %v", err)
+		}
+	}()
+
+	for _, conv := range conversations {
+		if ctx.Err() != nil {
+			return indexed, ctx.Err()
+		}
+		if ci.indexed(conv) {
+			unchanged++
+			continue
+		}
+		if err := ci.indexConversation(ctx, conv); err != nil {
+			log.Printf("[RAG] Warning: failed to index conversation %s: %v", conv.ConversationID, err)
+			continue
+		}
+		indexed++
+	}
+
+	log.Printf("[RAG] Indexed %d AM conversations, %d unchanged (%d chunks)", indexed, unchanged, ci.Count())
+	return indexed, nil
+}
+
+// Subscribe indexes conversations as they complete by listening for LLM_END on the AM event bus.
+func (ci *ConversationIndex) Subscribe(amDir string) {
+	am.EventBus.Subscribe(func(event *am.LayerEvent) {
+		if event.ConvID == "" {
+			return
+		}
+
+		conv, err := am.FindConversation(amDir, event.ConvID)
+		if err != nil {
+			log.Printf("[RAG] Warning: completed conversation %s not found: %v", event.ConvID, err)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		if err := ci.IndexConversation(ctx, conv); err != nil {
+			log.Printf("[RAG] Warning: failed to index conversation %s: %v", event.ConvID, err)
+		}
+	}, am.TopicLLMEnd)
+}
+
+// Search returns the conversation chunks most relevant to query that pass filter.
+func (ci *ConversationIndex) Search(ctx context.Context, query string, filter ConversationFilter, limit int) ([]ConversationHit, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("query cannot be empty")
+	}
+	if limit <= 0 {
+		limit = 5
+	}
+
+	start := time.Now()
+	queryVector := ci.embed(ctx, query)
+
+	ci.mu.Lock()
+	results, err := ci.store.SearchFiltered(queryVector, limit, filter.Matches)
+	ci.mu.Unlock()
+	metrics.RAGQuerySeconds.WithLabelValues("conversations").Observe(time.Since(start).Seconds())
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]ConversationHit, 0, len(results))
+	for _, result := range results {
+		meta := result.Document.Metadata
+		started, _ := time.Parse(time.RFC3339, meta["startTime"])
+		hits = append(hits, ConversationHit{
+			ConversationID: meta["conversationId"],
+			TabID:          meta["tabId"],
+			Project:        meta["project"],
+			Provider:       meta["provider"],
+			StartTime:      started,
+			Link:           meta["link"],
+			Snippet:        result.Document.Content,
+			Similarity:     result.Similarity,
+		})
+	}
+
+	return hits, nil
+}
+
+// Count returns the number of indexed conversation chunks.
+func (ci *ConversationIndex) Count() int {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	return ci.store.Count()
+}
+
+// embed embeds text, falling back to the hash-based embedding when Ollama is unavailable.
+func (ci *ConversationIndex) embed(ctx context.Context, text string) []float32 {
+	vector, err := ci.embeddingsClient.Embed(ctx, text)
+	if err != nil {
+		return ci.embeddingsClient.MockEmbed(text)
+	}
+	return vector
+}
+
+// saveLocked persists the index. Caller must hold ci.mu.
+func (ci *ConversationIndex) saveLocked() error {
+	if ci.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(ci.path), 0755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+	return ci.store.Save(ci.path)
+}
+
+// chunkConversation renders a conversation as text chunks, each prefixed with a header
+// so the chunk stays meaningful on its own when retrieved.
+func chunkConversation(conv *am.LLMConversation) []string {
+	var body strings.Builder
+	for _, turn := range conv.Turns {
+		content := strings.TrimSpace(turn.Content)
+		if content == "" {
+			continue
+		}
+		role := "Assistant"
+		if turn.Role == "user" {
+			role = "User"
+		}
+		body.WriteString(role)
+		body.WriteString(": ")
+		body.WriteString(content)
+		body.WriteString("\n\n")
+	}
+
+	if body.Len() == 0 {
+		return nil
+	}
+
+	header := fmt.Sprintf("Conversation with %s in project %s on %s",
+		conv.Provider, conv.GetProjectName(), conv.StartTime.Format("2006-01-02 15:04"))
+	if conv.Metadata != nil && conv.Metadata.GitBranch != "" {
+		header += fmt.Sprintf(" (branch %s)", conv.Metadata.GitBranch)
+	}
+
+	chunks := ChunkDocument(body.String(), conversationChunkSize)
+	for i, chunk := range chunks {
+		chunks[i] = header + "\n" + chunk
+	}
+	return chunks
+}
@@ -0,0 +1,207 @@
+package assistant
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/am"
+)
+
+// keywordEmbeddingServer returns embeddings built from keyword counts so that
+// similarity between texts is predictable in tests.
+func keywordEmbeddingServer(t *testing.T) *httptest.Server {
+	keywords := []string{"auth", "middleware", "database", "migration", "css"}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req EmbeddingsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("bad embeddings request: %v", err)
+		}
+		text := strings.ToLower(req.Prompt)
+		vector := make([]float32, len(keywords)+1)
+		for i, kw := range keywords {
+			vector[i] = float32(strings.Count(text, kw))
+		}
+		vector[len(keywords)] = 0.1 // avoid zero vectors
+		json.NewEncoder(w).Encode(EmbeddingsResponse{Embedding: vector})
+	}))
+}
+
+func testConversation(id, provider, workDir string, start time.Time, userMsg, reply string) *am.LLMConversation {
+	return &am.LLMConversation{
+		ConversationID: id,
+		TabID:          "tab-1",
+		Provider:       provider,
+		StartTime:      start,
+		Metadata:       &am.ConversationMetadata{WorkingDirectory: workDir},
+		Turns: []am.ConversationTurn{
+			{Role: "user", Content: userMsg, Timestamp: start},
+			{Role: "assistant", Content: reply, Timestamp: start.Add(time.Minute)},
+		},
+	}
+}
+
+func TestConversationIndex_SearchWithFilters(t *testing.T) {
+	server := keywordEmbeddingServer(t)
+	defer server.Close()
+
+	idx := NewConversationIndex(NewEmbeddingsClient(server.URL, "test"), "")
+	ctx := context.Background()
+	yesterday := time.Now().Add(-24 * time.Hour)
+
+	convs := []*am.LLMConversation{
+		testConversation("conv-auth", "claude", "/home/u/projects/api", yesterday,
+			"How should the auth middleware validate tokens?",
+			"Put the auth check in the middleware before routing."),
+		testConversation("conv-db", "github-copilot", "/home/u/projects/api", yesterday,
+			"Write a database migration", "Here is the migration for the database."),
+		testConversation("conv-old", "claude", "/home/u/projects/web", yesterday.Add(-30*24*time.Hour),
+			"Fix the auth middleware", "The auth middleware is missing a header."),
+	}
+	for _, conv := range convs {
+		if err := idx.IndexConversation(ctx, conv); err != nil {
+			t.Fatalf("IndexConversation(%s) failed: %v", conv.ConversationID, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter ConversationFilter
+		want   string
+	}{
+		{"no filter", ConversationFilter{}, ""},
+		{"provider", ConversationFilter{Provider: "claude"}, ""},
+		{"project", ConversationFilter{Project: "web"}, "conv-old"},
+		{"since", ConversationFilter{Since: yesterday.Add(-time.Hour)}, "conv-auth"},
+		{"until", ConversationFilter{Until: yesterday.Add(-24 * time.Hour)}, "conv-old"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, err := idx.Search(ctx, "auth middleware", tt.filter, 1)
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			if len(hits) != 1 {
+				t.Fatalf("Expected 1 hit, got %d", len(hits))
+			}
+			hit := hits[0]
+			if tt.want != "" && hit.ConversationID != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, hit.ConversationID)
+			}
+			if hit.ConversationID == "conv-db" {
+				t.Errorf("Database conversation should not rank first for auth query")
+			}
+			if hit.Link != "/api/am/llm/conversation/"+hit.ConversationID {
+				t.Errorf("Unexpected link %q", hit.Link)
+			}
+		})
+	}
+
+	hits, err := idx.Search(ctx, "auth middleware", ConversationFilter{Provider: "aider"}, 5)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(hits) != 0 {
+		t.Errorf("Expected no hits for unknown provider, got %d", len(hits))
+	}
+}
+
+func TestConversationIndex_ReindexReplacesChunks(t *testing.T) {
+	server := keywordEmbeddingServer(t)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "conversation-index.json")
+	idx := NewConversationIndex(NewEmbeddingsClient(server.URL, "test"), path)
+	ctx := context.Background()
+
+	conv := testConversation("conv-1", "claude", "/home/u/projects/api", time.Now(),
+		"auth question", "auth answer")
+	if err := idx.IndexConversation(ctx, conv); err != nil {
+		t.Fatalf("IndexConversation failed: %v", err)
+	}
+	first := idx.Count()
+
+	conv.Turns = append(conv.Turns, am.ConversationTurn{Role: "user", Content: "follow up about css"})
+	if err := idx.IndexConversation(ctx, conv); err != nil {
+		t.Fatalf("Re-index failed: %v", err)
+	}
+	if idx.Count() != first {
+		t.Errorf("Re-indexing should replace chunks: had %d, now %d", first, idx.Count())
+	}
+
+	reloaded := NewConversationIndex(NewEmbeddingsClient(server.URL, "test"), path)
+	if reloaded.Count() != idx.Count() {
+		t.Errorf("Expected %d chunks after reload, got %d", idx.Count(), reloaded.Count())
+	}
+}
+
+func TestConversationIndex_IndexAllSkipsUnchanged(t *testing.T) {
+	var embeds atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		embeds.Add(1)
+		json.NewEncoder(w).Encode(EmbeddingsResponse{Embedding: []float32{1, 0.5}})
+	}))
+	defer server.Close()
+
+	amDir := t.TempDir()
+	writeConv := func(conv *am.LLMConversation) {
+		data, _ := json.Marshal(conv)
+		name := "api-conv-2025-01-01-0000-" + conv.ConversationID + ".json"
+		if err := os.WriteFile(filepath.Join(amDir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now().Add(-time.Hour)
+	first := testConversation("conv-1", "claude", "/home/u/projects/api", start, "auth question", "auth answer")
+	writeConv(first)
+	writeConv(testConversation("conv-2", "claude", "/home/u/projects/api", start, "css question", "css answer"))
+
+	path := filepath.Join(t.TempDir(), "conversation-index.json")
+	idx := NewConversationIndex(NewEmbeddingsClient(server.URL, "test"), path)
+	ctx := context.Background()
+	if n, err := idx.IndexAll(ctx, amDir); err != nil || n != 2 {
+		t.Fatalf("IndexAll = %d, %v; want 2", n, err)
+	}
+
+	// A restart with nothing changed embeds nothing
+	reloaded := NewConversationIndex(NewEmbeddingsClient(server.URL, "test"), path)
+	before := embeds.Load()
+	if n, err := reloaded.IndexAll(ctx, amDir); err != nil || n != 0 {
+		t.Fatalf("IndexAll after restart = %d, %v; want 0", n, err)
+	}
+	if embeds.Load() != before {
+		t.Errorf("Unchanged conversations were re-embedded")
+	}
+
+	first.Turns = append(first.Turns, am.ConversationTurn{Role: "user", Content: "follow up"})
+	first.EndTime = time.Now()
+	first.Complete = true
+	writeConv(first)
+	if n, err := reloaded.IndexAll(ctx, amDir); err != nil || n != 1 {
+		t.Fatalf("IndexAll after update = %d, %v; want 1", n, err)
+	}
+}
+
+func TestChunkConversation_SkipsEmptyTurns(t *testing.T) {
+	conv := testConversation("conv-1", "aider", "", time.Now(), "   ", "")
+	if chunks := chunkConversation(conv); len(chunks) != 0 {
+		t.Errorf("Expected no chunks for empty conversation, got %d", len(chunks))
+	}
+
+	conv.Turns[0].Content = "hello"
+	chunks := chunkConversation(conv)
+	if len(chunks) != 1 {
+		t.Fatalf("Expected 1 chunk, got %d", len(chunks))
+	}
+	if !strings.Contains(chunks[0], "project adhoc") || !strings.Contains(chunks[0], "User: hello") {
+		t.Errorf("Unexpected chunk content: %q", chunks[0])
+	}
+}
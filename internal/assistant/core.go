@@ -3,10 +3,15 @@
 package assistant
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"sort"
+	"strings"
 
 	"github.com/mikejsmith1985/forge-terminal/internal/am"
 	"github.com/mikejsmith1985/forge-terminal/internal/llm"
+	"github.com/mikejsmith1985/forge-terminal/internal/storage"
 	"github.com/mikejsmith1985/forge-terminal/internal/terminal/vision"
 )
 
@@ -17,8 +22,10 @@ type Core struct {
 	llmDetector    *llm.Detector
 	amSystem       *am.System
 	ollamaClient   *OllamaClient
+	embeddings     *EmbeddingsClient
 	knowledgeBase  *KnowledgeBase
 	ragEngine      *RAGEngine
+	convIndex      *ConversationIndex
 }
 
 // NewCore creates a new assistant core with all AI features.
@@ -37,6 +44,10 @@ func NewCore(amSystem *am.System) *Core {
 	embeddingsClient := NewEmbeddingsClient("", "")
 	vectorStore := NewVectorStore()
 	ragEngine := NewRAGEngine(embeddingsClient, vectorStore, ollamaClient, knowledgeBase)
+
+	// AM conversations live in their own collection so they never crowd out docs
+	convIndex := NewConversationIndex(embeddingsClient, storage.GetConversationIndexPath())
+	ragEngine.SetConversationIndex(convIndex)
 	
 	log.Printf("[Assistant] Core initialized")
 
@@ -46,8 +57,10 @@ func NewCore(amSystem *am.System) *Core {
 		llmDetector:    llm.NewDetector(),
 		amSystem:       amSystem,
 		ollamaClient:   ollamaClient,
+		embeddings:     embeddingsClient,
 		knowledgeBase:  knowledgeBase,
 		ragEngine:      ragEngine,
+		convIndex:      convIndex,
 	}
 }
 
@@ -123,3 +136,37 @@ func (c *Core) GetKnowledgeBase() *KnowledgeBase {
 }
 
 
+
+// GetConversationIndex returns the AM conversation index for external use.
+func (c *Core) GetConversationIndex() *ConversationIndex {
+	return c.convIndex
+}
+
+// ModelRoles returns the model each assistant role currently depends on.
+func (c *Core) ModelRoles() map[string]string {
+	roles := map[string]string{"chat": c.ollamaClient.GetCurrentModel()}
+	if c.embeddings != nil {
+		roles["embedding"] = c.embeddings.GetModel()
+	}
+	return roles
+}
+
+// ModelUsedBy returns the roles that depend on the named model, sorted.
+func (c *Core) ModelUsedBy(name string) []string {
+	var roles []string
+	for role, model := range c.ModelRoles() {
+		if sameModel(model, name) {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// DeleteModel removes an Ollama model unless a role depends on it.
+func (c *Core) DeleteModel(ctx context.Context, name string) error {
+	if roles := c.ModelUsedBy(name); len(roles) > 0 {
+		return fmt.Errorf("%w: %s is the %s model", ErrModelProtected, name, strings.Join(roles, " and "))
+	}
+	return c.ollamaClient.DeleteModel(ctx, name)
+}
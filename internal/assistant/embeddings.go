@@ -163,27 +163,10 @@ func (c *EmbeddingsClient) EnsureModelAvailable(ctx context.Context) bool {
 
 	// Model not available, try to pull it
 	log.Printf("[Embeddings] Attempting to pull model %s...", c.model)
-	pullReq := struct {
-		Name string `json:"name"`
-	}{Name: c.model}
-
-	body, _ := json.Marshal(pullReq)
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/pull", bytes.NewReader(body))
-	if err != nil {
-		log.Printf("[Embeddings] Failed to create pull request: %v", err)
-		return false
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.client.Do(httpReq)
-	if err != nil {
+	if err := pullModel(ctx, c.baseURL, c.model, nil); err != nil {
 		log.Printf("[Embeddings] Failed to pull model: %v", err)
 		return false
 	}
-	defer resp.Body.Close()
-
-	// Read response to completion (pull is streaming)
-	_, _ = io.ReadAll(resp.Body)
 
 	// Test again after pull
 	testEmbed, err = c.Embed(ctx, "test")
@@ -196,6 +179,11 @@ func (c *EmbeddingsClient) EnsureModelAvailable(ctx context.Context) bool {
 	return false
 }
 
+// GetModel returns the embedding model name.
+func (c *EmbeddingsClient) GetModel() string {
+	return c.model
+}
+
 // GetDimensions returns the vector dimension of embeddings.
 func (c *EmbeddingsClient) GetDimensions(ctx context.Context) (int, error) {
 	embedding, err := c.Embed(ctx, "dimension test")
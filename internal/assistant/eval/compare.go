@@ -0,0 +1,156 @@
+// Side-by-side comparison of evaluation runs.
+
+package eval
+
+import (
+	"fmt"
+)
+
+// ModelSummary is one column of a comparison.
+type ModelSummary struct {
+	Model        string  `json:"model"`
+	RunID        string  `json:"runId"`
+	SetVersion   string  `json:"setVersion"`
+	Accuracy     float64 `json:"accuracy"`
+	AverageScore float64 `json:"averageScore"`
+	AvgLatencyMs int64   `json:"avgLatencyMs"`
+	P95LatencyMs int64   `json:"p95LatencyMs"`
+	Errors       int     `json:"errors"`
+}
+
+// CellResult is one model's outcome for one question.
+type CellResult struct {
+	Score     float64 `json:"score"`
+	Passed    bool    `json:"passed"`
+	LatencyMs int64   `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// QuestionComparison is one row of a comparison, keyed by model.
+type QuestionComparison struct {
+	QuestionID string                `json:"questionId"`
+	Question   string                `json:"question"`
+	Results    map[string]CellResult `json:"results"`
+}
+
+// Comparison lays out models side by side over the questions they were asked.
+type Comparison struct {
+	SetName   string               `json:"setName"`
+	Models    []ModelSummary       `json:"models"`
+	Questions []QuestionComparison `json:"questions"`
+	Warnings  []string             `json:"warnings,omitempty"`
+}
+
+// Compare builds a comparison from the given runs. Every model in every run becomes a column;
+// if a model appears in several runs the later-listed run wins.
+func (r *Runner) Compare(runIDs []string) (*Comparison, error) {
+	if len(runIDs) == 0 {
+		return nil, fmt.Errorf("at least one run is required")
+	}
+
+	runs := make([]*Run, 0, len(runIDs))
+	for _, id := range runIDs {
+		run, ok := r.Get(id)
+		if !ok {
+			return nil, fmt.Errorf("run not found: %s", id)
+		}
+		runs = append(runs, run)
+	}
+
+	return compareRuns(runs), nil
+}
+
+// CompareModels compares the latest completed run of each model.
+func (r *Runner) CompareModels(models []string, setName string) (*Comparison, error) {
+	if setName == "" {
+		setName = DefaultSetName
+	}
+
+	var runIDs []string
+	for _, model := range models {
+		id := r.latestRunFor(model, setName)
+		if id == "" {
+			return nil, fmt.Errorf("no completed %s run for model %s", setName, model)
+		}
+		runIDs = append(runIDs, id)
+	}
+	return r.Compare(runIDs)
+}
+
+// latestRunFor returns the newest completed run of setName that includes model.
+func (r *Runner) latestRunFor(model, setName string) string {
+	for _, summary := range r.List() {
+		if summary.SetName != setName || summary.Status != StatusCompleted {
+			continue
+		}
+		for _, m := range summary.Models {
+			if m == model {
+				return summary.ID
+			}
+		}
+	}
+	return ""
+}
+
+// compareRuns lays out the model results of runs side by side.
+func compareRuns(runs []*Run) *Comparison {
+	cmp := &Comparison{SetName: runs[0].SetName}
+
+	columns := make(map[string]int)
+	rows := make(map[string]int)
+	hashes := make(map[string]bool)
+
+	for _, run := range runs {
+		if run.SetName != cmp.SetName {
+			cmp.Warnings = append(cmp.Warnings,
+				fmt.Sprintf("run %s uses set %s, not %s", run.ID, run.SetName, cmp.SetName))
+		}
+		hashes[run.SetHash] = true
+		if run.Status != StatusCompleted {
+			cmp.Warnings = append(cmp.Warnings, fmt.Sprintf("run %s is %s", run.ID, run.Status))
+		}
+
+		for _, result := range run.Results {
+			summary := ModelSummary{
+				Model:        result.Model,
+				RunID:        run.ID,
+				SetVersion:   run.SetVersion,
+				Accuracy:     result.Accuracy,
+				AverageScore: result.AverageScore,
+				AvgLatencyMs: result.AvgLatencyMs,
+				P95LatencyMs: result.P95LatencyMs,
+				Errors:       result.Errors,
+			}
+			if i, ok := columns[result.Model]; ok {
+				cmp.Models[i] = summary
+			} else {
+				columns[result.Model] = len(cmp.Models)
+				cmp.Models = append(cmp.Models, summary)
+			}
+
+			for _, q := range result.Questions {
+				i, ok := rows[q.QuestionID]
+				if !ok {
+					i = len(cmp.Questions)
+					rows[q.QuestionID] = i
+					cmp.Questions = append(cmp.Questions, QuestionComparison{
+						QuestionID: q.QuestionID,
+						Question:   q.Question,
+						Results:    make(map[string]CellResult),
+					})
+				}
+				cmp.Questions[i].Results[result.Model] = CellResult{
+					Score:     q.Score,
+					Passed:    q.Passed,
+					LatencyMs: q.LatencyMs,
+					Error:     q.Error,
+				}
+			}
+		}
+	}
+
+	if len(hashes) > 1 {
+		cmp.Warnings = append(cmp.Warnings, "runs used different revisions of the question set; scores may not be comparable")
+	}
+	return cmp
+}
@@ -0,0 +1,225 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/assistant"
+)
+
+// stubBackend answers from a fixed table per model and records calls.
+type stubBackend struct {
+	mu      sync.Mutex
+	answers map[string]map[string]string // model -> question -> answer
+	judge   string                       // Reply returned to judge prompts
+	calls   int
+}
+
+func (s *stubBackend) ChatWithModel(ctx context.Context, model string, messages []assistant.OllamaMessage) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+
+	last := messages[len(messages)-1].Content
+	if strings.Contains(last, "Grade (0-10):") {
+		return s.judge, nil
+	}
+	answers, ok := s.answers[model]
+	if !ok {
+		return "", fmt.Errorf("model %s not found", model)
+	}
+	return answers[last], nil
+}
+
+func writeSet(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, "set.json"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const testSet = `{
+  "name": "test-set",
+  "version": "2.1.0",
+  "passThreshold": 0.5,
+  "questions": [
+    {"id": "k", "question": "keywords?", "expectedKeywords": ["alpha", "beta"]},
+    {"id": "r", "question": "regex?", "patterns": ["(?i)ctrl\\+t"]},
+    {"id": "j", "question": "judge?", "judgeCriteria": "Mentions gamma", "expectedKeywords": ["gamma"]}
+  ]
+}`
+
+func TestBuiltinSetIsValid(t *testing.T) {
+	sets, err := LoadSets("")
+	if err != nil {
+		t.Fatalf("LoadSets failed: %v", err)
+	}
+	set, ok := sets[DefaultSetName]
+	if !ok {
+		t.Fatalf("Builtin set %s missing", DefaultSetName)
+	}
+	if set.Version == "" || set.Hash == "" || len(set.Questions) == 0 {
+		t.Errorf("Builtin set incomplete: version=%q hash=%q questions=%d", set.Version, set.Hash, len(set.Questions))
+	}
+}
+
+func TestQuestionSet_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		set  QuestionSet
+	}{
+		{"no version", QuestionSet{Name: "x", Questions: []Question{{ID: "a", Question: "q", ExpectedKeywords: []string{"k"}}}}},
+		{"no criteria", QuestionSet{Name: "x", Version: "1", Questions: []Question{{ID: "a", Question: "q"}}}},
+		{"duplicate id", QuestionSet{Name: "x", Version: "1", Questions: []Question{
+			{ID: "a", Question: "q", ExpectedKeywords: []string{"k"}},
+			{ID: "a", Question: "q2", ExpectedKeywords: []string{"k"}},
+		}}},
+		{"bad regex", QuestionSet{Name: "x", Version: "1", Questions: []Question{{ID: "a", Question: "q", Patterns: []string{"("}}}}},
+	}
+
+	for _, tt := range tests {
+		if err := tt.set.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", tt.name)
+		}
+	}
+}
+
+func TestScorers(t *testing.T) {
+	ctx := context.Background()
+	q := Question{
+		ID:               "q",
+		Question:         "How do I open a tab?",
+		ExpectedKeywords: []string{"Ctrl+T", "tab"},
+		Patterns:         []string{`(?i)ctrl\+t`, `never`},
+		JudgeCriteria:    "Mentions Ctrl+T",
+	}
+	answer := "Press ctrl+t to open a new tab."
+
+	kw, _ := KeywordScorer{}.Score(ctx, q, answer)
+	if kw.Value != 1 {
+		t.Errorf("Keyword score = %v, want 1", kw.Value)
+	}
+
+	re, _ := RegexScorer{}.Score(ctx, q, answer)
+	if re.Value != 0.5 {
+		t.Errorf("Regex score = %v, want 0.5", re.Value)
+	}
+
+	judge := JudgeScorer{Backend: &stubBackend{judge: "Score: 7"}, Model: "judge"}
+	js, err := judge.Score(ctx, q, answer)
+	if err != nil {
+		t.Fatalf("Judge failed: %v", err)
+	}
+	if js.Value != 0.7 {
+		t.Errorf("Judge score = %v, want 0.7", js.Value)
+	}
+
+	if (JudgeScorer{}).Applies(q) {
+		t.Error("Judge without a model should not apply")
+	}
+	if _, err := parseGrade("no idea"); err == nil {
+		t.Error("Expected error for reply without a grade")
+	}
+}
+
+func TestRunner_RunAndCompare(t *testing.T) {
+	goldens := t.TempDir()
+	runsDir := t.TempDir()
+	writeSet(t, goldens, testSet)
+
+	backend := &stubBackend{
+		judge: "9",
+		answers: map[string]map[string]string{
+			"good": {"keywords?": "alpha and beta", "regex?": "Press Ctrl+T", "judge?": "gamma"},
+			"bad":  {"keywords?": "alpha", "regex?": "no idea", "judge?": "delta"},
+		},
+	}
+
+	var prompts []string
+	runner := NewRunner(RunnerConfig{
+		Backend:    backend,
+		RunsDir:    runsDir,
+		GoldensDir: goldens,
+		JudgeModel: "judge",
+		SystemPrompt: func(ctx context.Context, question string) string {
+			prompts = append(prompts, question)
+			return "system"
+		},
+	})
+
+	run, err := runner.Start([]string{"good", "bad"}, "test-set")
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if run.Total != 6 || run.SetVersion != "2.1.0" {
+		t.Errorf("Unexpected run: total=%d version=%s", run.Total, run.SetVersion)
+	}
+	runner.Wait()
+
+	done, ok := runner.Get(run.ID)
+	if !ok {
+		t.Fatal("Run not found")
+	}
+	if done.Status != StatusCompleted || done.Answered != 6 {
+		t.Fatalf("Run status=%s answered=%d", done.Status, done.Answered)
+	}
+	if len(prompts) != 6 {
+		t.Errorf("Expected system prompt for each question, got %d", len(prompts))
+	}
+
+	good, bad := done.Results[0], done.Results[1]
+	if good.Accuracy != 1 {
+		t.Errorf("good accuracy = %v, want 1", good.Accuracy)
+	}
+	// keywords 0.5 passes at threshold 0.5, regex 0 fails, judge+keyword (0.9+0)/2 = 0.45 fails
+	if bad.Passed != 1 || bad.Failed != 2 {
+		t.Errorf("bad passed=%d failed=%d, want 1/2", bad.Passed, bad.Failed)
+	}
+
+	// Runs survive a restart
+	reloaded := NewRunner(RunnerConfig{Backend: backend, RunsDir: runsDir, GoldensDir: goldens})
+	if _, ok := reloaded.Get(run.ID); !ok {
+		t.Fatal("Run was not persisted")
+	}
+
+	cmp, err := reloaded.CompareModels([]string{"good", "bad"}, "test-set")
+	if err != nil {
+		t.Fatalf("CompareModels failed: %v", err)
+	}
+	if len(cmp.Models) != 2 || len(cmp.Questions) != 3 {
+		t.Fatalf("Comparison has %d models, %d questions", len(cmp.Models), len(cmp.Questions))
+	}
+	cell := cmp.Questions[1].Results["bad"]
+	if cell.Passed || cmp.Questions[1].QuestionID != "r" {
+		t.Errorf("Unexpected regex cell for bad model: %+v", cell)
+	}
+	if len(cmp.Warnings) != 0 {
+		t.Errorf("Unexpected warnings: %v", cmp.Warnings)
+	}
+}
+
+func TestRunner_BackendErrorsAreRecorded(t *testing.T) {
+	goldens := t.TempDir()
+	writeSet(t, goldens, testSet)
+
+	runner := NewRunner(RunnerConfig{Backend: &stubBackend{}, GoldensDir: goldens})
+	run, err := runner.Start([]string{"missing"}, "test-set")
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	runner.Wait()
+
+	done, _ := runner.Get(run.ID)
+	if done.Results[0].Errors != 3 || done.Results[0].Accuracy != 0 {
+		t.Errorf("Expected 3 errors, got %+v", done.Results[0])
+	}
+
+	if _, err := runner.Start([]string{"x"}, "no-such-set"); err == nil {
+		t.Error("Expected error for unknown set")
+	}
+}
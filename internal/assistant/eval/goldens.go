@@ -0,0 +1,165 @@
+// In-process evaluation of assistant models against golden question sets.
+
+package eval
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+//go:embed goldens/*.json
+var goldensFS embed.FS
+
+// DefaultSetName is the golden set used when a run does not name one.
+const DefaultSetName = "forge-docs"
+
+// defaultPassThreshold matches the 80% bar the old shell harness used.
+const defaultPassThreshold = 0.8
+
+// Question is a single golden question with the criteria used to score answers.
+type Question struct {
+	ID               string   `json:"id"`
+	Question         string   `json:"question"`
+	Category         string   `json:"category,omitempty"`
+	Difficulty       string   `json:"difficulty,omitempty"`
+	Source           string   `json:"source,omitempty"`
+	ExpectedKeywords []string `json:"expectedKeywords,omitempty"` // Case-insensitive substrings
+	Patterns         []string `json:"patterns,omitempty"`         // Regular expressions
+	JudgeCriteria    string   `json:"judgeCriteria,omitempty"`    // Rubric for the LLM judge
+	PassThreshold    float64  `json:"passThreshold,omitempty"`    // Overrides the set threshold
+}
+
+// QuestionSet is a versioned collection of golden questions.
+type QuestionSet struct {
+	Name          string     `json:"name"`
+	Version       string     `json:"version"`
+	Description   string     `json:"description,omitempty"`
+	PassThreshold float64    `json:"passThreshold,omitempty"`
+	Questions     []Question `json:"questions"`
+
+	// Hash identifies the exact content so runs against edited sets are distinguishable
+	// even if the version was not bumped.
+	Hash   string `json:"hash"`
+	Source string `json:"source"` // "builtin" or the file path
+}
+
+// threshold returns the pass threshold for q within the set.
+func (s *QuestionSet) threshold(q Question) float64 {
+	if q.PassThreshold > 0 {
+		return q.PassThreshold
+	}
+	if s.PassThreshold > 0 {
+		return s.PassThreshold
+	}
+	return defaultPassThreshold
+}
+
+// Validate checks the set is usable: named, versioned, with unique non-empty questions
+// that each have at least one way to be scored.
+func (s *QuestionSet) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("question set name is required")
+	}
+	if s.Version == "" {
+		return fmt.Errorf("question set %s: version is required", s.Name)
+	}
+	if len(s.Questions) == 0 {
+		return fmt.Errorf("question set %s: no questions", s.Name)
+	}
+
+	seen := make(map[string]bool)
+	for i, q := range s.Questions {
+		if q.ID == "" || q.Question == "" {
+			return fmt.Errorf("question set %s: question %d needs an id and question", s.Name, i)
+		}
+		if seen[q.ID] {
+			return fmt.Errorf("question set %s: duplicate question id %s", s.Name, q.ID)
+		}
+		seen[q.ID] = true
+		if len(q.ExpectedKeywords) == 0 && len(q.Patterns) == 0 && q.JudgeCriteria == "" {
+			return fmt.Errorf("question set %s: question %s has no scoring criteria", s.Name, q.ID)
+		}
+		for _, p := range q.Patterns {
+			if _, err := compilePattern(p); err != nil {
+				return fmt.Errorf("question set %s: question %s: %w", s.Name, q.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// parseQuestionSet decodes and validates a set, recording its content hash.
+func parseQuestionSet(data []byte, source string) (*QuestionSet, error) {
+	var set QuestionSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse question set %s: %w", source, err)
+	}
+	if err := set.Validate(); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	set.Hash = hex.EncodeToString(sum[:8])
+	set.Source = source
+	return &set, nil
+}
+
+// LoadSets returns the built-in golden sets plus any *.json sets in dir.
+// A user set with the same name as a built-in one replaces it.
+func LoadSets(dir string) (map[string]*QuestionSet, error) {
+	sets := make(map[string]*QuestionSet)
+
+	entries, err := goldensFS.ReadDir("goldens")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		data, err := goldensFS.ReadFile("goldens/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		set, err := parseQuestionSet(data, "builtin")
+		if err != nil {
+			return nil, err
+		}
+		sets[set.Name] = set
+	}
+
+	if dir == "" {
+		return sets, nil
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			log.Printf("[Eval] ⚠️ Skipping question set %s: %v", file, err)
+			continue
+		}
+		set, err := parseQuestionSet(data, file)
+		if err != nil {
+			log.Printf("[Eval] ⚠️ Skipping question set %s: %v", file, err)
+			continue
+		}
+		sets[set.Name] = set
+	}
+
+	return sets, nil
+}
+
+// SortedSets returns sets ordered by name.
+func SortedSets(sets map[string]*QuestionSet) []*QuestionSet {
+	list := make([]*QuestionSet, 0, len(sets))
+	for _, set := range sets {
+		list = append(list, set)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
@@ -0,0 +1,429 @@
+// Package eval provides in-process evaluation of assistant models against golden
+// question sets: answer scoring, persisted runs and side-by-side comparison.
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/assistant"
+)
+
+// ChatBackend sends chat messages to a named model. OllamaClient satisfies it.
+type ChatBackend interface {
+	ChatWithModel(ctx context.Context, model string, messages []assistant.OllamaMessage) (string, error)
+}
+
+// Run statuses.
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// QuestionResult is one model's answer to one golden question.
+type QuestionResult struct {
+	QuestionID string  `json:"questionId"`
+	Question   string  `json:"question"`
+	Answer     string  `json:"answer"`
+	Scores     []Score `json:"scores,omitempty"`
+	Score      float64 `json:"score"`
+	Passed     bool    `json:"passed"`
+	LatencyMs  int64   `json:"latencyMs"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// ModelResult aggregates a model's results for a run.
+type ModelResult struct {
+	Model        string           `json:"model"`
+	Questions    []QuestionResult `json:"questions"`
+	Passed       int              `json:"passed"`
+	Failed       int              `json:"failed"`
+	Errors       int              `json:"errors"`
+	Accuracy     float64          `json:"accuracy"`     // Fraction of questions passed
+	AverageScore float64          `json:"averageScore"` // Mean question score
+	AvgLatencyMs int64            `json:"avgLatencyMs"`
+	P95LatencyMs int64            `json:"p95LatencyMs"`
+}
+
+// Run is an evaluation of one or more models against a golden set.
+type Run struct {
+	ID          string         `json:"id"`
+	SetName     string         `json:"setName"`
+	SetVersion  string         `json:"setVersion"`
+	SetHash     string         `json:"setHash"`
+	Models      []string       `json:"models"`
+	JudgeModel  string         `json:"judgeModel,omitempty"`
+	Status      string         `json:"status"`
+	Error       string         `json:"error,omitempty"`
+	StartedAt   time.Time      `json:"startedAt"`
+	CompletedAt time.Time      `json:"completedAt,omitempty"`
+	Total       int            `json:"total"`    // Questions x models
+	Answered    int            `json:"answered"` // Progress towards Total
+	Results     []*ModelResult `json:"results"`
+}
+
+// RunSummary is the run listing view without per-question detail.
+type RunSummary struct {
+	ID          string             `json:"id"`
+	SetName     string             `json:"setName"`
+	SetVersion  string             `json:"setVersion"`
+	Models      []string           `json:"models"`
+	Status      string             `json:"status"`
+	StartedAt   time.Time          `json:"startedAt"`
+	CompletedAt time.Time          `json:"completedAt,omitempty"`
+	Total       int                `json:"total"`
+	Answered    int                `json:"answered"`
+	Accuracy    map[string]float64 `json:"accuracy"`
+}
+
+// RunnerConfig configures a Runner.
+type RunnerConfig struct {
+	Backend    ChatBackend
+	RunsDir    string // Where runs are persisted; empty keeps them in memory
+	GoldensDir string // Extra user golden sets
+	JudgeModel string // Model used for LLM-as-judge scoring; empty disables it
+	// SystemPrompt builds the system prompt sent with each question, so runs exercise
+	// the same prompt the assistant uses. Nil sends the question alone.
+	SystemPrompt func(ctx context.Context, question string) string
+	// QuestionTimeout bounds a single answer; zero means two minutes.
+	QuestionTimeout time.Duration
+}
+
+// Runner executes evaluation runs in the background and persists them.
+type Runner struct {
+	config  RunnerConfig
+	mu      sync.Mutex
+	runs    map[string]*Run
+	cancels map[string]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewRunner creates a runner and loads previously persisted runs.
+func NewRunner(config RunnerConfig) *Runner {
+	if config.QuestionTimeout == 0 {
+		config.QuestionTimeout = 2 * time.Minute
+	}
+
+	r := &Runner{
+		config:  config,
+		runs:    make(map[string]*Run),
+		cancels: make(map[string]context.CancelFunc),
+	}
+	r.loadRuns()
+	return r
+}
+
+// Sets returns the available golden sets.
+func (r *Runner) Sets() ([]*QuestionSet, error) {
+	sets, err := LoadSets(r.config.GoldensDir)
+	if err != nil {
+		return nil, err
+	}
+	return SortedSets(sets), nil
+}
+
+// Start begins evaluating models against the named set (DefaultSetName if empty).
+// The run proceeds in the background; poll Get for progress.
+func (r *Runner) Start(models []string, setName string) (*Run, error) {
+	if len(models) == 0 {
+		return nil, fmt.Errorf("at least one model is required")
+	}
+	if r.config.Backend == nil {
+		return nil, fmt.Errorf("no chat backend configured")
+	}
+	if setName == "" {
+		setName = DefaultSetName
+	}
+
+	sets, err := LoadSets(r.config.GoldensDir)
+	if err != nil {
+		return nil, err
+	}
+	set, ok := sets[setName]
+	if !ok {
+		return nil, fmt.Errorf("unknown question set: %s", setName)
+	}
+
+	run := &Run{
+		ID:         fmt.Sprintf("run-%d", time.Now().UnixNano()),
+		SetName:    set.Name,
+		SetVersion: set.Version,
+		SetHash:    set.Hash,
+		Models:     models,
+		JudgeModel: r.config.JudgeModel,
+		Status:     StatusRunning,
+		StartedAt:  time.Now(),
+		Total:      len(models) * len(set.Questions),
+		Results:    make([]*ModelResult, 0, len(models)),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r.mu.Lock()
+	r.runs[run.ID] = run
+	r.cancels[run.ID] = cancel
+	snapshot := r.snapshotLocked(run)
+	r.mu.Unlock()
+
+	log.Printf("[Eval] Starting %s: set %s v%s, models %v", run.ID, set.Name, set.Version, models)
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer cancel()
+		r.execute(ctx, run, set)
+	}()
+
+	return snapshot, nil
+}
+
+// Cancel stops a running evaluation.
+func (r *Runner) Cancel(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cancel, ok := r.cancels[id]
+	if !ok {
+		return fmt.Errorf("run %s is not running", id)
+	}
+	cancel()
+	return nil
+}
+
+// Wait blocks until all background runs finish. Used by tests and shutdown.
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
+
+// Get returns a copy of the run with the given ID.
+func (r *Runner) Get(id string) (*Run, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run, ok := r.runs[id]
+	if !ok {
+		return nil, false
+	}
+	return r.snapshotLocked(run), true
+}
+
+// List returns summaries of all runs, newest first.
+func (r *Runner) List() []RunSummary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	summaries := make([]RunSummary, 0, len(r.runs))
+	for _, run := range r.runs {
+		summary := RunSummary{
+			ID:          run.ID,
+			SetName:     run.SetName,
+			SetVersion:  run.SetVersion,
+			Models:      run.Models,
+			Status:      run.Status,
+			StartedAt:   run.StartedAt,
+			CompletedAt: run.CompletedAt,
+			Total:       run.Total,
+			Answered:    run.Answered,
+			Accuracy:    make(map[string]float64),
+		}
+		for _, result := range run.Results {
+			summary.Accuracy[result.Model] = result.Accuracy
+		}
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].StartedAt.After(summaries[j].StartedAt)
+	})
+	return summaries
+}
+
+// execute answers and scores every question for every model.
+func (r *Runner) execute(ctx context.Context, run *Run, set *QuestionSet) {
+	scorers := []Scorer{
+		KeywordScorer{},
+		RegexScorer{},
+		JudgeScorer{Backend: r.config.Backend, Model: r.config.JudgeModel},
+	}
+
+	for _, model := range run.Models {
+		result := &ModelResult{Model: model, Questions: make([]QuestionResult, 0, len(set.Questions))}
+
+		r.mu.Lock()
+		run.Results = append(run.Results, result)
+		r.mu.Unlock()
+
+		for _, q := range set.Questions {
+			if ctx.Err() != nil {
+				r.finish(run, StatusCancelled, "")
+				return
+			}
+
+			qr := r.answer(ctx, scorers, set, model, q)
+
+			r.mu.Lock()
+			result.Questions = append(result.Questions, qr)
+			run.Answered++
+			summarize(result)
+			r.mu.Unlock()
+		}
+
+		log.Printf("[Eval] %s: %s accuracy %.0f%%, avg latency %dms",
+			run.ID, model, result.Accuracy*100, result.AvgLatencyMs)
+		r.persist(run)
+	}
+
+	r.finish(run, StatusCompleted, "")
+}
+
+// answer asks model one question and scores the reply.
+func (r *Runner) answer(ctx context.Context, scorers []Scorer, set *QuestionSet, model string, q Question) QuestionResult {
+	qr := QuestionResult{QuestionID: q.ID, Question: q.Question}
+
+	var messages []assistant.OllamaMessage
+	if r.config.SystemPrompt != nil {
+		messages = append(messages, assistant.OllamaMessage{Role: "system", Content: r.config.SystemPrompt(ctx, q.Question)})
+	}
+	messages = append(messages, assistant.OllamaMessage{Role: "user", Content: q.Question})
+
+	qctx, cancel := context.WithTimeout(ctx, r.config.QuestionTimeout)
+	defer cancel()
+
+	start := time.Now()
+	reply, err := r.config.Backend.ChatWithModel(qctx, model, messages)
+	qr.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		qr.Error = err.Error()
+		return qr
+	}
+	qr.Answer = reply
+
+	scores, value, err := scoreAnswer(ctx, scorers, q, reply)
+	qr.Scores = scores
+	if err != nil {
+		qr.Error = err.Error()
+		return qr
+	}
+	qr.Score = value
+	qr.Passed = value >= set.threshold(q)
+	return qr
+}
+
+// finish marks the run done and persists it.
+func (r *Runner) finish(run *Run, status, errMsg string) {
+	r.mu.Lock()
+	run.Status = status
+	run.Error = errMsg
+	run.CompletedAt = time.Now()
+	delete(r.cancels, run.ID)
+	r.mu.Unlock()
+
+	log.Printf("[Eval] %s %s (%d/%d answered)", run.ID, status, run.Answered, run.Total)
+	r.persist(run)
+}
+
+// summarize recomputes aggregate metrics. Caller must hold r.mu.
+func summarize(result *ModelResult) {
+	result.Passed, result.Failed, result.Errors = 0, 0, 0
+	totalScore := 0.0
+	latencies := make([]int64, 0, len(result.Questions))
+	var totalLatency int64
+
+	for _, q := range result.Questions {
+		switch {
+		case q.Error != "":
+			result.Errors++
+		case q.Passed:
+			result.Passed++
+		default:
+			result.Failed++
+		}
+		totalScore += q.Score
+		latencies = append(latencies, q.LatencyMs)
+		totalLatency += q.LatencyMs
+	}
+
+	n := len(result.Questions)
+	if n == 0 {
+		return
+	}
+	result.Accuracy = float64(result.Passed) / float64(n)
+	result.AverageScore = totalScore / float64(n)
+	result.AvgLatencyMs = totalLatency / int64(n)
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	idx := (95*n+99)/100 - 1
+	result.P95LatencyMs = latencies[idx]
+}
+
+// snapshotLocked deep-copies a run so callers can read it while it progresses.
+// Caller must hold r.mu.
+func (r *Runner) snapshotLocked(run *Run) *Run {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return nil
+	}
+	var copied Run
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil
+	}
+	return &copied
+}
+
+// persist writes the run to RunsDir.
+func (r *Runner) persist(run *Run) {
+	if r.config.RunsDir == "" {
+		return
+	}
+
+	r.mu.Lock()
+	data, err := json.MarshalIndent(run, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		log.Printf("[Eval] ❌ Failed to marshal %s: %v", run.ID, err)
+		return
+	}
+
+	if err := os.MkdirAll(r.config.RunsDir, 0755); err != nil {
+		log.Printf("[Eval] ❌ Failed to create runs dir: %v", err)
+		return
+	}
+	path := filepath.Join(r.config.RunsDir, run.ID+".json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Printf("[Eval] ❌ Failed to write %s: %v", path, err)
+	}
+}
+
+// loadRuns reads persisted runs. Runs left "running" by a previous process are marked failed.
+func (r *Runner) loadRuns() {
+	if r.config.RunsDir == "" {
+		return
+	}
+
+	files, _ := filepath.Glob(filepath.Join(r.config.RunsDir, "run-*.json"))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var run Run
+		if err := json.Unmarshal(data, &run); err != nil {
+			log.Printf("[Eval] ⚠️ Skipping unreadable run %s: %v", file, err)
+			continue
+		}
+		if run.Status == StatusRunning {
+			run.Status = StatusFailed
+			run.Error = "interrupted by restart"
+		}
+		r.runs[run.ID] = &run
+	}
+}
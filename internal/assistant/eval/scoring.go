@@ -0,0 +1,194 @@
+// Answer scoring for golden questions.
+
+package eval
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/assistant"
+)
+
+// Scorer names recorded in question results.
+const (
+	ScorerKeyword = "keyword"
+	ScorerRegex   = "regex"
+	ScorerJudge   = "judge"
+)
+
+// Score is one scorer's verdict on an answer, from 0 to 1.
+type Score struct {
+	Scorer  string  `json:"scorer"`
+	Value   float64 `json:"value"`
+	Details string  `json:"details,omitempty"`
+}
+
+// Scorer grades an answer to a golden question.
+// Applies reports whether the question has criteria for this scorer.
+type Scorer interface {
+	Name() string
+	Applies(q Question) bool
+	Score(ctx context.Context, q Question, answer string) (Score, error)
+}
+
+// KeywordScorer scores the fraction of expected keywords present in the answer.
+type KeywordScorer struct{}
+
+// Name returns the scorer name.
+func (KeywordScorer) Name() string { return ScorerKeyword }
+
+// Applies reports whether q has expected keywords.
+func (KeywordScorer) Applies(q Question) bool { return len(q.ExpectedKeywords) > 0 }
+
+// Score matches keywords case-insensitively.
+func (KeywordScorer) Score(ctx context.Context, q Question, answer string) (Score, error) {
+	lower := strings.ToLower(answer)
+	var missing []string
+	for _, kw := range q.ExpectedKeywords {
+		if !strings.Contains(lower, strings.ToLower(kw)) {
+			missing = append(missing, kw)
+		}
+	}
+
+	matched := len(q.ExpectedKeywords) - len(missing)
+	score := Score{
+		Scorer: ScorerKeyword,
+		Value:  float64(matched) / float64(len(q.ExpectedKeywords)),
+	}
+	if len(missing) > 0 {
+		score.Details = "missing: " + strings.Join(missing, ", ")
+	}
+	return score, nil
+}
+
+// RegexScorer scores the fraction of patterns that match the answer.
+type RegexScorer struct{}
+
+// Name returns the scorer name.
+func (RegexScorer) Name() string { return ScorerRegex }
+
+// Applies reports whether q has patterns.
+func (RegexScorer) Applies(q Question) bool { return len(q.Patterns) > 0 }
+
+// Score matches each pattern against the answer.
+func (RegexScorer) Score(ctx context.Context, q Question, answer string) (Score, error) {
+	var failed []string
+	for _, p := range q.Patterns {
+		re, err := compilePattern(p)
+		if err != nil {
+			return Score{}, err
+		}
+		if !re.MatchString(answer) {
+			failed = append(failed, p)
+		}
+	}
+
+	matched := len(q.Patterns) - len(failed)
+	score := Score{
+		Scorer: ScorerRegex,
+		Value:  float64(matched) / float64(len(q.Patterns)),
+	}
+	if len(failed) > 0 {
+		score.Details = "unmatched: " + strings.Join(failed, ", ")
+	}
+	return score, nil
+}
+
+// JudgeScorer asks a model to grade the answer against the question's rubric.
+type JudgeScorer struct {
+	Backend ChatBackend
+	Model   string
+}
+
+// Name returns the scorer name.
+func (j JudgeScorer) Name() string { return ScorerJudge }
+
+// Applies reports whether q has a rubric and a judge model is configured.
+func (j JudgeScorer) Applies(q Question) bool {
+	return q.JudgeCriteria != "" && j.Backend != nil && j.Model != ""
+}
+
+// Score asks the judge for a 0-10 grade and normalizes it.
+func (j JudgeScorer) Score(ctx context.Context, q Question, answer string) (Score, error) {
+	messages := []assistant.OllamaMessage{
+		{
+			Role: "system",
+			Content: "You grade answers from a terminal assistant. " +
+				"Reply with a single integer from 0 to 10 and nothing else.",
+		},
+		{
+			Role: "user",
+			Content: fmt.Sprintf("Question:\n%s\n\nGrading criteria:\n%s\n\nAnswer to grade:\n%s\n\nGrade (0-10):",
+				q.Question, q.JudgeCriteria, answer),
+		},
+	}
+
+	reply, err := j.Backend.ChatWithModel(ctx, j.Model, messages)
+	if err != nil {
+		return Score{}, fmt.Errorf("judge %s failed: %w", j.Model, err)
+	}
+
+	grade, err := parseGrade(reply)
+	if err != nil {
+		return Score{}, err
+	}
+
+	return Score{
+		Scorer:  ScorerJudge,
+		Value:   grade / 10,
+		Details: fmt.Sprintf("%s graded %.0f/10", j.Model, grade),
+	}, nil
+}
+
+// gradePattern finds the first number in a judge reply.
+var gradePattern = regexp.MustCompile(`\d+(\.\d+)?`)
+
+// parseGrade extracts a 0-10 grade from a judge reply.
+func parseGrade(reply string) (float64, error) {
+	match := gradePattern.FindString(reply)
+	if match == "" {
+		return 0, fmt.Errorf("judge reply has no grade: %q", reply)
+	}
+	grade, err := strconv.ParseFloat(match, 64)
+	if err != nil {
+		return 0, err
+	}
+	if grade > 10 {
+		grade = 10
+	}
+	return grade, nil
+}
+
+// compilePattern compiles a golden-set regular expression.
+func compilePattern(p string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+	}
+	return re, nil
+}
+
+// scoreAnswer runs every applicable scorer and averages their values.
+func scoreAnswer(ctx context.Context, scorers []Scorer, q Question, answer string) ([]Score, float64, error) {
+	var scores []Score
+	total := 0.0
+	for _, s := range scorers {
+		if !s.Applies(q) {
+			continue
+		}
+		score, err := s.Score(ctx, q, answer)
+		if err != nil {
+			return scores, 0, err
+		}
+		scores = append(scores, score)
+		total += score.Value
+	}
+
+	if len(scores) == 0 {
+		return nil, 0, fmt.Errorf("no scorer applies to question %s", q.ID)
+	}
+	return scores, total / float64(len(scores)), nil
+}
@@ -119,7 +119,7 @@ func (s *LocalService) GetStatus(ctx context.Context) (*OllamaStatusResponse, er
 		}, nil
 	}
 
-	models, err := ollamaClient.GetModels(ctx)
+	models, err := ollamaClient.GetModelsWithDetails(ctx)
 	if err != nil {
 		return &OllamaStatusResponse{
 			Available:    true,
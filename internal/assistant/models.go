@@ -0,0 +1,324 @@
+// Package assistant provides Ollama model management: pull, delete and inspect.
+package assistant
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrModelProtected is returned when deleting a model that a role depends on.
+var ErrModelProtected = errors.New("model is in use")
+
+// ModelDetails is the metadata Ollama reports for an installed model via /api/show.
+type ModelDetails struct {
+	Name              string   `json:"name"`
+	Family            string   `json:"family"`
+	Families          []string `json:"families,omitempty"`
+	Format            string   `json:"format"`
+	ParameterSize     string   `json:"parameterSize"`
+	QuantizationLevel string   `json:"quantizationLevel"`
+	ContextLength     int      `json:"contextLength"`
+	Parameters        string   `json:"parameters,omitempty"`
+	Template          string   `json:"template,omitempty"`
+	License           string   `json:"license,omitempty"`
+}
+
+// PullProgress is one progress event from an Ollama pull.
+type PullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// showResponse mirrors the parts of Ollama's /api/show response we use.
+type showResponse struct {
+	License    string `json:"license"`
+	Parameters string `json:"parameters"`
+	Template   string `json:"template"`
+	Details    struct {
+		Format            string   `json:"format"`
+		Family            string   `json:"family"`
+		Families          []string `json:"families"`
+		ParameterSize     string   `json:"parameter_size"`
+		QuantizationLevel string   `json:"quantization_level"`
+	} `json:"details"`
+	ModelInfo map[string]interface{} `json:"model_info"`
+}
+
+// pullClient has no timeout: pulls of large models run for many minutes and are
+// bounded by the request context instead.
+var pullClient = &http.Client{}
+
+// ShowModel returns metadata for an installed model.
+func (c *OllamaClient) ShowModel(ctx context.Context, name string) (*ModelDetails, error) {
+	resp, err := c.postJSON(ctx, "/api/show", map[string]string{"model": name})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var show showResponse
+	if err := json.NewDecoder(resp.Body).Decode(&show); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	details := &ModelDetails{
+		Name:              name,
+		Family:            show.Details.Family,
+		Families:          show.Details.Families,
+		Format:            show.Details.Format,
+		ParameterSize:     show.Details.ParameterSize,
+		QuantizationLevel: show.Details.QuantizationLevel,
+		ContextLength:     contextLength(show),
+		Parameters:        show.Parameters,
+		Template:          show.Template,
+		License:           show.License,
+	}
+	return details, nil
+}
+
+// PullModel downloads a model, calling onProgress for each status line Ollama streams.
+func (c *OllamaClient) PullModel(ctx context.Context, name string, onProgress func(PullProgress)) error {
+	return pullModel(ctx, c.baseURL, name, onProgress)
+}
+
+// DeleteModel removes an installed model. Callers should check ModelRoles first.
+func (c *OllamaClient) DeleteModel(ctx context.Context, name string) error {
+	body, err := json.Marshal(map[string]string{"model": name})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.baseURL+"/api/delete", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(bodyBytes)))
+	}
+
+	c.forgetDetails(name)
+	log.Printf("[Assistant] Deleted model %s", name)
+	return nil
+}
+
+// GetModelsWithDetails lists installed models enriched with /api/show metadata.
+// Details are cached per model revision; models whose details cannot be read keep
+// the name-based defaults.
+func (c *OllamaClient) GetModelsWithDetails(ctx context.Context) ([]ModelInfo, error) {
+	models, err := c.GetModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, m := range models {
+		details, err := c.cachedDetails(ctx, m.Name, m.ModifiedAt)
+		if err != nil {
+			log.Printf("[Assistant] Could not read details for %s: %v", m.Name, err)
+			continue
+		}
+		models[i] = enrichModelInfo(m.Name, m.Size, details)
+		models[i].ModifiedAt = m.ModifiedAt
+	}
+	return models, nil
+}
+
+// cachedDetails returns ShowModel results, reusing them until the model is modified.
+func (c *OllamaClient) cachedDetails(ctx context.Context, name, modifiedAt string) (*ModelDetails, error) {
+	key := name + "@" + modifiedAt
+
+	c.mu.Lock()
+	details, ok := c.details[key]
+	c.mu.Unlock()
+	if ok {
+		return details, nil
+	}
+
+	details, err := c.ShowModel(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.details == nil {
+		c.details = make(map[string]*ModelDetails)
+	}
+	c.details[key] = details
+	c.mu.Unlock()
+	return details, nil
+}
+
+// forgetDetails drops cached details for every revision of name.
+func (c *OllamaClient) forgetDetails(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.details {
+		if strings.HasPrefix(key, name+"@") {
+			delete(c.details, key)
+		}
+	}
+}
+
+// postJSON posts body to an Ollama endpoint and returns the response if it succeeded.
+func (c *OllamaClient) postJSON(ctx context.Context, path string, body interface{}) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Ollama: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(bodyBytes)))
+	}
+	return resp, nil
+}
+
+// pullModel streams an Ollama pull, reporting each progress line.
+// Ollama reports failures mid-stream as an "error" field, which is returned as an error.
+func pullModel(ctx context.Context, baseURL, name string, onProgress func(PullProgress)) error {
+	body, err := json.Marshal(map[string]interface{}{"model": name, "stream": true})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/api/pull", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := pullClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(bodyBytes)))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	success := false
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var progress PullProgress
+		if err := json.Unmarshal(line, &progress); err != nil {
+			return fmt.Errorf("failed to decode pull progress: %w", err)
+		}
+		if onProgress != nil {
+			onProgress(progress)
+		}
+		if progress.Error != "" {
+			return fmt.Errorf("pull failed: %s", progress.Error)
+		}
+		if progress.Status == "success" {
+			success = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("pull interrupted: %w", err)
+	}
+	if !success {
+		return fmt.Errorf("pull of %s ended without success", name)
+	}
+
+	log.Printf("[Assistant] Pulled model %s", name)
+	return nil
+}
+
+// contextLength reads "<architecture>.context_length" from model_info, preferring a
+// num_ctx override in the model's parameters.
+func contextLength(show showResponse) int {
+	for _, line := range strings.Split(show.Parameters, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "num_ctx" {
+			if n, err := strconv.Atoi(fields[1]); err == nil {
+				return n
+			}
+		}
+	}
+
+	arch, _ := show.ModelInfo["general.architecture"].(string)
+	if arch == "" {
+		return 0
+	}
+	if n, ok := show.ModelInfo[arch+".context_length"].(float64); ok {
+		return int(n)
+	}
+	return 0
+}
+
+// parameterCount converts an Ollama parameter size such as "7.2B" or "494M" to billions.
+func parameterCount(size string) (float64, bool) {
+	size = strings.TrimSpace(strings.ToUpper(size))
+	if size == "" {
+		return 0, false
+	}
+
+	scale := 1.0
+	switch size[len(size)-1] {
+	case 'B':
+		size = size[:len(size)-1]
+	case 'M':
+		scale = 0.001
+		size = size[:len(size)-1]
+	case 'K':
+		scale = 0.000001
+		size = size[:len(size)-1]
+	}
+
+	n, err := strconv.ParseFloat(size, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n * scale, true
+}
+
+// sameModel compares model names the way Ollama resolves them: an untagged name means ":latest".
+func sameModel(a, b string) bool {
+	normalize := func(name string) string {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" && !strings.Contains(name, ":") {
+			name += ":latest"
+		}
+		return name
+	}
+	return normalize(a) == normalize(b)
+}
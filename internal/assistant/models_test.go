@@ -0,0 +1,152 @@
+package assistant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+const showFixture = `{
+  "license": "MIT",
+  "parameters": "stop \"<|eot|>\"",
+  "template": "{{ .Prompt }}",
+  "details": {"format": "gguf", "family": "llama", "families": ["llama"], "parameter_size": "8.0B", "quantization_level": "Q4_K_M"},
+  "model_info": {"general.architecture": "llama", "llama.context_length": 131072}
+}`
+
+func newModelServer(t *testing.T, showCalls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			fmt.Fprint(w, `{"models":[{"name":"llama3:8b","size":4700000000,"modified_at":"2025-01-01"}]}`)
+		case "/api/show":
+			atomic.AddInt32(showCalls, 1)
+			fmt.Fprint(w, showFixture)
+		case "/api/pull":
+			var req map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&req)
+			if req["model"] == "missing" {
+				fmt.Fprintln(w, `{"status":"pulling manifest"}`)
+				fmt.Fprintln(w, `{"error":"pull model manifest: file does not exist"}`)
+				return
+			}
+			fmt.Fprintln(w, `{"status":"pulling manifest"}`)
+			fmt.Fprintln(w, `{"status":"downloading","digest":"sha256:abc","total":100,"completed":50}`)
+			fmt.Fprintln(w, `{"status":"success"}`)
+		case "/api/delete":
+			if r.Method != http.MethodDelete {
+				t.Errorf("delete used method %s", r.Method)
+			}
+			w.WriteHeader(http.StatusOK)
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+}
+
+func TestOllamaClient_ShowModel(t *testing.T) {
+	var calls int32
+	server := newModelServer(t, &calls)
+	defer server.Close()
+
+	client := NewOllamaClient(server.URL, "llama3:8b")
+	details, err := client.ShowModel(context.Background(), "llama3:8b")
+	if err != nil {
+		t.Fatalf("ShowModel() error = %v", err)
+	}
+	if details.ContextLength != 131072 || details.QuantizationLevel != "Q4_K_M" || details.ParameterSize != "8.0B" {
+		t.Errorf("Unexpected details: %+v", details)
+	}
+
+	// num_ctx in the model's parameters overrides the architecture default
+	show := showResponse{Parameters: "num_ctx 8192\nstop x"}
+	if got := contextLength(show); got != 8192 {
+		t.Errorf("contextLength() = %d, want 8192", got)
+	}
+}
+
+func TestOllamaClient_GetModelsWithDetails(t *testing.T) {
+	var calls int32
+	server := newModelServer(t, &calls)
+	defer server.Close()
+
+	client := NewOllamaClient(server.URL, "llama3:8b")
+	for i := 0; i < 2; i++ {
+		models, err := client.GetModelsWithDetails(context.Background())
+		if err != nil {
+			t.Fatalf("GetModelsWithDetails() error = %v", err)
+		}
+		m := models[0]
+		if m.ParameterSize != "8.0B" || m.Quantization != "Q4_K_M" || m.ContextLength != 131072 {
+			t.Errorf("Model not enriched from /api/show: %+v", m)
+		}
+		if m.Performance != "Fast" || m.Family != "llama" {
+			t.Errorf("Ratings not derived from parameter size: %+v", m)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected details to be cached, /api/show called %d times", calls)
+	}
+}
+
+func TestOllamaClient_PullModel(t *testing.T) {
+	var calls int32
+	server := newModelServer(t, &calls)
+	defer server.Close()
+
+	client := NewOllamaClient(server.URL, "llama3:8b")
+	var events []PullProgress
+	err := client.PullModel(context.Background(), "phi3", func(p PullProgress) {
+		events = append(events, p)
+	})
+	if err != nil {
+		t.Fatalf("PullModel() error = %v", err)
+	}
+	if len(events) != 3 || events[1].Completed != 50 || events[2].Status != "success" {
+		t.Errorf("Unexpected progress events: %+v", events)
+	}
+
+	if err := client.PullModel(context.Background(), "missing", nil); err == nil {
+		t.Error("Expected error when Ollama reports a failure mid-stream")
+	}
+}
+
+func TestCore_DeleteModelProtectsRoles(t *testing.T) {
+	var calls int32
+	server := newModelServer(t, &calls)
+	defer server.Close()
+
+	core := &Core{
+		ollamaClient: NewOllamaClient(server.URL, "llama3:8b"),
+		embeddings:   NewEmbeddingsClient(server.URL, "nomic-embed-text"),
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		protected bool
+	}{
+		{"llama3:8b", true},
+		{"nomic-embed-text:latest", true},
+		{"phi3", false},
+	}
+
+	for _, tt := range tests {
+		err := core.DeleteModel(ctx, tt.name)
+		if tt.protected && !errors.Is(err, ErrModelProtected) {
+			t.Errorf("DeleteModel(%s) = %v, want ErrModelProtected", tt.name, err)
+		}
+		if !tt.protected && err != nil {
+			t.Errorf("DeleteModel(%s) error = %v", tt.name, err)
+		}
+	}
+
+	if roles := core.ModelUsedBy("llama3:8b"); len(roles) != 1 || roles[0] != "chat" {
+		t.Errorf("ModelUsedBy() = %v, want [chat]", roles)
+	}
+}
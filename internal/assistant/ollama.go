@@ -7,9 +7,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
+	"sync"
 	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/prompts"
 )
 
 // OllamaClient handles communication with Ollama API.
@@ -17,6 +21,8 @@ type OllamaClient struct {
 	baseURL string
 	model   string
 	client  *http.Client
+	mu      sync.Mutex
+	details map[string]*ModelDetails // /api/show results by "name@modified_at"
 }
 
 // OllamaMessage represents a chat message for Ollama.
@@ -109,7 +115,8 @@ func (c *OllamaClient) GetModels(ctx context.Context) ([]ModelInfo, error) {
 
 	models := make([]ModelInfo, len(tagsResp.Models))
 	for i, m := range tagsResp.Models {
-		models[i] = enrichModelInfo(m.Name, m.Size)
+		models[i] = enrichModelInfo(m.Name, m.Size, nil)
+		models[i].ModifiedAt = m.ModifiedAt
 	}
 
 	return models, nil
@@ -127,8 +134,13 @@ func (c *OllamaClient) GetCurrentModel() string {
 
 // Chat sends a chat request to Ollama and returns the response.
 func (c *OllamaClient) Chat(ctx context.Context, messages []OllamaMessage) (string, error) {
+	return c.ChatWithModel(ctx, c.model, messages)
+}
+
+// ChatWithModel sends a chat request to a specific model without changing the current one.
+func (c *OllamaClient) ChatWithModel(ctx context.Context, model string, messages []OllamaMessage) (string, error) {
 	chatReq := OllamaChatRequest{
-		Model:    c.model,
+		Model:    model,
 		Messages: messages,
 		Stream:   false,
 	}
@@ -180,23 +192,15 @@ func BuildContextPrompt(ctx *TerminalContext, userMessage string) []OllamaMessag
 
 	// Add context if available
 	if ctx != nil {
-		contextInfo := fmt.Sprintf("Current directory: %s\n", ctx.WorkingDirectory)
-		
-		if len(ctx.RecentCommands) > 0 {
-			contextInfo += "Recent commands:\n"
-			for _, cmd := range ctx.RecentCommands {
-				contextInfo += fmt.Sprintf("  $ %s\n", cmd)
-			}
+		contextInfo, err := prompts.Render(prompts.TerminalContext, TerminalContextData(ctx))
+		if err != nil {
+			log.Printf("[Assistant] Failed to render terminal context prompt: %v", err)
+		} else {
+			messages = append(messages, OllamaMessage{
+				Role:    "system",
+				Content: contextInfo,
+			})
 		}
-
-		if ctx.RecentOutput != "" {
-			contextInfo += fmt.Sprintf("\nRecent output:\n%s\n", ctx.RecentOutput)
-		}
-
-		messages = append(messages, OllamaMessage{
-			Role:    "system",
-			Content: "Terminal context:\n" + contextInfo,
-		})
 	}
 
 	// Add user message
@@ -208,8 +212,19 @@ func BuildContextPrompt(ctx *TerminalContext, userMessage string) []OllamaMessag
 	return messages
 }
 
+// TerminalContextData returns the variables available to the "terminal_context" prompt template.
+func TerminalContextData(ctx *TerminalContext) map[string]interface{} {
+	return map[string]interface{}{
+		"WorkingDirectory": ctx.WorkingDirectory,
+		"RecentCommands":   ctx.RecentCommands,
+		"RecentOutput":     ctx.RecentOutput,
+		"SessionID":        ctx.SessionID,
+	}
+}
+
 // enrichModelInfo adds friendly names and metadata to model names.
-func enrichModelInfo(name string, size int64) ModelInfo {
+// When Ollama's /api/show details are available they replace the name-based guesses.
+func enrichModelInfo(name string, size int64, details *ModelDetails) ModelInfo {
 	info := ModelInfo{
 		Name:          name,
 		FriendlyName:  makeFriendlyName(name),
@@ -218,45 +233,88 @@ func enrichModelInfo(name string, size int64) ModelInfo {
 		Family:        extractFamily(name),
 	}
 
+	if details != nil {
+		if details.Family != "" {
+			info.Family = details.Family
+		}
+		info.ParameterSize = details.ParameterSize
+		info.Quantization = details.QuantizationLevel
+		info.ContextLength = details.ContextLength
+		info.Format = details.Format
+	}
+
+	info.BestFor = bestFor(info.Family)
+
+	// Rate speed and quality from the real parameter count when we know it
+	if billions, ok := parameterCount(info.ParameterSize); ok {
+		switch {
+		case billions < 4:
+			info.Performance, info.Quality = "Very Fast", "Good"
+		case billions < 10:
+			info.Performance, info.Quality = "Fast", "Excellent"
+		case billions < 30:
+			info.Performance, info.Quality = "Balanced", "Excellent"
+		default:
+			info.Performance, info.Quality = "Slow", "Outstanding"
+		}
+		return info
+	}
+
 	// Add performance/quality ratings based on model type
 	switch info.Family {
 	case "llama", "llama2", "llama3":
 		info.Performance = "Balanced"
 		info.Quality = "Excellent"
-		info.BestFor = "General purpose, code"
 	case "mistral":
 		info.Performance = "Fast"
 		info.Quality = "Excellent"
-		info.BestFor = "Code, chat, reasoning"
 	case "codellama":
 		info.Performance = "Balanced"
 		info.Quality = "Excellent"
-		info.BestFor = "Code generation"
 	case "phi", "phi2", "phi3":
 		info.Performance = "Very Fast"
 		info.Quality = "Good"
-		info.BestFor = "Quick responses, chat"
 	case "gemma":
 		info.Performance = "Fast"
 		info.Quality = "Good"
-		info.BestFor = "Chat, general purpose"
 	case "qwen", "qwen2":
 		info.Performance = "Balanced"
 		info.Quality = "Excellent"
-		info.BestFor = "Multilingual, code"
 	case "deepseek-coder":
 		info.Performance = "Balanced"
 		info.Quality = "Excellent"
-		info.BestFor = "Code generation, debugging"
 	default:
 		info.Performance = "Unknown"
 		info.Quality = "Unknown"
-		info.BestFor = "General purpose"
 	}
 
 	return info
 }
 
+// bestFor describes what a model family is suited to.
+func bestFor(family string) string {
+	switch family {
+	case "llama", "llama2", "llama3":
+		return "General purpose, code"
+	case "mistral":
+		return "Code, chat, reasoning"
+	case "codellama":
+		return "Code generation"
+	case "phi", "phi2", "phi3":
+		return "Quick responses, chat"
+	case "gemma", "gemma2":
+		return "Chat, general purpose"
+	case "qwen", "qwen2":
+		return "Multilingual, code"
+	case "deepseek-coder":
+		return "Code generation, debugging"
+	case "nomic-bert", "bert":
+		return "Embeddings"
+	default:
+		return "General purpose"
+	}
+}
+
 // makeFriendlyName converts model names to friendly display names.
 func makeFriendlyName(name string) string {
 	// Examples:
@@ -6,6 +6,10 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/metrics"
+	"github.com/mikejsmith1985/forge-terminal/internal/prompts"
 )
 
 // RAGEngine combines embeddings, vector search, and chat for RAG-based responses.
@@ -14,15 +18,17 @@ type RAGEngine struct {
 	vectorStore      *VectorStore
 	ollamaClient     *OllamaClient
 	knowledgeBase    *KnowledgeBase
+	convIndex        *ConversationIndex
 }
 
 // RAGConfig holds configuration for the RAG engine.
 type RAGConfig struct {
-	TopK              int     // Number of documents to retrieve
-	Threshold         float32 // Similarity threshold
-	IncludeKnowledge  bool    // Include knowledge base in prompt
-	MaxContextLength  int     // Maximum context to inject
-	FallbackToKB      bool    // Fall back to KB if RAG unavailable
+	TopK               int                // Number of documents to retrieve
+	Threshold          float32            // Similarity threshold
+	IncludeKnowledge   bool               // Include knowledge base in prompt
+	MaxContextLength   int                // Maximum context to inject
+	FallbackToKB       bool               // Fall back to KB if RAG unavailable
+	ConversationFilter ConversationFilter // Restricts which AM conversations are retrieved
 }
 
 // DefaultRAGConfig returns sensible defaults.
@@ -51,6 +57,11 @@ func NewRAGEngine(
 	}
 }
 
+// SetConversationIndex attaches the AM conversation collection used alongside documentation.
+func (r *RAGEngine) SetConversationIndex(index *ConversationIndex) {
+	r.convIndex = index
+}
+
 // ContextualChat sends a question with RAG-retrieved context.
 func (r *RAGEngine) ContextualChat(
 	ctx context.Context,
@@ -95,17 +106,32 @@ func (r *RAGEngine) ContextualChat(
 	}, nil
 }
 
-// buildEnhancedPrompt builds a prompt with RAG context.
+// buildEnhancedPrompt builds a prompt with RAG context using the "rag" prompt template.
 func (r *RAGEngine) buildEnhancedPrompt(
 	ctx context.Context,
 	userMessage string,
 	config RAGConfig,
 ) (string, error) {
-	var prompt strings.Builder
+	return prompts.Render(prompts.RAG, r.PromptData(ctx, userMessage, config))
+}
+
+// PromptData retrieves context for userMessage and returns the variables available
+// to the "rag" prompt template.
+func (r *RAGEngine) PromptData(
+	ctx context.Context,
+	userMessage string,
+	config RAGConfig,
+) map[string]interface{} {
+	data := map[string]interface{}{
+		"SystemPrompt":  "",
+		"Documentation": "",
+		"Conversations": "",
+		"UserMessage":   userMessage,
+	}
 
 	// Start with knowledge base
 	if config.IncludeKnowledge && r.knowledgeBase != nil {
-		prompt.WriteString(r.knowledgeBase.GetSystemPrompt())
+		data["SystemPrompt"] = r.knowledgeBase.GetSystemPrompt()
 	}
 
 	// Try to add RAG context
@@ -114,13 +140,22 @@ func (r *RAGEngine) buildEnhancedPrompt(
 		if err != nil {
 			log.Printf("[RAG] Warning: Failed to retrieve context: %v", err)
 			// Don't fail, just use knowledge base
-		} else if ragContext != "" {
-			prompt.WriteString("\n\n# RELEVANT DOCUMENTATION\n\n")
-			prompt.WriteString(ragContext)
+		} else {
+			data["Documentation"] = ragContext
 		}
 	}
 
-	return prompt.String(), nil
+	// Add past AM conversations, each linked back to its detail view
+	if r.convIndex != nil && r.convIndex.Count() > 0 {
+		convContext, err := r.retrieveConversations(ctx, userMessage, config)
+		if err != nil {
+			log.Printf("[RAG] Warning: Failed to retrieve conversations: %v", err)
+		} else {
+			data["Conversations"] = convContext
+		}
+	}
+
+	return data
 }
 
 // buildKnowledgeBasePrompt builds a prompt with only knowledge base (fallback).
@@ -141,6 +176,11 @@ func (r *RAGEngine) retrieveContext(
 		return "", nil
 	}
 
+	start := time.Now()
+	defer func() {
+		metrics.RAGQuerySeconds.WithLabelValues("docs").Observe(time.Since(start).Seconds())
+	}()
+
 	// Embed the user message
 	queryVector, err := r.embeddingsClient.Embed(ctx, userMessage)
 	if err != nil {
@@ -181,6 +221,36 @@ func (r *RAGEngine) retrieveContext(
 	return context.String(), nil
 }
 
+// retrieveConversations retrieves relevant AM conversation chunks.
+func (r *RAGEngine) retrieveConversations(
+	ctx context.Context,
+	userMessage string,
+	config RAGConfig,
+) (string, error) {
+	hits, err := r.convIndex.Search(ctx, userMessage, config.ConversationFilter, config.TopK)
+	if err != nil {
+		return "", err
+	}
+
+	var context strings.Builder
+	for i, hit := range hits {
+		if context.Len() >= config.MaxContextLength {
+			break
+		}
+		context.WriteString(fmt.Sprintf("[%d] %s conversation in %s on %s (relevance: %.1f%%, see %s)\n%s\n\n",
+			i+1,
+			hit.Provider,
+			hit.Project,
+			hit.StartTime.Format("2006-01-02"),
+			hit.Similarity*100,
+			hit.Link,
+			hit.Snippet,
+		))
+	}
+
+	return context.String(), nil
+}
+
 // IndexDocuments indexes documents from a file system path.
 // DEPRECATED: Use IndexAllContent for comprehensive indexing.
 func (r *RAGEngine) IndexDocuments(ctx context.Context, docPath string) error {
@@ -270,12 +340,29 @@ func (r *RAGEngine) GetStats() map[string]interface{} {
 	}
 
 	return map[string]interface{}{
-		"indexed_files":      len(sources),
-		"total_chunks":       len(docs),
-		"total_characters":   totalChars,
-		"average_chunk_size": avgChunkSize,
-		"threshold":          r.vectorStore.threshold,
+		"indexed_files":       len(sources),
+		"total_chunks":        len(docs),
+		"total_characters":    totalChars,
+		"average_chunk_size":  avgChunkSize,
+		"threshold":           r.vectorStore.threshold,
+		"conversation_chunks": r.conversationCount(),
+	}
+}
+
+// conversationCount returns the number of indexed conversation chunks.
+func (r *RAGEngine) conversationCount() int {
+	if r.convIndex == nil {
+		return 0
+	}
+	return r.convIndex.Count()
+}
+
+// IndexSizes returns the number of indexed document and conversation chunks.
+func (r *RAGEngine) IndexSizes() (docs, conversations int) {
+	if r.vectorStore != nil {
+		docs = r.vectorStore.Count()
 	}
+	return docs, r.conversationCount()
 }
 
 // IsReady checks if the RAG engine is ready to use.
@@ -283,7 +370,7 @@ func (r *RAGEngine) IsReady() bool {
 	return r.embeddingsClient != nil &&
 		r.vectorStore != nil &&
 		r.ollamaClient != nil &&
-		r.vectorStore.Count() > 0
+		(r.vectorStore.Count() > 0 || r.conversationCount() > 0)
 }
 
 // EnsureEmbeddingsAvailable ensures the embedding model is available.
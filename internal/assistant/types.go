@@ -61,6 +61,11 @@ type ModelInfo struct {
 	Quality       string  `json:"quality"`
 	BestFor       string  `json:"bestFor"`
 	Family        string  `json:"family"`
+	ParameterSize string  `json:"parameterSize,omitempty"`
+	Quantization  string  `json:"quantization,omitempty"`
+	ContextLength int     `json:"contextLength,omitempty"`
+	Format        string  `json:"format,omitempty"`
+	ModifiedAt    string  `json:"modifiedAt,omitempty"`
 }
 
 // SetModelRequest represents a request to change the current model.
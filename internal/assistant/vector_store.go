@@ -66,6 +66,12 @@ func (vs *VectorStore) Index(doc Document) error {
 // Search finds documents semantically similar to the query vector.
 // Returns top N documents sorted by similarity score (highest first).
 func (vs *VectorStore) Search(queryVector []float32, limit int) ([]SearchResult, error) {
+	return vs.SearchFiltered(queryVector, limit, nil)
+}
+
+// SearchFiltered is like Search but only considers documents accepted by filter.
+// A nil filter accepts every document.
+func (vs *VectorStore) SearchFiltered(queryVector []float32, limit int, filter func(*Document) bool) ([]SearchResult, error) {
 	if len(queryVector) == 0 {
 		return nil, fmt.Errorf("query vector cannot be empty")
 	}
@@ -88,7 +94,12 @@ func (vs *VectorStore) Search(queryVector []float32, limit int) ([]SearchResult,
 	// Calculate similarity for all documents
 	results := make([]SearchResult, 0, len(vs.documents))
 
-	for _, doc := range vs.documents {
+	for i := range vs.documents {
+		doc := vs.documents[i]
+		if filter != nil && !filter(&doc) {
+			continue
+		}
+
 		similarity := cosineSimilarity(queryVector, doc.Vector)
 
 		// Only include documents above threshold
@@ -135,6 +146,21 @@ func (vs *VectorStore) Clear() error {
 	return nil
 }
 
+// RemoveBySource deletes every document indexed from source and returns how many were removed.
+func (vs *VectorStore) RemoveBySource(source string) int {
+	kept := vs.documents[:0]
+	removed := 0
+	for _, doc := range vs.documents {
+		if doc.Source == source {
+			removed++
+			continue
+		}
+		kept = append(kept, doc)
+	}
+	vs.documents = kept
+	return removed
+}
+
 // SetThreshold sets the similarity threshold for search results.
 func (vs *VectorStore) SetThreshold(threshold float32) error {
 	if threshold < 0 || threshold > 1 {
@@ -0,0 +1,242 @@
+// Package auth provides authentication for the Forge HTTP API and terminal
+// WebSocket: a per-install secret, a per-launch session token derived from it,
+// and strict Origin checks.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// CookieName is the cookie that carries the session token for the embedded frontend.
+	CookieName = "forge_session"
+	// HeaderName carries the session token (or the install secret) for API clients.
+	HeaderName = "X-Forge-Token"
+	// QueryParam carries the session token where headers can't be set: the
+	// launch URL, WebSocket and EventSource connections from other origins.
+	QueryParam = "token"
+
+	// defaultAllowedOrigins are trusted when ALLOWED_ORIGINS is unset, besides
+	// the server's own origin: the Vite dev server.
+	defaultAllowedOrigins = "http://localhost:3000,http://127.0.0.1:3000"
+)
+
+// secretFile is the on-disk form of the per-install secret.
+type secretFile struct {
+	Secret  string    `json:"secret"`
+	Created time.Time `json:"created"`
+}
+
+// Manager holds the install secret and the current session token.
+type Manager struct {
+	path string
+
+	mu     sync.RWMutex
+	secret []byte
+	token  string
+}
+
+// Load reads the install secret from path, creating it on first run, and
+// derives a fresh session token for this launch.
+func Load(path string) (*Manager, error) {
+	m := &Manager{path: path}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		var f secretFile
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("invalid auth secret file %s: %w", path, err)
+		}
+		if m.secret, err = hex.DecodeString(f.Secret); err != nil || len(m.secret) < 16 {
+			return nil, fmt.Errorf("invalid auth secret in %s", path)
+		}
+	case os.IsNotExist(err):
+		if err := m.newSecret(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	if _, err := m.Rotate(false); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Token returns the current session token.
+func (m *Manager) Token() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.token
+}
+
+// Rotate replaces the session token, invalidating the old one, and returns the
+// new token. With newSecret the install secret is regenerated as well, which
+// also revokes clients that authenticate with the secret.
+func (m *Manager) Rotate(newSecret bool) (string, error) {
+	if newSecret {
+		if err := m.newSecret(); err != nil {
+			return "", err
+		}
+	}
+	nonce, err := randomBytes(16)
+	if err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write([]byte("forge-session:"))
+	mac.Write(nonce)
+	m.token = hex.EncodeToString(mac.Sum(nil))
+	return m.token, nil
+}
+
+// newSecret generates and saves a new install secret.
+func (m *Manager) newSecret() error {
+	secret, err := randomBytes(32)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(secretFile{Secret: hex.EncodeToString(secret), Created: time.Now()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(m.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save auth secret: %w", err)
+	}
+
+	m.mu.Lock()
+	m.secret = secret
+	m.mu.Unlock()
+	return nil
+}
+
+// Authenticate reports whether r carries the session token, in the session
+// cookie, the X-Forge-Token or Authorization: Bearer header, or the token query
+// parameter. Headers may carry the install secret instead, for long-lived clients
+// such as metrics scrapers that can read ~/.forge/auth.json.
+func (m *Manager) Authenticate(r *http.Request) bool {
+	m.mu.RLock()
+	token := m.token
+	secret := hex.EncodeToString(m.secret)
+	m.mu.RUnlock()
+
+	if c, err := r.Cookie(CookieName); err == nil && equal(c.Value, token) {
+		return true
+	}
+	if equal(r.URL.Query().Get(QueryParam), token) {
+		return true
+	}
+	header := r.Header.Get(HeaderName)
+	if bearer := r.Header.Get("Authorization"); strings.HasPrefix(bearer, "Bearer ") {
+		header = strings.TrimPrefix(bearer, "Bearer ")
+	}
+	return equal(header, token) || equal(header, secret)
+}
+
+// SetCookie gives the client the session token as an HttpOnly, same-site cookie.
+func (m *Manager) SetCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName,
+		Value:    m.Token(),
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// Middleware rejects requests from disallowed origins (403) and requests
+// without valid credentials (401).
+func (m *Manager) Middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !OriginAllowed(r) {
+			writeError(w, http.StatusForbidden, "origin not allowed")
+			return
+		}
+		if !m.Authenticate(r) {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": false,
+		"error":   message,
+	})
+}
+
+// AllowedOrigins returns the origins trusted besides the server's own, from the
+// comma-separated ALLOWED_ORIGINS environment variable or the defaults.
+func AllowedOrigins() []string {
+	list := os.Getenv("ALLOWED_ORIGINS")
+	if list == "" {
+		list = defaultAllowedOrigins
+	}
+	var origins []string
+	for _, origin := range strings.Split(list, ",") {
+		origin = strings.TrimSpace(origin)
+		if u, err := url.Parse(origin); err == nil && u.Scheme != "" && u.Host != "" {
+			origin = u.Scheme + "://" + u.Host // Browsers send no path; tolerate one in the setting
+		}
+		if origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// OriginAllowed reports whether a request's Origin may use the API: no Origin
+// (non-browser clients), the server's own origin, or an allowed origin. An
+// ALLOWED_ORIGINS entry of "*" allows every origin.
+func OriginAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && u.Host == r.Host {
+		return true
+	}
+	for _, allowed := range AllowedOrigins() {
+		if allowed == "*" || origin == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func equal(got, want string) bool {
+	return got != "" && want != "" && subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManager_TokenLifecycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth.json")
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("Secret file not created with 0600: %v %v", info, err)
+	}
+
+	// The secret persists across launches; the session token does not
+	again, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again.secret) != string(m.secret) || again.Token() == m.Token() {
+		t.Error("Expected the same secret and a new token on reload")
+	}
+
+	req := func(mutate func(*http.Request)) *http.Request {
+		r := httptest.NewRequest("GET", "/api/config", nil)
+		mutate(r)
+		return r
+	}
+	token := m.Token()
+	for name, r := range map[string]*http.Request{
+		"cookie": req(func(r *http.Request) { r.AddCookie(&http.Cookie{Name: CookieName, Value: token}) }),
+		"header": req(func(r *http.Request) { r.Header.Set(HeaderName, token) }),
+		"bearer": req(func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }),
+		"query":  req(func(r *http.Request) { r.URL.RawQuery = QueryParam + "=" + token }),
+		"secret": req(func(r *http.Request) { r.Header.Set(HeaderName, hexSecret(m)) }),
+	} {
+		if !m.Authenticate(r) {
+			t.Errorf("%s: not authenticated", name)
+		}
+	}
+	if m.Authenticate(req(func(*http.Request) {})) {
+		t.Error("Request without credentials authenticated")
+	}
+	if m.Authenticate(req(func(r *http.Request) { r.AddCookie(&http.Cookie{Name: CookieName, Value: hexSecret(m)}) })) {
+		t.Error("The install secret must not be accepted as a cookie")
+	}
+
+	if _, err := m.Rotate(false); err != nil {
+		t.Fatal(err)
+	}
+	if m.Authenticate(req(func(r *http.Request) { r.Header.Set(HeaderName, token) })) {
+		t.Error("Rotated token still accepted")
+	}
+	oldSecret := hexSecret(m)
+	if _, err := m.Rotate(true); err != nil {
+		t.Fatal(err)
+	}
+	if m.Authenticate(req(func(r *http.Request) { r.Header.Set(HeaderName, oldSecret) })) {
+		t.Error("Rotated secret still accepted")
+	}
+}
+
+func hexSecret(m *Manager) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return hex.EncodeToString(m.secret)
+}
+
+func TestMiddleware_OriginsAndAuth(t *testing.T) {
+	t.Setenv("ALLOWED_ORIGINS", "https://forge.example.com")
+	m, err := Load(filepath.Join(t.TempDir(), "auth.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := m.Middleware(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+
+	tests := []struct {
+		name   string
+		origin string
+		token  string
+		want   int
+	}{
+		{"no origin", "", m.Token(), http.StatusNoContent},
+		{"same origin", "http://127.0.0.1:8333", m.Token(), http.StatusNoContent},
+		{"allowed origin", "https://forge.example.com", m.Token(), http.StatusNoContent},
+		{"foreign origin", "https://evil.example", m.Token(), http.StatusForbidden},
+		{"default dev origin no longer trusted", "http://localhost:3000", m.Token(), http.StatusForbidden},
+		{"missing token", "", "", http.StatusUnauthorized},
+	}
+	for _, tc := range tests {
+		r := httptest.NewRequest("GET", "http://127.0.0.1:8333/api/files/write", nil)
+		if tc.origin != "" {
+			r.Header.Set("Origin", tc.origin)
+		}
+		if tc.token != "" {
+			r.Header.Set(HeaderName, tc.token)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, r)
+		if rec.Code != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, rec.Code, tc.want)
+		}
+	}
+}
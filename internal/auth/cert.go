@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	selfSignedValidity = 365 * 24 * time.Hour
+	selfSignedRenewal  = 30 * 24 * time.Hour // Regenerate when this close to expiry
+)
+
+// EnsureSelfSignedCert makes sure certFile and keyFile hold a self-signed
+// certificate valid for hosts (names or IPs), generating a new one when they are
+// missing, near expiry or don't cover every host.
+func EnsureSelfSignedCert(certFile, keyFile string, hosts []string) error {
+	if cert, err := readCert(certFile); err == nil && coversHosts(cert, hosts) &&
+		time.Until(cert.NotAfter) > selfSignedRenewal {
+		if _, err := os.Stat(keyFile); err == nil {
+			return nil
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"Forge Terminal"}, CommonName: "Forge Terminal (self-signed)"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if h != "" {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return fmt.Errorf("failed to create certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(certFile), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return fmt.Errorf("failed to save key: %w", err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return fmt.Errorf("failed to save certificate: %w", err)
+	}
+	return nil
+}
+
+// CertFingerprint returns the SHA-256 fingerprint of the first certificate in
+// certFile, as colon-separated hex the way browsers show it.
+func CertFingerprint(certFile string) (string, error) {
+	cert, err := readCert(certFile)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(cert.Raw)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":"), nil
+}
+
+// CertHosts returns the names and addresses a certificate for a server bound to
+// bind should cover: loopback, this machine's hostname and, for a wildcard bind,
+// every interface address.
+func CertHosts(bind string) []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	if name, err := os.Hostname(); err == nil && name != "" {
+		hosts = append(hosts, name)
+	}
+	ip := net.ParseIP(bind)
+	switch {
+	case bind == "" || ip != nil && ip.IsUnspecified():
+		if addrs, err := net.InterfaceAddrs(); err == nil {
+			for _, addr := range addrs {
+				if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && !ipNet.IP.IsLinkLocalUnicast() {
+					hosts = append(hosts, ipNet.IP.String())
+				}
+			}
+		}
+	case ip == nil || !ip.IsLoopback():
+		hosts = append(hosts, bind)
+	}
+	return hosts
+}
+
+func readCert(certFile string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no certificate in %s", certFile)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func coversHosts(cert *x509.Certificate, hosts []string) bool {
+	for _, h := range hosts {
+		if h != "" && cert.VerifyHostname(h) != nil {
+			return false
+		}
+	}
+	return true
+}
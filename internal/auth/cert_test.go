@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnsureSelfSignedCert(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls", "cert.pem"), filepath.Join(dir, "tls", "key.pem")
+
+	if err := EnsureSelfSignedCert(certFile, keyFile, []string{"localhost", "127.0.0.1", "devvm"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+		t.Fatalf("Generated pair does not load: %v", err)
+	}
+	if info, _ := os.Stat(keyFile); info.Mode().Perm() != 0600 {
+		t.Errorf("Key mode %v, want 0600", info.Mode().Perm())
+	}
+	fingerprint, err := CertFingerprint(certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fingerprint) != 95 || strings.Count(fingerprint, ":") != 31 {
+		t.Errorf("Unexpected fingerprint format %q", fingerprint)
+	}
+
+	// A certificate that covers the hosts is kept
+	if err := EnsureSelfSignedCert(certFile, keyFile, []string{"devvm"}); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := CertFingerprint(certFile); again != fingerprint {
+		t.Error("Certificate regenerated although it covers the hosts")
+	}
+
+	// A new address gets a new certificate
+	if err := EnsureSelfSignedCert(certFile, keyFile, []string{"10.0.0.5"}); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := CertFingerprint(certFile); again == fingerprint {
+		t.Error("Certificate not regenerated for a new host")
+	}
+}
+
+func TestCertHosts(t *testing.T) {
+	if hosts := CertHosts("192.168.1.50"); hosts[len(hosts)-1] != "192.168.1.50" {
+		t.Errorf("Bind address missing from %v", hosts)
+	}
+	for _, h := range CertHosts("127.0.0.1") {
+		if h == "0.0.0.0" {
+			t.Errorf("Unexpected host %s", h)
+		}
+	}
+}
@@ -2,7 +2,8 @@ package commands
 
 import (
 	"log"
-	"strings"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/llm"
 )
 
 // MigrateCommands upgrades legacy command cards to include new LLM metadata fields.
@@ -38,19 +39,11 @@ func MigrateCommands(commands []Command) ([]Command, bool) {
 }
 
 // inferProviderFromCommand attempts to detect LLM provider from command text
+// using the provider registry's keywords. It returns the short name cards store.
 func inferProviderFromCommand(command, description string) string {
-	combined := strings.ToLower(command + " " + description)
-
-	if strings.Contains(combined, "copilot") || strings.Contains(combined, "gh copilot") {
-		return "copilot"
-	}
-	if strings.Contains(combined, "claude") {
-		return "claude"
+	if def := llm.GetRegistry().Infer(command + " " + description); def != nil {
+		return def.ShortName()
 	}
-	if strings.Contains(combined, "aider") {
-		return "aider"
-	}
-
 	return ""
 }
 
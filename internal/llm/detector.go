@@ -35,6 +35,8 @@ type DetectedCommand struct {
 	Prompt   string
 	RawInput string
 	Detected bool
+	TUI      bool // Provider draws a full-screen TUI
+	PID      int  // Foreground process the command was detected from, if any
 }
 
 // LLMPattern defines a detection pattern for an LLM CLI.
@@ -46,105 +48,102 @@ type LLMPattern struct {
 
 // Detector handles LLM command detection.
 type Detector struct {
-	patterns []*LLMPattern
+	registry *Registry // nil follows the process-wide registry, including reloads
 }
 
-// NewDetector creates a new LLM detector with all supported patterns.
+// NewDetector creates a new LLM detector driven by the provider registry.
 func NewDetector() *Detector {
-	d := &Detector{
-		patterns: []*LLMPattern{
-			// Exact command patterns (highest priority)
-			{
-				Name:  "copilot-standalone",
-				Regex: regexp.MustCompile(`(?i)^copilot(\s|$)`),
-				Extract: func(cmd string) (Provider, CommandType) {
-					return ProviderGitHubCopilot, CommandChat
-				},
-			},
-			{
-				Name:  "gh-copilot-suggest",
-				Regex: regexp.MustCompile(`(?i)^gh\s+copilot\s+suggest`),
-				Extract: func(cmd string) (Provider, CommandType) {
-					return ProviderGitHubCopilot, CommandSuggest
-				},
-			},
-			{
-				Name:  "gh-copilot-explain",
-				Regex: regexp.MustCompile(`(?i)^gh\s+copilot\s+explain`),
-				Extract: func(cmd string) (Provider, CommandType) {
-					return ProviderGitHubCopilot, CommandExplain
-				},
-			},
-			{
-				Name:  "gh-copilot",
-				Regex: regexp.MustCompile(`(?i)^gh\s+copilot`),
-				Extract: func(cmd string) (Provider, CommandType) {
-					return ProviderGitHubCopilot, CommandChat
-				},
-			},
-			{
-				Name:  "claude-standalone",
-				Regex: regexp.MustCompile(`(?i)^claude\s*$`),
-				Extract: func(cmd string) (Provider, CommandType) {
-					return ProviderClaude, CommandChat
-				},
-			},
-			{
-				Name:  "claude-code",
-				Regex: regexp.MustCompile(`(?i)^claude\s+code`),
-				Extract: func(cmd string) (Provider, CommandType) {
-					return ProviderClaude, CommandCode
-				},
-			},
-			{
-				Name:  "aider",
-				Regex: regexp.MustCompile(`(?i)^aider`),
-				Extract: func(cmd string) (Provider, CommandType) {
-					return ProviderAider, CommandCode
-				},
-			},
-			// Path-based patterns (fallback for shell-resolved commands)
-			{
-				Name:  "copilot-path",
-				Regex: regexp.MustCompile(`(?i)/copilot(\s|$)`),
-				Extract: func(cmd string) (Provider, CommandType) {
-					return ProviderGitHubCopilot, CommandChat
-				},
-			},
-			{
-				Name:  "claude-path",
-				Regex: regexp.MustCompile(`(?i)/claude(\s|$)`),
-				Extract: func(cmd string) (Provider, CommandType) {
-					return ProviderClaude, CommandChat
-				},
-			},
-			{
-				Name:  "aider-path",
-				Regex: regexp.MustCompile(`(?i)/aider(\s|$)`),
-				Extract: func(cmd string) (Provider, CommandType) {
-					return ProviderAider, CommandCode
-				},
-			},
-		},
+	return &Detector{}
+}
+
+// NewDetectorWithRegistry creates a detector bound to a specific registry.
+func NewDetectorWithRegistry(r *Registry) *Detector {
+	return &Detector{registry: r}
+}
+
+func (d *Detector) providers() *Registry {
+	if d.registry != nil {
+		return d.registry
 	}
-	return d
+	return GetRegistry()
 }
 
 // DetectCommand analyzes input to determine if it's an LLM command.
+// The typed line is tried first, then the command it actually runs once env
+// assignments and wrappers are stripped ("FOO=1 sudo -E claude", "npx
+// @anthropic-ai/claude-code"), then that command's executable on its own.
 func (d *Detector) DetectCommand(input string) *DetectedCommand {
 	trimmed := strings.TrimSpace(input)
+	registry := d.providers()
 
 	log.Printf("[LLM Detector] ═══ DETECTION START ═══")
 	log.Printf("[LLM Detector] Raw input: '%s' (len=%d)", input, len(input))
 	log.Printf("[LLM Detector] Trimmed: '%s' (len=%d)", trimmed, len(trimmed))
 	log.Printf("[LLM Detector] Hex: % X", []byte(trimmed))
-	log.Printf("[LLM Detector] Testing %d patterns...", len(d.patterns))
 
-	for i, pattern := range d.patterns {
-		log.Printf("[LLM Detector] [%d/%d] Testing pattern '%s'...", i+1, len(d.patterns), pattern.Name)
-		
-		if pattern.Regex.MatchString(trimmed) {
-			provider, cmdType := pattern.Extract(trimmed)
+	if result := d.matchLine(registry, trimmed, input, true); result != nil {
+		return result
+	}
+	for _, candidate := range normalizedCandidates(registry, SplitShellWords(trimmed), trimmed) {
+		log.Printf("[LLM Detector] Retrying with normalized command: '%s'", candidate)
+		if result := d.matchLine(registry, candidate, input, true); result != nil {
+			return result
+		}
+	}
+
+	log.Printf("[LLM Detector] ❌ NO PATTERNS MATCHED")
+	log.Printf("[LLM Detector] ═══ DETECTION END (NO MATCH) ═══")
+	return undetected(input)
+}
+
+// DetectArgv identifies an LLM CLI from a process's argument vector, such as
+// /proc/<pid>/cmdline. Interpreters and wrappers are stripped, so
+// "node .../node_modules/@anthropic-ai/claude-code/cli.js --continue" is Claude.
+func (d *Detector) DetectArgv(argv []string) *DetectedCommand {
+	registry := d.providers()
+	raw := strings.Join(argv, " ")
+
+	if result := d.matchLine(registry, raw, raw, false); result != nil {
+		return result
+	}
+	for _, candidate := range normalizedCandidates(registry, argv, raw) {
+		if result := d.matchLine(registry, candidate, raw, false); result != nil {
+			return result
+		}
+	}
+	return undetected(raw)
+}
+
+// normalizedCandidates returns the wrapper-free command line and its bare
+// executable, skipping any that equal the line already tried.
+func normalizedCandidates(registry *Registry, words []string, tried string) []string {
+	normalized := registry.NormalizeWords(words)
+	if len(normalized) == 0 {
+		return nil
+	}
+	var candidates []string
+	for _, candidate := range []string{strings.Join(normalized, " "), normalized[0]} {
+		if candidate != tried && (len(candidates) == 0 || candidates[0] != candidate) {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
+// matchLine tests line against every registered pattern in order.
+func (d *Detector) matchLine(registry *Registry, line, input string, verbose bool) *DetectedCommand {
+	patterns := registry.Patterns()
+	if verbose {
+		log.Printf("[LLM Detector] Testing %d patterns...", len(patterns))
+	}
+
+	for i, pattern := range patterns {
+		if verbose {
+			log.Printf("[LLM Detector] [%d/%d] Testing pattern '%s'...", i+1, len(patterns), pattern.Name)
+		}
+
+		if pattern.Regex.MatchString(line) {
+			provider, cmdType := pattern.Extract(line)
 			log.Printf("[LLM Detector] ✅ MATCH! pattern='%s' provider=%s type=%s", pattern.Name, provider, cmdType)
 			log.Printf("[LLM Detector] ═══ DETECTION END (MATCHED) ═══")
 			return &DetectedCommand{
@@ -153,14 +152,16 @@ func (d *Detector) DetectCommand(input string) *DetectedCommand {
 				Prompt:   "",
 				RawInput: input,
 				Detected: true,
+				TUI:      registry.Get(provider).isTUI(),
 			}
-		} else {
+		} else if verbose {
 			log.Printf("[LLM Detector] ✗ No match for pattern '%s'", pattern.Name)
 		}
 	}
+	return nil
+}
 
-	log.Printf("[LLM Detector] ❌ NO PATTERNS MATCHED")
-	log.Printf("[LLM Detector] ═══ DETECTION END (NO MATCH) ═══")
+func undetected(input string) *DetectedCommand {
 	return &DetectedCommand{
 		Provider: ProviderUnknown,
 		Type:     CommandUnknown,
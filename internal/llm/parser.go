@@ -33,10 +33,6 @@ var (
 	// TUI frame characters
 	tuiFramePattern = regexp.MustCompile(`[╭╮╯╰│─┌┐└┘├┤┬┴┼═║╔╗╚╝╠╣╦╩╬]`)
 
-	// Copilot CLI specific patterns
-	copilotFooter = regexp.MustCompile(`(?i)(Ctrl\+c\s+Exit|Remaining\s+requests:|Enter\s+@\s+to\s+mention)`)
-	copilotMenu   = regexp.MustCompile(`(?i)(Confirm with number keys|Cancel with Esc)`)
-
 	// Multiple newlines
 	multiNewline = regexp.MustCompile(`\n{3,}`)
 )
@@ -68,19 +64,29 @@ func CleanANSI(text string) string {
 
 // ParseCopilotOutput extracts clean content from GitHub Copilot CLI output.
 func ParseCopilotOutput(raw string) string {
+	return ParseProviderOutput(raw, GetRegistry().Get(ProviderGitHubCopilot))
+}
+
+// ParseClaudeOutput extracts clean content from Claude CLI output.
+func ParseClaudeOutput(raw string) string {
+	return ParseProviderOutput(raw, GetRegistry().Get(ProviderClaude))
+}
+
+// ParseProviderOutput cleans output using a provider definition. TUI providers
+// also lose frame characters and the lines their definition marks as chrome.
+func ParseProviderOutput(raw string, def *ProviderDefinition) string {
 	cleaned := CleanANSI(raw)
+	if def == nil || !def.TUI {
+		return cleaned
+	}
 	cleaned = tuiFramePattern.ReplaceAllString(cleaned, "")
 
 	var contentLines []string
 	for _, line := range strings.Split(cleaned, "\n") {
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "" || len(trimmed) < 3 {
+		if def.IsChrome(line) {
 			continue
 		}
-		if copilotFooter.MatchString(trimmed) || copilotMenu.MatchString(trimmed) {
-			continue
-		}
-		contentLines = append(contentLines, trimmed)
+		contentLines = append(contentLines, strings.TrimRight(line, " \t\r"))
 	}
 
 	result := strings.Join(contentLines, "\n")
@@ -89,23 +95,7 @@ func ParseCopilotOutput(raw string) string {
 	return result
 }
 
-// ParseClaudeOutput extracts clean content from Claude CLI output.
-func ParseClaudeOutput(raw string) string {
-	cleaned := CleanANSI(raw)
-	cleaned = tuiFramePattern.ReplaceAllString(cleaned, "")
-	cleaned = strings.TrimSpace(cleaned)
-	cleaned = multiNewline.ReplaceAllString(cleaned, "\n\n")
-	return cleaned
-}
-
-// ParseLLMOutput routes to provider-specific parser.
+// ParseLLMOutput routes to the parser for the provider's registered definition.
 func ParseLLMOutput(raw string, provider Provider) string {
-	switch provider {
-	case ProviderGitHubCopilot:
-		return ParseCopilotOutput(raw)
-	case ProviderClaude:
-		return ParseClaudeOutput(raw)
-	default:
-		return CleanANSI(raw)
-	}
+	return ParseProviderOutput(raw, GetRegistry().Get(provider))
 }
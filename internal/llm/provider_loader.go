@@ -0,0 +1,325 @@
+// Package llm provides loading of provider definitions from JSON and YAML files.
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LoadRegistry builds a registry from the built-in providers plus every *.json,
+// *.yaml and *.yml definition in dir. A file whose ID matches a built-in replaces
+// it; new providers are tried before the built-ins so they can claim more specific
+// commands. Files that fail to parse are skipped and reported in the returned errors.
+func LoadRegistry(dir string) (*Registry, []error) {
+	builtins := BuiltinProviders()
+	builtinIndex := make(map[Provider]int, len(builtins))
+	for i, def := range builtins {
+		builtinIndex[def.ID] = i
+	}
+
+	var custom []*ProviderDefinition
+	var errs []error
+	for _, def := range readProviderDir(dir, &errs) {
+		if i, ok := builtinIndex[def.ID]; ok {
+			builtins[i] = def
+			continue
+		}
+		custom = append(custom, def)
+	}
+
+	r, err := NewRegistry(append(custom, builtins...)...)
+	if err != nil {
+		// Definitions are validated per file, so this only guards against a bad built-in
+		errs = append(errs, err)
+		r, _ = NewRegistry(BuiltinProviders()...)
+	}
+	return r, errs
+}
+
+func readProviderDir(dir string, errs *[]error) []*ProviderDefinition {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			*errs = append(*errs, err)
+		}
+		return nil
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var defs []*ProviderDefinition
+	for _, name := range names {
+		ext := strings.ToLower(filepath.Ext(name))
+		if ext != ".json" && ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			*errs = append(*errs, err)
+			continue
+		}
+		def, err := ParseProviderDefinition(data, ext)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		def.Source = path
+		defs = append(defs, def)
+	}
+	return defs
+}
+
+// ParseProviderDefinition decodes a definition from JSON, or from YAML when ext is
+// ".yaml" or ".yml", and validates its patterns.
+func ParseProviderDefinition(data []byte, ext string) (*ProviderDefinition, error) {
+	if ext == ".yaml" || ext == ".yml" {
+		doc, err := parseSimpleYAML(string(data))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	var def ProviderDefinition
+	if err := json.Unmarshal(data, &def); err != nil {
+		return nil, err
+	}
+	if err := def.compile(); err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+// yamlLine is a non-blank, non-comment line of a YAML document.
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+// parseSimpleYAML parses the subset of YAML provider files need: nested mappings,
+// block and flow sequences, and plain or quoted scalars. Anchors, multi-line
+// strings and multiple documents are not supported.
+func parseSimpleYAML(src string) (interface{}, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+		text := strings.TrimSpace(raw)
+		if text == "" || strings.HasPrefix(text, "#") || text == "---" {
+			continue
+		}
+		if strings.Contains(raw[:len(raw)-len(strings.TrimLeft(raw, " \t"))], "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		lines = append(lines, yamlLine{num: i + 1, indent: len(raw) - len(strings.TrimLeft(raw, " ")), text: text})
+	}
+	if len(lines) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	value, next, err := parseYAMLBlock(lines, 0, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if next < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[next].num)
+	}
+	return value, nil
+}
+
+func isYAMLListItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func parseYAMLBlock(lines []yamlLine, i, indent int) (interface{}, int, error) {
+	if isYAMLListItem(lines[i].text) {
+		return parseYAMLList(lines, i, indent)
+	}
+	return parseYAMLMap(lines, i, indent)
+}
+
+func parseYAMLList(lines []yamlLine, i, indent int) (interface{}, int, error) {
+	list := []interface{}{}
+	for i < len(lines) && lines[i].indent == indent && isYAMLListItem(lines[i].text) {
+		item := strings.TrimSpace(strings.TrimPrefix(lines[i].text, "-"))
+		switch {
+		case item == "":
+			if i+1 >= len(lines) || lines[i+1].indent <= indent {
+				list = append(list, nil)
+				i++
+				continue
+			}
+			value, next, err := parseYAMLBlock(lines, i+1, lines[i+1].indent)
+			if err != nil {
+				return nil, 0, err
+			}
+			list, i = append(list, value), next
+		case yamlKeyValue(item):
+			// "- key: value" starts a mapping indented to where the key begins
+			lines[i].indent += len(lines[i].text) - len(item)
+			lines[i].text = item
+			value, next, err := parseYAMLMap(lines, i, lines[i].indent)
+			if err != nil {
+				return nil, 0, err
+			}
+			list, i = append(list, value), next
+		default:
+			value, err := parseYAMLScalar(item, lines[i].num)
+			if err != nil {
+				return nil, 0, err
+			}
+			list = append(list, value)
+			i++
+		}
+	}
+	return list, i, nil
+}
+
+func parseYAMLMap(lines []yamlLine, i, indent int) (interface{}, int, error) {
+	m := map[string]interface{}{}
+	for i < len(lines) && lines[i].indent == indent && !isYAMLListItem(lines[i].text) {
+		line := lines[i]
+		if !yamlKeyValue(line.text) {
+			return nil, 0, fmt.Errorf("line %d: expected \"key: value\"", line.num)
+		}
+		key, rest := splitYAMLKey(line.text)
+		i++
+
+		if rest != "" {
+			value, err := parseYAMLScalar(rest, line.num)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[key] = value
+			continue
+		}
+
+		// A nested block is indented further, or is a sequence at the same indent
+		if i < len(lines) && (lines[i].indent > indent || (lines[i].indent == indent && isYAMLListItem(lines[i].text))) {
+			value, next, err := parseYAMLBlock(lines, i, lines[i].indent)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[key], i = value, next
+			continue
+		}
+		m[key] = nil
+	}
+	return m, i, nil
+}
+
+// yamlKeyValue reports whether text is a "key: value" or "key:" pair rather than a scalar.
+func yamlKeyValue(text string) bool {
+	if text == "" || text[0] == '"' || text[0] == '\'' || text[0] == '[' {
+		return false
+	}
+	key, _ := splitYAMLKey(text)
+	return key != ""
+}
+
+func splitYAMLKey(text string) (string, string) {
+	if strings.HasSuffix(text, ":") {
+		return strings.TrimSpace(text[:len(text)-1]), ""
+	}
+	if idx := strings.Index(text, ": "); idx > 0 {
+		return strings.TrimSpace(text[:idx]), strings.TrimSpace(text[idx+2:])
+	}
+	return "", ""
+}
+
+func parseYAMLScalar(s string, num int) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(s, "["):
+		if !strings.HasSuffix(s, "]") {
+			return nil, fmt.Errorf("line %d: unterminated flow sequence", num)
+		}
+		list := []interface{}{}
+		for _, item := range splitYAMLFlow(s[1 : len(s)-1]) {
+			value, err := parseYAMLScalar(item, num)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+	case strings.HasPrefix(s, `"`):
+		end := closingQuote(s, '"')
+		if end < 0 {
+			return nil, fmt.Errorf("line %d: unterminated string", num)
+		}
+		return strconv.Unquote(s[:end+1])
+	case strings.HasPrefix(s, "'"):
+		end := closingQuote(s, '\'')
+		if end < 0 {
+			return nil, fmt.Errorf("line %d: unterminated string", num)
+		}
+		return strings.ReplaceAll(s[1:end], "''", "'"), nil
+	}
+
+	// Trailing comments only apply to plain scalars; regexes belong in quotes
+	if idx := strings.Index(s, " #"); idx >= 0 {
+		s = strings.TrimSpace(s[:idx])
+	}
+	switch s {
+	case "true", "True", "yes":
+		return true, nil
+	case "false", "False", "no":
+		return false, nil
+	case "null", "~", "":
+		return nil, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, nil
+	}
+	return s, nil
+}
+
+// closingQuote returns the index of the quote that closes s, honouring \" escapes in
+// double-quoted strings and doubled quotes in single-quoted ones.
+func closingQuote(s string, quote byte) int {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case s[i] == quote && quote == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// splitYAMLFlow splits the inside of a flow sequence on commas outside quotes.
+func splitYAMLFlow(s string) []string {
+	var items []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\'':
+			if end := closingQuote(s[i:], s[i]); end > 0 {
+				i += end
+			}
+		case ',':
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		items = append(items, last)
+	}
+	return items
+}
@@ -0,0 +1,460 @@
+// Package llm provides the registry of LLM CLI provider definitions.
+package llm
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/storage"
+)
+
+// ProviderDefinition describes an LLM CLI: how to recognise it on the command line
+// and how to read its screen. Built-in definitions cover Copilot, Claude and Aider;
+// more can be added (or the built-ins overridden) from ~/.forge/providers.
+type ProviderDefinition struct {
+	ID       Provider         `json:"id"`
+	Name     string           `json:"name"`
+	Aliases  []string         `json:"aliases,omitempty"`  // Other names clients may send; the first is the short name command cards store
+	Keywords []string         `json:"keywords,omitempty"` // Substrings used to infer the provider from free-form command text
+	Commands []CommandPattern `json:"commands"`
+
+	// Packages maps npm/pip packages that install the CLI to the command they provide,
+	// so "npx @anthropic-ai/claude-code" or "pipx run aider-chat" are recognised.
+	Packages map[string]string `json:"packages,omitempty"`
+
+	// TUI tools redraw a full screen and are captured through snapshots rather
+	// than line-by-line output.
+	TUI bool `json:"tui"`
+
+	UserPromptMarkers []string `json:"userPromptMarkers,omitempty"` // Line prefixes marking user input
+	ResponseMarkers   []string `json:"responseMarkers,omitempty"`   // Line prefixes marking the start of a response
+	ExitPrompts       []string `json:"exitPrompts,omitempty"`       // Screen text showing the CLI is waiting for input again
+	IgnoreLines       []string `json:"ignoreLines,omitempty"`       // Regexes for UI chrome lines (banners, footers, status bars)
+	CleanupPatterns   []string `json:"cleanupPatterns,omitempty"`   // Regexes removed from captured assistant output
+
+	// MinResponseLength is the shortest screen line treated as response content.
+	MinResponseLength int `json:"minResponseLength,omitempty"`
+
+	// Launch is the command line that starts the CLI, used when restoring a session.
+	// It defaults to the short name.
+	Launch          string   `json:"launch,omitempty"`
+	ReadyPatterns   []string `json:"readyPatterns,omitempty"`   // Regexes for screen text showing the CLI is ready for a prompt
+	CommandPrefixes []string `json:"commandPrefixes,omitempty"` // Input prefixes the CLI reads as commands, not prompts
+
+	// Source is "builtin" or the file the definition was loaded from.
+	Source string `json:"source"`
+
+	ignoreRes  []*regexp.Regexp
+	cleanupRes []*regexp.Regexp
+	readyRes   []*regexp.Regexp
+}
+
+// CommandPattern matches a command line that launches a provider.
+type CommandPattern struct {
+	Name    string      `json:"name,omitempty"`
+	Pattern string      `json:"pattern"`
+	Type    CommandType `json:"type,omitempty"`
+	// Fallback patterns are only tried after every provider's primary patterns,
+	// e.g. matching a shell-resolved path like /usr/local/bin/claude.
+	Fallback bool `json:"fallback,omitempty"`
+}
+
+// DefaultMinResponseLength applies to providers that don't set MinResponseLength.
+const DefaultMinResponseLength = 25
+
+// compile validates the definition and prepares its regexes.
+func (d *ProviderDefinition) compile() error {
+	if d.ID == "" {
+		return fmt.Errorf("provider definition has no id")
+	}
+	if d.Name == "" {
+		d.Name = string(d.ID)
+	}
+	for i := range d.Commands {
+		cmd := &d.Commands[i]
+		if cmd.Pattern == "" {
+			return fmt.Errorf("provider %s: command %d has no pattern", d.ID, i)
+		}
+		if _, err := regexp.Compile(cmd.Pattern); err != nil {
+			return fmt.Errorf("provider %s: invalid command pattern %q: %w", d.ID, cmd.Pattern, err)
+		}
+		if cmd.Type == "" {
+			cmd.Type = CommandChat
+		}
+		if cmd.Name == "" {
+			cmd.Name = fmt.Sprintf("%s-%d", d.ID, i+1)
+		}
+	}
+
+	var err error
+	if d.ignoreRes, err = compilePatterns(d.ID, d.IgnoreLines); err != nil {
+		return err
+	}
+	if d.cleanupRes, err = compilePatterns(d.ID, d.CleanupPatterns); err != nil {
+		return err
+	}
+	if d.readyRes, err = compilePatterns(d.ID, d.ReadyPatterns); err != nil {
+		return err
+	}
+	return nil
+}
+
+func compilePatterns(id Provider, patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("provider %s: invalid pattern %q: %w", id, p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// ShortName returns the name command cards store for this provider.
+func (d *ProviderDefinition) ShortName() string {
+	if len(d.Aliases) > 0 {
+		return d.Aliases[0]
+	}
+	return string(d.ID)
+}
+
+// IsChrome reports whether a screen line is UI chrome rather than conversation content.
+func (d *ProviderDefinition) IsChrome(line string) bool {
+	for _, re := range d.ignoreRes {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// UserPrompt returns the text after a user prompt marker, if line starts with one.
+func (d *ProviderDefinition) UserPrompt(line string) (string, bool) {
+	return cutMarker(line, d.UserPromptMarkers)
+}
+
+// ResponseStart returns the text after a response marker, if line starts with one.
+func (d *ProviderDefinition) ResponseStart(line string) (string, bool) {
+	return cutMarker(line, d.ResponseMarkers)
+}
+
+func cutMarker(line string, markers []string) (string, bool) {
+	for _, marker := range markers {
+		if strings.HasPrefix(line, marker) {
+			return strings.TrimSpace(line[len(marker):]), true
+		}
+	}
+	return "", false
+}
+
+// PromptReturned reports whether the tail of output shows one of the provider's
+// exit prompts, meaning the response has finished.
+func (d *ProviderDefinition) PromptReturned(output string) bool {
+	tail := output
+	if len(tail) > 100 {
+		tail = tail[len(tail)-100:]
+	}
+	for _, prompt := range d.ExitPrompts {
+		if strings.Contains(tail, prompt) {
+			return true
+		}
+	}
+	return false
+}
+
+// Cleanup removes the provider's TUI artifacts from captured output.
+func (d *ProviderDefinition) Cleanup(s string) string {
+	for _, re := range d.cleanupRes {
+		s = re.ReplaceAllString(s, "")
+	}
+	return s
+}
+
+// ResponseLineLength returns the shortest line length treated as response content.
+func (d *ProviderDefinition) ResponseLineLength() int {
+	if d == nil || d.MinResponseLength <= 0 {
+		return DefaultMinResponseLength
+	}
+	return d.MinResponseLength
+}
+
+// LaunchCommand returns the command line that starts the CLI.
+func (d *ProviderDefinition) LaunchCommand() string {
+	if d.Launch != "" {
+		return d.Launch
+	}
+	return d.ShortName()
+}
+
+// IsReady reports whether cleaned screen text shows the CLI waiting for a prompt.
+// Providers without ready patterns never report ready; callers fall back to output
+// going quiet.
+func (d *ProviderDefinition) IsReady(screen string) bool {
+	for _, re := range d.readyRes {
+		if re.MatchString(screen) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasReadyPatterns reports whether the provider defines a ready prompt.
+func (d *ProviderDefinition) HasReadyPatterns() bool {
+	return len(d.readyRes) > 0
+}
+
+// FormatPrompt prepares text to be pasted into the CLI as a single prompt: line
+// endings become carriage returns as a terminal paste sends them, escape bytes
+// that could end a bracketed paste early are dropped, and a leading command
+// prefix (such as / or !) is indented so the CLI doesn't run it as a command.
+func (d *ProviderDefinition) FormatPrompt(text string) string {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\x1b", ""))
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	for _, prefix := range d.CommandPrefixes {
+		if prefix != "" && strings.HasPrefix(text, prefix) {
+			text = " " + text
+			break
+		}
+	}
+	return strings.ReplaceAll(text, "\n", "\r")
+}
+
+// Registry holds provider definitions in detection order.
+type Registry struct {
+	providers []*ProviderDefinition
+	byName    map[string]*ProviderDefinition // IDs and aliases, lower-cased
+	patterns  []*LLMPattern                  // Primary patterns first, then fallbacks
+	packages  map[string]string              // Package name (lower-cased) -> command
+}
+
+// NewRegistry builds a registry from definitions. A definition whose ID is already
+// present replaces the earlier one in place.
+func NewRegistry(defs ...*ProviderDefinition) (*Registry, error) {
+	r := &Registry{byName: make(map[string]*ProviderDefinition), packages: make(map[string]string)}
+
+	index := make(map[Provider]int)
+	for _, def := range defs {
+		if err := def.compile(); err != nil {
+			return nil, err
+		}
+		if i, ok := index[def.ID]; ok {
+			r.providers[i] = def
+			continue
+		}
+		index[def.ID] = len(r.providers)
+		r.providers = append(r.providers, def)
+	}
+
+	// IDs take precedence over aliases
+	for _, def := range r.providers {
+		r.byName[strings.ToLower(string(def.ID))] = def
+	}
+	for _, def := range r.providers {
+		for _, alias := range def.Aliases {
+			if _, taken := r.byName[strings.ToLower(alias)]; !taken {
+				r.byName[strings.ToLower(alias)] = def
+			}
+		}
+	}
+
+	for _, def := range r.providers {
+		for pkg, cmd := range def.Packages {
+			r.packages[strings.ToLower(pkg)] = cmd
+		}
+	}
+
+	for _, fallback := range []bool{false, true} {
+		for _, def := range r.providers {
+			for _, cmd := range def.Commands {
+				if cmd.Fallback == fallback {
+					r.patterns = append(r.patterns, newLLMPattern(def, cmd))
+				}
+			}
+		}
+	}
+	return r, nil
+}
+
+func newLLMPattern(def *ProviderDefinition, cmd CommandPattern) *LLMPattern {
+	provider, cmdType := def.ID, cmd.Type
+	return &LLMPattern{
+		Name:  cmd.Name,
+		Regex: regexp.MustCompile(cmd.Pattern),
+		Extract: func(string) (Provider, CommandType) {
+			return provider, cmdType
+		},
+	}
+}
+
+// List returns the definitions in detection order.
+func (r *Registry) List() []*ProviderDefinition {
+	return append([]*ProviderDefinition(nil), r.providers...)
+}
+
+// Get returns the definition for a provider ID, or nil.
+func (r *Registry) Get(id Provider) *ProviderDefinition {
+	def := r.byName[strings.ToLower(string(id))]
+	if def == nil || def.ID != id {
+		return nil
+	}
+	return def
+}
+
+// Resolve returns the definition named by an ID or alias, ignoring case, or nil.
+func (r *Registry) Resolve(name string) *ProviderDefinition {
+	return r.byName[strings.ToLower(strings.TrimSpace(name))]
+}
+
+// Infer returns the first provider whose keywords appear in text, or nil.
+func (r *Registry) Infer(text string) *ProviderDefinition {
+	lower := strings.ToLower(text)
+	for _, def := range r.providers {
+		for _, keyword := range def.Keywords {
+			if keyword != "" && strings.Contains(lower, strings.ToLower(keyword)) {
+				return def
+			}
+		}
+	}
+	return nil
+}
+
+// Patterns returns the command patterns in the order they should be tried.
+func (r *Registry) Patterns() []*LLMPattern {
+	return r.patterns
+}
+
+// BuiltinProviders returns the definitions shipped with Forge.
+func BuiltinProviders() []*ProviderDefinition {
+	return []*ProviderDefinition{
+		{
+			ID:       ProviderGitHubCopilot,
+			Name:     "GitHub Copilot",
+			Aliases:  []string{"copilot", "gh-copilot"},
+			Keywords: []string{"copilot"},
+			Packages: map[string]string{"@github/copilot": "copilot"},
+			Commands: []CommandPattern{
+				{Name: "copilot-standalone", Pattern: `(?i)^copilot(\s|$)`, Type: CommandChat},
+				{Name: "gh-copilot-suggest", Pattern: `(?i)^gh\s+copilot\s+suggest`, Type: CommandSuggest},
+				{Name: "gh-copilot-explain", Pattern: `(?i)^gh\s+copilot\s+explain`, Type: CommandExplain},
+				{Name: "gh-copilot", Pattern: `(?i)^gh\s+copilot`, Type: CommandChat},
+				{Name: "copilot-path", Pattern: `(?i)/copilot(\s|$)`, Type: CommandChat, Fallback: true},
+			},
+			TUI:               true,
+			UserPromptMarkers: []string{">"},
+			ExitPrompts:       []string{"\n‌", "\n❯", "~/"},
+			IgnoreLines: []string{
+				`────`,
+				`(?i)Ctrl\+`,
+				`(?i)Welcome to GitHub Copilot`,
+				`(?i)Enter\s+@\s+to\s+mention`,
+				`(?i)Remaining\s+requests:`,
+				`(?i)Confirm with number keys|Cancel with Esc`,
+				`gpt-|claude-`, // Model status line
+			},
+			CleanupPatterns:   []string{`Welcome to GitHub Copilot.*?mistakes\.`, `●.*?\n`},
+			MinResponseLength: 30,
+			Launch:            "copilot",
+			ReadyPatterns:     []string{`(?i)Enter\s+@\s+to\s+mention`},
+			CommandPrefixes:   []string{"/", "!", "@"},
+			Source:            "builtin",
+		},
+		{
+			ID:       ProviderClaude,
+			Name:     "Claude",
+			Keywords: []string{"claude"},
+			Packages: map[string]string{"@anthropic-ai/claude-code": "claude"},
+			Commands: []CommandPattern{
+				{Name: "claude-standalone", Pattern: `(?i)^claude\s*$`, Type: CommandChat},
+				{Name: "claude-code", Pattern: `(?i)^claude\s+code`, Type: CommandCode},
+				{Name: "claude-path", Pattern: `(?i)/claude(\s|$)`, Type: CommandChat, Fallback: true},
+			},
+			TUI:               true,
+			UserPromptMarkers: []string{">", "You:", "User:"},
+			ResponseMarkers:   []string{"Claude:", "Assistant:", "AI:"},
+			ExitPrompts:       []string{"\n>", "Claude >", "\n❯"},
+			IgnoreLines:       []string{`Claude Code`, `(?i)Welcome to Claude`, `(?i)Tips for getting started`},
+			CleanupPatterns:   []string{`Claude Code v[\d.]+`, `Tips for getting started.*?\n`},
+			MinResponseLength: 20,
+			Launch:            "claude",
+			ReadyPatterns:     []string{`\? for shortcuts`, `(?m)^\s*│\s*>\s`},
+			CommandPrefixes:   []string{"/", "!", "#"},
+			Source:            "builtin",
+		},
+		{
+			ID:       ProviderAider,
+			Name:     "Aider",
+			Keywords: []string{"aider"},
+			Packages: map[string]string{"aider-chat": "aider", "aider-install": "aider"},
+			Commands: []CommandPattern{
+				{Name: "aider", Pattern: `(?i)^aider`, Type: CommandCode},
+				{Name: "aider-path", Pattern: `(?i)/aider(\s|$)`, Type: CommandCode, Fallback: true},
+			},
+			UserPromptMarkers: []string{"> "},
+			ExitPrompts:       []string{"\n>", "aider>"},
+			MinResponseLength: 15,
+			Launch:            "aider",
+			ReadyPatterns:     []string{`(?m)^[a-z-]*> ?$`},
+			CommandPrefixes:   []string{"/"},
+			Source:            "builtin",
+		},
+	}
+}
+
+var (
+	registryMu     sync.RWMutex
+	globalRegistry *Registry
+)
+
+// GetRegistry returns the process-wide registry, loading ~/.forge/providers on first use.
+func GetRegistry() *Registry {
+	registryMu.RLock()
+	r := globalRegistry
+	registryMu.RUnlock()
+	if r != nil {
+		return r
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if globalRegistry == nil {
+		globalRegistry = loadRegistryLogged(storage.GetProvidersDir())
+	}
+	return globalRegistry
+}
+
+// SetRegistry replaces the process-wide registry.
+func SetRegistry(r *Registry) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	globalRegistry = r
+}
+
+// ReloadProviders re-reads ~/.forge/providers and returns any per-file errors.
+// Files that fail to load are skipped; the rest still take effect.
+func ReloadProviders() []error {
+	r, errs := LoadRegistry(storage.GetProvidersDir())
+	SetRegistry(r)
+	return errs
+}
+
+func loadRegistryLogged(dir string) *Registry {
+	r, errs := LoadRegistry(dir)
+	for _, err := range errs {
+		log.Printf("[LLM Providers] ⚠️ %v", err)
+	}
+	log.Printf("[LLM Providers] Loaded %d provider definitions", len(r.providers))
+	return r
+}
+
+// LookupProvider resolves an ID or alias against the process-wide registry.
+func LookupProvider(name string) *ProviderDefinition {
+	return GetRegistry().Resolve(name)
+}
+
+func (d *ProviderDefinition) isTUI() bool {
+	return d != nil && d.TUI
+}
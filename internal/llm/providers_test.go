@@ -0,0 +1,217 @@
+package llm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuiltinRegistry_DetectsKnownCLIs(t *testing.T) {
+	r, errs := LoadRegistry(t.TempDir())
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected load errors: %v", errs)
+	}
+	d := NewDetectorWithRegistry(r)
+
+	tests := []struct {
+		input    string
+		provider Provider
+		cmdType  CommandType
+		tui      bool
+	}{
+		{"copilot", ProviderGitHubCopilot, CommandChat, true},
+		{"gh copilot suggest list files", ProviderGitHubCopilot, CommandSuggest, true},
+		{"gh copilot explain 'ls -la'", ProviderGitHubCopilot, CommandExplain, true},
+		{"claude", ProviderClaude, CommandChat, true},
+		{"claude code", ProviderClaude, CommandCode, true},
+		{"aider --model gpt-4", ProviderAider, CommandCode, false},
+		{"/usr/local/bin/claude --resume", ProviderClaude, CommandChat, true},
+	}
+	for _, tc := range tests {
+		got := d.DetectCommand(tc.input)
+		if !got.Detected || got.Provider != tc.provider || got.Type != tc.cmdType || got.TUI != tc.tui {
+			t.Errorf("DetectCommand(%q) = %+v, want %s/%s tui=%v", tc.input, got, tc.provider, tc.cmdType, tc.tui)
+		}
+	}
+
+	if d.IsLLMCommand("ls -la") {
+		t.Error("Plain shell command detected as LLM")
+	}
+}
+
+func TestRegistry_ResolveAndInfer(t *testing.T) {
+	r, _ := NewRegistry(BuiltinProviders()...)
+
+	if def := r.Resolve("Copilot"); def == nil || def.ID != ProviderGitHubCopilot {
+		t.Errorf("Alias not resolved: %+v", def)
+	}
+	if def := r.Resolve("gh-copilot"); def == nil || def.ShortName() != "copilot" {
+		t.Errorf("Unexpected short name for gh-copilot: %+v", def)
+	}
+	if r.Get("copilot") != nil {
+		t.Error("Get should only match provider IDs")
+	}
+	if def := r.Infer("Ask Claude to review"); def == nil || def.ID != ProviderClaude {
+		t.Errorf("Infer failed: %+v", def)
+	}
+	if r.Infer("npm test") != nil {
+		t.Error("Infer matched unrelated text")
+	}
+}
+
+func TestProviderDefinition_RestoreHelpers(t *testing.T) {
+	r, _ := NewRegistry(BuiltinProviders()...)
+	claude := r.Get(ProviderClaude)
+
+	if claude.LaunchCommand() != "claude" || r.Resolve("copilot").LaunchCommand() != "copilot" {
+		t.Error("Unexpected launch commands")
+	}
+	if !claude.IsReady("\x1b[2mwelcome\n  ? for shortcuts") {
+		t.Error("Claude's input hint not recognised as ready")
+	}
+	if claude.IsReady("Loading...") {
+		t.Error("Claude reported ready while loading")
+	}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Continue the refactor\r\nthen run tests", "Continue the refactor\rthen run tests"},
+		{"/clear the cache first", " /clear the cache first"},
+		{"colour \x1b[31mred\x1b[0m", "colour [31mred[0m"},
+	}
+	for _, tc := range tests {
+		if got := claude.FormatPrompt(tc.input); got != tc.want {
+			t.Errorf("FormatPrompt(%q) = %q, want %q", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestLoadRegistry_CustomAndOverriddenProviders(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlDef := `# Cursor's agent CLI
+id: cursor-agent
+name: Cursor Agent
+aliases: [cursor]
+keywords: ["cursor-agent"]
+tui: true
+commands:
+  - pattern: '^cursor-agent(\s|$)'
+    type: code
+  - pattern: '(?i)^claude\s+code\s+--cursor'
+userPromptMarkers:
+  - "> "
+ignoreLines:
+  - 'Cursor Agent v\d+'
+minResponseLength: 12
+`
+	jsonOverride := `{
+  "id": "aider",
+  "name": "Aider (custom)",
+  "keywords": ["aider"],
+  "tui": true,
+  "commands": [{"pattern": "^aider-dev", "type": "code"}]
+}`
+	os.WriteFile(filepath.Join(dir, "cursor.yaml"), []byte(yamlDef), 0644)
+	os.WriteFile(filepath.Join(dir, "aider.json"), []byte(jsonOverride), 0644)
+	os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{"id": "x", "commands": [{"pattern": "("}]}`), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0644)
+
+	r, errs := LoadRegistry(dir)
+	if len(errs) != 1 {
+		t.Errorf("Expected one error for broken.json, got %v", errs)
+	}
+
+	cursor := r.Get("cursor-agent")
+	if cursor == nil {
+		t.Fatal("YAML provider not loaded")
+	}
+	if !cursor.TUI || cursor.ResponseLineLength() != 12 || cursor.Source != filepath.Join(dir, "cursor.yaml") {
+		t.Errorf("Unexpected YAML definition: %+v", cursor)
+	}
+	if !cursor.IsChrome("Cursor Agent v2") || cursor.IsChrome("plain content") {
+		t.Error("ignoreLines not applied")
+	}
+	if prompt, ok := cursor.UserPrompt("> fix the tests"); !ok || prompt != "fix the tests" {
+		t.Errorf("UserPrompt() = %q, %v", prompt, ok)
+	}
+
+	d := NewDetectorWithRegistry(r)
+	if got := d.DetectCommand("cursor-agent --resume"); got.Provider != "cursor-agent" || got.Type != CommandCode {
+		t.Errorf("Custom command not detected: %+v", got)
+	}
+	// Custom providers are tried before the built-ins
+	if got := d.DetectCommand("claude code --cursor"); got.Provider != "cursor-agent" {
+		t.Errorf("Custom pattern should win over built-in patterns: %+v", got)
+	}
+
+	// The override replaces the built-in Aider definition entirely
+	if got := d.DetectCommand("aider --model x"); got.Detected {
+		t.Errorf("Overridden aider pattern still matched: %+v", got)
+	}
+	if got := d.DetectCommand("aider-dev"); got.Provider != ProviderAider || !got.TUI {
+		t.Errorf("Override not applied: %+v", got)
+	}
+	if n := len(r.List()); n != 4 {
+		t.Errorf("Expected 4 providers, got %d", n)
+	}
+}
+
+func TestParseLLMOutput_UsesRegistryChrome(t *testing.T) {
+	def := &ProviderDefinition{ID: "custom", TUI: true, IgnoreLines: []string{`^Status:`}}
+	r, err := NewRegistry(append(BuiltinProviders(), def)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetRegistry(r)
+	defer SetRegistry(nil)
+
+	got := ParseLLMOutput("│answer│\nStatus: thinking\nmore", "custom")
+	if got != "answer\nmore" {
+		t.Errorf("ParseLLMOutput() = %q", got)
+	}
+}
+
+func TestParseSimpleYAML(t *testing.T) {
+	doc, err := parseSimpleYAML(`
+name: "quoted: value"
+list:
+- a
+- 'it''s'
+nested:
+  flag: true
+  count: 3
+items:
+  - key: one
+    other: [x, "y, z"]
+  -
+    key: two
+`)
+	if err != nil {
+		t.Fatalf("parseSimpleYAML failed: %v", err)
+	}
+	m := doc.(map[string]interface{})
+	if m["name"] != "quoted: value" {
+		t.Errorf("name = %v", m["name"])
+	}
+	if list := m["list"].([]interface{}); len(list) != 2 || list[1] != "it's" {
+		t.Errorf("list = %v", list)
+	}
+	if nested := m["nested"].(map[string]interface{}); nested["flag"] != true || nested["count"] != 3 {
+		t.Errorf("nested = %v", nested)
+	}
+	items := m["items"].([]interface{})
+	first := items[0].(map[string]interface{})
+	if len(items) != 2 || first["key"] != "one" || len(first["other"].([]interface{})) != 2 {
+		t.Errorf("items = %v", items)
+	}
+	if items[1].(map[string]interface{})["key"] != "two" {
+		t.Errorf("items[1] = %v", items[1])
+	}
+
+	if _, err := parseSimpleYAML("key: 'unterminated"); err == nil {
+		t.Error("Expected error for unterminated string")
+	}
+}
@@ -0,0 +1,241 @@
+// Package llm provides shell command-line normalization for LLM CLI detection.
+package llm
+
+import (
+	"path"
+	"strings"
+)
+
+// SplitShellWords splits the first command of a shell line into words, honouring
+// single quotes, double quotes and backslash escapes. Parsing stops at the first
+// unquoted ;, | or & since only the leading command can launch a CLI in the foreground.
+func SplitShellWords(line string) []string {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+
+	flush := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else if r == '\\' && i+1 < len(runes) && strings.ContainsRune(`"\$`+"`", runes[i+1]) {
+				i++
+				word.WriteRune(runes[i])
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '\\' && i+1 < len(runes):
+			i++
+			word.WriteRune(runes[i])
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			flush()
+		case r == ';' || r == '|' || r == '&':
+			flush()
+			return words
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	flush()
+	return words
+}
+
+// commandWrapper describes a command that runs another command: sudo, time, npx...
+type commandWrapper struct {
+	subs        []string        // Subcommands that must follow, e.g. "run" for pipx run
+	optionalSub bool            // The subcommand may be omitted (bun x / bun script.js)
+	argFlags    map[string]bool // Flags that consume the following word
+	moduleFlag  string          // Flag naming a module to run instead of a script (python -m)
+}
+
+func flagSet(flags ...string) map[string]bool {
+	set := make(map[string]bool, len(flags))
+	for _, f := range flags {
+		set[f] = true
+	}
+	return set
+}
+
+// commandWrappers are stripped before matching provider patterns.
+var commandWrappers = map[string]commandWrapper{
+	"sudo":    {argFlags: flagSet("-u", "-g", "-h", "-p", "-C", "-D", "-U", "-r", "-t", "-T", "--user", "--group", "--host", "--prompt", "--chdir")},
+	"doas":    {argFlags: flagSet("-u", "-C")},
+	"time":    {argFlags: flagSet("-f", "-o", "--format", "--output")},
+	"env":     {argFlags: flagSet("-u", "-C", "--unset", "--chdir")},
+	"nice":    {argFlags: flagSet("-n", "--adjustment")},
+	"nohup":   {},
+	"command": {},
+	"exec":    {argFlags: flagSet("-a")},
+	"stdbuf":  {argFlags: flagSet("-i", "-o", "-e")},
+	"npx":     {argFlags: flagSet("-p", "--package")},
+	"bunx":    {argFlags: flagSet("-p", "--package")},
+	"uvx":     {argFlags: flagSet("--from", "--with", "--python", "-p")},
+	"npm":     {subs: []string{"exec", "x"}, argFlags: flagSet("-p", "--package")},
+	"pnpm":    {subs: []string{"dlx", "exec"}, argFlags: flagSet("-p", "--package")},
+	"yarn":    {subs: []string{"dlx"}, argFlags: flagSet("-p", "--package")},
+	"pipx":    {subs: []string{"run"}, argFlags: flagSet("--spec", "--python", "--index-url", "--pip-args")},
+	"uv":      {subs: []string{"tool run", "run"}, argFlags: flagSet("--from", "--with", "--python", "-p")},
+	"bun":     {subs: []string{"x", "run"}, optionalSub: true, argFlags: flagSet("-p", "--package", "--cwd")},
+	"node":    {argFlags: flagSet("-r", "--require", "--import", "--loader")},
+	"deno":    {subs: []string{"run"}, optionalSub: true},
+	"python":  {argFlags: flagSet("-W", "-X"), moduleFlag: "-m"},
+}
+
+// commandBase returns the lower-cased executable name of a command word, without
+// directory or Windows extension.
+func commandBase(word string) string {
+	base := strings.ToLower(path.Base(strings.ReplaceAll(word, `\`, "/")))
+	for _, ext := range []string{".exe", ".cmd", ".bat"} {
+		base = strings.TrimSuffix(base, ext)
+	}
+	// python3, python3.12 and friends behave like python
+	if strings.HasPrefix(base, "python") && strings.Trim(base[len("python"):], "0123456789.") == "" {
+		return "python"
+	}
+	return base
+}
+
+func isEnvAssignment(word string) bool {
+	name, _, ok := strings.Cut(word, "=")
+	if !ok || name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && !(r >= 'A' && r <= 'Z') && !(r >= 'a' && r <= 'z') && !(i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// stripWrapper removes a wrapper and its options from words. It returns false when
+// words don't start with w's required subcommand or nothing follows the wrapper.
+func stripWrapper(words []string, w commandWrapper) ([]string, bool) {
+	rest := words[1:]
+	if len(w.subs) > 0 {
+		matched := false
+		for _, sub := range w.subs {
+			parts := strings.Fields(sub)
+			if len(rest) >= len(parts) && strings.Join(rest[:len(parts)], " ") == sub {
+				rest, matched = rest[len(parts):], true
+				break
+			}
+		}
+		if !matched && !w.optionalSub {
+			return words, false
+		}
+	}
+
+	for len(rest) > 0 && strings.HasPrefix(rest[0], "-") {
+		flag := rest[0]
+		switch {
+		case flag == "--":
+			rest = rest[1:]
+			return rest, len(rest) > 0
+		case w.moduleFlag != "" && flag == w.moduleFlag:
+			rest = rest[1:]
+			return rest, len(rest) > 0
+		case w.argFlags[flag]:
+			if len(rest) < 2 {
+				return words, false
+			}
+			rest = rest[2:]
+		default:
+			rest = rest[1:]
+		}
+	}
+	return rest, len(rest) > 0
+}
+
+// NormalizeWords strips environment assignments and wrappers (sudo, time, npx,
+// pipx run, interpreters...) from a command's words and replaces the command with
+// the CLI it launches, so "npx @anthropic-ai/claude-code" becomes "claude".
+func (r *Registry) NormalizeWords(words []string) []string {
+	for len(words) > 0 {
+		if isEnvAssignment(words[0]) {
+			words = words[1:]
+			continue
+		}
+		w, ok := commandWrappers[commandBase(words[0])]
+		if !ok {
+			break
+		}
+		rest, ok := stripWrapper(words, w)
+		if !ok {
+			break
+		}
+		words = rest
+	}
+	if len(words) == 0 {
+		return words
+	}
+
+	normalized := make([]string, len(words))
+	copy(normalized, words)
+	normalized[0] = r.commandName(words[0])
+	return normalized
+}
+
+// NormalizeCommandLine returns line reduced to the command it actually runs.
+func (r *Registry) NormalizeCommandLine(line string) string {
+	return strings.Join(r.NormalizeWords(SplitShellWords(line)), " ")
+}
+
+// commandName maps a command word to the CLI it provides: package specs such as
+// "@anthropic-ai/claude-code@latest" or "aider-chat==0.50", scripts inside
+// node_modules, and paths to executables.
+func (r *Registry) commandName(word string) string {
+	slashed := strings.ReplaceAll(word, `\`, "/")
+	if idx := strings.LastIndex(slashed, "node_modules/"); idx >= 0 {
+		parts := strings.Split(slashed[idx+len("node_modules/"):], "/")
+		pkg := parts[0]
+		if strings.HasPrefix(pkg, "@") && len(parts) > 1 {
+			pkg += "/" + parts[1]
+		}
+		if cmd, ok := r.packages[strings.ToLower(pkg)]; ok {
+			return cmd
+		}
+	}
+
+	spec := strings.ToLower(word)
+	if strings.HasPrefix(spec, "@") {
+		if i := strings.Index(spec[1:], "@"); i >= 0 {
+			spec = spec[:i+1]
+		}
+	} else if i := strings.IndexAny(spec, "@=<>~!["); i > 0 {
+		spec = spec[:i]
+	}
+	if cmd, ok := r.packages[spec]; ok {
+		return cmd
+	}
+
+	return commandBase(word)
+}
+
+// NormalizeCommandLine uses the process-wide registry.
+func NormalizeCommandLine(line string) string {
+	return GetRegistry().NormalizeCommandLine(line)
+}
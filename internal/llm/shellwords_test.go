@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{`claude`, []string{"claude"}},
+		{`FOO="a b" claude  --model 'opus 4'`, []string{"FOO=a b", "claude", "--model", "opus 4"}},
+		{`aider my\ file.py "say \"hi\""`, []string{"aider", "my file.py", `say "hi"`}},
+		{`claude && ls`, []string{"claude"}},
+		{`time aider | tee log`, []string{"time", "aider"}},
+		{`''`, []string{""}},
+	}
+	for _, tc := range tests {
+		if got := SplitShellWords(tc.input); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("SplitShellWords(%q) = %q, want %q", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestDetectCommand_WrappersAndEnvAssignments(t *testing.T) {
+	r, _ := NewRegistry(BuiltinProviders()...)
+	d := NewDetectorWithRegistry(r)
+
+	tests := []struct {
+		input    string
+		provider Provider
+		cmdType  CommandType
+	}{
+		{"npx @anthropic-ai/claude-code", ProviderClaude, CommandChat},
+		{"npx -y @anthropic-ai/claude-code@latest --continue", ProviderClaude, CommandChat},
+		{"bunx @anthropic-ai/claude-code", ProviderClaude, CommandChat},
+		{"FOO=1 claude", ProviderClaude, CommandChat},
+		{"ANTHROPIC_MODEL=opus sudo -E claude code", ProviderClaude, CommandCode},
+		{"sudo -u dev claude", ProviderClaude, CommandChat},
+		{"time aider", ProviderAider, CommandCode},
+		{"time -p env OPENAI_API_KEY=x aider --yes", ProviderAider, CommandCode},
+		{"pipx run aider-chat", ProviderAider, CommandCode},
+		{"pipx run --spec aider-chat aider", ProviderAider, CommandCode},
+		{"uvx --from aider-chat aider", ProviderAider, CommandCode},
+		{"python3 -m aider", ProviderAider, CommandCode},
+		{"claude --resume", ProviderClaude, CommandChat},
+		{"npx @github/copilot", ProviderGitHubCopilot, CommandChat},
+	}
+	for _, tc := range tests {
+		got := d.DetectCommand(tc.input)
+		if !got.Detected || got.Provider != tc.provider || got.Type != tc.cmdType {
+			t.Errorf("DetectCommand(%q) = %s/%s detected=%v, want %s/%s",
+				tc.input, got.Provider, got.Type, got.Detected, tc.provider, tc.cmdType)
+		}
+		if got.RawInput != tc.input {
+			t.Errorf("RawInput should be the typed line, got %q", got.RawInput)
+		}
+	}
+
+	for _, input := range []string{"npm install @anthropic-ai/claude-code", "time make", "FOO=1 ls", "sudo -E", "cat notes.md"} {
+		if got := d.DetectCommand(input); got.Detected {
+			t.Errorf("DetectCommand(%q) unexpectedly detected %s", input, got.Provider)
+		}
+	}
+}
+
+func TestDetectArgv_ProcessCommandLines(t *testing.T) {
+	r, _ := NewRegistry(BuiltinProviders()...)
+	d := NewDetectorWithRegistry(r)
+
+	tests := []struct {
+		argv     []string
+		provider Provider
+	}{
+		{[]string{"node", "--no-warnings", "/usr/lib/node_modules/@anthropic-ai/claude-code/cli.js", "--continue"}, ProviderClaude},
+		{[]string{"/home/dev/.nvm/versions/node/v20/bin/node", "/home/dev/.npm/_npx/1a2b/node_modules/@github/copilot/index.js"}, ProviderGitHubCopilot},
+		{[]string{"/usr/bin/python3.12", "/home/dev/.local/bin/aider", "--model", "sonnet"}, ProviderAider},
+		{[]string{"claude"}, ProviderClaude},
+	}
+	for _, tc := range tests {
+		if got := d.DetectArgv(tc.argv); !got.Detected || got.Provider != tc.provider {
+			t.Errorf("DetectArgv(%q) = %s detected=%v, want %s", tc.argv, got.Provider, got.Detected, tc.provider)
+		}
+	}
+
+	if got := d.DetectArgv([]string{"node", "server.js"}); got.Detected {
+		t.Errorf("Plain node process detected as %s", got.Provider)
+	}
+}
@@ -0,0 +1,87 @@
+// Package metrics declares every metric Forge exports on /metrics. Names are
+// part of the monitoring contract: add new metrics freely, but never rename or
+// repurpose an existing one.
+package metrics
+
+var (
+	latencyBuckets      = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+	queryLatencyBuckets = []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+	confidenceBuckets   = []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1}
+)
+
+// Terminal sessions.
+var (
+	// SessionsActive (forge_sessions_active) is the number of open terminal
+	// sessions, one per connected tab.
+	SessionsActive = Default.NewGauge("forge_sessions_active",
+		"Open terminal sessions.")
+
+	// PTYBytes (forge_pty_bytes_total{direction}) counts bytes through terminal
+	// PTYs: "in" is written to the shell (keystrokes, injected commands), "out"
+	// is read from it.
+	PTYBytes = Default.NewCounterVec("forge_pty_bytes_total",
+		"Bytes written to (in) and read from (out) terminal PTYs.", "direction")
+
+	// WebSocketWriteSeconds (forge_websocket_write_duration_seconds) is the time
+	// taken to write PTY output to the browser; slow writes are what freeze the UI.
+	WebSocketWriteSeconds = Default.NewHistogram("forge_websocket_write_duration_seconds",
+		"Time to write one PTY output message to the terminal WebSocket.", latencyBuckets)
+)
+
+// Vision.
+var (
+	// VisionDetections (forge_vision_detections_total{type}) counts Vision
+	// overlays detected in terminal output, by detector type such as GIT_STATUS.
+	VisionDetections = Default.NewCounterVec("forge_vision_detections_total",
+		"Vision detections in terminal output by detector type.", "type")
+)
+
+// Artificial Memory.
+var (
+	// AMConversationsActive (forge_am_conversations_active) is the number of LLM
+	// conversations being captured.
+	AMConversationsActive = Default.NewFuncMetric("forge_am_conversations_active",
+		"LLM conversations currently being captured.", TypeGauge, "")
+
+	// AMConversationsEnded (forge_am_conversations_ended_total{method}) counts
+	// completed conversations by how their end was detected (process_exit,
+	// prompt_heuristic, manual, ...).
+	AMConversationsEnded = Default.NewFuncMetric("forge_am_conversations_ended_total",
+		"Completed LLM conversations by end detection method.", TypeCounter, "method")
+
+	// AMTurns (forge_am_turns_total{role}) counts captured conversation turns;
+	// role is "user" or "assistant".
+	AMTurns = Default.NewCounterVec("forge_am_turns_total",
+		"Conversation turns captured by role.", "role")
+
+	// AMParseConfidence (forge_am_parse_confidence) is the confidence (0-1) of
+	// each assistant output parse; below 0.8 counts as low confidence.
+	AMParseConfidence = Default.NewHistogram("forge_am_parse_confidence",
+		"Confidence of assistant output parses.", confidenceBuckets)
+
+	// AMParseFailures (forge_am_parse_failures_total) counts user input the
+	// capture layer could not parse.
+	AMParseFailures = Default.NewFuncMetric("forge_am_parse_failures_total",
+		"Input parse failures in the AM capture pipeline.", TypeCounter, "")
+)
+
+// Assistant RAG.
+var (
+	// RAGIndexChunks (forge_rag_index_chunks{index}) is the number of chunks in
+	// each RAG index: "docs" for indexed files, "conversations" for AM history.
+	RAGIndexChunks = Default.NewFuncMetric("forge_rag_index_chunks",
+		"Chunks in each RAG vector index.", TypeGauge, "index")
+
+	// RAGQuerySeconds (forge_rag_query_duration_seconds{index}) is the time to
+	// embed a query and search one RAG index.
+	RAGQuerySeconds = Default.NewHistogramVec("forge_rag_query_duration_seconds",
+		"Time to embed and search a RAG index.", queryLatencyBuckets, "index")
+)
+
+// Updater.
+var (
+	// UpdateChecks (forge_update_checks_total{outcome}) counts checks for a new
+	// release; outcome is "available", "current" or "error".
+	UpdateChecks = Default.NewCounterVec("forge_update_checks_total",
+		"Update checks by outcome.", "outcome")
+)
@@ -0,0 +1,371 @@
+// Package metrics provides Prometheus-style counters, gauges and histograms and
+// renders them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Metric types as written on # TYPE lines.
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// Registry holds metric families and writes them in text format.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// Default is the registry behind the package-level constructors and /metrics.
+var Default = NewRegistry()
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// family is one metric name with its help, type and labeled series.
+type family struct {
+	name    string
+	help    string
+	typ     string
+	labels  []string
+	buckets []float64 // Histograms only
+
+	mu      sync.Mutex
+	series  map[string]*series
+	collect func() map[string]float64 // Scrape-time values keyed by joined label values
+}
+
+type series struct {
+	values []string
+
+	value atomic.Uint64 // float64 bits, for counters and gauges
+
+	histMu sync.Mutex
+	counts []uint64 // Per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+func (r *Registry) register(f *family) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.families[f.name]; exists {
+		panic("metrics: duplicate metric " + f.name)
+	}
+	f.series = make(map[string]*series)
+	r.families[f.name] = f
+	return f
+}
+
+// labelKey joins label values into a map key.
+func labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+func (f *family) with(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := labelKey(values)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		if f.typ == TypeHistogram {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (s *series) add(v float64) {
+	for {
+		old := s.value.Load()
+		if s.value.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func (s *series) load() float64 {
+	return math.Float64frombits(s.value.Load())
+}
+
+// Counter is a value that only goes up.
+type Counter struct{ s *series }
+
+// Inc adds one.
+func (c Counter) Inc() { c.s.add(1) }
+
+// Add adds v, which must not be negative.
+func (c Counter) Add(v float64) {
+	if v > 0 {
+		c.s.add(v)
+	}
+}
+
+// Value returns the current count.
+func (c Counter) Value() float64 { return c.s.load() }
+
+// Gauge is a value that goes up and down.
+type Gauge struct{ s *series }
+
+// Set sets the gauge to v.
+func (g Gauge) Set(v float64) { g.s.value.Store(math.Float64bits(v)) }
+
+// Inc adds one.
+func (g Gauge) Inc() { g.s.add(1) }
+
+// Dec subtracts one.
+func (g Gauge) Dec() { g.s.add(-1) }
+
+// Value returns the current value.
+func (g Gauge) Value() float64 { return g.s.load() }
+
+// Histogram counts observations into buckets.
+type Histogram struct {
+	f *family
+	s *series
+}
+
+// Observe records one observation.
+func (h Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.f.buckets, v) // First bucket with upper bound >= v
+	h.s.histMu.Lock()
+	if i < len(h.s.counts) {
+		h.s.counts[i]++
+	}
+	h.s.sum += v
+	h.s.count++
+	h.s.histMu.Unlock()
+}
+
+// Count returns the number of observations.
+func (h Histogram) Count() uint64 {
+	h.s.histMu.Lock()
+	defer h.s.histMu.Unlock()
+	return h.s.count
+}
+
+// CounterVec is a counter partitioned by labels.
+type CounterVec struct{ f *family }
+
+// WithLabelValues returns the counter for the given label values, in label order.
+func (v CounterVec) WithLabelValues(values ...string) Counter { return Counter{v.f.with(values)} }
+
+// GaugeVec is a gauge partitioned by labels.
+type GaugeVec struct{ f *family }
+
+// WithLabelValues returns the gauge for the given label values, in label order.
+func (v GaugeVec) WithLabelValues(values ...string) Gauge { return Gauge{v.f.with(values)} }
+
+// HistogramVec is a histogram partitioned by labels.
+type HistogramVec struct{ f *family }
+
+// WithLabelValues returns the histogram for the given label values, in label order.
+func (v HistogramVec) WithLabelValues(values ...string) Histogram {
+	return Histogram{v.f, v.f.with(values)}
+}
+
+// FuncMetric is a counter or gauge whose values are read at scrape time, for
+// state another package already tracks.
+type FuncMetric struct{ f *family }
+
+// Collect sets the function that supplies the metric's values. For an unlabeled
+// metric fn returns a single entry under the key "". Labeled metrics key each
+// value by its label value (one label only).
+func (m FuncMetric) Collect(fn func() map[string]float64) {
+	m.f.mu.Lock()
+	m.f.collect = fn
+	m.f.mu.Unlock()
+}
+
+// CollectValue is Collect for an unlabeled metric.
+func (m FuncMetric) CollectValue(fn func() float64) {
+	m.Collect(func() map[string]float64 { return map[string]float64{"": fn()} })
+}
+
+// NewCounter registers a counter.
+func (r *Registry) NewCounter(name, help string) Counter {
+	return Counter{r.register(&family{name: name, help: help, typ: TypeCounter}).with(nil)}
+}
+
+// NewCounterVec registers a counter with labels.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) CounterVec {
+	return CounterVec{r.register(&family{name: name, help: help, typ: TypeCounter, labels: labels})}
+}
+
+// NewGauge registers a gauge.
+func (r *Registry) NewGauge(name, help string) Gauge {
+	return Gauge{r.register(&family{name: name, help: help, typ: TypeGauge}).with(nil)}
+}
+
+// NewGaugeVec registers a gauge with labels.
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) GaugeVec {
+	return GaugeVec{r.register(&family{name: name, help: help, typ: TypeGauge, labels: labels})}
+}
+
+// NewHistogram registers a histogram with the given upper bucket bounds.
+func (r *Registry) NewHistogram(name, help string, buckets []float64) Histogram {
+	f := r.register(&family{name: name, help: help, typ: TypeHistogram, buckets: sortedBuckets(buckets)})
+	return Histogram{f, f.with(nil)}
+}
+
+// NewHistogramVec registers a histogram with labels.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) HistogramVec {
+	return HistogramVec{r.register(&family{name: name, help: help, typ: TypeHistogram,
+		buckets: sortedBuckets(buckets), labels: labels})}
+}
+
+// NewFuncMetric registers a counter or gauge read at scrape time. It has at most
+// one label; pass "" for none. Nothing is written until Collect is called.
+func (r *Registry) NewFuncMetric(name, help, typ, label string) FuncMetric {
+	f := &family{name: name, help: help, typ: typ}
+	if label != "" {
+		f.labels = []string{label}
+	}
+	return FuncMetric{r.register(f)}
+}
+
+func sortedBuckets(buckets []float64) []float64 {
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+	return b
+}
+
+// WriteText writes every family in the Prometheus text exposition format,
+// sorted by name.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	var b strings.Builder
+	for _, f := range families {
+		f.write(&b)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (f *family) write(b *strings.Builder) {
+	f.mu.Lock()
+	collect := f.collect
+	all := make([]*series, 0, len(f.series))
+	for _, s := range f.series {
+		all = append(all, s)
+	}
+	f.mu.Unlock()
+
+	type sample struct {
+		values []string
+		value  float64
+	}
+	var collected []sample
+	if collect != nil {
+		for key, v := range collect() {
+			var values []string
+			if len(f.labels) > 0 {
+				values = []string{key}
+			}
+			collected = append(collected, sample{values, v})
+		}
+		sort.Slice(collected, func(i, j int) bool {
+			return labelKey(collected[i].values) < labelKey(collected[j].values)
+		})
+	} else if len(all) == 0 {
+		return
+	}
+	sort.Slice(all, func(i, j int) bool { return labelKey(all[i].values) < labelKey(all[j].values) })
+
+	fmt.Fprintf(b, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(b, "# TYPE %s %s\n", f.name, f.typ)
+	for _, s := range collected {
+		fmt.Fprintf(b, "%s%s %s\n", f.name, formatLabels(f.labels, s.values), formatValue(s.value))
+	}
+	for _, s := range all {
+		if f.typ != TypeHistogram {
+			fmt.Fprintf(b, "%s%s %s\n", f.name, formatLabels(f.labels, s.values), formatValue(s.load()))
+			continue
+		}
+		s.histMu.Lock()
+		var cumulative uint64
+		for i, bound := range f.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, bucketLabels(f.labels, s.values, formatValue(bound)), cumulative)
+		}
+		fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, bucketLabels(f.labels, s.values, "+Inf"), s.count)
+		fmt.Fprintf(b, "%s_sum%s %s\n", f.name, formatLabels(f.labels, s.values), formatValue(s.sum))
+		fmt.Fprintf(b, "%s_count%s %d\n", f.name, formatLabels(f.labels, s.values), s.count)
+		s.histMu.Unlock()
+	}
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escapeLabel(values[i]) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// bucketLabels formats a series' labels plus the bucket's le label.
+func bucketLabels(names, values []string, le string) string {
+	n := len(names)
+	return formatLabels(append(names[:n:n], "le"), append(values[:n:n], le))
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+func escapeHelp(s string) string { return helpEscaper.Replace(s) }
+
+// Handler serves the registry in the Prometheus text format.
+func (r *Registry) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteText(w)
+	}
+}
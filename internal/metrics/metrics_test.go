@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	r := NewRegistry()
+	sessions := r.NewGauge("test_sessions", "Open sessions.")
+	bytes := r.NewCounterVec("test_bytes_total", "Bytes by direction.", "direction")
+	latency := r.NewHistogram("test_latency_seconds", "Write latency.", []float64{1, 0.1})
+	ended := r.NewFuncMetric("test_ended_total", "Ended by method.", TypeCounter, "method")
+	r.NewCounterVec("test_unused_total", "Never incremented.", "kind")
+
+	sessions.Inc()
+	sessions.Inc()
+	sessions.Dec()
+	bytes.WithLabelValues("out").Add(1024)
+	bytes.WithLabelValues("in").Add(3)
+	bytes.WithLabelValues(`we"ird`).Inc()
+	latency.Observe(0.05)
+	latency.Observe(0.5)
+	latency.Observe(5)
+	ended.Collect(func() map[string]float64 { return map[string]float64{"manual": 2} })
+
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP test_bytes_total Bytes by direction.
+# TYPE test_bytes_total counter
+test_bytes_total{direction="in"} 3
+test_bytes_total{direction="out"} 1024
+test_bytes_total{direction="we\"ird"} 1
+# HELP test_ended_total Ended by method.
+# TYPE test_ended_total counter
+test_ended_total{method="manual"} 2
+# HELP test_latency_seconds Write latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{le="0.1"} 1
+test_latency_seconds_bucket{le="1"} 2
+test_latency_seconds_bucket{le="+Inf"} 3
+test_latency_seconds_sum 5.55
+test_latency_seconds_count 3
+# HELP test_sessions Open sessions.
+# TYPE test_sessions gauge
+test_sessions 1
+`
+	if b.String() != want {
+		t.Errorf("Unexpected exposition:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	r := NewRegistry()
+	r.NewHistogramVec("test_query_seconds", "Query latency.", []float64{1}, "index").
+		WithLabelValues("docs").Observe(0.5)
+
+	rec := httptest.NewRecorder()
+	r.Handler()(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `test_query_seconds_bucket{index="docs",le="1"} 1`) {
+		t.Errorf("Missing labeled bucket:\n%s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	r.Handler()(rec, httptest.NewRequest("POST", "/metrics", nil))
+	if rec.Code != 405 {
+		t.Errorf("POST returned %d, want 405", rec.Code)
+	}
+}
+
+func TestForgeMetricNames(t *testing.T) {
+	var b strings.Builder
+	Default.WriteText(&b)
+	for _, name := range []string{"forge_sessions_active", "forge_websocket_write_duration_seconds", "forge_am_parse_confidence"} {
+		if !strings.Contains(b.String(), "# TYPE "+name+" ") {
+			t.Errorf("%s missing from the default registry", name)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+// Package prompts provides user-editable text/template prompts with embedded defaults.
+//
+// Each named template ships with a default compiled into the binary. A file named
+// <name>.tmpl in the prompts directory (~/.forge/assistant/prompts) overrides the
+// default and is picked up on the next render, so prompts can be tuned without
+// recompiling or restarting Forge.
+package prompts
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/storage"
+)
+
+//go:embed defaults/*.tmpl
+var defaultsFS embed.FS
+
+// Template names.
+const (
+	System          = "system"
+	TerminalContext = "terminal_context"
+	RAG             = "rag"
+	Restore         = "restore"
+)
+
+// Definition describes a named template and the variables it must be rendered with.
+type Definition struct {
+	Name        string
+	Description string
+	Required    []string
+	// Sample is used to validate user templates before they replace a default.
+	Sample map[string]interface{}
+}
+
+var definitions = map[string]Definition{
+	System: {
+		Name:        System,
+		Description: "Assistant system prompt built from the Forge knowledge base",
+		Required:    []string{"Version", "Categories"},
+		Sample: map[string]interface{}{
+			"Version": "0.0.0",
+			"Categories": []map[string]interface{}{{
+				"Name": "Sample",
+				"Features": []map[string]interface{}{{
+					"Name": "Feature", "Description": "Description", "Category": "Sample",
+					"Details": "Details", "Example": "Example",
+				}},
+			}},
+		},
+	},
+	TerminalContext: {
+		Name:        TerminalContext,
+		Description: "Terminal state sent to the assistant alongside a chat message",
+		Required:    []string{"WorkingDirectory", "RecentCommands", "RecentOutput"},
+		Sample: map[string]interface{}{
+			"WorkingDirectory": "/home/user/project",
+			"RecentCommands":   []string{"ls"},
+			"RecentOutput":     "README.md",
+			"SessionID":        "session",
+		},
+	},
+	RAG: {
+		Name:        RAG,
+		Description: "RAG system prompt combining knowledge base, documentation and past conversations",
+		Required:    []string{"SystemPrompt", "Documentation", "Conversations"},
+		Sample: map[string]interface{}{
+			"SystemPrompt":  "system",
+			"Documentation": "docs",
+			"Conversations": "conversations",
+			"UserMessage":   "question",
+		},
+	},
+	Restore: {
+		Name:        Restore,
+		Description: "Prompt used to resume an interrupted AM conversation",
+		Required:    []string{"LastUserPrompt", "HasAssistantResponse"},
+		Sample: map[string]interface{}{
+			"LastUserPrompt":        "Fix the bug",
+			"LastAssistantResponse": "Looking at it",
+			"HasAssistantResponse":  true,
+			"Provider":              "claude",
+			"Project":               "project",
+			"WorkingDirectory":      "/home/user/project",
+			"GitBranch":             "main",
+			"TurnCount":             2,
+			"Complete":              false,
+		},
+	},
+}
+
+// TemplateInfo describes the template currently in effect for a name.
+type TemplateInfo struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Required    []string `json:"required"`
+	Source      string   `json:"source"` // "default" or "user"
+	Path        string   `json:"path,omitempty"`
+	Text        string   `json:"text"`
+	Error       string   `json:"error,omitempty"` // Why a user template was rejected
+}
+
+// entry is the loaded state of one named template.
+type entry struct {
+	tmpl    *template.Template
+	text    string
+	source  string
+	modTime time.Time
+	err     error
+}
+
+// Store loads and renders named templates.
+type Store struct {
+	mu      sync.Mutex
+	dir     string
+	entries map[string]*entry
+}
+
+// NewStore creates a store that reads overrides from dir.
+func NewStore(dir string) *Store {
+	return &Store{
+		dir:     dir,
+		entries: make(map[string]*entry),
+	}
+}
+
+// Dir returns the directory user templates are read from.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Render renders the named template with data.
+// If a user template fails at render time the embedded default is used instead.
+func (s *Store) Render(name string, data map[string]interface{}) (string, error) {
+	def, ok := definitions[name]
+	if !ok {
+		return "", fmt.Errorf("unknown prompt template: %s", name)
+	}
+	if err := checkRequired(def, data); err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	e := s.lookupLocked(name)
+	s.mu.Unlock()
+
+	out, err := execute(e.tmpl, data)
+	if err == nil || e.source == "default" {
+		return out, err
+	}
+
+	log.Printf("[Prompts] ⚠️ User template %s failed, using default: %v", name, err)
+	tmpl, _, defErr := parseDefault(name)
+	if defErr != nil {
+		return "", defErr
+	}
+	return execute(tmpl, data)
+}
+
+// RenderText validates text as a replacement for the named template and renders it with data.
+// Used to preview edits before they are saved.
+func (s *Store) RenderText(name, text string, data map[string]interface{}) (string, error) {
+	def, ok := definitions[name]
+	if !ok {
+		return "", fmt.Errorf("unknown prompt template: %s", name)
+	}
+	if err := checkRequired(def, data); err != nil {
+		return "", err
+	}
+
+	tmpl, err := Validate(name, text)
+	if err != nil {
+		return "", err
+	}
+	return execute(tmpl, data)
+}
+
+// List returns the templates currently in effect, sorted by name.
+func (s *Store) List() []TemplateInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	infos := make([]TemplateInfo, 0, len(names))
+	for _, name := range names {
+		def := definitions[name]
+		e := s.lookupLocked(name)
+		info := TemplateInfo{
+			Name:        name,
+			Description: def.Description,
+			Required:    def.Required,
+			Source:      e.source,
+			Text:        e.text,
+		}
+		if s.dir != "" {
+			info.Path = filepath.Join(s.dir, name+".tmpl")
+		}
+		if e.err != nil {
+			info.Error = e.err.Error()
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// Validate parses text as the named template and checks it renders against sample data.
+func Validate(name, text string) (*template.Template, error) {
+	def, ok := definitions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown prompt template: %s", name)
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", name, err)
+	}
+	if _, err := execute(tmpl, def.Sample); err != nil {
+		return nil, fmt.Errorf("validate %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// lookupLocked returns the entry for name, reloading the user file if it changed.
+// Caller must hold s.mu.
+func (s *Store) lookupLocked(name string) *entry {
+	e := s.entries[name]
+
+	var info os.FileInfo
+	var statErr error = os.ErrNotExist
+	path := ""
+	if s.dir != "" {
+		path = filepath.Join(s.dir, name+".tmpl")
+		info, statErr = os.Stat(path)
+	}
+
+	if statErr != nil {
+		// No override: use (or go back to) the embedded default
+		if e == nil || e.source != "default" {
+			e = s.loadDefault(name)
+			s.entries[name] = e
+		}
+		return e
+	}
+
+	if e != nil && e.modTime.Equal(info.ModTime()) {
+		return e
+	}
+
+	e = s.loadUser(name, path)
+	e.modTime = info.ModTime()
+	s.entries[name] = e
+	return e
+}
+
+// loadDefault loads the embedded default for name.
+func (s *Store) loadDefault(name string) *entry {
+	tmpl, text, err := parseDefault(name)
+	if err != nil {
+		// Embedded defaults are covered by tests; this only fires on a broken build
+		log.Printf("[Prompts] ❌ Invalid embedded template %s: %v", name, err)
+		tmpl = template.Must(template.New(name).Parse(""))
+	}
+	return &entry{tmpl: tmpl, text: text, source: "default", err: err}
+}
+
+// loadUser loads a user override, falling back to the default when it is invalid.
+func (s *Store) loadUser(name, path string) *entry {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		var tmpl *template.Template
+		tmpl, err = Validate(name, string(data))
+		if err == nil {
+			log.Printf("[Prompts] Loaded user template %s from %s", name, path)
+			return &entry{tmpl: tmpl, text: string(data), source: "user"}
+		}
+	}
+
+	log.Printf("[Prompts] ⚠️ Ignoring user template %s: %v", path, err)
+	e := s.loadDefault(name)
+	e.err = err
+	return e
+}
+
+// parseDefault parses the embedded default for name.
+func parseDefault(name string) (*template.Template, string, error) {
+	data, err := defaultsFS.ReadFile("defaults/" + name + ".tmpl")
+	if err != nil {
+		return nil, "", fmt.Errorf("no default template %s: %w", name, err)
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, string(data), err
+	}
+	return tmpl, string(data), nil
+}
+
+// checkRequired ensures data supplies every variable the template requires.
+func checkRequired(def Definition, data map[string]interface{}) error {
+	var missing []string
+	for _, key := range def.Required {
+		if _, ok := data[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("template %s missing required variables: %v", def.Name, missing)
+	}
+	return nil
+}
+
+// execute renders tmpl into a string.
+func execute(tmpl *template.Template, data map[string]interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+var (
+	defaultStore     *Store
+	defaultStoreOnce sync.Once
+)
+
+// Default returns the shared store reading from ~/.forge/assistant/prompts.
+func Default() *Store {
+	defaultStoreOnce.Do(func() {
+		defaultStore = NewStore(storage.GetPromptsDir())
+	})
+	return defaultStore
+}
+
+// Render renders the named template using the shared store.
+func Render(name string, data map[string]interface{}) (string, error) {
+	return Default().Render(name, data)
+}
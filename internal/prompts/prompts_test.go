@@ -0,0 +1,172 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultsRenderAgainstSamples(t *testing.T) {
+	store := NewStore("")
+
+	for name, def := range definitions {
+		out, err := store.Render(name, def.Sample)
+		if err != nil {
+			t.Errorf("Default template %s failed to render: %v", name, err)
+			continue
+		}
+		if strings.TrimSpace(out) == "" {
+			t.Errorf("Default template %s rendered empty output", name)
+		}
+	}
+}
+
+func TestRender_RestoreMatchesLegacyFormat(t *testing.T) {
+	store := NewStore("")
+
+	tests := []struct {
+		name string
+		data map[string]interface{}
+		want string
+	}{
+		{
+			"with assistant response",
+			map[string]interface{}{"LastUserPrompt": "Fix it", "HasAssistantResponse": true},
+			"I was working on: Fix it\n\nYou had started helping with this. Please continue from where we left off.",
+		},
+		{
+			"user prompt only",
+			map[string]interface{}{"LastUserPrompt": "Fix it", "HasAssistantResponse": false},
+			"I was working on: Fix it",
+		},
+		{
+			"no user prompt",
+			map[string]interface{}{"LastUserPrompt": "", "HasAssistantResponse": true},
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.Render(Restore, tt.data)
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender_MissingRequiredVariable(t *testing.T) {
+	store := NewStore("")
+
+	_, err := store.Render(Restore, map[string]interface{}{"LastUserPrompt": "x"})
+	if err == nil || !strings.Contains(err.Error(), "HasAssistantResponse") {
+		t.Errorf("Expected missing variable error naming HasAssistantResponse, got %v", err)
+	}
+
+	if _, err := store.Render("nope", nil); err == nil {
+		t.Error("Expected error for unknown template")
+	}
+}
+
+func TestStore_UserOverrideAndReload(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+	data := map[string]interface{}{"LastUserPrompt": "tests", "HasAssistantResponse": false}
+
+	path := filepath.Join(dir, Restore+".tmpl")
+	if err := os.WriteFile(path, []byte("Resume {{.Provider}} work on {{.LastUserPrompt}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Provider is optional for the default but used by this override
+	data["Provider"] = "claude"
+	got, err := store.Render(Restore, data)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if got != "Resume claude work on tests" {
+		t.Errorf("Expected user template output, got %q", got)
+	}
+
+	// Edits are picked up without restarting
+	if err := os.WriteFile(path, []byte("Continue: {{.LastUserPrompt}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(path, future, future)
+
+	got, _ = store.Render(Restore, data)
+	if got != "Continue: tests" {
+		t.Errorf("Expected reloaded template output, got %q", got)
+	}
+
+	// Removing the override falls back to the default
+	os.Remove(path)
+	got, _ = store.Render(Restore, data)
+	if got != "I was working on: tests" {
+		t.Errorf("Expected default output after removal, got %q", got)
+	}
+}
+
+func TestStore_InvalidOverrideFallsBackToDefault(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+
+	overrides := map[string]string{
+		"syntax error":     "{{if .LastUserPrompt}",
+		"unknown variable": "{{.NoSuchVariable}}",
+	}
+
+	for name, text := range overrides {
+		t.Run(name, func(t *testing.T) {
+			if err := os.WriteFile(filepath.Join(dir, Restore+".tmpl"), []byte(text), 0644); err != nil {
+				t.Fatal(err)
+			}
+			future := time.Now().Add(time.Duration(len(text)) * time.Minute)
+			os.Chtimes(filepath.Join(dir, Restore+".tmpl"), future, future)
+
+			got, err := store.Render(Restore, map[string]interface{}{
+				"LastUserPrompt": "tests", "HasAssistantResponse": false,
+			})
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if got != "I was working on: tests" {
+				t.Errorf("Expected default output, got %q", got)
+			}
+
+			for _, info := range store.List() {
+				if info.Name == Restore && (info.Source != "default" || info.Error == "") {
+					t.Errorf("Expected rejected override to be reported, got %+v", info)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderText_Preview(t *testing.T) {
+	store := NewStore("")
+	data := map[string]interface{}{
+		"WorkingDirectory": "/repo",
+		"RecentCommands":   []string{"go test ./..."},
+		"RecentOutput":     "",
+	}
+
+	got, err := store.RenderText(TerminalContext, "cwd={{.WorkingDirectory}}", data)
+	if err != nil {
+		t.Fatalf("RenderText failed: %v", err)
+	}
+	if got != "cwd=/repo" {
+		t.Errorf("RenderText() = %q", got)
+	}
+
+	if _, err := store.RenderText(TerminalContext, "{{.Missing}}", data); err == nil {
+		t.Error("Expected validation error for unknown variable")
+	}
+}
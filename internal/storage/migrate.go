@@ -77,6 +77,7 @@ func EnsureDirectories() error {
 		GetAssistantDir(),
 		GetSessionsDir(),
 		GetAMDir(),
+		GetPromptsDir(),
 	}
 
 	for _, dir := range dirs {
@@ -50,6 +50,72 @@ func GetAMDir() string {
 	return filepath.Join(GetForgeDir(), "am")
 }
 
+// GetProvidersDir returns the directory for user-defined LLM provider definitions.
+func GetProvidersDir() string {
+	return filepath.Join(GetForgeDir(), "providers")
+}
+
+// GetAutoRespondPolicyPath returns the path to the global auto-respond policy.
+func GetAutoRespondPolicyPath() string {
+	return filepath.Join(GetForgeDir(), "autorespond.json")
+}
+
+// GetAutoRespondProjectsDir returns the directory for per-project auto-respond
+// policies, one file per project root.
+func GetAutoRespondProjectsDir() string {
+	return filepath.Join(GetForgeDir(), "autorespond", "projects")
+}
+
+// GetAutoRespondTrustPath returns the path to the list of repositories whose own
+// auto-respond policy the user has trusted.
+func GetAutoRespondTrustPath() string {
+	return filepath.Join(GetForgeDir(), "autorespond", "trusted.json")
+}
+
+// GetLoopDetectionConfigPath returns the path to the LLM loop detection settings.
+func GetLoopDetectionConfigPath() string {
+	return filepath.Join(GetForgeDir(), "loopdetect.json")
+}
+
+// GetPricingConfigPath returns the path to the LLM provider pricing table.
+func GetPricingConfigPath() string {
+	return filepath.Join(GetForgeDir(), "pricing.json")
+}
+
+// GetAuthSecretPath returns the path to the per-install API secret.
+func GetAuthSecretPath() string {
+	return filepath.Join(GetForgeDir(), "auth.json")
+}
+
+// GetServerConfigPath returns the path to the bind address and TLS settings.
+func GetServerConfigPath() string {
+	return filepath.Join(GetForgeDir(), "server.json")
+}
+
+// GetTLSDir returns the directory for the auto-generated TLS certificate.
+func GetTLSDir() string {
+	return filepath.Join(GetForgeDir(), "tls")
+}
+
+// GetControlSocketPath returns the Unix socket the forge CLI uses to reach the
+// running instance. FORGE_SOCKET overrides it.
+func GetControlSocketPath() string {
+	if path := os.Getenv("FORGE_SOCKET"); path != "" {
+		return path
+	}
+	return filepath.Join(GetForgeDir(), "run", "forge.sock")
+}
+
+// GetEventJournalConfigPath returns the path to the AM event journal settings.
+func GetEventJournalConfigPath() string {
+	return filepath.Join(GetForgeDir(), "eventjournal.json")
+}
+
+// GetEventJournalDir returns the directory for the AM event journal.
+func GetEventJournalDir() string {
+	return filepath.Join(GetAMDir(), "events")
+}
+
 // GetAssistantConfigPath returns the path to assistant config file (v2).
 func GetAssistantConfigPath() string {
 	return filepath.Join(GetAssistantDir(), "config.json")
@@ -70,6 +136,21 @@ func GetTrainingDataDir() string {
 	return filepath.Join(GetAssistantDir(), "training-data")
 }
 
+// GetPromptsDir returns the directory for user prompt template overrides (v2).
+func GetPromptsDir() string {
+	return filepath.Join(GetAssistantDir(), "prompts")
+}
+
+// GetEvalDir returns the directory for model evaluation golden sets and runs (v2).
+func GetEvalDir() string {
+	return filepath.Join(GetAssistantDir(), "eval")
+}
+
+// GetConversationIndexPath returns the path to the AM conversation vector index (v2).
+func GetConversationIndexPath() string {
+	return filepath.Join(GetAssistantDir(), "conversation-index.json")
+}
+
 // LegacyConfigPath returns the old config path for migration.
 func LegacyConfigPath() string {
 	return filepath.Join(GetForgeDir(), "config.json")
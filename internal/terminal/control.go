@@ -0,0 +1,165 @@
+// Package terminal provides programmatic control of terminal sessions for the
+// forge CLI: listing tabs, opening headless ones and typing into them.
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikejsmith1985/forge-terminal/internal/metrics"
+)
+
+// enterDelay separates typed text from its Enter key, as the browser does, so TUI
+// CLIs see a paste followed by a submit rather than one burst.
+const enterDelay = 15 * time.Millisecond
+
+var (
+	// ErrTabNotFound is returned when no open tab matches a reference.
+	ErrTabNotFound = errors.New("no such tab")
+	// ErrTabAmbiguous is returned when a tab ID prefix matches several tabs.
+	ErrTabAmbiguous = errors.New("tab ID prefix matches several tabs")
+)
+
+// TabInfo describes an open terminal session.
+type TabInfo struct {
+	ID         string    `json:"id"`
+	Shell      string    `json:"shell"`
+	ShellType  string    `json:"shellType,omitempty"`
+	WorkingDir string    `json:"workingDir,omitempty"`
+	Headless   bool      `json:"headless"` // Opened with `forge open`; not shown in the browser
+	Started    time.Time `json:"started"`
+	PID        int       `json:"pid,omitempty"`
+	Foreground string    `json:"foreground,omitempty"` // Command running in the foreground, "" at the prompt
+}
+
+// Tabs lists the open terminal sessions, oldest first.
+func (h *Handler) Tabs() []TabInfo {
+	var tabs []TabInfo
+	h.sessions.Range(func(_, value interface{}) bool {
+		s := value.(*TerminalSession)
+		info := TabInfo{
+			ID:         s.ID,
+			Shell:      filepath.Base(s.Shell),
+			ShellType:  s.ShellType,
+			WorkingDir: s.WorkingDir,
+			Headless:   s.Headless,
+			Started:    s.Started,
+		}
+		if s.Cmd != nil && s.Cmd.Process != nil {
+			info.PID = s.Cmd.Process.Pid
+		}
+		if proc, err := s.ForegroundProcess(); err == nil && proc != nil && len(proc.Argv) > 0 {
+			info.Foreground = strings.Join(proc.Argv, " ")
+		}
+		tabs = append(tabs, info)
+		return true
+	})
+	sort.Slice(tabs, func(i, j int) bool { return tabs[i].Started.Before(tabs[j].Started) })
+	return tabs
+}
+
+// FindTab returns the session whose ID is ref or, failing that, the only one
+// whose ID starts with ref.
+func (h *Handler) FindTab(ref string) (*TerminalSession, error) {
+	if ref == "" {
+		return nil, ErrTabNotFound
+	}
+	if value, ok := h.sessions.Load(ref); ok {
+		return value.(*TerminalSession), nil
+	}
+
+	var match *TerminalSession
+	ambiguous := false
+	h.sessions.Range(func(key, value interface{}) bool {
+		if strings.HasPrefix(key.(string), ref) {
+			if match != nil {
+				ambiguous = true
+				return false
+			}
+			match = value.(*TerminalSession)
+		}
+		return true
+	})
+	switch {
+	case ambiguous:
+		return nil, ErrTabAmbiguous
+	case match == nil:
+		return nil, ErrTabNotFound
+	}
+	return match, nil
+}
+
+// OpenTab starts a headless terminal session: a shell with no browser attached,
+// driven through SendInput and read through FollowOutput. It closes when the
+// shell exits.
+func (h *Handler) OpenTab(config *ShellConfig) (*TerminalSession, error) {
+	id := uuid.New().String()
+	session, err := NewTerminalSessionWithConfig(id, config)
+	if err != nil {
+		return nil, err
+	}
+	session.Headless = true
+	_ = session.Resize(80, 24)
+	// AM watches headless tabs like browser ones, minus Vision and overlays
+	monitor := h.startMonitor(session, id, session.ShellType, nil)
+	session.monitor = monitor
+
+	h.sessions.Store(id, session)
+	metrics.SessionsActive.Inc()
+	log.Printf("[Terminal] Headless session %s opened (shell: %s, dir: %s)", id, session.Shell, session.WorkingDir)
+
+	// Nobody renders this tab; keep reading so the shell never blocks on output
+	go func() {
+		defer func() {
+			monitor.stop()
+			session.Close()
+			h.sessions.Delete(id)
+			metrics.SessionsActive.Dec()
+			log.Printf("[Terminal] Headless session %s closed", id)
+		}()
+		buf := make([]byte, 4096)
+		for {
+			n, err := session.Read(buf)
+			if n > 0 {
+				session.publishOutput(buf[:n])
+				monitor.feedOutput(buf[:n])
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return session, nil
+}
+
+// SendInput types text into a tab and, with enter, presses Enter after it. AM sees
+// the input as if it were typed in the browser: it's captured into the active
+// conversation, and an LLM CLI it starts begins one.
+func (h *Handler) SendInput(ref, text string, enter bool) (*TerminalSession, error) {
+	session, err := h.FindTab(ref)
+	if err != nil {
+		return nil, err
+	}
+	if text != "" {
+		if _, err := session.Write([]byte(text)); err != nil {
+			return nil, fmt.Errorf("failed to write to tab %s: %w", session.ID, err)
+		}
+		session.monitor.feedInput(text)
+	}
+	if enter {
+		if text != "" {
+			time.Sleep(enterDelay)
+		}
+		if _, err := session.Write([]byte("\r")); err != nil {
+			return nil, fmt.Errorf("failed to write to tab %s: %w", session.ID, err)
+		}
+		session.monitor.feedInput("\r")
+	}
+	return session, nil
+}
@@ -0,0 +1,96 @@
+package terminal
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFindTab(t *testing.T) {
+	h := &Handler{}
+	for _, id := range []string{"3f2a9c10-aaaa", "3f2b0000-bbbb", "9e7d1111-cccc"} {
+		h.sessions.Store(id, &TerminalSession{ID: id})
+	}
+
+	tests := []struct {
+		ref  string
+		want string
+		err  error
+	}{
+		{"9e7d1111-cccc", "9e7d1111-cccc", nil},
+		{"9e", "9e7d1111-cccc", nil},
+		{"3f2b", "3f2b0000-bbbb", nil},
+		{"3f2", "", ErrTabAmbiguous},
+		{"ffff", "", ErrTabNotFound},
+		{"", "", ErrTabNotFound},
+	}
+	for _, tc := range tests {
+		s, err := h.FindTab(tc.ref)
+		if err != tc.err {
+			t.Errorf("FindTab(%q) error = %v, want %v", tc.ref, err, tc.err)
+			continue
+		}
+		if err == nil && s.ID != tc.want {
+			t.Errorf("FindTab(%q) = %s, want %s", tc.ref, s.ID, tc.want)
+		}
+	}
+}
+
+func TestOpenTab_SendAndFollow(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("headless tab test runs a POSIX shell")
+	}
+	dir := t.TempDir()
+	h := &Handler{}
+	session, err := h.OpenTab(&ShellConfig{Shell: "/bin/sh", WorkingDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	tabs := h.Tabs()
+	if len(tabs) != 1 || !tabs[0].Headless || tabs[0].WorkingDir != dir || tabs[0].Shell != "sh" {
+		t.Fatalf("Unexpected tabs %+v", tabs)
+	}
+
+	_, output, untap := session.FollowOutput()
+	defer untap()
+	// The arithmetic keeps the echoed command line itself from matching
+	if _, err := h.SendInput(session.ID[:8], "echo forge-$((40+2)) && pwd", true); err != nil {
+		t.Fatal(err)
+	}
+	var seen strings.Builder
+	deadline := time.After(5 * time.Second)
+	for !strings.Contains(seen.String(), "forge-42") {
+		select {
+		case data := <-output:
+			seen.Write(data)
+		case <-deadline:
+			t.Fatalf("Command output not seen; got %q", seen.String())
+		}
+	}
+
+	// Late readers get the scrollback
+	if recent, _, stop := session.FollowOutput(); !strings.Contains(string(recent), "forge-42") {
+		t.Errorf("Scrollback missing output: %q", recent)
+	} else {
+		stop()
+	}
+
+	if _, err := h.SendInput(session.ID, "exit", true); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-session.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Session did not end after exit")
+	}
+	for i := 0; i < 50; i++ {
+		if _, err := h.FindTab(session.ID); err == ErrTabNotFound {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Error("Closed tab still listed")
+}
@@ -0,0 +1,212 @@
+// Package terminal provides foreground process detection for PTY sessions.
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/am"
+	"github.com/mikejsmith1985/forge-terminal/internal/llm"
+)
+
+// foregroundPollInterval is how often the PTY's foreground process group is checked.
+const foregroundPollInterval = 500 * time.Millisecond
+
+// ForegroundProcess is the leader of the process group in the foreground of a PTY.
+type ForegroundProcess struct {
+	PID  int
+	Exe  string   // Resolved executable, e.g. /usr/bin/node
+	Argv []string // Command line as the process sees it
+}
+
+var errForegroundUnsupported = errors.New("foreground process detection requires Linux procfs")
+
+// procRoot is the procfs mount point; tests point it at a fake tree.
+var procRoot = "/proc"
+
+// ForegroundProcess returns the process running in the foreground of the session's
+// PTY, or nil while the shell itself is at its prompt.
+func (s *TerminalSession) ForegroundProcess() (*ForegroundProcess, error) {
+	if s.Cmd == nil || s.Cmd.Process == nil {
+		return nil, errForegroundUnsupported
+	}
+	return readForegroundProcess(s.Cmd.Process.Pid)
+}
+
+// readForegroundProcess reads the terminal's foreground process group from the
+// shell's /proc stat entry. A group's ID is its leader's PID, so the leader's
+// cmdline and exe identify what the user is running.
+func readForegroundProcess(shellPID int) (*ForegroundProcess, error) {
+	if runtime.GOOS != "linux" {
+		return nil, errForegroundUnsupported
+	}
+
+	data, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(shellPID), "stat"))
+	if err != nil {
+		return nil, err
+	}
+	pgrp, tpgid, err := parseProcStat(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if tpgid <= 0 || tpgid == pgrp {
+		return nil, nil
+	}
+
+	argv, err := readProcCmdline(tpgid)
+	if err != nil {
+		// The leader can exit while the rest of its group (e.g. a pipeline) runs on
+		return nil, err
+	}
+	exe, _ := os.Readlink(filepath.Join(procRoot, strconv.Itoa(tpgid), "exe"))
+	return &ForegroundProcess{PID: tpgid, Exe: exe, Argv: argv}, nil
+}
+
+// parseProcStat returns the process group and terminal foreground process group
+// from /proc/<pid>/stat. The command name is parenthesised and may itself contain
+// spaces or parentheses, so fields are counted from the last ')'.
+func parseProcStat(stat string) (pgrp, tpgid int, err error) {
+	end := strings.LastIndex(stat, ")")
+	if end < 0 {
+		return 0, 0, fmt.Errorf("malformed stat: %q", stat)
+	}
+	// state ppid pgrp session tty_nr tpgid ...
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 6 {
+		return 0, 0, fmt.Errorf("malformed stat: %q", stat)
+	}
+	if pgrp, err = strconv.Atoi(fields[2]); err != nil {
+		return 0, 0, err
+	}
+	if tpgid, err = strconv.Atoi(fields[5]); err != nil {
+		return 0, 0, err
+	}
+	return pgrp, tpgid, nil
+}
+
+// readProcCmdline returns a process's arguments, falling back to its command name
+// when the cmdline is empty.
+func readProcCmdline(pid int) ([]string, error) {
+	dir := filepath.Join(procRoot, strconv.Itoa(pid))
+	data, err := os.ReadFile(filepath.Join(dir, "cmdline"))
+	if err != nil {
+		return nil, err
+	}
+
+	var argv []string
+	for _, arg := range strings.Split(string(data), "\x00") {
+		if arg != "" {
+			argv = append(argv, arg)
+		}
+	}
+	if len(argv) == 0 {
+		comm, err := os.ReadFile(filepath.Join(dir, "comm"))
+		if err != nil {
+			return nil, err
+		}
+		argv = []string{strings.TrimSpace(string(comm))}
+	}
+	return argv, nil
+}
+
+// processAlive reports whether pid is still running. Zombies have exited and are
+// only waiting to be reaped, so they count as gone.
+func processAlive(pid int) bool {
+	data, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return false
+	}
+	stat := string(data)
+	end := strings.LastIndex(stat, ")")
+	if end < 0 {
+		return false
+	}
+	fields := strings.Fields(stat[end+1:])
+	return len(fields) > 0 && fields[0] != "Z" && fields[0] != "X"
+}
+
+// watchForeground polls the session's foreground process group and calls onPoll on
+// every tick with the current foreground process (nil while the shell is in the
+// foreground) and whether it changed since the last poll. It returns when done is
+// closed, or immediately where detection is unsupported.
+func watchForeground(session *TerminalSession, interval time.Duration, done <-chan struct{}, onPoll func(proc *ForegroundProcess, changed bool)) {
+	if _, err := session.ForegroundProcess(); errors.Is(err, errForegroundUnsupported) {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastPID := 0
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		proc, err := session.ForegroundProcess()
+		if err != nil {
+			continue
+		}
+		pid := 0
+		if proc != nil {
+			pid = proc.PID
+		}
+		changed := pid != lastPID
+		lastPID = pid
+		onPoll(proc, changed)
+	}
+}
+
+// endExitedConversation ends the logger's active conversation once the process
+// behind it is gone: the tracked PID exited, or the foreground process group just
+// returned to the shell. It reports whether a conversation was ended.
+func endExitedConversation(logger *am.LLMLogger, proc *ForegroundProcess, changed bool) bool {
+	if logger.GetActiveConversationID() == "" {
+		return false
+	}
+	if pid := logger.ActiveProcessPID(); pid > 0 && !processAlive(pid) {
+		logger.EndConversationBy(am.EndProcessExit)
+		return true
+	}
+	// Only on the transition: a conversation started from the typed command is
+	// active for a moment before its process takes the foreground
+	if proc == nil && changed {
+		logger.EndConversationBy(am.EndForegroundReturn)
+		return true
+	}
+	return false
+}
+
+// detectForegroundLLM identifies an LLM CLI from a foreground process, trying its
+// command line first and then its resolved executable (for renamed process titles).
+func detectForegroundLLM(detector *llm.Detector, proc *ForegroundProcess) *llm.DetectedCommand {
+	detected := detector.DetectArgv(proc.Argv)
+	if !detected.Detected && proc.Exe != "" {
+		argv := []string{proc.Exe}
+		if len(proc.Argv) > 1 {
+			argv = append(argv, proc.Argv[1:]...)
+		}
+		detected = detector.DetectArgv(argv)
+	}
+	if detected.Detected {
+		detected.PID = proc.PID
+	}
+	return detected
+}
+
+// startLLMConversation starts capture for a detected CLI. TUI tools are captured
+// from the process's screen; line-based tools from their output.
+func startLLMConversation(logger *am.LLMLogger, detected *llm.DetectedCommand) string {
+	if detected.TUI {
+		return logger.StartConversationFromProcess(string(detected.Provider), string(detected.Type), detected.PID)
+	}
+	return logger.StartConversation(detected)
+}
@@ -0,0 +1,83 @@
+package terminal
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/llm"
+)
+
+// writeFakeProc creates /proc/<pid>/{stat,cmdline} entries under root.
+func writeFakeProc(t *testing.T, root string, pid int, stat, cmdline string) {
+	t.Helper()
+	dir := filepath.Join(root, strconv.Itoa(pid))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0644)
+	os.WriteFile(filepath.Join(dir, "cmdline"), []byte(cmdline), 0644)
+}
+
+func TestParseProcStat(t *testing.T) {
+	pgrp, tpgid, err := parseProcStat("4242 (my (odd) shell) S 1 4242 4242 34816 5150 4194304 0 0")
+	if err != nil || pgrp != 4242 || tpgid != 5150 {
+		t.Errorf("parseProcStat() = %d, %d, %v; want 4242, 5150", pgrp, tpgid, err)
+	}
+	if _, _, err := parseProcStat("garbage"); err == nil {
+		t.Error("Expected error for malformed stat")
+	}
+}
+
+func TestReadForegroundProcess(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("foreground detection uses Linux procfs")
+	}
+	root := t.TempDir()
+	old := procRoot
+	procRoot = root
+	defer func() { procRoot = old }()
+
+	// Shell at its prompt: it owns the foreground group
+	writeFakeProc(t, root, 100, "100 (bash) S 1 100 100 34816 100 0", "bash\x00")
+	if proc, err := readForegroundProcess(100); err != nil || proc != nil {
+		t.Errorf("Expected no foreground process, got %+v (%v)", proc, err)
+	}
+
+	// An aliased claude running through node
+	writeFakeProc(t, root, 100, "100 (bash) S 1 100 100 34816 200 0", "bash\x00")
+	writeFakeProc(t, root, 200, "200 (claude) S 100 200 100 34816 200 0",
+		"node\x00/usr/lib/node_modules/@anthropic-ai/claude-code/cli.js\x00--continue\x00")
+	proc, err := readForegroundProcess(100)
+	if err != nil || proc == nil || proc.PID != 200 || len(proc.Argv) != 3 {
+		t.Fatalf("Unexpected foreground process %+v (%v)", proc, err)
+	}
+
+	r, _ := llm.NewRegistry(llm.BuiltinProviders()...)
+	detected := detectForegroundLLM(llm.NewDetectorWithRegistry(r), proc)
+	if !detected.Detected || detected.Provider != llm.ProviderClaude || detected.PID != 200 {
+		t.Errorf("Unexpected detection %+v", detected)
+	}
+}
+
+func TestProcessAlive(t *testing.T) {
+	root := t.TempDir()
+	old := procRoot
+	procRoot = root
+	defer func() { procRoot = old }()
+
+	writeFakeProc(t, root, 300, "300 (claude) S 100 300 100 34816 300 0", "claude\x00")
+	writeFakeProc(t, root, 301, "301 (aider) Z 100 301 100 34816 100 0", "")
+
+	if !processAlive(300) {
+		t.Error("Running process reported as exited")
+	}
+	if processAlive(301) {
+		t.Error("Zombie process reported as alive")
+	}
+	if processAlive(302) {
+		t.Error("Missing process reported as alive")
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
-	"os"
 	"strings"
 	"sync"
 	"time"
@@ -14,7 +13,8 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/mikejsmith1985/forge-terminal/internal/am"
 	"github.com/mikejsmith1985/forge-terminal/internal/assistant"
-	"github.com/mikejsmith1985/forge-terminal/internal/terminal/vision"
+	"github.com/mikejsmith1985/forge-terminal/internal/auth"
+	"github.com/mikejsmith1985/forge-terminal/internal/metrics"
 )
 
 // Custom WebSocket close codes (4000-4999 range is for application use)
@@ -30,6 +30,7 @@ type Handler struct {
 	sessions      sync.Map // map[string]*TerminalSession
 	assistantCore *assistant.Core
 	assistant     assistant.Service
+	pending       pendingRestores // Conversation restores waiting for their tab to connect
 }
 
 // ResizeMessage represents a terminal resize request from the client.
@@ -54,36 +55,38 @@ type VisionOverlayMessage struct {
 
 // AMControlMessage represents AM control commands from client.
 type AMControlMessage struct {
-	Type        string `json:"type"` // "AM_AUTO_RESPOND"
+	Type        string `json:"type"` // "AM_AUTO_RESPOND", "AM_LOOP_INTERVENE"
 	AutoRespond bool   `json:"autoRespond"`
 }
 
+// wsWriter serializes writes to a WebSocket connection. gorilla/websocket allows
+// one concurrent writer, and PTY output, overlays and notifications are sent from
+// several goroutines.
+type wsWriter struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+// WriteMessage sends one data message.
+func (w *wsWriter) WriteMessage(messageType int, data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.conn.WriteMessage(messageType, data)
+}
+
+// WriteJSON sends v as a JSON text message.
+func (w *wsWriter) WriteJSON(v interface{}) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.conn.WriteJSON(v)
+}
+
 // NewHandler creates a new terminal WebSocket handler.
 func NewHandler(service assistant.Service, core *assistant.Core) *Handler {
 	return &Handler{
 		upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				// Check allowed origins for GitHub Pages deployment support
-				origin := r.Header.Get("Origin")
-
-				// Allow localhost for local development
-				if strings.HasPrefix(origin, "http://localhost") || strings.HasPrefix(origin, "http://127.0.0.1") {
-					return true
-				}
-
-				// Allow GitHub Pages deployments (github.io domain)
-				if strings.Contains(origin, ".github.io") {
-					return true
-				}
-
-				// Allow GitHub Codespaces
-				if strings.Contains(origin, "app.github.dev") || strings.Contains(origin, ".csb.app") {
-					return true
-				}
-
-				// Allow any origin for backward compatibility (can be restricted via ALLOWED_ORIGINS env var)
-				return true
-			},
+			// Same origin or ALLOWED_ORIGINS only; the route also requires the session token
+			CheckOrigin:     auth.OriginAllowed,
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
 		},
@@ -101,6 +104,7 @@ func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	defer conn.Close()
+	writer := &wsWriter{conn: conn} // Every write goes through here, see wsWriter
 
 	// Parse shell config from query params
 	query := r.URL.Query()
@@ -125,85 +129,39 @@ func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	session, err := NewTerminalSessionWithConfig(sessionID, shellConfig)
 	if err != nil {
 		log.Printf("[Terminal] Failed to create session: %v", err)
-		_ = conn.WriteJSON(map[string]string{"error": "Failed to create terminal session: " + err.Error()})
+		_ = writer.WriteJSON(map[string]string{"error": "Failed to create terminal session: " + err.Error()})
 		return
 	}
 	defer func() {
 		session.Close()
 		h.sessions.Delete(sessionID)
+		metrics.SessionsActive.Dec()
 	}()
 
-	h.sessions.Store(sessionID, session)
-	log.Printf("[Terminal] Session %s created (shell: %s, tabID: %s)", sessionID, shellConfig.ShellType, tabID)
-
 	// Set initial terminal size (default 80x24)
 	_ = session.Resize(80, 24)
 
-	// Get Vision parser from assistant core
-	visionParser := h.assistantCore.GetVisionParser()
-
-	// PERFORMANCE FIX: Initialize AM/Vision/LLM systems asynchronously
-	// These don't need to block the terminal from becoming interactive
-	var llmLogger *am.LLMLogger
-	var insightsTracker *vision.InsightsTracker
-	amSystem := h.assistantCore.GetAMSystem()
+	// AM capture, foreground and loop detection, and Vision for this tab
+	monitor := h.startMonitor(session, tabID, shellConfig.ShellType, func(msg VisionOverlayMessage) {
+		if err := writer.WriteJSON(msg); err != nil {
+			log.Printf("[Terminal] Failed to send %s overlay: %v", msg.OverlayType, err)
+		}
+	})
+	session.monitor = monitor
 
-	// Launch async initialization - doesn't block terminal readiness
-	go func() {
-		if amSystem != nil {
-			llmLogger = amSystem.GetLLMLogger(tabID)
-			if llmLogger != nil {
-				activeConv := llmLogger.GetActiveConversationID()
-				log.Printf("[Terminal] Using LLM logger for tabID: %s, activeConv: %s", tabID, activeConv)
-			} else {
-				log.Printf("[Terminal] NO LLM logger available for tabID: %s", tabID)
-			}
-			// Record PTY heartbeat for Layer 1
-			if amSystem.HealthMonitor != nil {
-				amSystem.HealthMonitor.RecordPTYHeartbeat()
-			}
+	h.sessions.Store(sessionID, session)
+	metrics.SessionsActive.Inc()
+	log.Printf("[Terminal] Session %s created (shell: %s, tabID: %s)", sessionID, shellConfig.ShellType, tabID)
 
-			// Initialize Vision Insights tracker
-			cwd, _ := os.Getwd()
-			sessionInfo := vision.SessionInfo{
-				TabID:      tabID,
-				WorkingDir: cwd,
-				ShellType:  shellConfig.ShellType,
-				InAutoMode: false, // Will be updated when auto-respond starts
-			}
-			insightsTracker = vision.NewInsightsTracker(amSystem.AMDir, sessionInfo)
-			visionParser.SetInsightsTracker(insightsTracker)
-			log.Printf("[Terminal] Vision insights tracker initialized for session %s", sessionID)
-
-			// Set up low-confidence callback for AM v2.0
-			// When parsing confidence is low during auto-respond, notify user via Vision
-			if llmLogger != nil {
-				llmLogger.SetLowConfidenceCallback(func(raw string) {
-					log.Printf("[AM] Low confidence parsing detected, sending Vision notification")
-					// Send a Vision overlay to notify the user
-					overlayMsg := VisionOverlayMessage{
-						Type:        "VISION_OVERLAY",
-						OverlayType: "AM_LOW_CONFIDENCE",
-						Payload: map[string]interface{}{
-							"message":     "AM detected low-confidence parsing. Raw data preserved for manual review.",
-							"severity":    "warning",
-							"autoRespond": true,
-							"rawLength":   len(raw),
-						},
-					}
-					if err := conn.WriteJSON(overlayMsg); err != nil {
-						log.Printf("[AM] Failed to send low-confidence notification: %v", err)
-					}
-				})
-			}
-			log.Printf("[Terminal] Session %s: AM system initialized with tabID %s", sessionID, tabID)
+	// Continue a restored conversation queued for this tab
+	go h.runPendingRestore(session, tabID, func(msg VisionOverlayMessage) {
+		if err := writer.WriteJSON(msg); err != nil {
+			log.Printf("[AM Restore] Failed to send restore notification: %v", err)
 		}
-	}()
+	})
 
-	detector := h.assistantCore.GetLLMDetector()
-	var inputBuffer strings.Builder
-	const flushTimeout = 2 * time.Second
-	lastFlushCheck := time.Now()
+	// Get Vision parser from assistant core
+	visionParser := h.assistantCore.GetVisionParser()
 
 	// Channel to coordinate shutdown with reason
 	type closeReason struct {
@@ -214,23 +172,6 @@ func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	done := make(chan struct{})
 	var closeOnce sync.Once
 
-	// Layer 1: PTY Heartbeat - Send periodic heartbeats for health monitoring
-	go func() {
-		ticker := time.NewTicker(15 * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ticker.C:
-				if amSystem != nil && amSystem.HealthMonitor != nil {
-					amSystem.HealthMonitor.RecordPTYHeartbeat()
-				}
-			case <-done:
-				return
-			}
-		}
-	}()
-
 	// PTY -> WebSocket (read from terminal, send to browser)
 	go func() {
 		defer closeOnce.Do(func() { close(done) })
@@ -264,8 +205,9 @@ func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 				
 				// FREEZE INSTRUMENTATION: Time WebSocket writes
 				writeStart := time.Now()
-				err = conn.WriteMessage(websocket.BinaryMessage, buf[:n])
+				err = writer.WriteMessage(websocket.BinaryMessage, buf[:n])
 				writeDuration := time.Since(writeStart)
+				metrics.WebSocketWriteSeconds.Observe(writeDuration.Seconds())
 				
 				// Track cumulative stats
 				totalWriteTime += writeDuration
@@ -295,28 +237,9 @@ func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 					return
 				}
 
-				// Vision: Feed data to parser asynchronously (non-blocking)
-				if visionParser.Enabled() {
-					go func(data []byte) {
-						if match := visionParser.Feed(data); match != nil {
-							overlayMsg := VisionOverlayMessage{
-								Type:        "VISION_OVERLAY",
-								OverlayType: match.Type,
-								Payload:     match.Payload,
-							}
-							conn.WriteJSON(overlayMsg) // Best effort, ignore errors
-						}
-					}(buf[:n])
-				}
-
-				// Feed output to LLM logger asynchronously (non-blocking)
-				if llmLogger != nil {
-					go func(data string) {
-						if llmLogger.GetActiveConversationID() != "" {
-							llmLogger.AddOutput(data)
-						}
-					}(string(buf[:n]))
-				}
+				// Restore and other in-process readers, then Vision and AM
+				session.publishOutput(buf[:n])
+				monitor.feedOutput(buf[:n])
 			}
 		}
 	}()
@@ -330,6 +253,7 @@ func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 				log.Printf("[Terminal] WebSocket read error: %v", err)
 				return
 			}
+			llmLogger := monitor.llmLogger()
 
 			// Check if it's a control message (JSON)
 			if msgType == websocket.TextMessage {
@@ -343,9 +267,10 @@ func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 					continue
 				}
 
-				// Check for Vision control messages
+				// Check for Vision control messages; other JSON falls through to AM
 				var visionMsg VisionControlMessage
-				if err := json.Unmarshal(data, &visionMsg); err == nil {
+				if err := json.Unmarshal(data, &visionMsg); err == nil &&
+					(strings.HasPrefix(visionMsg.Type, "VISION_") || visionMsg.Type == "INJECT_COMMAND") {
 					switch visionMsg.Type {
 					case "VISION_ENABLE":
 						visionParser.SetEnabled(true)
@@ -375,6 +300,15 @@ func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 					}
 					continue
 				}
+				if amMsg.Type == "AM_LOOP_INTERVENE" {
+					// The user accepted the loop overlay's intervention
+					if llmLogger != nil && llmLogger.GetActiveConversationID() != "" {
+						if err := interveneInLoop(session, llmLogger.GetActiveProvider(), am.LoadLoopDetectionConfig()); err != nil {
+							log.Printf("[Loop Detector] Intervention failed: %v", err)
+						}
+					}
+					continue
+				}
 			}
 
 			// ═══ CRITICAL PERFORMANCE: Write to PTY FIRST, process later ═══
@@ -388,55 +322,8 @@ func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			// Accumulate input for LLM detection (after PTY write)
-			dataStr := string(data)
-			inputBuffer.WriteString(dataStr)
-
-			// AM: Capture user input when inside active LLM session (async, non-blocking)
-			if llmLogger != nil {
-				activeConv := llmLogger.GetActiveConversationID()
-				if activeConv != "" {
-					// Fire and forget - don't block on this
-					go llmLogger.AddUserInput(dataStr)
-				}
-			}
-
-			// Check for newline/enter (command submission)
-			if strings.Contains(dataStr, "\r") || strings.Contains(dataStr, "\n") {
-				commandLine := strings.TrimSpace(inputBuffer.String())
-				inputBuffer.Reset()
-
-				if commandLine != "" && llmLogger != nil {
-					// Only detect new LLM command if no conversation is active
-					activeConv := llmLogger.GetActiveConversationID()
-					if activeConv == "" {
-						detected := detector.DetectCommand(commandLine)
-
-						if detected.Detected {
-							// Check if this is a TUI-based tool (Copilot, Claude)
-							isTUITool := detected.Provider == "github-copilot" || detected.Provider == "claude"
-
-							if isTUITool {
-								llmLogger.StartConversationFromProcess(
-									string(detected.Provider),
-									string(detected.Type),
-									0,
-								)
-							} else {
-								llmLogger.StartConversation(detected)
-							}
-						}
-					}
-				}
-			}
-
-			// Periodic flush check for LLM output (reduced frequency)
-			if llmLogger != nil && time.Since(lastFlushCheck) > flushTimeout {
-				if llmLogger.ShouldFlushOutput(flushTimeout) {
-					go llmLogger.FlushOutput() // Async flush
-				}
-				lastFlushCheck = time.Now()
-			}
+			// AM input capture and LLM command detection (after PTY write)
+			monitor.feedInput(string(data))
 		}
 	}()
 
@@ -458,17 +345,7 @@ func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		finalReason = closeReason{CloseCodeTimeout, "Session timed out after 24 hours"}
 	}
 
-	// CRITICAL: Clean up LLM logger when session ends
-	if llmLogger != nil {
-		// End any active conversation
-		if activeConv := llmLogger.GetActiveConversationID(); activeConv != "" {
-			log.Printf("[Terminal] Ending active conversation %s on session close", activeConv)
-			llmLogger.EndConversation()
-		}
-		// Remove the logger from global map to prevent memory leaks
-		am.RemoveLLMLogger(tabID)
-		log.Printf("[Terminal] LLM logger cleaned up for tab %s", tabID)
-	}
+	monitor.stop()
 
 	// Send close message with reason
 	closeMessage := websocket.FormatCloseMessage(finalReason.code, finalReason.reason)
@@ -0,0 +1,53 @@
+package terminal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestWSWriter_ConcurrentWriters(t *testing.T) {
+	const writers, perWriter = 4, 50
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		writer := &wsWriter{conn: conn}
+
+		var wg sync.WaitGroup
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < perWriter; j++ {
+					if i%2 == 0 {
+						writer.WriteMessage(websocket.BinaryMessage, []byte("pty output"))
+					} else {
+						writer.WriteJSON(VisionOverlayMessage{Type: "VISION_OVERLAY", OverlayType: "TEST"})
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+		conn.ReadMessage() // Wait for the client to finish reading
+	}))
+	defer server.Close()
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	for n := 0; n < writers*perWriter; n++ {
+		if _, _, err := client.ReadMessage(); err != nil {
+			t.Fatalf("Message %d: %v", n, err)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+// Package terminal provides reporting and breaking LLM agent loops found by AM.
+package terminal
+
+import (
+	"log"
+	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/am"
+	"github.com/mikejsmith1985/forge-terminal/internal/llm"
+)
+
+// loopCheckInterval is how often the active conversation is checked for loops.
+const loopCheckInterval = 5 * time.Second
+
+// loopOverlay describes a detected loop for the AM_LOOP_DETECTED Vision overlay.
+func loopOverlay(detection *am.LoopDetection, intervened bool) VisionOverlayMessage {
+	return VisionOverlayMessage{
+		Type:        "VISION_OVERLAY",
+		OverlayType: "AM_LOOP_DETECTED",
+		Payload: map[string]interface{}{
+			"conversationId": detection.ConversationID,
+			"provider":       detection.Provider,
+			"signals":        detection.Signals,
+			"detectedAt":     detection.DetectedAt,
+			"intervened":     intervened,
+		},
+	}
+}
+
+// interveneInLoop pastes the configured intervention prompt into the looping CLI.
+func interveneInLoop(session *TerminalSession, provider string, cfg *am.LoopDetectionConfig) error {
+	def := llm.LookupProvider(provider)
+	if def == nil {
+		def = &llm.ProviderDefinition{}
+	}
+	log.Printf("[Loop Detector] Pasting intervention prompt into %s session %s", provider, session.ID)
+	return pastePrompt(session, def, cfg.Intervention, true)
+}
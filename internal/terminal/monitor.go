@@ -0,0 +1,331 @@
+package terminal
+
+import (
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/am"
+	"github.com/mikejsmith1985/forge-terminal/internal/llm"
+	"github.com/mikejsmith1985/forge-terminal/internal/terminal/vision"
+)
+
+const (
+	outputFlushTimeout = 2 * time.Second // How long buffered LLM output may wait before a flush
+	outputQueueSize    = 1024            // PTY chunks waiting for the conversation capture
+)
+
+// sessionMonitor runs AM for one terminal session, whether a browser tab or a
+// headless one: the tab's LLM logger and auto-responder, foreground process and
+// loop detection, Vision, and the input and output feeds that drive them.
+type sessionMonitor struct {
+	session  *TerminalSession
+	tabID    string
+	amSystem *am.System
+	detector *llm.Detector
+	vision   *vision.Parser             // nil for headless tabs
+	notify   func(VisionOverlayMessage) // Sends overlays to the browser; nil for headless tabs
+
+	// Set by the init goroutine and read by the I/O goroutines, so published atomically
+	logger    atomic.Pointer[am.LLMLogger]
+	responder atomic.Pointer[am.AutoResponder]
+
+	output chan string // PTY output for the conversation, consumed in order by captureOutput
+
+	inputMu        sync.Mutex
+	inputBuffer    strings.Builder // Line typed so far, for LLM command detection
+	lastFlushCheck time.Time
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// startMonitor starts AM for a session. notify delivers overlays to the tab's
+// browser; headless tabs pass nil and get no Vision or overlays.
+func (h *Handler) startMonitor(session *TerminalSession, tabID, shellType string, notify func(VisionOverlayMessage)) *sessionMonitor {
+	m := &sessionMonitor{
+		session:        session,
+		tabID:          tabID,
+		notify:         notify,
+		output:         make(chan string, outputQueueSize),
+		lastFlushCheck: time.Now(),
+		done:           make(chan struct{}),
+	}
+	if h.assistantCore != nil {
+		m.amSystem = h.assistantCore.GetAMSystem()
+		m.detector = h.assistantCore.GetLLMDetector()
+		if notify != nil {
+			m.vision = h.assistantCore.GetVisionParser()
+		}
+	}
+
+	// PERFORMANCE FIX: Initialize AM/Vision asynchronously so it doesn't block
+	// the terminal from becoming interactive
+	go m.init(shellType)
+	go m.heartbeat()
+	go m.watchForeground()
+	go m.watchLoops()
+	go m.captureOutput()
+	return m
+}
+
+func (m *sessionMonitor) init(shellType string) {
+	if m.amSystem == nil {
+		return
+	}
+	logger := m.amSystem.GetLLMLogger(m.tabID)
+	if logger != nil {
+		log.Printf("[Terminal] Using LLM logger for tabID: %s, activeConv: %s", m.tabID, logger.GetActiveConversationID())
+		// Answers confirmation prompts per project policy while auto-respond is on
+		m.responder.Store(am.NewAutoResponder(logger, func(keys string) error {
+			_, err := m.session.Write([]byte(keys))
+			return err
+		}))
+
+		// AM v2.0: when parsing confidence is low during auto-respond, notify the user via Vision
+		if m.notify != nil {
+			logger.SetLowConfidenceCallback(func(raw string) {
+				log.Printf("[AM] Low confidence parsing detected, sending Vision notification")
+				m.notify(VisionOverlayMessage{
+					Type:        "VISION_OVERLAY",
+					OverlayType: "AM_LOW_CONFIDENCE",
+					Payload: map[string]interface{}{
+						"message":     "AM detected low-confidence parsing. Raw data preserved for manual review.",
+						"severity":    "warning",
+						"autoRespond": true,
+						"rawLength":   len(raw),
+					},
+				})
+			})
+		}
+		m.logger.Store(logger)
+	} else {
+		log.Printf("[Terminal] NO LLM logger available for tabID: %s", m.tabID)
+	}
+	// Record PTY heartbeat for Layer 1
+	if m.amSystem.HealthMonitor != nil {
+		m.amSystem.HealthMonitor.RecordPTYHeartbeat()
+	}
+
+	if m.vision != nil {
+		cwd, _ := os.Getwd()
+		m.vision.SetInsightsTracker(vision.NewInsightsTracker(m.amSystem.AMDir, vision.SessionInfo{
+			TabID:      m.tabID,
+			WorkingDir: cwd,
+			ShellType:  shellType,
+			InAutoMode: false, // Will be updated when auto-respond starts
+		}))
+		log.Printf("[Terminal] Vision insights tracker initialized for session %s", m.session.ID)
+	}
+	log.Printf("[Terminal] Session %s: AM system initialized with tabID %s", m.session.ID, m.tabID)
+}
+
+// heartbeat sends periodic PTY heartbeats for Layer 1 health monitoring.
+func (m *sessionMonitor) heartbeat() {
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if m.amSystem != nil && m.amSystem.HealthMonitor != nil {
+				m.amSystem.HealthMonitor.RecordPTYHeartbeat()
+			}
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// watchForeground is Layer 1 foreground process detection. The PTY's real
+// foreground process catches launches the typed line hides (aliases, scripts, and
+// input sent without a browser), supplies the PID and ends conversations when that
+// process exits.
+func (m *sessionMonitor) watchForeground() {
+	trackingEnabled := false
+	watchForeground(m.session, foregroundPollInterval, m.done, func(proc *ForegroundProcess, changed bool) {
+		logger := m.logger.Load()
+		if logger == nil || m.detector == nil {
+			return
+		}
+		if !trackingEnabled {
+			// Process exit is authoritative here; prompt heuristics become a fallback
+			logger.SetProcessTracking(true)
+			trackingEnabled = true
+		}
+		endExitedConversation(logger, proc, changed)
+		if proc == nil || !changed {
+			return
+		}
+
+		detected := detectForegroundLLM(m.detector, proc)
+		if !detected.Detected {
+			return
+		}
+
+		if logger.GetActiveConversationID() != "" {
+			// Started from the typed command; record the process behind it
+			if logger.GetActiveProvider() == string(detected.Provider) {
+				logger.AttachProcess(proc.PID)
+			}
+			return
+		}
+
+		log.Printf("[Terminal] Foreground LLM process detected: %s (PID %d, %s)",
+			detected.Provider, proc.PID, strings.Join(proc.Argv, " "))
+		startLLMConversation(logger, detected)
+	})
+}
+
+// watchLoops watches the active conversation for an agent stuck in a loop.
+func (m *sessionMonitor) watchLoops() {
+	ticker := time.NewTicker(loopCheckInterval)
+	defer ticker.Stop()
+
+	var monitor *am.LoopMonitor
+	for {
+		select {
+		case <-ticker.C:
+		case <-m.done:
+			return
+		}
+		logger := m.logger.Load()
+		if logger == nil {
+			continue
+		}
+		if monitor == nil {
+			monitor = am.NewLoopMonitor(logger)
+		}
+
+		cfg := am.LoadLoopDetectionConfig()
+		detection := monitor.Check(cfg)
+		if detection == nil {
+			continue
+		}
+		intervened := false
+		if cfg.AutoIntervene {
+			if err := interveneInLoop(m.session, detection.Provider, cfg); err != nil {
+				log.Printf("[Loop Detector] Intervention failed: %v", err)
+			} else {
+				intervened = true
+			}
+		}
+		if m.notify != nil {
+			m.notify(loopOverlay(detection, intervened))
+		}
+	}
+}
+
+// feedOutput hands PTY output to Vision and the active conversation. The work
+// happens on other goroutines.
+func (m *sessionMonitor) feedOutput(data []byte) {
+	if m.vision != nil && m.vision.Enabled() {
+		go func(data []byte) {
+			if match := m.vision.Feed(data); match != nil {
+				m.notify(VisionOverlayMessage{
+					Type:        "VISION_OVERLAY",
+					OverlayType: match.Type,
+					Payload:     match.Payload,
+				})
+			}
+		}(append([]byte(nil), data...)) // The caller reuses its buffer
+	}
+
+	// The transcript and the auto-responder's screen need the chunks in order, so
+	// one goroutine consumes them; a full queue holds up the PTY reader rather
+	// than reorder or lose output
+	if m.logger.Load() != nil {
+		select {
+		case m.output <- string(data):
+		case <-m.done:
+		}
+	}
+}
+
+// captureOutput feeds queued PTY output to the active conversation and the
+// auto-responder, in the order it was read.
+func (m *sessionMonitor) captureOutput() {
+	for {
+		select {
+		case data := <-m.output:
+			logger := m.logger.Load()
+			if logger == nil || logger.GetActiveConversationID() == "" {
+				continue
+			}
+			logger.AddOutput(data)
+			if responder := m.responder.Load(); responder != nil {
+				responder.Feed(data)
+			}
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// feedInput records input written to the PTY, from the browser or SendInput. It's
+// captured into the active conversation, and a submitted line that launches an LLM
+// CLI starts one.
+func (m *sessionMonitor) feedInput(data string) {
+	m.inputMu.Lock()
+	defer m.inputMu.Unlock()
+
+	m.inputBuffer.WriteString(data)
+	logger := m.logger.Load()
+
+	// AM: Capture user input when inside active LLM session (async, non-blocking)
+	if logger != nil && logger.GetActiveConversationID() != "" {
+		go logger.AddUserInput(data)
+	}
+
+	// Check for newline/enter (command submission)
+	if strings.ContainsAny(data, "\r\n") {
+		commandLine := strings.TrimSpace(m.inputBuffer.String())
+		m.inputBuffer.Reset()
+
+		// Only detect a new LLM command if no conversation is active
+		if commandLine != "" && logger != nil && m.detector != nil && logger.GetActiveConversationID() == "" {
+			if detected := m.detector.DetectCommand(commandLine); detected.Detected {
+				// The PID is attached once the process reaches the foreground
+				startLLMConversation(logger, detected)
+			}
+		}
+	}
+
+	// Periodic flush check for LLM output (reduced frequency)
+	if logger != nil && time.Since(m.lastFlushCheck) > outputFlushTimeout {
+		if logger.ShouldFlushOutput(outputFlushTimeout) {
+			go logger.FlushOutput() // Async flush
+		}
+		m.lastFlushCheck = time.Now()
+	}
+}
+
+// llmLogger returns the tab's LLM logger, nil until initialization finishes.
+func (m *sessionMonitor) llmLogger() *am.LLMLogger {
+	return m.logger.Load()
+}
+
+// stop ends the monitor when its session does: the watchers exit, the active
+// conversation ends and the tab's logger is released.
+func (m *sessionMonitor) stop() {
+	m.stopOnce.Do(func() {
+		close(m.done)
+		if responder := m.responder.Load(); responder != nil {
+			responder.Stop()
+		}
+
+		// CRITICAL: Clean up LLM logger when session ends
+		if logger := m.logger.Load(); logger != nil {
+			if activeConv := logger.GetActiveConversationID(); activeConv != "" {
+				log.Printf("[Terminal] Ending active conversation %s on session close", activeConv)
+				logger.EndConversationBy(am.EndSessionClose)
+			}
+			// Remove the logger from global map to prevent memory leaks
+			am.RemoveLLMLogger(m.tabID)
+			log.Printf("[Terminal] LLM logger cleaned up for tab %s", m.tabID)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/am"
+	"github.com/mikejsmith1985/forge-terminal/internal/llm"
+)
+
+func TestSessionMonitor_FeedInputStartsConversation(t *testing.T) {
+	registry, errs := llm.LoadRegistry(t.TempDir())
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected load errors: %v", errs)
+	}
+	logger := am.GetLLMLogger("monitor-test", t.TempDir())
+	defer am.RemoveLLMLogger("monitor-test")
+
+	m := &sessionMonitor{detector: llm.NewDetectorWithRegistry(registry), lastFlushCheck: time.Now()}
+	m.logger.Store(logger)
+
+	// Typed in pieces, as SendInput and the browser deliver it
+	m.feedInput("aider --model gpt-4")
+	if logger.GetActiveConversationID() != "" {
+		t.Fatal("Conversation started before Enter")
+	}
+	m.feedInput("\r")
+	if got := logger.GetActiveProvider(); got != string(llm.ProviderAider) {
+		t.Fatalf("Active provider = %q, want %s", got, llm.ProviderAider)
+	}
+	logger.EndConversationBy(am.EndSessionClose)
+	am.WaitForPendingWrites()
+}
+
+func TestSessionMonitor_OutputCapturedInOrder(t *testing.T) {
+	registry, errs := llm.LoadRegistry(t.TempDir())
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected load errors: %v", errs)
+	}
+	logger := am.GetLLMLogger("monitor-order-test", t.TempDir())
+	defer am.RemoveLLMLogger("monitor-order-test")
+	logger.SetProcessTracking(true) // Keep the prompt heuristic from ending the conversation
+	convID := logger.StartConversation(llm.NewDetectorWithRegistry(registry).DetectCommand("aider --model gpt-4"))
+
+	m := &sessionMonitor{output: make(chan string, outputQueueSize), done: make(chan struct{})}
+	m.logger.Store(logger)
+	go m.captureOutput()
+	defer m.stop()
+
+	var want strings.Builder
+	for i := 0; i < 200; i++ {
+		chunk := fmt.Sprintf("line-%03d\n", i)
+		want.WriteString(chunk)
+		m.feedOutput([]byte(chunk))
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(m.output) > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond) // The last chunk may still be in AddOutput
+	logger.FlushOutput()
+	am.WaitForPendingWrites()
+
+	conv := logger.GetConversation(convID)
+	if conv == nil || len(conv.Turns) == 0 {
+		t.Fatal("No output captured")
+	}
+	if got := conv.Turns[len(conv.Turns)-1].Raw; got != want.String() {
+		t.Errorf("Output captured out of order or incomplete:\n%.200s", got)
+	}
+}
@@ -0,0 +1,326 @@
+// Package terminal provides restoring AM conversations into a fresh LLM CLI session.
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikejsmith1985/forge-terminal/internal/am"
+	"github.com/mikejsmith1985/forge-terminal/internal/llm"
+)
+
+const (
+	restoreShellTimeout = 5 * time.Second         // Wait for a new shell's first prompt
+	restoreReadyTimeout = 45 * time.Second        // Wait for the CLI's input prompt
+	restoreQuietPeriod  = 1500 * time.Millisecond // Output silence taken as "ready" without patterns
+	pendingRestoreTTL   = 2 * time.Minute         // Pending restores for tabs that never connect expire
+	restoreScreenBytes  = 16 * 1024               // Rolling window of output checked for the ready prompt
+
+	bracketedPasteOpen  = "\x1b[200~"
+	bracketedPasteClose = "\x1b[201~"
+)
+
+// Restore statuses.
+const (
+	RestoreStatusRestored = "restored" // The prompt was pasted into the CLI
+	RestoreStatusPending  = "pending"  // Runs once the tab's terminal connects
+)
+
+var (
+	// ErrRestoreBusy is returned when the tab is already running something.
+	ErrRestoreBusy = errors.New("terminal is busy; restore needs a shell prompt")
+	// ErrRestoreNotReady is returned when the CLI never showed its input prompt.
+	ErrRestoreNotReady = errors.New("LLM CLI did not become ready")
+)
+
+// RestoreRequest asks for a conversation to be continued in a fresh CLI session.
+type RestoreRequest struct {
+	ConversationID string `json:"conversationId"`
+	TabID          string `json:"tabId,omitempty"`    // Reuse this tab; a new tab ID is issued when empty
+	Provider       string `json:"provider,omitempty"` // Defaults to the conversation's provider
+	Submit         bool   `json:"submit,omitempty"`   // Press Enter after pasting the prompt
+}
+
+// RestoreResult reports where a conversation was restored.
+type RestoreResult struct {
+	ConversationID    string `json:"conversationId"`
+	NewConversationID string `json:"newConversationId,omitempty"`
+	TabID             string `json:"tabId"`
+	Provider          string `json:"provider"`
+	WorkingDirectory  string `json:"workingDirectory,omitempty"`
+	Status            string `json:"status"`
+}
+
+// restorePlan is a validated restore request.
+type restorePlan struct {
+	req      RestoreRequest
+	def      *llm.ProviderDefinition
+	ctx      *am.RestoreContext
+	dir      string
+	amDir    string
+	queuedAt time.Time
+}
+
+// pendingRestores holds restores for tabs whose terminal hasn't connected yet.
+type pendingRestores struct {
+	mu    sync.Mutex
+	plans map[string]*restorePlan
+}
+
+func (p *pendingRestores) put(tabID string, plan *restorePlan) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.plans == nil {
+		p.plans = make(map[string]*restorePlan)
+	}
+	for id, queued := range p.plans {
+		if time.Since(queued.queuedAt) > pendingRestoreTTL {
+			delete(p.plans, id)
+		}
+	}
+	p.plans[tabID] = plan
+}
+
+func (p *pendingRestores) take(tabID string) *restorePlan {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	plan := p.plans[tabID]
+	delete(p.plans, tabID)
+	if plan != nil && time.Since(plan.queuedAt) > pendingRestoreTTL {
+		return nil
+	}
+	return plan
+}
+
+// RestoreConversation launches the conversation's CLI in its original working
+// directory and pastes the restore prompt once the CLI is ready. An open tab is
+// restored immediately; otherwise the restore runs when the tab connects.
+func (h *Handler) RestoreConversation(req RestoreRequest) (*RestoreResult, error) {
+	plan, err := h.planRestore(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if plan.req.TabID != "" {
+		if value, ok := h.sessions.Load(plan.req.TabID); ok {
+			return h.runRestore(value.(*TerminalSession), plan, false)
+		}
+	} else {
+		plan.req.TabID = uuid.New().String()
+	}
+
+	h.pending.put(plan.req.TabID, plan)
+	log.Printf("[AM Restore] Restore of %s queued for tab %s", plan.req.ConversationID, plan.req.TabID)
+	return plan.result(RestoreStatusPending, ""), nil
+}
+
+// planRestore loads the conversation and resolves its provider and directory.
+func (h *Handler) planRestore(req RestoreRequest) (*restorePlan, error) {
+	amDir := am.DefaultAMDir()
+	if amSystem := h.assistantCore.GetAMSystem(); amSystem != nil && amSystem.AMDir != "" {
+		amDir = amSystem.AMDir
+	}
+
+	conv, err := am.FindConversation(amDir, req.ConversationID)
+	if err != nil {
+		return nil, err
+	}
+	ctx := am.NewContextBuilder(amDir).BuildRestoreContext(conv)
+	if ctx == nil {
+		return nil, fmt.Errorf("conversation %s has nothing to restore", req.ConversationID)
+	}
+
+	provider := req.Provider
+	if provider == "" {
+		provider = conv.Provider
+	}
+	def := llm.GetRegistry().Resolve(provider)
+	if def == nil {
+		return nil, fmt.Errorf("unknown provider: %s", provider)
+	}
+
+	return &restorePlan{
+		req:      req,
+		def:      def,
+		ctx:      ctx,
+		dir:      conv.GetWorkingDirectory(),
+		amDir:    amDir,
+		queuedAt: time.Now(),
+	}, nil
+}
+
+func (p *restorePlan) result(status, newConvID string) *RestoreResult {
+	return &RestoreResult{
+		ConversationID:    p.req.ConversationID,
+		NewConversationID: newConvID,
+		TabID:             p.req.TabID,
+		Provider:          string(p.def.ID),
+		WorkingDirectory:  p.dir,
+		Status:            status,
+	}
+}
+
+// runPendingRestore runs a restore queued for a tab that just connected and tells
+// the browser how it went.
+func (h *Handler) runPendingRestore(session *TerminalSession, tabID string, notify func(VisionOverlayMessage)) {
+	plan := h.pending.take(tabID)
+	if plan == nil {
+		return
+	}
+
+	payload := map[string]interface{}{
+		"conversationId": plan.req.ConversationID,
+		"provider":       string(plan.def.ID),
+	}
+	result, err := h.runRestore(session, plan, true)
+	if err != nil {
+		log.Printf("[AM Restore] ❌ Restore of %s failed: %v", plan.req.ConversationID, err)
+		payload["error"] = err.Error()
+		payload["severity"] = "error"
+	} else {
+		payload["newConversationId"] = result.NewConversationID
+		payload["status"] = result.Status
+	}
+	notify(VisionOverlayMessage{Type: "VISION_OVERLAY", OverlayType: "AM_RESTORE", Payload: payload})
+}
+
+// runRestore types the launch line, waits for the CLI, pastes the restore prompt
+// and links the new conversation to the restored one.
+func (h *Handler) runRestore(session *TerminalSession, plan *restorePlan, fresh bool) (*RestoreResult, error) {
+	if proc, err := session.ForegroundProcess(); err == nil && proc != nil {
+		return nil, ErrRestoreBusy
+	}
+	logger := am.GetLLMLogger(plan.req.TabID, plan.amDir)
+	if logger.GetActiveConversationID() != "" {
+		return nil, ErrRestoreBusy
+	}
+	// Windows shells may see a different filesystem (WSL), so leave checking to them
+	if plan.dir != "" && runtime.GOOS != "windows" {
+		if info, err := os.Stat(plan.dir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("working directory no longer exists: %s", plan.dir)
+		}
+	}
+
+	output, untap := session.tapOutput()
+	defer untap()
+
+	if fresh {
+		// Typing before the shell prints its prompt can be swallowed by shell startup
+		waitForQuiet(output, restoreQuietPeriod, restoreShellTimeout)
+	}
+
+	launch := plan.def.LaunchCommand()
+	line := launchLine(session.ShellType, plan.dir, launch)
+	log.Printf("[AM Restore] Launching %s in tab %s: %s", plan.def.ID, plan.req.TabID, line)
+	if _, err := session.Write([]byte(line + "\r")); err != nil {
+		return nil, err
+	}
+
+	// The line never passes through the browser's input path, so start capture here
+	detected := h.assistantCore.GetLLMDetector().DetectCommand(launch)
+	if !detected.Detected || detected.Provider != plan.def.ID {
+		detected = &llm.DetectedCommand{Provider: plan.def.ID, Type: llm.CommandChat, RawInput: launch, Detected: true}
+	}
+	newConvID := startLLMConversation(logger, detected)
+
+	if !waitForReady(output, plan.def, restoreReadyTimeout) {
+		return nil, ErrRestoreNotReady
+	}
+
+	if err := pastePrompt(session, plan.def, plan.ctx.RestorePrompt, plan.req.Submit); err != nil {
+		return nil, err
+	}
+
+	cb := am.NewContextBuilder(plan.amDir)
+	if err := cb.MarkAsRestored(plan.req.ConversationID); err != nil {
+		log.Printf("[AM Restore] ⚠️ Could not mark %s as restored: %v", plan.req.ConversationID, err)
+	}
+	if newConvID != "" {
+		if err := cb.LinkRestoredConversation(plan.req.ConversationID, newConvID); err != nil {
+			log.Printf("[AM Restore] ⚠️ Could not link %s to %s: %v", plan.req.ConversationID, newConvID, err)
+		}
+	}
+
+	log.Printf("[AM Restore] ✅ Restored %s into %s (tab %s)", plan.req.ConversationID, newConvID, plan.req.TabID)
+	return plan.result(RestoreStatusRestored, newConvID), nil
+}
+
+// pastePrompt pastes text into the CLI running in session as one bracketed paste,
+// formatted for the provider, and presses Enter if submit is set.
+func pastePrompt(session *TerminalSession, def *llm.ProviderDefinition, text string, submit bool) error {
+	paste := bracketedPasteOpen + def.FormatPrompt(text) + bracketedPasteClose
+	if submit {
+		paste += "\r"
+	}
+	_, err := session.Write([]byte(paste))
+	return err
+}
+
+// launchLine builds the command that changes to dir and starts the CLI in the
+// given shell: "" for POSIX shells, "powershell" or "cmd".
+func launchLine(shellType, dir, launch string) string {
+	if dir == "" {
+		return launch
+	}
+	switch shellType {
+	case "powershell":
+		return fmt.Sprintf("Set-Location -LiteralPath '%s'; %s", strings.ReplaceAll(dir, "'", "''"), launch)
+	case "cmd":
+		return fmt.Sprintf(`cd /d "%s" && %s`, dir, launch)
+	default:
+		return fmt.Sprintf("cd '%s' && %s", strings.ReplaceAll(dir, "'", `'\''`), launch)
+	}
+}
+
+// waitForQuiet returns once output has been seen and then stopped for quiet, or
+// when timeout passes.
+func waitForQuiet(output <-chan []byte, quiet, timeout time.Duration) bool {
+	deadline := time.After(timeout)
+	seen := false
+	for {
+		var silence <-chan time.Time
+		if seen {
+			silence = time.After(quiet)
+		}
+		select {
+		case <-output:
+			seen = true
+		case <-silence:
+			return true
+		case <-deadline:
+			return false
+		}
+	}
+}
+
+// waitForReady waits for the provider's ready prompt on screen. Providers without
+// ready patterns are taken to be ready once their startup output goes quiet.
+func waitForReady(output <-chan []byte, def *llm.ProviderDefinition, timeout time.Duration) bool {
+	if !def.HasReadyPatterns() {
+		return waitForQuiet(output, restoreQuietPeriod, timeout)
+	}
+
+	deadline := time.After(timeout)
+	var screen string
+	for {
+		select {
+		case data := <-output:
+			screen += llm.CleanANSI(string(data))
+			if len(screen) > restoreScreenBytes {
+				screen = screen[len(screen)-restoreScreenBytes:]
+			}
+			if def.IsReady(screen) {
+				return true
+			}
+		case <-deadline:
+			return false
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package terminal
+
+import "testing"
+
+func TestLaunchLine(t *testing.T) {
+	tests := []struct {
+		shell, dir, want string
+	}{
+		{"", "", "claude"},
+		{"", "/home/dev/it's here", `cd '/home/dev/it'\''s here' && claude`},
+		{"powershell", `C:\Users\dev\it's`, `Set-Location -LiteralPath 'C:\Users\dev\it''s'; claude`},
+		{"cmd", `C:\Projects\forge`, `cd /d "C:\Projects\forge" && claude`},
+	}
+	for _, tc := range tests {
+		if got := launchLine(tc.shell, tc.dir, "claude"); got != tc.want {
+			t.Errorf("launchLine(%q, %q) = %q, want %q", tc.shell, tc.dir, got, tc.want)
+		}
+	}
+}
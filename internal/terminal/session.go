@@ -11,6 +11,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/metrics"
 )
 
 // ShellConfig contains shell configuration options
@@ -20,6 +22,8 @@ type ShellConfig struct {
 	WSLHomePath    string // WSL home directory (e.g., "/home/mikej")
 	CmdHomePath    string // CMD home directory (e.g., "C:\ProjectsWin")
 	PSHomePath     string // PowerShell home directory (e.g., "C:\ProjectsWin")
+	Shell          string // Unix shell to run instead of $SHELL (e.g., "zsh")
+	WorkingDir     string // Start directory; overrides the home paths above
 }
 
 // TerminalSession represents a single PTY terminal session.
@@ -28,11 +32,26 @@ type TerminalSession struct {
 	PTY io.ReadWriteCloser
 	Cmd *exec.Cmd // nil on Windows (ConPTY manages process internally)
 
+	// ShellType is "cmd" or "powershell" for those Windows shells, "" for POSIX shells
+	// (including WSL), and decides how commands are typed into the session.
+	ShellType string
+
+	Shell      string // Program running in the PTY
+	WorkingDir string // Directory the shell started in ("" for Forge's own)
+	Started    time.Time
+	Headless   bool // Opened through the control socket rather than a browser tab
+
 	mu       sync.Mutex
 	closed   bool
 	doneChan chan struct{}
+	taps     map[chan []byte]struct{} // Receive copies of PTY output, see tapOutput
+	recent   []byte                   // At least the last scrollbackBytes of output, see FollowOutput
+	monitor  *sessionMonitor          // AM for the session; set before it's listed in Handler.sessions
 }
 
+// scrollbackBytes is how much recent output a session keeps for late readers.
+const scrollbackBytes = 64 * 1024
+
 // NewTerminalSession creates a new PTY session with default shell.
 func NewTerminalSession(id string) (*TerminalSession, error) {
 	return NewTerminalSessionWithConfig(id, nil)
@@ -82,6 +101,7 @@ func NewTerminalSessionWithConfig(id string, config *ShellConfig) (*TerminalSess
 	shell := os.Getenv("SHELL")
 	shellArgs := []string{}
 	workingDir := ""
+	shellType := ""
 
 	if runtime.GOOS == "windows" {
 		// Windows shell selection
@@ -100,12 +120,14 @@ func NewTerminalSessionWithConfig(id string, config *ShellConfig) (*TerminalSess
 			shellArgs = append(shellArgs, "-e", "bash", "-l")
 		} else if config != nil && config.ShellType == "powershell" {
 			shell = "powershell.exe"
+			shellType = "powershell"
 			// Set working directory for PowerShell
 			if config.PSHomePath != "" {
 				workingDir = config.PSHomePath
 			}
 		} else {
 			shell = "cmd.exe"
+			shellType = "cmd"
 			// Set working directory for CMD
 			if config.CmdHomePath != "" {
 				workingDir = config.CmdHomePath
@@ -122,6 +144,12 @@ func NewTerminalSessionWithConfig(id string, config *ShellConfig) (*TerminalSess
 		if config != nil && config.WSLHomePath != "" {
 			workingDir = convertWSLPath(config.WSLHomePath)
 		}
+		if config != nil && config.Shell != "" {
+			shell = config.Shell
+		}
+	}
+	if config != nil && config.WorkingDir != "" && shell != "wsl.exe" {
+		workingDir = config.WorkingDir
 	}
 
 	// Create command (only used on Unix)
@@ -151,10 +179,14 @@ func NewTerminalSessionWithConfig(id string, config *ShellConfig) (*TerminalSess
 	}
 
 	session := &TerminalSession{
-		ID:       id,
-		PTY:      ptmx,
-		Cmd:      cmd,
-		doneChan: make(chan struct{}),
+		ID:         id,
+		PTY:        ptmx,
+		Cmd:        cmd,
+		ShellType:  shellType,
+		Shell:      shell,
+		WorkingDir: workingDir,
+		Started:    time.Now(),
+		doneChan:   make(chan struct{}),
 	}
 
 	// Monitor process exit (only on Unix where we have cmd)
@@ -180,12 +212,16 @@ func NewTerminalSessionWithConfig(id string, config *ShellConfig) (*TerminalSess
 
 // Read reads output from the PTY.
 func (s *TerminalSession) Read(p []byte) (int, error) {
-	return s.PTY.Read(p)
+	n, err := s.PTY.Read(p)
+	metrics.PTYBytes.WithLabelValues("out").Add(float64(n))
+	return n, err
 }
 
 // Write writes data to the PTY.
 func (s *TerminalSession) Write(p []byte) (int, error) {
-	return s.PTY.Write(p)
+	n, err := s.PTY.Write(p)
+	metrics.PTYBytes.WithLabelValues("in").Add(float64(n))
+	return n, err
 }
 
 // Resize changes the terminal size.
@@ -260,3 +296,52 @@ func (s *TerminalSession) Close() error {
 func (s *TerminalSession) Done() <-chan struct{} {
 	return s.doneChan
 }
+
+// tapOutput returns a channel receiving a copy of everything the PTY writes from
+// now on, and a function that removes the tap. Slow readers miss output rather
+// than stall the terminal.
+func (s *TerminalSession) tapOutput() (<-chan []byte, func()) {
+	_, ch, untap := s.FollowOutput()
+	return ch, untap
+}
+
+// FollowOutput is tapOutput that also returns the recent output the session
+// kept, with nothing lost or repeated between the two.
+func (s *TerminalSession) FollowOutput() ([]byte, <-chan []byte, func()) {
+	ch := make(chan []byte, 64)
+	s.mu.Lock()
+	if s.taps == nil {
+		s.taps = make(map[chan []byte]struct{})
+	}
+	s.taps[ch] = struct{}{}
+	recent := s.recent
+	if len(recent) > scrollbackBytes {
+		recent = recent[len(recent)-scrollbackBytes:]
+	}
+	recent = append([]byte(nil), recent...)
+	s.mu.Unlock()
+
+	return recent, ch, func() {
+		s.mu.Lock()
+		delete(s.taps, ch)
+		s.mu.Unlock()
+	}
+}
+
+// publishOutput hands PTY output to any taps and the scrollback.
+func (s *TerminalSession) publishOutput(p []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recent = append(s.recent, p...)
+	if len(s.recent) > 2*scrollbackBytes { // Trim in batches rather than on every chunk
+		s.recent = append(s.recent[:0:0], s.recent[len(s.recent)-scrollbackBytes:]...)
+	}
+	for ch := range s.taps {
+		data := make([]byte, len(p))
+		copy(data, p)
+		select {
+		case ch <- data:
+		default:
+		}
+	}
+}
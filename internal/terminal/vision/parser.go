@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/metrics"
 )
 
 // Parser manages a ring buffer for stream analysis with debouncing.
@@ -71,6 +73,7 @@ func (p *Parser) Feed(data []byte) *Match {
 	match := p.registry.Detect(p.buffer)
 	if match != nil {
 		p.lastDetection = now
+		metrics.VisionDetections.WithLabelValues(match.Type).Inc()
 		
 		// Record insight if tracker is enabled
 		if p.insightsTracker != nil {
@@ -10,6 +10,8 @@ import (
 	"runtime"
 	"strings"
 	"time"
+
+	"github.com/mikejsmith1985/forge-terminal/internal/metrics"
 )
 
 // Version is set at build time via ldflags
@@ -50,6 +52,19 @@ type UpdateInfo struct {
 
 // CheckForUpdate checks GitHub for a newer version
 func CheckForUpdate() (*UpdateInfo, error) {
+	info, err := checkForUpdate()
+	switch {
+	case err != nil:
+		metrics.UpdateChecks.WithLabelValues("error").Inc()
+	case info.Available:
+		metrics.UpdateChecks.WithLabelValues("available").Inc()
+	default:
+		metrics.UpdateChecks.WithLabelValues("current").Inc()
+	}
+	return info, err
+}
+
+func checkForUpdate() (*UpdateInfo, error) {
 	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", repoOwner, repoName)
 
 	client := &http.Client{Timeout: 10 * time.Second}
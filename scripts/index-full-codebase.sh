@@ -213,10 +213,10 @@ echo -e "${GREEN}✅ Comprehensive indexing successful!${NC}"
 echo ""
 echo -e "${CYAN}Next steps:${NC}"
 echo "  1. Run tests with the new index:"
-echo "     ./scripts/test-model-comparison.sh mistral:7b-instruct"
+echo "     POST /api/assistant/eval/runs {\"models\": [\"mistral:7b-instruct\"]}"
 echo ""
 echo "  2. Compare accuracy against previous baseline:"
-echo "     ./scripts/test-model-comparison.sh --compare-last-two"
+echo "     GET /api/assistant/eval/compare?runs=<previous>,<latest>"
 echo ""
 
 exit 0